@@ -10,6 +10,7 @@ import (
 	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -22,27 +23,2367 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Role decides which procedures a device may call.
+type Role int32
+
+const (
+	Role_ROLE_UNSPECIFIED Role = 0
+	// Can do anything, managing devices included.
+	Role_ROLE_ADMIN Role = 1
+	// Can read and modify files.
+	Role_ROLE_MEMBER    Role = 2
+	Role_ROLE_READ_ONLY Role = 3
+	// Can only add files, e.g. a phone backing up photos.
+	Role_ROLE_UPLOAD_ONLY Role = 4
+)
+
+// Enum value maps for Role.
+var (
+	Role_name = map[int32]string{
+		0: "ROLE_UNSPECIFIED",
+		1: "ROLE_ADMIN",
+		2: "ROLE_MEMBER",
+		3: "ROLE_READ_ONLY",
+		4: "ROLE_UPLOAD_ONLY",
+	}
+	Role_value = map[string]int32{
+		"ROLE_UNSPECIFIED": 0,
+		"ROLE_ADMIN":       1,
+		"ROLE_MEMBER":      2,
+		"ROLE_READ_ONLY":   3,
+		"ROLE_UPLOAD_ONLY": 4,
+	}
+)
+
+func (x Role) Enum() *Role {
+	p := new(Role)
+	*p = x
+	return p
+}
+
+func (x Role) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Role) Descriptor() protoreflect.EnumDescriptor {
+	return file_devices_v1_devices_proto_enumTypes[0].Descriptor()
+}
+
+func (Role) Type() protoreflect.EnumType {
+	return &file_devices_v1_devices_proto_enumTypes[0]
+}
+
+func (x Role) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Role.Descriptor instead.
+func (Role) EnumDescriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{0}
+}
+
+type EnrollmentState int32
+
+const (
+	EnrollmentState_ENROLLMENT_STATE_UNSPECIFIED EnrollmentState = 0
+	EnrollmentState_ENROLLMENT_STATE_PENDING     EnrollmentState = 1
+	EnrollmentState_ENROLLMENT_STATE_APPROVED    EnrollmentState = 2
+	EnrollmentState_ENROLLMENT_STATE_DENIED      EnrollmentState = 3
+	// The request was not decided in time.
+	EnrollmentState_ENROLLMENT_STATE_EXPIRED EnrollmentState = 4
+)
+
+// Enum value maps for EnrollmentState.
+var (
+	EnrollmentState_name = map[int32]string{
+		0: "ENROLLMENT_STATE_UNSPECIFIED",
+		1: "ENROLLMENT_STATE_PENDING",
+		2: "ENROLLMENT_STATE_APPROVED",
+		3: "ENROLLMENT_STATE_DENIED",
+		4: "ENROLLMENT_STATE_EXPIRED",
+	}
+	EnrollmentState_value = map[string]int32{
+		"ENROLLMENT_STATE_UNSPECIFIED": 0,
+		"ENROLLMENT_STATE_PENDING":     1,
+		"ENROLLMENT_STATE_APPROVED":    2,
+		"ENROLLMENT_STATE_DENIED":      3,
+		"ENROLLMENT_STATE_EXPIRED":     4,
+	}
+)
+
+func (x EnrollmentState) Enum() *EnrollmentState {
+	p := new(EnrollmentState)
+	*p = x
+	return p
+}
+
+func (x EnrollmentState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EnrollmentState) Descriptor() protoreflect.EnumDescriptor {
+	return file_devices_v1_devices_proto_enumTypes[1].Descriptor()
+}
+
+func (EnrollmentState) Type() protoreflect.EnumType {
+	return &file_devices_v1_devices_proto_enumTypes[1]
+}
+
+func (x EnrollmentState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EnrollmentState.Descriptor instead.
+func (EnrollmentState) EnumDescriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{1}
+}
+
 type CreateDeviceRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Role of the new device. Defaults to ROLE_MEMBER.
+	Role Role `protobuf:"varint,1,opt,name=role,proto3,enum=devices.v1.Role" json:"role,omitempty"`
+	// name of the new device, e.g. its owner's phone. Defaults to the name the
+	// device reports when it claims its credentials.
+	Name          string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDeviceRequest) Reset() {
+	*x = CreateDeviceRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDeviceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDeviceRequest) ProtoMessage() {}
+
+func (x *CreateDeviceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDeviceRequest.ProtoReflect.Descriptor instead.
+func (*CreateDeviceRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateDeviceRequest) GetRole() Role {
+	if x != nil {
+		return x.Role
+	}
+	return Role_ROLE_UNSPECIFIED
+}
+
+func (x *CreateDeviceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type CreateDeviceResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// version of the server secret the new device key is derived from. Devices
+	// name versions other than 1 as the key id in the footer of their tokens.
+	KeyVersion int32 `protobuf:"varint,3,opt,name=key_version,json=keyVersion,proto3" json:"key_version,omitempty"`
+	// identity of the server that tokens of the new device must be minted for.
+	// It differs from the one of the calling device if the server secret was
+	// rotated since the calling device was enrolled.
+	ServerId string `protobuf:"bytes,4,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	// single use code the new device claims its credentials with, see
+	// PairingService.ClaimDevice. The device key never leaves the server
+	// otherwise.
+	PairingCode           string                 `protobuf:"bytes,5,opt,name=pairing_code,json=pairingCode,proto3" json:"pairing_code,omitempty"`
+	PairingCodeExpireTime *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=pairing_code_expire_time,json=pairingCodeExpireTime,proto3" json:"pairing_code_expire_time,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *CreateDeviceResponse) Reset() {
+	*x = CreateDeviceResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDeviceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDeviceResponse) ProtoMessage() {}
+
+func (x *CreateDeviceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDeviceResponse.ProtoReflect.Descriptor instead.
+func (*CreateDeviceResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateDeviceResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateDeviceResponse) GetKeyVersion() int32 {
+	if x != nil {
+		return x.KeyVersion
+	}
+	return 0
+}
+
+func (x *CreateDeviceResponse) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+func (x *CreateDeviceResponse) GetPairingCode() string {
+	if x != nil {
+		return x.PairingCode
+	}
+	return ""
+}
+
+func (x *CreateDeviceResponse) GetPairingCodeExpireTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PairingCodeExpireTime
+	}
+	return nil
+}
+
+type ClaimDeviceRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	PairingCode string                 `protobuf:"bytes,1,opt,name=pairing_code,json=pairingCode,proto3" json:"pairing_code,omitempty"`
+	// name of the device, e.g. its host name. Only used if the device was
+	// created without one.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// platform of the device, e.g. linux or ios.
+	Platform      string `protobuf:"bytes,3,opt,name=platform,proto3" json:"platform,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClaimDeviceRequest) Reset() {
+	*x = ClaimDeviceRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClaimDeviceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimDeviceRequest) ProtoMessage() {}
+
+func (x *ClaimDeviceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimDeviceRequest.ProtoReflect.Descriptor instead.
+func (*ClaimDeviceRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ClaimDeviceRequest) GetPairingCode() string {
+	if x != nil {
+		return x.PairingCode
+	}
+	return ""
+}
+
+func (x *ClaimDeviceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ClaimDeviceRequest) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+type ClaimDeviceResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	DeviceId  string                 `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	DeviceKey []byte                 `protobuf:"bytes,2,opt,name=device_key,json=deviceKey,proto3" json:"device_key,omitempty"`
+	// version of the server secret the device key is derived from.
+	KeyVersion int32 `protobuf:"varint,3,opt,name=key_version,json=keyVersion,proto3" json:"key_version,omitempty"`
+	// generation of the device key.
+	KeyGeneration int32 `protobuf:"varint,4,opt,name=key_generation,json=keyGeneration,proto3" json:"key_generation,omitempty"`
+	// identity of the server that tokens of the device must be minted for.
+	ServerId      string `protobuf:"bytes,5,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClaimDeviceResponse) Reset() {
+	*x = ClaimDeviceResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClaimDeviceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimDeviceResponse) ProtoMessage() {}
+
+func (x *ClaimDeviceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimDeviceResponse.ProtoReflect.Descriptor instead.
+func (*ClaimDeviceResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ClaimDeviceResponse) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *ClaimDeviceResponse) GetDeviceKey() []byte {
+	if x != nil {
+		return x.DeviceKey
+	}
+	return nil
+}
+
+func (x *ClaimDeviceResponse) GetKeyVersion() int32 {
+	if x != nil {
+		return x.KeyVersion
+	}
+	return 0
+}
+
+func (x *ClaimDeviceResponse) GetKeyGeneration() int32 {
+	if x != nil {
+		return x.KeyGeneration
+	}
+	return 0
+}
+
+func (x *ClaimDeviceResponse) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+type ListDevicesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDevicesRequest) Reset() {
+	*x = ListDevicesRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesRequest) ProtoMessage() {}
+
+func (x *ListDevicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesRequest.ProtoReflect.Descriptor instead.
+func (*ListDevicesRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{4}
+}
+
+type ListDevicesResponse struct {
+	state         protoimpl.MessageState        `protogen:"open.v1"`
+	Devices       []*ListDevicesResponse_Device `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDevicesResponse) Reset() {
+	*x = ListDevicesResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesResponse) ProtoMessage() {}
+
+func (x *ListDevicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesResponse.ProtoReflect.Descriptor instead.
+func (*ListDevicesResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListDevicesResponse) GetDevices() []*ListDevicesResponse_Device {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+type RotateDeviceKeyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Device to rotate the key of. Rotates the key of the calling device when
+	// empty.
+	DeviceId      string `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateDeviceKeyRequest) Reset() {
+	*x = RotateDeviceKeyRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateDeviceKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateDeviceKeyRequest) ProtoMessage() {}
+
+func (x *RotateDeviceKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateDeviceKeyRequest.ProtoReflect.Descriptor instead.
+func (*RotateDeviceKeyRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RotateDeviceKeyRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+type RotateDeviceKeyResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	DeviceId string                 `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	// new device key encrypted with the key encryption key of the calling
+	// device, like in CreateDeviceResponse. When a device rotates its own key,
+	// this is its previous key.
+	EncryptedDeviceKey []byte `protobuf:"bytes,2,opt,name=encrypted_device_key,json=encryptedDeviceKey,proto3" json:"encrypted_device_key,omitempty"`
+	// version of the server secret the new device key is derived from.
+	KeyVersion int32 `protobuf:"varint,3,opt,name=key_version,json=keyVersion,proto3" json:"key_version,omitempty"`
+	// generation of the new device key. Devices name the key version and
+	// generation other than 0 as the key id in the footer of their tokens.
+	KeyGeneration int32 `protobuf:"varint,4,opt,name=key_generation,json=keyGeneration,proto3" json:"key_generation,omitempty"`
+	// identity of the server that tokens minted with the new key must be
+	// minted for.
+	ServerId      string `protobuf:"bytes,5,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateDeviceKeyResponse) Reset() {
+	*x = RotateDeviceKeyResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateDeviceKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateDeviceKeyResponse) ProtoMessage() {}
+
+func (x *RotateDeviceKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateDeviceKeyResponse.ProtoReflect.Descriptor instead.
+func (*RotateDeviceKeyResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RotateDeviceKeyResponse) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *RotateDeviceKeyResponse) GetEncryptedDeviceKey() []byte {
+	if x != nil {
+		return x.EncryptedDeviceKey
+	}
+	return nil
+}
+
+func (x *RotateDeviceKeyResponse) GetKeyVersion() int32 {
+	if x != nil {
+		return x.KeyVersion
+	}
+	return 0
+}
+
+func (x *RotateDeviceKeyResponse) GetKeyGeneration() int32 {
+	if x != nil {
+		return x.KeyGeneration
+	}
+	return 0
+}
+
+func (x *RotateDeviceKeyResponse) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+type RegisterPublicKeyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Ed25519 public key. Replaces the one registered before, if any.
+	PublicKey     []byte `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterPublicKeyRequest) Reset() {
+	*x = RegisterPublicKeyRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterPublicKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterPublicKeyRequest) ProtoMessage() {}
+
+func (x *RegisterPublicKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterPublicKeyRequest.ProtoReflect.Descriptor instead.
+func (*RegisterPublicKeyRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RegisterPublicKeyRequest) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+type RegisterPublicKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterPublicKeyResponse) Reset() {
+	*x = RegisterPublicKeyResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterPublicKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterPublicKeyResponse) ProtoMessage() {}
+
+func (x *RegisterPublicKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterPublicKeyResponse.ProtoReflect.Descriptor instead.
+func (*RegisterPublicKeyResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{9}
+}
+
+type Enrollment struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name     string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Platform string                 `protobuf:"bytes,3,opt,name=platform,proto3" json:"platform,omitempty"`
+	// SHA256 fingerprint of the public key of the device, which the device
+	// shows so that admins can tell it is the one they expect.
+	Fingerprint string          `protobuf:"bytes,4,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	State       EnrollmentState `protobuf:"varint,5,opt,name=state,proto3,enum=devices.v1.EnrollmentState" json:"state,omitempty"`
+	// address the request was submitted from.
+	RemoteAddr string                 `protobuf:"bytes,6,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
+	CreateTime *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	ExpireTime *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	// device created for the request once approved.
+	DeviceId      string `protobuf:"bytes,9,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Enrollment) Reset() {
+	*x = Enrollment{}
+	mi := &file_devices_v1_devices_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Enrollment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Enrollment) ProtoMessage() {}
+
+func (x *Enrollment) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Enrollment.ProtoReflect.Descriptor instead.
+func (*Enrollment) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Enrollment) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Enrollment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Enrollment) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *Enrollment) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *Enrollment) GetState() EnrollmentState {
+	if x != nil {
+		return x.State
+	}
+	return EnrollmentState_ENROLLMENT_STATE_UNSPECIFIED
+}
+
+func (x *Enrollment) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
+}
+
+func (x *Enrollment) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *Enrollment) GetExpireTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpireTime
+	}
+	return nil
+}
+
+func (x *Enrollment) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+type RequestEnrollmentRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name of the device, e.g. its host name.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// platform of the device, e.g. linux or ios.
+	Platform string `protobuf:"bytes,2,opt,name=platform,proto3" json:"platform,omitempty"`
+	// Ed25519 public key of the device.
+	PublicKey     []byte `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestEnrollmentRequest) Reset() {
+	*x = RequestEnrollmentRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestEnrollmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestEnrollmentRequest) ProtoMessage() {}
+
+func (x *RequestEnrollmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestEnrollmentRequest.ProtoReflect.Descriptor instead.
+func (*RequestEnrollmentRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RequestEnrollmentRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RequestEnrollmentRequest) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *RequestEnrollmentRequest) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+type RequestEnrollmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enrollment    *Enrollment            `protobuf:"bytes,1,opt,name=enrollment,proto3" json:"enrollment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestEnrollmentResponse) Reset() {
+	*x = RequestEnrollmentResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestEnrollmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestEnrollmentResponse) ProtoMessage() {}
+
+func (x *RequestEnrollmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestEnrollmentResponse.ProtoReflect.Descriptor instead.
+func (*RequestEnrollmentResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RequestEnrollmentResponse) GetEnrollment() *Enrollment {
+	if x != nil {
+		return x.Enrollment
+	}
+	return nil
+}
+
+type GetEnrollmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EnrollmentId  string                 `protobuf:"bytes,1,opt,name=enrollment_id,json=enrollmentId,proto3" json:"enrollment_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEnrollmentRequest) Reset() {
+	*x = GetEnrollmentRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEnrollmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEnrollmentRequest) ProtoMessage() {}
+
+func (x *GetEnrollmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEnrollmentRequest.ProtoReflect.Descriptor instead.
+func (*GetEnrollmentRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetEnrollmentRequest) GetEnrollmentId() string {
+	if x != nil {
+		return x.EnrollmentId
+	}
+	return ""
+}
+
+type GetEnrollmentResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Enrollment *Enrollment            `protobuf:"bytes,1,opt,name=enrollment,proto3" json:"enrollment,omitempty"`
+	// version of the server secret of the device once approved, which names
+	// the identity of the server its tokens must be minted for.
+	KeyVersion int32 `protobuf:"varint,2,opt,name=key_version,json=keyVersion,proto3" json:"key_version,omitempty"`
+	// identity of the server that tokens of the device must be minted for once
+	// approved.
+	ServerId      string `protobuf:"bytes,3,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEnrollmentResponse) Reset() {
+	*x = GetEnrollmentResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEnrollmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEnrollmentResponse) ProtoMessage() {}
+
+func (x *GetEnrollmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEnrollmentResponse.ProtoReflect.Descriptor instead.
+func (*GetEnrollmentResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetEnrollmentResponse) GetEnrollment() *Enrollment {
+	if x != nil {
+		return x.Enrollment
+	}
+	return nil
+}
+
+func (x *GetEnrollmentResponse) GetKeyVersion() int32 {
+	if x != nil {
+		return x.KeyVersion
+	}
+	return 0
+}
+
+func (x *GetEnrollmentResponse) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+type ListEnrollmentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEnrollmentsRequest) Reset() {
+	*x = ListEnrollmentsRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEnrollmentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEnrollmentsRequest) ProtoMessage() {}
+
+func (x *ListEnrollmentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEnrollmentsRequest.ProtoReflect.Descriptor instead.
+func (*ListEnrollmentsRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{15}
+}
+
+type ListEnrollmentsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// pending enrollment requests.
+	Enrollments   []*Enrollment `protobuf:"bytes,1,rep,name=enrollments,proto3" json:"enrollments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEnrollmentsResponse) Reset() {
+	*x = ListEnrollmentsResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEnrollmentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEnrollmentsResponse) ProtoMessage() {}
+
+func (x *ListEnrollmentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEnrollmentsResponse.ProtoReflect.Descriptor instead.
+func (*ListEnrollmentsResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListEnrollmentsResponse) GetEnrollments() []*Enrollment {
+	if x != nil {
+		return x.Enrollments
+	}
+	return nil
+}
+
+type ApproveEnrollmentRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	EnrollmentId string                 `protobuf:"bytes,1,opt,name=enrollment_id,json=enrollmentId,proto3" json:"enrollment_id,omitempty"`
+	// Role of the new device. Defaults to ROLE_MEMBER.
+	Role          Role `protobuf:"varint,2,opt,name=role,proto3,enum=devices.v1.Role" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApproveEnrollmentRequest) Reset() {
+	*x = ApproveEnrollmentRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApproveEnrollmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApproveEnrollmentRequest) ProtoMessage() {}
+
+func (x *ApproveEnrollmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApproveEnrollmentRequest.ProtoReflect.Descriptor instead.
+func (*ApproveEnrollmentRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ApproveEnrollmentRequest) GetEnrollmentId() string {
+	if x != nil {
+		return x.EnrollmentId
+	}
+	return ""
+}
+
+func (x *ApproveEnrollmentRequest) GetRole() Role {
+	if x != nil {
+		return x.Role
+	}
+	return Role_ROLE_UNSPECIFIED
+}
+
+type ApproveEnrollmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enrollment    *Enrollment            `protobuf:"bytes,1,opt,name=enrollment,proto3" json:"enrollment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApproveEnrollmentResponse) Reset() {
+	*x = ApproveEnrollmentResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApproveEnrollmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApproveEnrollmentResponse) ProtoMessage() {}
+
+func (x *ApproveEnrollmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApproveEnrollmentResponse.ProtoReflect.Descriptor instead.
+func (*ApproveEnrollmentResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ApproveEnrollmentResponse) GetEnrollment() *Enrollment {
+	if x != nil {
+		return x.Enrollment
+	}
+	return nil
+}
+
+type DenyEnrollmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EnrollmentId  string                 `protobuf:"bytes,1,opt,name=enrollment_id,json=enrollmentId,proto3" json:"enrollment_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DenyEnrollmentRequest) Reset() {
+	*x = DenyEnrollmentRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DenyEnrollmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DenyEnrollmentRequest) ProtoMessage() {}
+
+func (x *DenyEnrollmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DenyEnrollmentRequest.ProtoReflect.Descriptor instead.
+func (*DenyEnrollmentRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DenyEnrollmentRequest) GetEnrollmentId() string {
+	if x != nil {
+		return x.EnrollmentId
+	}
+	return ""
+}
+
+type DenyEnrollmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enrollment    *Enrollment            `protobuf:"bytes,1,opt,name=enrollment,proto3" json:"enrollment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DenyEnrollmentResponse) Reset() {
+	*x = DenyEnrollmentResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DenyEnrollmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DenyEnrollmentResponse) ProtoMessage() {}
+
+func (x *DenyEnrollmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DenyEnrollmentResponse.ProtoReflect.Descriptor instead.
+func (*DenyEnrollmentResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DenyEnrollmentResponse) GetEnrollment() *Enrollment {
+	if x != nil {
+		return x.Enrollment
+	}
+	return nil
+}
+
+type DeleteDeviceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDeviceRequest) Reset() {
+	*x = DeleteDeviceRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDeviceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDeviceRequest) ProtoMessage() {}
+
+func (x *DeleteDeviceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDeviceRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDeviceRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DeleteDeviceRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteDeviceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDeviceResponse) Reset() {
+	*x = DeleteDeviceResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDeviceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDeviceResponse) ProtoMessage() {}
+
+func (x *DeleteDeviceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDeviceResponse.ProtoReflect.Descriptor instead.
+func (*DeleteDeviceResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{22}
+}
+
+type SSHKey struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// SHA256 fingerprint of the key as printed by ssh-keygen -l.
+	Fingerprint string `protobuf:"bytes,1,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	DeviceId    string `protobuf:"bytes,2,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	// Public key in authorized_keys format.
+	PublicKey     string `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Comment       string `protobuf:"bytes,4,opt,name=comment,proto3" json:"comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SSHKey) Reset() {
+	*x = SSHKey{}
+	mi := &file_devices_v1_devices_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SSHKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SSHKey) ProtoMessage() {}
+
+func (x *SSHKey) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SSHKey.ProtoReflect.Descriptor instead.
+func (*SSHKey) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SSHKey) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *SSHKey) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *SSHKey) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *SSHKey) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+type AddSSHKeyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Device the key is registered to. Defaults to the calling device.
+	DeviceId string `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	// Public key in authorized_keys format.
+	PublicKey     string `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddSSHKeyRequest) Reset() {
+	*x = AddSSHKeyRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddSSHKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddSSHKeyRequest) ProtoMessage() {}
+
+func (x *AddSSHKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddSSHKeyRequest.ProtoReflect.Descriptor instead.
+func (*AddSSHKeyRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *AddSSHKeyRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *AddSSHKeyRequest) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+type AddSSHKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           *SSHKey                `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddSSHKeyResponse) Reset() {
+	*x = AddSSHKeyResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddSSHKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddSSHKeyResponse) ProtoMessage() {}
+
+func (x *AddSSHKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddSSHKeyResponse.ProtoReflect.Descriptor instead.
+func (*AddSSHKeyResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *AddSSHKeyResponse) GetKey() *SSHKey {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+type ListSSHKeysRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only list keys for this device. Lists keys for all devices when empty.
+	DeviceId      string `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSSHKeysRequest) Reset() {
+	*x = ListSSHKeysRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSSHKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSSHKeysRequest) ProtoMessage() {}
+
+func (x *ListSSHKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSSHKeysRequest.ProtoReflect.Descriptor instead.
+func (*ListSSHKeysRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ListSSHKeysRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+type ListSSHKeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []*SSHKey              `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSSHKeysResponse) Reset() {
+	*x = ListSSHKeysResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSSHKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSSHKeysResponse) ProtoMessage() {}
+
+func (x *ListSSHKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSSHKeysResponse.ProtoReflect.Descriptor instead.
+func (*ListSSHKeysResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListSSHKeysResponse) GetKeys() []*SSHKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type RemoveSSHKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fingerprint   string                 `protobuf:"bytes,1,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveSSHKeyRequest) Reset() {
+	*x = RemoveSSHKeyRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveSSHKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveSSHKeyRequest) ProtoMessage() {}
+
+func (x *RemoveSSHKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveSSHKeyRequest.ProtoReflect.Descriptor instead.
+func (*RemoveSSHKeyRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RemoveSSHKeyRequest) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+type RemoveSSHKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveSSHKeyResponse) Reset() {
+	*x = RemoveSSHKeyResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveSSHKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveSSHKeyResponse) ProtoMessage() {}
+
+func (x *RemoveSSHKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveSSHKeyResponse.ProtoReflect.Descriptor instead.
+func (*RemoveSSHKeyResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{29}
+}
+
+type PathGrant struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	DeviceId string                 `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	// Path the grant applies to, along with everything below it.
+	Prefix        string `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Read          bool   `protobuf:"varint,3,opt,name=read,proto3" json:"read,omitempty"`
+	Write         bool   `protobuf:"varint,4,opt,name=write,proto3" json:"write,omitempty"`
+	Delete        bool   `protobuf:"varint,5,opt,name=delete,proto3" json:"delete,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PathGrant) Reset() {
+	*x = PathGrant{}
+	mi := &file_devices_v1_devices_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PathGrant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PathGrant) ProtoMessage() {}
+
+func (x *PathGrant) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PathGrant.ProtoReflect.Descriptor instead.
+func (*PathGrant) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *PathGrant) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *PathGrant) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *PathGrant) GetRead() bool {
+	if x != nil {
+		return x.Read
+	}
+	return false
+}
+
+func (x *PathGrant) GetWrite() bool {
+	if x != nil {
+		return x.Write
+	}
+	return false
+}
+
+func (x *PathGrant) GetDelete() bool {
+	if x != nil {
+		return x.Delete
+	}
+	return false
+}
+
+type GrantPathRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Replaces the grant of the device for the same prefix, if any.
+	Grant         *PathGrant `protobuf:"bytes,1,opt,name=grant,proto3" json:"grant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrantPathRequest) Reset() {
+	*x = GrantPathRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrantPathRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrantPathRequest) ProtoMessage() {}
+
+func (x *GrantPathRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrantPathRequest.ProtoReflect.Descriptor instead.
+func (*GrantPathRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *GrantPathRequest) GetGrant() *PathGrant {
+	if x != nil {
+		return x.Grant
+	}
+	return nil
+}
+
+type GrantPathResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Grant         *PathGrant             `protobuf:"bytes,1,opt,name=grant,proto3" json:"grant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrantPathResponse) Reset() {
+	*x = GrantPathResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrantPathResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrantPathResponse) ProtoMessage() {}
+
+func (x *GrantPathResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrantPathResponse.ProtoReflect.Descriptor instead.
+func (*GrantPathResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GrantPathResponse) GetGrant() *PathGrant {
+	if x != nil {
+		return x.Grant
+	}
+	return nil
+}
+
+type ListPathGrantsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only list grants of this device. Lists grants of all devices when empty.
+	DeviceId      string `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPathGrantsRequest) Reset() {
+	*x = ListPathGrantsRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPathGrantsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPathGrantsRequest) ProtoMessage() {}
+
+func (x *ListPathGrantsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPathGrantsRequest.ProtoReflect.Descriptor instead.
+func (*ListPathGrantsRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ListPathGrantsRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+type ListPathGrantsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Grants        []*PathGrant           `protobuf:"bytes,1,rep,name=grants,proto3" json:"grants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPathGrantsResponse) Reset() {
+	*x = ListPathGrantsResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPathGrantsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPathGrantsResponse) ProtoMessage() {}
+
+func (x *ListPathGrantsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPathGrantsResponse.ProtoReflect.Descriptor instead.
+func (*ListPathGrantsResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ListPathGrantsResponse) GetGrants() []*PathGrant {
+	if x != nil {
+		return x.Grants
+	}
+	return nil
+}
+
+type RevokePathRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeviceId      string                 `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	Prefix        string                 `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokePathRequest) Reset() {
+	*x = RevokePathRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokePathRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokePathRequest) ProtoMessage() {}
+
+func (x *RevokePathRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokePathRequest.ProtoReflect.Descriptor instead.
+func (*RevokePathRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *RevokePathRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *RevokePathRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+type RevokePathResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokePathResponse) Reset() {
+	*x = RevokePathResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokePathResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokePathResponse) ProtoMessage() {}
+
+func (x *RevokePathResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokePathResponse.ProtoReflect.Descriptor instead.
+func (*RevokePathResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{36}
+}
+
+// Ban is a peer address or device whose authentication attempts are refused
+// until it expires. Exactly one of remote_addr and device_id is set.
+type Ban struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	RemoteAddr string                 `protobuf:"bytes,1,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
+	DeviceId   string                 `protobuf:"bytes,2,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	ExpireTime *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	// Number of bans in a row, this one included. Each lasts twice as long as
+	// the previous one.
+	Count         int32 `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ban) Reset() {
+	*x = Ban{}
+	mi := &file_devices_v1_devices_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ban) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ban) ProtoMessage() {}
+
+func (x *Ban) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ban.ProtoReflect.Descriptor instead.
+func (*Ban) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *Ban) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
+}
+
+func (x *Ban) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *Ban) GetExpireTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpireTime
+	}
+	return nil
+}
+
+func (x *Ban) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type ListBansRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBansRequest) Reset() {
+	*x = ListBansRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBansRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBansRequest) ProtoMessage() {}
+
+func (x *ListBansRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBansRequest.ProtoReflect.Descriptor instead.
+func (*ListBansRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{38}
+}
+
+type ListBansResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Bans in effect, the longest lasting first.
+	Bans          []*Ban `protobuf:"bytes,1,rep,name=bans,proto3" json:"bans,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBansResponse) Reset() {
+	*x = ListBansResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBansResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBansResponse) ProtoMessage() {}
+
+func (x *ListBansResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBansResponse.ProtoReflect.Descriptor instead.
+func (*ListBansResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ListBansResponse) GetBans() []*Ban {
+	if x != nil {
+		return x.Bans
+	}
+	return nil
+}
+
+type ClearBansRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Target:
+	//
+	//	*ClearBansRequest_RemoteAddr
+	//	*ClearBansRequest_DeviceId
+	//	*ClearBansRequest_All
+	Target        isClearBansRequest_Target `protobuf_oneof:"target"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearBansRequest) Reset() {
+	*x = ClearBansRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearBansRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearBansRequest) ProtoMessage() {}
+
+func (x *ClearBansRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearBansRequest.ProtoReflect.Descriptor instead.
+func (*ClearBansRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ClearBansRequest) GetTarget() isClearBansRequest_Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *ClearBansRequest) GetRemoteAddr() string {
+	if x != nil {
+		if x, ok := x.Target.(*ClearBansRequest_RemoteAddr); ok {
+			return x.RemoteAddr
+		}
+	}
+	return ""
+}
+
+func (x *ClearBansRequest) GetDeviceId() string {
+	if x != nil {
+		if x, ok := x.Target.(*ClearBansRequest_DeviceId); ok {
+			return x.DeviceId
+		}
+	}
+	return ""
+}
+
+func (x *ClearBansRequest) GetAll() bool {
+	if x != nil {
+		if x, ok := x.Target.(*ClearBansRequest_All); ok {
+			return x.All
+		}
+	}
+	return false
+}
+
+type isClearBansRequest_Target interface {
+	isClearBansRequest_Target()
+}
+
+type ClearBansRequest_RemoteAddr struct {
+	// Lifts the ban of a peer address and forgets its failed attempts.
+	RemoteAddr string `protobuf:"bytes,1,opt,name=remote_addr,json=remoteAddr,proto3,oneof"`
+}
+
+type ClearBansRequest_DeviceId struct {
+	// Lifts the ban of a device and forgets its failed attempts.
+	DeviceId string `protobuf:"bytes,2,opt,name=device_id,json=deviceId,proto3,oneof"`
+}
+
+type ClearBansRequest_All struct {
+	// Lifts every ban.
+	All bool `protobuf:"varint,3,opt,name=all,proto3,oneof"`
+}
+
+func (*ClearBansRequest_RemoteAddr) isClearBansRequest_Target() {}
+
+func (*ClearBansRequest_DeviceId) isClearBansRequest_Target() {}
+
+func (*ClearBansRequest_All) isClearBansRequest_Target() {}
+
+type ClearBansResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of peer addresses and devices whose ban or failed attempts were
+	// cleared.
+	Cleared       int32 `protobuf:"varint,1,opt,name=cleared,proto3" json:"cleared,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearBansResponse) Reset() {
+	*x = ClearBansResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearBansResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearBansResponse) ProtoMessage() {}
+
+func (x *ClearBansResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearBansResponse.ProtoReflect.Descriptor instead.
+func (*ClearBansResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ClearBansResponse) GetCleared() int32 {
+	if x != nil {
+		return x.Cleared
+	}
+	return 0
+}
+
+type StartWebLoginRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateDeviceRequest) Reset() {
-	*x = CreateDeviceRequest{}
-	mi := &file_devices_v1_devices_proto_msgTypes[0]
+func (x *StartWebLoginRequest) Reset() {
+	*x = StartWebLoginRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateDeviceRequest) String() string {
+func (x *StartWebLoginRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateDeviceRequest) ProtoMessage() {}
+func (*StartWebLoginRequest) ProtoMessage() {}
 
-func (x *CreateDeviceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_devices_v1_devices_proto_msgTypes[0]
+func (x *StartWebLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -53,38 +2394,35 @@ func (x *CreateDeviceRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateDeviceRequest.ProtoReflect.Descriptor instead.
-func (*CreateDeviceRequest) Descriptor() ([]byte, []int) {
-	return file_devices_v1_devices_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use StartWebLoginRequest.ProtoReflect.Descriptor instead.
+func (*StartWebLoginRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{42}
 }
 
-type CreateDeviceResponse struct {
+type StartWebLoginResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	// new device key is encrypted with the key encryption key of the calling
-	// device. This prevents exposure of the new device key. Its assumed that the
-	// current device will share the decrypted device key out of band (via QR
-	// code etc).
-	EncryptedDeviceKey []byte `protobuf:"bytes,2,opt,name=encrypted_device_key,json=encryptedDeviceKey,proto3" json:"encrypted_device_key,omitempty"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+	// code for a paired device to approve the login with.
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	ExpireTime    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateDeviceResponse) Reset() {
-	*x = CreateDeviceResponse{}
-	mi := &file_devices_v1_devices_proto_msgTypes[1]
+func (x *StartWebLoginResponse) Reset() {
+	*x = StartWebLoginResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateDeviceResponse) String() string {
+func (x *StartWebLoginResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateDeviceResponse) ProtoMessage() {}
+func (*StartWebLoginResponse) ProtoMessage() {}
 
-func (x *CreateDeviceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_devices_v1_devices_proto_msgTypes[1]
+func (x *StartWebLoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -95,46 +2433,46 @@ func (x *CreateDeviceResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateDeviceResponse.ProtoReflect.Descriptor instead.
-func (*CreateDeviceResponse) Descriptor() ([]byte, []int) {
-	return file_devices_v1_devices_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use StartWebLoginResponse.ProtoReflect.Descriptor instead.
+func (*StartWebLoginResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{43}
 }
 
-func (x *CreateDeviceResponse) GetId() string {
+func (x *StartWebLoginResponse) GetCode() string {
 	if x != nil {
-		return x.Id
+		return x.Code
 	}
 	return ""
 }
 
-func (x *CreateDeviceResponse) GetEncryptedDeviceKey() []byte {
+func (x *StartWebLoginResponse) GetExpireTime() *timestamppb.Timestamp {
 	if x != nil {
-		return x.EncryptedDeviceKey
+		return x.ExpireTime
 	}
 	return nil
 }
 
-type ListDevicesRequest struct {
+type CompleteWebLoginRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListDevicesRequest) Reset() {
-	*x = ListDevicesRequest{}
-	mi := &file_devices_v1_devices_proto_msgTypes[2]
+func (x *CompleteWebLoginRequest) Reset() {
+	*x = CompleteWebLoginRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListDevicesRequest) String() string {
+func (x *CompleteWebLoginRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDevicesRequest) ProtoMessage() {}
+func (*CompleteWebLoginRequest) ProtoMessage() {}
 
-func (x *ListDevicesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_devices_v1_devices_proto_msgTypes[2]
+func (x *CompleteWebLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -145,33 +2483,95 @@ func (x *ListDevicesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDevicesRequest.ProtoReflect.Descriptor instead.
-func (*ListDevicesRequest) Descriptor() ([]byte, []int) {
-	return file_devices_v1_devices_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use CompleteWebLoginRequest.ProtoReflect.Descriptor instead.
+func (*CompleteWebLoginRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{44}
 }
 
-type ListDevicesResponse struct {
-	state         protoimpl.MessageState        `protogen:"open.v1"`
-	Devices       []*ListDevicesResponse_Device `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+type CompleteWebLoginResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Whether the login was approved. The session cookie is only set once it
+	// is, and the browser must keep polling until then.
+	Approved bool `protobuf:"varint,1,opt,name=approved,proto3" json:"approved,omitempty"`
+	// device the browser is logged in as once approved.
+	DeviceId          string                 `protobuf:"bytes,2,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	SessionExpireTime *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=session_expire_time,json=sessionExpireTime,proto3" json:"session_expire_time,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CompleteWebLoginResponse) Reset() {
+	*x = CompleteWebLoginResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompleteWebLoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteWebLoginResponse) ProtoMessage() {}
+
+func (x *CompleteWebLoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteWebLoginResponse.ProtoReflect.Descriptor instead.
+func (*CompleteWebLoginResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *CompleteWebLoginResponse) GetApproved() bool {
+	if x != nil {
+		return x.Approved
+	}
+	return false
+}
+
+func (x *CompleteWebLoginResponse) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *CompleteWebLoginResponse) GetSessionExpireTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SessionExpireTime
+	}
+	return nil
+}
+
+type EndWebSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListDevicesResponse) Reset() {
-	*x = ListDevicesResponse{}
-	mi := &file_devices_v1_devices_proto_msgTypes[3]
+func (x *EndWebSessionRequest) Reset() {
+	*x = EndWebSessionRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListDevicesResponse) String() string {
+func (x *EndWebSessionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDevicesResponse) ProtoMessage() {}
+func (*EndWebSessionRequest) ProtoMessage() {}
 
-func (x *ListDevicesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_devices_v1_devices_proto_msgTypes[3]
+func (x *EndWebSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -182,40 +2582,69 @@ func (x *ListDevicesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDevicesResponse.ProtoReflect.Descriptor instead.
-func (*ListDevicesResponse) Descriptor() ([]byte, []int) {
-	return file_devices_v1_devices_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use EndWebSessionRequest.ProtoReflect.Descriptor instead.
+func (*EndWebSessionRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{46}
 }
 
-func (x *ListDevicesResponse) GetDevices() []*ListDevicesResponse_Device {
+type EndWebSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EndWebSessionResponse) Reset() {
+	*x = EndWebSessionResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EndWebSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EndWebSessionResponse) ProtoMessage() {}
+
+func (x *EndWebSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[47]
 	if x != nil {
-		return x.Devices
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-type DeleteDeviceRequest struct {
+// Deprecated: Use EndWebSessionResponse.ProtoReflect.Descriptor instead.
+func (*EndWebSessionResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{47}
+}
+
+type ApproveWebLoginRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteDeviceRequest) Reset() {
-	*x = DeleteDeviceRequest{}
-	mi := &file_devices_v1_devices_proto_msgTypes[4]
+func (x *ApproveWebLoginRequest) Reset() {
+	*x = ApproveWebLoginRequest{}
+	mi := &file_devices_v1_devices_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteDeviceRequest) String() string {
+func (x *ApproveWebLoginRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteDeviceRequest) ProtoMessage() {}
+func (*ApproveWebLoginRequest) ProtoMessage() {}
 
-func (x *DeleteDeviceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_devices_v1_devices_proto_msgTypes[4]
+func (x *ApproveWebLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -226,39 +2655,42 @@ func (x *DeleteDeviceRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteDeviceRequest.ProtoReflect.Descriptor instead.
-func (*DeleteDeviceRequest) Descriptor() ([]byte, []int) {
-	return file_devices_v1_devices_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use ApproveWebLoginRequest.ProtoReflect.Descriptor instead.
+func (*ApproveWebLoginRequest) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{48}
 }
 
-func (x *DeleteDeviceRequest) GetId() string {
+func (x *ApproveWebLoginRequest) GetCode() string {
 	if x != nil {
-		return x.Id
+		return x.Code
 	}
 	return ""
 }
 
-type DeleteDeviceResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+type ApproveWebLoginResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// address and user agent of the browser that started the login.
+	RemoteAddr    string `protobuf:"bytes,1,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
+	UserAgent     string `protobuf:"bytes,2,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteDeviceResponse) Reset() {
-	*x = DeleteDeviceResponse{}
-	mi := &file_devices_v1_devices_proto_msgTypes[5]
+func (x *ApproveWebLoginResponse) Reset() {
+	*x = ApproveWebLoginResponse{}
+	mi := &file_devices_v1_devices_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteDeviceResponse) String() string {
+func (x *ApproveWebLoginResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteDeviceResponse) ProtoMessage() {}
+func (*ApproveWebLoginResponse) ProtoMessage() {}
 
-func (x *DeleteDeviceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_devices_v1_devices_proto_msgTypes[5]
+func (x *ApproveWebLoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_devices_v1_devices_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -269,21 +2701,51 @@ func (x *DeleteDeviceResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteDeviceResponse.ProtoReflect.Descriptor instead.
-func (*DeleteDeviceResponse) Descriptor() ([]byte, []int) {
-	return file_devices_v1_devices_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use ApproveWebLoginResponse.ProtoReflect.Descriptor instead.
+func (*ApproveWebLoginResponse) Descriptor() ([]byte, []int) {
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *ApproveWebLoginResponse) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
+}
+
+func (x *ApproveWebLoginResponse) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
 }
 
 type ListDevicesResponse_Device struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Role  Role                   `protobuf:"varint,2,opt,name=role,proto3,enum=devices.v1.Role" json:"role,omitempty"`
+	// version of the server secret the device key is derived from.
+	KeyVersion int32 `protobuf:"varint,3,opt,name=key_version,json=keyVersion,proto3" json:"key_version,omitempty"`
+	// whether the device authenticates with a registered public key.
+	HasPublicKey bool   `protobuf:"varint,4,opt,name=has_public_key,json=hasPublicKey,proto3" json:"has_public_key,omitempty"`
+	Name         string `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	Platform     string `protobuf:"bytes,6,opt,name=platform,proto3" json:"platform,omitempty"`
+	// version of the app the device last authenticated with, if it reports
+	// it.
+	AppVersion string `protobuf:"bytes,7,opt,name=app_version,json=appVersion,proto3" json:"app_version,omitempty"`
+	// unset for devices created before it was recorded.
+	CreateTime *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	// when and from where the device last authenticated, unset if it never
+	// did. It is only recorded every few minutes, so it can be that late.
+	LastSeenTime  *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=last_seen_time,json=lastSeenTime,proto3" json:"last_seen_time,omitempty"`
+	LastSeenIp    string                 `protobuf:"bytes,10,opt,name=last_seen_ip,json=lastSeenIp,proto3" json:"last_seen_ip,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListDevicesResponse_Device) Reset() {
 	*x = ListDevicesResponse_Device{}
-	mi := &file_devices_v1_devices_proto_msgTypes[6]
+	mi := &file_devices_v1_devices_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -295,7 +2757,7 @@ func (x *ListDevicesResponse_Device) String() string {
 func (*ListDevicesResponse_Device) ProtoMessage() {}
 
 func (x *ListDevicesResponse_Device) ProtoReflect() protoreflect.Message {
-	mi := &file_devices_v1_devices_proto_msgTypes[6]
+	mi := &file_devices_v1_devices_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -308,7 +2770,7 @@ func (x *ListDevicesResponse_Device) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListDevicesResponse_Device.ProtoReflect.Descriptor instead.
 func (*ListDevicesResponse_Device) Descriptor() ([]byte, []int) {
-	return file_devices_v1_devices_proto_rawDescGZIP(), []int{3, 0}
+	return file_devices_v1_devices_proto_rawDescGZIP(), []int{5, 0}
 }
 
 func (x *ListDevicesResponse_Device) GetId() string {
@@ -318,28 +2780,293 @@ func (x *ListDevicesResponse_Device) GetId() string {
 	return ""
 }
 
+func (x *ListDevicesResponse_Device) GetRole() Role {
+	if x != nil {
+		return x.Role
+	}
+	return Role_ROLE_UNSPECIFIED
+}
+
+func (x *ListDevicesResponse_Device) GetKeyVersion() int32 {
+	if x != nil {
+		return x.KeyVersion
+	}
+	return 0
+}
+
+func (x *ListDevicesResponse_Device) GetHasPublicKey() bool {
+	if x != nil {
+		return x.HasPublicKey
+	}
+	return false
+}
+
+func (x *ListDevicesResponse_Device) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ListDevicesResponse_Device) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *ListDevicesResponse_Device) GetAppVersion() string {
+	if x != nil {
+		return x.AppVersion
+	}
+	return ""
+}
+
+func (x *ListDevicesResponse_Device) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *ListDevicesResponse_Device) GetLastSeenTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeenTime
+	}
+	return nil
+}
+
+func (x *ListDevicesResponse_Device) GetLastSeenIp() string {
+	if x != nil {
+		return x.LastSeenIp
+	}
+	return ""
+}
+
 var File_devices_v1_devices_proto protoreflect.FileDescriptor
 
 const file_devices_v1_devices_proto_rawDesc = "" +
 	"\n" +
 	"\x18devices/v1/devices.proto\x12\n" +
-	"devices.v1\x1a\x1bbuf/validate/validate.proto\"\x15\n" +
-	"\x13CreateDeviceRequest\"b\n" +
+	"devices.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bbuf/validate/validate.proto\"b\n" +
+	"\x13CreateDeviceRequest\x12.\n" +
+	"\x04role\x18\x01 \x01(\x0e2\x10.devices.v1.RoleB\b\xbaH\x05\x82\x01\x02\x10\x01R\x04role\x12\x1b\n" +
+	"\x04name\x18\x02 \x01(\tB\a\xbaH\x04r\x02\x18@R\x04name\"\x82\x02\n" +
 	"\x14CreateDeviceResponse\x12\x18\n" +
-	"\x02id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x02id\x120\n" +
-	"\x14encrypted_device_key\x18\x02 \x01(\fR\x12encryptedDeviceKey\"\x14\n" +
-	"\x12ListDevicesRequest\"{\n" +
+	"\x02id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x02id\x12\x1f\n" +
+	"\vkey_version\x18\x03 \x01(\x05R\n" +
+	"keyVersion\x12\x1b\n" +
+	"\tserver_id\x18\x04 \x01(\tR\bserverId\x12!\n" +
+	"\fpairing_code\x18\x05 \x01(\tR\vpairingCode\x12S\n" +
+	"\x18pairing_code_expire_time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x15pairingCodeExpireTimeJ\x04\b\x02\x10\x03R\x14encrypted_device_key\"\x84\x01\n" +
+	"\x12ClaimDeviceRequest\x12,\n" +
+	"\fpairing_code\x18\x01 \x01(\tB\t\xbaH\x06r\x04\x10\x01\x18@R\vpairingCode\x12\x1b\n" +
+	"\x04name\x18\x02 \x01(\tB\a\xbaH\x04r\x02\x18@R\x04name\x12#\n" +
+	"\bplatform\x18\x03 \x01(\tB\a\xbaH\x04r\x02\x18 R\bplatform\"\xc0\x01\n" +
+	"\x13ClaimDeviceResponse\x12%\n" +
+	"\tdevice_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\bdeviceId\x12\x1d\n" +
+	"\n" +
+	"device_key\x18\x02 \x01(\fR\tdeviceKey\x12\x1f\n" +
+	"\vkey_version\x18\x03 \x01(\x05R\n" +
+	"keyVersion\x12%\n" +
+	"\x0ekey_generation\x18\x04 \x01(\x05R\rkeyGeneration\x12\x1b\n" +
+	"\tserver_id\x18\x05 \x01(\tR\bserverId\"\x14\n" +
+	"\x12ListDevicesRequest\"\xdb\x03\n" +
 	"\x13ListDevicesResponse\x12@\n" +
-	"\adevices\x18\x01 \x03(\v2&.devices.v1.ListDevicesResponse.DeviceR\adevices\x1a\"\n" +
+	"\adevices\x18\x01 \x03(\v2&.devices.v1.ListDevicesResponse.DeviceR\adevices\x1a\x81\x03\n" +
 	"\x06Device\x12\x18\n" +
-	"\x02id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x02id\"/\n" +
+	"\x02id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x02id\x12$\n" +
+	"\x04role\x18\x02 \x01(\x0e2\x10.devices.v1.RoleR\x04role\x12\x1f\n" +
+	"\vkey_version\x18\x03 \x01(\x05R\n" +
+	"keyVersion\x12$\n" +
+	"\x0ehas_public_key\x18\x04 \x01(\bR\fhasPublicKey\x12\x12\n" +
+	"\x04name\x18\x05 \x01(\tR\x04name\x12\x1a\n" +
+	"\bplatform\x18\x06 \x01(\tR\bplatform\x12\x1f\n" +
+	"\vapp_version\x18\a \x01(\tR\n" +
+	"appVersion\x12;\n" +
+	"\vcreate_time\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x12@\n" +
+	"\x0elast_seen_time\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\flastSeenTime\x12 \n" +
+	"\flast_seen_ip\x18\n" +
+	" \x01(\tR\n" +
+	"lastSeenIp\"B\n" +
+	"\x16RotateDeviceKeyRequest\x12(\n" +
+	"\tdevice_id\x18\x01 \x01(\tB\v\xbaH\b\xd8\x01\x01r\x03\xb0\x01\x01R\bdeviceId\"\xd7\x01\n" +
+	"\x17RotateDeviceKeyResponse\x12%\n" +
+	"\tdevice_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\bdeviceId\x120\n" +
+	"\x14encrypted_device_key\x18\x02 \x01(\fR\x12encryptedDeviceKey\x12\x1f\n" +
+	"\vkey_version\x18\x03 \x01(\x05R\n" +
+	"keyVersion\x12%\n" +
+	"\x0ekey_generation\x18\x04 \x01(\x05R\rkeyGeneration\x12\x1b\n" +
+	"\tserver_id\x18\x05 \x01(\tR\bserverId\"B\n" +
+	"\x18RegisterPublicKeyRequest\x12&\n" +
+	"\n" +
+	"public_key\x18\x01 \x01(\fB\a\xbaH\x04z\x02h R\tpublicKey\"\x1b\n" +
+	"\x19RegisterPublicKeyResponse\"\xe3\x02\n" +
+	"\n" +
+	"Enrollment\x12\x18\n" +
+	"\x02id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bplatform\x18\x03 \x01(\tR\bplatform\x12 \n" +
+	"\vfingerprint\x18\x04 \x01(\tR\vfingerprint\x121\n" +
+	"\x05state\x18\x05 \x01(\x0e2\x1b.devices.v1.EnrollmentStateR\x05state\x12\x1f\n" +
+	"\vremote_addr\x18\x06 \x01(\tR\n" +
+	"remoteAddr\x12;\n" +
+	"\vcreate_time\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x12;\n" +
+	"\vexpire_time\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"expireTime\x12\x1b\n" +
+	"\tdevice_id\x18\t \x01(\tR\bdeviceId\"\x86\x01\n" +
+	"\x18RequestEnrollmentRequest\x12\x1d\n" +
+	"\x04name\x18\x01 \x01(\tB\t\xbaH\x06r\x04\x10\x01\x18@R\x04name\x12#\n" +
+	"\bplatform\x18\x02 \x01(\tB\a\xbaH\x04r\x02\x18 R\bplatform\x12&\n" +
+	"\n" +
+	"public_key\x18\x03 \x01(\fB\a\xbaH\x04z\x02h R\tpublicKey\"S\n" +
+	"\x19RequestEnrollmentResponse\x126\n" +
+	"\n" +
+	"enrollment\x18\x01 \x01(\v2\x16.devices.v1.EnrollmentR\n" +
+	"enrollment\"E\n" +
+	"\x14GetEnrollmentRequest\x12-\n" +
+	"\renrollment_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\fenrollmentId\"\x8d\x01\n" +
+	"\x15GetEnrollmentResponse\x126\n" +
+	"\n" +
+	"enrollment\x18\x01 \x01(\v2\x16.devices.v1.EnrollmentR\n" +
+	"enrollment\x12\x1f\n" +
+	"\vkey_version\x18\x02 \x01(\x05R\n" +
+	"keyVersion\x12\x1b\n" +
+	"\tserver_id\x18\x03 \x01(\tR\bserverId\"\x18\n" +
+	"\x16ListEnrollmentsRequest\"S\n" +
+	"\x17ListEnrollmentsResponse\x128\n" +
+	"\venrollments\x18\x01 \x03(\v2\x16.devices.v1.EnrollmentR\venrollments\"y\n" +
+	"\x18ApproveEnrollmentRequest\x12-\n" +
+	"\renrollment_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\fenrollmentId\x12.\n" +
+	"\x04role\x18\x02 \x01(\x0e2\x10.devices.v1.RoleB\b\xbaH\x05\x82\x01\x02\x10\x01R\x04role\"S\n" +
+	"\x19ApproveEnrollmentResponse\x126\n" +
+	"\n" +
+	"enrollment\x18\x01 \x01(\v2\x16.devices.v1.EnrollmentR\n" +
+	"enrollment\"F\n" +
+	"\x15DenyEnrollmentRequest\x12-\n" +
+	"\renrollment_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\fenrollmentId\"P\n" +
+	"\x16DenyEnrollmentResponse\x126\n" +
+	"\n" +
+	"enrollment\x18\x01 \x01(\v2\x16.devices.v1.EnrollmentR\n" +
+	"enrollment\"/\n" +
 	"\x13DeleteDeviceRequest\x12\x18\n" +
 	"\x02id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x02id\"\x16\n" +
-	"\x14DeleteDeviceResponse2\x85\x02\n" +
+	"\x14DeleteDeviceResponse\"\x8a\x01\n" +
+	"\x06SSHKey\x12 \n" +
+	"\vfingerprint\x18\x01 \x01(\tR\vfingerprint\x12%\n" +
+	"\tdevice_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\bdeviceId\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x03 \x01(\tR\tpublicKey\x12\x18\n" +
+	"\acomment\x18\x04 \x01(\tR\acomment\"d\n" +
+	"\x10AddSSHKeyRequest\x12(\n" +
+	"\tdevice_id\x18\x01 \x01(\tB\v\xbaH\b\xd8\x01\x01r\x03\xb0\x01\x01R\bdeviceId\x12&\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tpublicKey\"9\n" +
+	"\x11AddSSHKeyResponse\x12$\n" +
+	"\x03key\x18\x01 \x01(\v2\x12.devices.v1.SSHKeyR\x03key\">\n" +
+	"\x12ListSSHKeysRequest\x12(\n" +
+	"\tdevice_id\x18\x01 \x01(\tB\v\xbaH\b\xd8\x01\x01r\x03\xb0\x01\x01R\bdeviceId\"=\n" +
+	"\x13ListSSHKeysResponse\x12&\n" +
+	"\x04keys\x18\x01 \x03(\v2\x12.devices.v1.SSHKeyR\x04keys\"@\n" +
+	"\x13RemoveSSHKeyRequest\x12)\n" +
+	"\vfingerprint\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\vfingerprint\"\x16\n" +
+	"\x14RemoveSSHKeyResponse\"\x96\x01\n" +
+	"\tPathGrant\x12%\n" +
+	"\tdevice_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\bdeviceId\x12 \n" +
+	"\x06prefix\x18\x02 \x01(\tB\b\xbaH\x05r\x03:\x01/R\x06prefix\x12\x12\n" +
+	"\x04read\x18\x03 \x01(\bR\x04read\x12\x14\n" +
+	"\x05write\x18\x04 \x01(\bR\x05write\x12\x16\n" +
+	"\x06delete\x18\x05 \x01(\bR\x06delete\"G\n" +
+	"\x10GrantPathRequest\x123\n" +
+	"\x05grant\x18\x01 \x01(\v2\x15.devices.v1.PathGrantB\x06\xbaH\x03\xc8\x01\x01R\x05grant\"@\n" +
+	"\x11GrantPathResponse\x12+\n" +
+	"\x05grant\x18\x01 \x01(\v2\x15.devices.v1.PathGrantR\x05grant\"A\n" +
+	"\x15ListPathGrantsRequest\x12(\n" +
+	"\tdevice_id\x18\x01 \x01(\tB\v\xbaH\b\xd8\x01\x01r\x03\xb0\x01\x01R\bdeviceId\"G\n" +
+	"\x16ListPathGrantsResponse\x12-\n" +
+	"\x06grants\x18\x01 \x03(\v2\x15.devices.v1.PathGrantR\x06grants\"\\\n" +
+	"\x11RevokePathRequest\x12%\n" +
+	"\tdevice_id\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\bdeviceId\x12 \n" +
+	"\x06prefix\x18\x02 \x01(\tB\b\xbaH\x05r\x03:\x01/R\x06prefix\"\x14\n" +
+	"\x12RevokePathResponse\"\x96\x01\n" +
+	"\x03Ban\x12\x1f\n" +
+	"\vremote_addr\x18\x01 \x01(\tR\n" +
+	"remoteAddr\x12\x1b\n" +
+	"\tdevice_id\x18\x02 \x01(\tR\bdeviceId\x12;\n" +
+	"\vexpire_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"expireTime\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x05R\x05count\"\x11\n" +
+	"\x0fListBansRequest\"7\n" +
+	"\x10ListBansResponse\x12#\n" +
+	"\x04bans\x18\x01 \x03(\v2\x0f.devices.v1.BanR\x04bans\"\x95\x01\n" +
+	"\x10ClearBansRequest\x12*\n" +
+	"\vremote_addr\x18\x01 \x01(\tB\a\xbaH\x04r\x02p\x01H\x00R\n" +
+	"remoteAddr\x12'\n" +
+	"\tdevice_id\x18\x02 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01H\x00R\bdeviceId\x12\x1b\n" +
+	"\x03all\x18\x03 \x01(\bB\a\xbaH\x04j\x02\b\x01H\x00R\x03allB\x0f\n" +
+	"\x06target\x12\x05\xbaH\x02\b\x01\"-\n" +
+	"\x11ClearBansResponse\x12\x18\n" +
+	"\acleared\x18\x01 \x01(\x05R\acleared\"\x16\n" +
+	"\x14StartWebLoginRequest\"h\n" +
+	"\x15StartWebLoginResponse\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12;\n" +
+	"\vexpire_time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"expireTime\"\x19\n" +
+	"\x17CompleteWebLoginRequest\"\x9f\x01\n" +
+	"\x18CompleteWebLoginResponse\x12\x1a\n" +
+	"\bapproved\x18\x01 \x01(\bR\bapproved\x12\x1b\n" +
+	"\tdevice_id\x18\x02 \x01(\tR\bdeviceId\x12J\n" +
+	"\x13session_expire_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x11sessionExpireTime\"\x16\n" +
+	"\x14EndWebSessionRequest\"\x17\n" +
+	"\x15EndWebSessionResponse\"5\n" +
+	"\x16ApproveWebLoginRequest\x12\x1b\n" +
+	"\x04code\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\x04code\"Y\n" +
+	"\x17ApproveWebLoginResponse\x12\x1f\n" +
+	"\vremote_addr\x18\x01 \x01(\tR\n" +
+	"remoteAddr\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x02 \x01(\tR\tuserAgent*g\n" +
+	"\x04Role\x12\x14\n" +
+	"\x10ROLE_UNSPECIFIED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"ROLE_ADMIN\x10\x01\x12\x0f\n" +
+	"\vROLE_MEMBER\x10\x02\x12\x12\n" +
+	"\x0eROLE_READ_ONLY\x10\x03\x12\x14\n" +
+	"\x10ROLE_UPLOAD_ONLY\x10\x04*\xab\x01\n" +
+	"\x0fEnrollmentState\x12 \n" +
+	"\x1cENROLLMENT_STATE_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18ENROLLMENT_STATE_PENDING\x10\x01\x12\x1d\n" +
+	"\x19ENROLLMENT_STATE_APPROVED\x10\x02\x12\x1b\n" +
+	"\x17ENROLLMENT_STATE_DENIED\x10\x03\x12\x1c\n" +
+	"\x18ENROLLMENT_STATE_EXPIRED\x10\x042\xa4\v\n" +
 	"\rDeviceService\x12Q\n" +
 	"\fCreateDevice\x12\x1f.devices.v1.CreateDeviceRequest\x1a .devices.v1.CreateDeviceResponse\x12N\n" +
 	"\vListDevices\x12\x1e.devices.v1.ListDevicesRequest\x1a\x1f.devices.v1.ListDevicesResponse\x12Q\n" +
-	"\fDeleteDevice\x12\x1f.devices.v1.DeleteDeviceRequest\x1a .devices.v1.DeleteDeviceResponseB\x98\x01\n" +
+	"\fDeleteDevice\x12\x1f.devices.v1.DeleteDeviceRequest\x1a .devices.v1.DeleteDeviceResponse\x12Z\n" +
+	"\x0fRotateDeviceKey\x12\".devices.v1.RotateDeviceKeyRequest\x1a#.devices.v1.RotateDeviceKeyResponse\x12`\n" +
+	"\x11RegisterPublicKey\x12$.devices.v1.RegisterPublicKeyRequest\x1a%.devices.v1.RegisterPublicKeyResponse\x12H\n" +
+	"\tAddSSHKey\x12\x1c.devices.v1.AddSSHKeyRequest\x1a\x1d.devices.v1.AddSSHKeyResponse\x12N\n" +
+	"\vListSSHKeys\x12\x1e.devices.v1.ListSSHKeysRequest\x1a\x1f.devices.v1.ListSSHKeysResponse\x12Q\n" +
+	"\fRemoveSSHKey\x12\x1f.devices.v1.RemoveSSHKeyRequest\x1a .devices.v1.RemoveSSHKeyResponse\x12H\n" +
+	"\tGrantPath\x12\x1c.devices.v1.GrantPathRequest\x1a\x1d.devices.v1.GrantPathResponse\x12W\n" +
+	"\x0eListPathGrants\x12!.devices.v1.ListPathGrantsRequest\x1a\".devices.v1.ListPathGrantsResponse\x12K\n" +
+	"\n" +
+	"RevokePath\x12\x1d.devices.v1.RevokePathRequest\x1a\x1e.devices.v1.RevokePathResponse\x12Z\n" +
+	"\x0fListEnrollments\x12\".devices.v1.ListEnrollmentsRequest\x1a#.devices.v1.ListEnrollmentsResponse\x12`\n" +
+	"\x11ApproveEnrollment\x12$.devices.v1.ApproveEnrollmentRequest\x1a%.devices.v1.ApproveEnrollmentResponse\x12W\n" +
+	"\x0eDenyEnrollment\x12!.devices.v1.DenyEnrollmentRequest\x1a\".devices.v1.DenyEnrollmentResponse\x12E\n" +
+	"\bListBans\x12\x1b.devices.v1.ListBansRequest\x1a\x1c.devices.v1.ListBansResponse\x12H\n" +
+	"\tClearBans\x12\x1c.devices.v1.ClearBansRequest\x1a\x1d.devices.v1.ClearBansResponse\x12Z\n" +
+	"\x0fApproveWebLogin\x12\".devices.v1.ApproveWebLoginRequest\x1a#.devices.v1.ApproveWebLoginResponse2\xa3\x04\n" +
+	"\x0ePairingService\x12N\n" +
+	"\vClaimDevice\x12\x1e.devices.v1.ClaimDeviceRequest\x1a\x1f.devices.v1.ClaimDeviceResponse\x12`\n" +
+	"\x11RequestEnrollment\x12$.devices.v1.RequestEnrollmentRequest\x1a%.devices.v1.RequestEnrollmentResponse\x12T\n" +
+	"\rGetEnrollment\x12 .devices.v1.GetEnrollmentRequest\x1a!.devices.v1.GetEnrollmentResponse\x12T\n" +
+	"\rStartWebLogin\x12 .devices.v1.StartWebLoginRequest\x1a!.devices.v1.StartWebLoginResponse\x12]\n" +
+	"\x10CompleteWebLogin\x12#.devices.v1.CompleteWebLoginRequest\x1a$.devices.v1.CompleteWebLoginResponse\x12T\n" +
+	"\rEndWebSession\x12 .devices.v1.EndWebSessionRequest\x1a!.devices.v1.EndWebSessionResponseB\x98\x01\n" +
 	"\x0ecom.devices.v1B\fDevicesProtoP\x01Z/github.com/cmp0st/byte/gen/devices/v1;devicesv1\xa2\x02\x03DXX\xaa\x02\n" +
 	"Devices.V1\xca\x02\n" +
 	"Devices\\V1\xe2\x02\x16Devices\\V1\\GPBMetadata\xea\x02\vDevices::V1b\x06proto3"
@@ -356,29 +3083,140 @@ func file_devices_v1_devices_proto_rawDescGZIP() []byte {
 	return file_devices_v1_devices_proto_rawDescData
 }
 
-var file_devices_v1_devices_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_devices_v1_devices_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_devices_v1_devices_proto_msgTypes = make([]protoimpl.MessageInfo, 51)
 var file_devices_v1_devices_proto_goTypes = []any{
-	(*CreateDeviceRequest)(nil),        // 0: devices.v1.CreateDeviceRequest
-	(*CreateDeviceResponse)(nil),       // 1: devices.v1.CreateDeviceResponse
-	(*ListDevicesRequest)(nil),         // 2: devices.v1.ListDevicesRequest
-	(*ListDevicesResponse)(nil),        // 3: devices.v1.ListDevicesResponse
-	(*DeleteDeviceRequest)(nil),        // 4: devices.v1.DeleteDeviceRequest
-	(*DeleteDeviceResponse)(nil),       // 5: devices.v1.DeleteDeviceResponse
-	(*ListDevicesResponse_Device)(nil), // 6: devices.v1.ListDevicesResponse.Device
+	(Role)(0),                          // 0: devices.v1.Role
+	(EnrollmentState)(0),               // 1: devices.v1.EnrollmentState
+	(*CreateDeviceRequest)(nil),        // 2: devices.v1.CreateDeviceRequest
+	(*CreateDeviceResponse)(nil),       // 3: devices.v1.CreateDeviceResponse
+	(*ClaimDeviceRequest)(nil),         // 4: devices.v1.ClaimDeviceRequest
+	(*ClaimDeviceResponse)(nil),        // 5: devices.v1.ClaimDeviceResponse
+	(*ListDevicesRequest)(nil),         // 6: devices.v1.ListDevicesRequest
+	(*ListDevicesResponse)(nil),        // 7: devices.v1.ListDevicesResponse
+	(*RotateDeviceKeyRequest)(nil),     // 8: devices.v1.RotateDeviceKeyRequest
+	(*RotateDeviceKeyResponse)(nil),    // 9: devices.v1.RotateDeviceKeyResponse
+	(*RegisterPublicKeyRequest)(nil),   // 10: devices.v1.RegisterPublicKeyRequest
+	(*RegisterPublicKeyResponse)(nil),  // 11: devices.v1.RegisterPublicKeyResponse
+	(*Enrollment)(nil),                 // 12: devices.v1.Enrollment
+	(*RequestEnrollmentRequest)(nil),   // 13: devices.v1.RequestEnrollmentRequest
+	(*RequestEnrollmentResponse)(nil),  // 14: devices.v1.RequestEnrollmentResponse
+	(*GetEnrollmentRequest)(nil),       // 15: devices.v1.GetEnrollmentRequest
+	(*GetEnrollmentResponse)(nil),      // 16: devices.v1.GetEnrollmentResponse
+	(*ListEnrollmentsRequest)(nil),     // 17: devices.v1.ListEnrollmentsRequest
+	(*ListEnrollmentsResponse)(nil),    // 18: devices.v1.ListEnrollmentsResponse
+	(*ApproveEnrollmentRequest)(nil),   // 19: devices.v1.ApproveEnrollmentRequest
+	(*ApproveEnrollmentResponse)(nil),  // 20: devices.v1.ApproveEnrollmentResponse
+	(*DenyEnrollmentRequest)(nil),      // 21: devices.v1.DenyEnrollmentRequest
+	(*DenyEnrollmentResponse)(nil),     // 22: devices.v1.DenyEnrollmentResponse
+	(*DeleteDeviceRequest)(nil),        // 23: devices.v1.DeleteDeviceRequest
+	(*DeleteDeviceResponse)(nil),       // 24: devices.v1.DeleteDeviceResponse
+	(*SSHKey)(nil),                     // 25: devices.v1.SSHKey
+	(*AddSSHKeyRequest)(nil),           // 26: devices.v1.AddSSHKeyRequest
+	(*AddSSHKeyResponse)(nil),          // 27: devices.v1.AddSSHKeyResponse
+	(*ListSSHKeysRequest)(nil),         // 28: devices.v1.ListSSHKeysRequest
+	(*ListSSHKeysResponse)(nil),        // 29: devices.v1.ListSSHKeysResponse
+	(*RemoveSSHKeyRequest)(nil),        // 30: devices.v1.RemoveSSHKeyRequest
+	(*RemoveSSHKeyResponse)(nil),       // 31: devices.v1.RemoveSSHKeyResponse
+	(*PathGrant)(nil),                  // 32: devices.v1.PathGrant
+	(*GrantPathRequest)(nil),           // 33: devices.v1.GrantPathRequest
+	(*GrantPathResponse)(nil),          // 34: devices.v1.GrantPathResponse
+	(*ListPathGrantsRequest)(nil),      // 35: devices.v1.ListPathGrantsRequest
+	(*ListPathGrantsResponse)(nil),     // 36: devices.v1.ListPathGrantsResponse
+	(*RevokePathRequest)(nil),          // 37: devices.v1.RevokePathRequest
+	(*RevokePathResponse)(nil),         // 38: devices.v1.RevokePathResponse
+	(*Ban)(nil),                        // 39: devices.v1.Ban
+	(*ListBansRequest)(nil),            // 40: devices.v1.ListBansRequest
+	(*ListBansResponse)(nil),           // 41: devices.v1.ListBansResponse
+	(*ClearBansRequest)(nil),           // 42: devices.v1.ClearBansRequest
+	(*ClearBansResponse)(nil),          // 43: devices.v1.ClearBansResponse
+	(*StartWebLoginRequest)(nil),       // 44: devices.v1.StartWebLoginRequest
+	(*StartWebLoginResponse)(nil),      // 45: devices.v1.StartWebLoginResponse
+	(*CompleteWebLoginRequest)(nil),    // 46: devices.v1.CompleteWebLoginRequest
+	(*CompleteWebLoginResponse)(nil),   // 47: devices.v1.CompleteWebLoginResponse
+	(*EndWebSessionRequest)(nil),       // 48: devices.v1.EndWebSessionRequest
+	(*EndWebSessionResponse)(nil),      // 49: devices.v1.EndWebSessionResponse
+	(*ApproveWebLoginRequest)(nil),     // 50: devices.v1.ApproveWebLoginRequest
+	(*ApproveWebLoginResponse)(nil),    // 51: devices.v1.ApproveWebLoginResponse
+	(*ListDevicesResponse_Device)(nil), // 52: devices.v1.ListDevicesResponse.Device
+	(*timestamppb.Timestamp)(nil),      // 53: google.protobuf.Timestamp
 }
 var file_devices_v1_devices_proto_depIdxs = []int32{
-	6, // 0: devices.v1.ListDevicesResponse.devices:type_name -> devices.v1.ListDevicesResponse.Device
-	0, // 1: devices.v1.DeviceService.CreateDevice:input_type -> devices.v1.CreateDeviceRequest
-	2, // 2: devices.v1.DeviceService.ListDevices:input_type -> devices.v1.ListDevicesRequest
-	4, // 3: devices.v1.DeviceService.DeleteDevice:input_type -> devices.v1.DeleteDeviceRequest
-	1, // 4: devices.v1.DeviceService.CreateDevice:output_type -> devices.v1.CreateDeviceResponse
-	3, // 5: devices.v1.DeviceService.ListDevices:output_type -> devices.v1.ListDevicesResponse
-	5, // 6: devices.v1.DeviceService.DeleteDevice:output_type -> devices.v1.DeleteDeviceResponse
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	0,  // 0: devices.v1.CreateDeviceRequest.role:type_name -> devices.v1.Role
+	53, // 1: devices.v1.CreateDeviceResponse.pairing_code_expire_time:type_name -> google.protobuf.Timestamp
+	52, // 2: devices.v1.ListDevicesResponse.devices:type_name -> devices.v1.ListDevicesResponse.Device
+	1,  // 3: devices.v1.Enrollment.state:type_name -> devices.v1.EnrollmentState
+	53, // 4: devices.v1.Enrollment.create_time:type_name -> google.protobuf.Timestamp
+	53, // 5: devices.v1.Enrollment.expire_time:type_name -> google.protobuf.Timestamp
+	12, // 6: devices.v1.RequestEnrollmentResponse.enrollment:type_name -> devices.v1.Enrollment
+	12, // 7: devices.v1.GetEnrollmentResponse.enrollment:type_name -> devices.v1.Enrollment
+	12, // 8: devices.v1.ListEnrollmentsResponse.enrollments:type_name -> devices.v1.Enrollment
+	0,  // 9: devices.v1.ApproveEnrollmentRequest.role:type_name -> devices.v1.Role
+	12, // 10: devices.v1.ApproveEnrollmentResponse.enrollment:type_name -> devices.v1.Enrollment
+	12, // 11: devices.v1.DenyEnrollmentResponse.enrollment:type_name -> devices.v1.Enrollment
+	25, // 12: devices.v1.AddSSHKeyResponse.key:type_name -> devices.v1.SSHKey
+	25, // 13: devices.v1.ListSSHKeysResponse.keys:type_name -> devices.v1.SSHKey
+	32, // 14: devices.v1.GrantPathRequest.grant:type_name -> devices.v1.PathGrant
+	32, // 15: devices.v1.GrantPathResponse.grant:type_name -> devices.v1.PathGrant
+	32, // 16: devices.v1.ListPathGrantsResponse.grants:type_name -> devices.v1.PathGrant
+	53, // 17: devices.v1.Ban.expire_time:type_name -> google.protobuf.Timestamp
+	39, // 18: devices.v1.ListBansResponse.bans:type_name -> devices.v1.Ban
+	53, // 19: devices.v1.StartWebLoginResponse.expire_time:type_name -> google.protobuf.Timestamp
+	53, // 20: devices.v1.CompleteWebLoginResponse.session_expire_time:type_name -> google.protobuf.Timestamp
+	0,  // 21: devices.v1.ListDevicesResponse.Device.role:type_name -> devices.v1.Role
+	53, // 22: devices.v1.ListDevicesResponse.Device.create_time:type_name -> google.protobuf.Timestamp
+	53, // 23: devices.v1.ListDevicesResponse.Device.last_seen_time:type_name -> google.protobuf.Timestamp
+	2,  // 24: devices.v1.DeviceService.CreateDevice:input_type -> devices.v1.CreateDeviceRequest
+	6,  // 25: devices.v1.DeviceService.ListDevices:input_type -> devices.v1.ListDevicesRequest
+	23, // 26: devices.v1.DeviceService.DeleteDevice:input_type -> devices.v1.DeleteDeviceRequest
+	8,  // 27: devices.v1.DeviceService.RotateDeviceKey:input_type -> devices.v1.RotateDeviceKeyRequest
+	10, // 28: devices.v1.DeviceService.RegisterPublicKey:input_type -> devices.v1.RegisterPublicKeyRequest
+	26, // 29: devices.v1.DeviceService.AddSSHKey:input_type -> devices.v1.AddSSHKeyRequest
+	28, // 30: devices.v1.DeviceService.ListSSHKeys:input_type -> devices.v1.ListSSHKeysRequest
+	30, // 31: devices.v1.DeviceService.RemoveSSHKey:input_type -> devices.v1.RemoveSSHKeyRequest
+	33, // 32: devices.v1.DeviceService.GrantPath:input_type -> devices.v1.GrantPathRequest
+	35, // 33: devices.v1.DeviceService.ListPathGrants:input_type -> devices.v1.ListPathGrantsRequest
+	37, // 34: devices.v1.DeviceService.RevokePath:input_type -> devices.v1.RevokePathRequest
+	17, // 35: devices.v1.DeviceService.ListEnrollments:input_type -> devices.v1.ListEnrollmentsRequest
+	19, // 36: devices.v1.DeviceService.ApproveEnrollment:input_type -> devices.v1.ApproveEnrollmentRequest
+	21, // 37: devices.v1.DeviceService.DenyEnrollment:input_type -> devices.v1.DenyEnrollmentRequest
+	40, // 38: devices.v1.DeviceService.ListBans:input_type -> devices.v1.ListBansRequest
+	42, // 39: devices.v1.DeviceService.ClearBans:input_type -> devices.v1.ClearBansRequest
+	50, // 40: devices.v1.DeviceService.ApproveWebLogin:input_type -> devices.v1.ApproveWebLoginRequest
+	4,  // 41: devices.v1.PairingService.ClaimDevice:input_type -> devices.v1.ClaimDeviceRequest
+	13, // 42: devices.v1.PairingService.RequestEnrollment:input_type -> devices.v1.RequestEnrollmentRequest
+	15, // 43: devices.v1.PairingService.GetEnrollment:input_type -> devices.v1.GetEnrollmentRequest
+	44, // 44: devices.v1.PairingService.StartWebLogin:input_type -> devices.v1.StartWebLoginRequest
+	46, // 45: devices.v1.PairingService.CompleteWebLogin:input_type -> devices.v1.CompleteWebLoginRequest
+	48, // 46: devices.v1.PairingService.EndWebSession:input_type -> devices.v1.EndWebSessionRequest
+	3,  // 47: devices.v1.DeviceService.CreateDevice:output_type -> devices.v1.CreateDeviceResponse
+	7,  // 48: devices.v1.DeviceService.ListDevices:output_type -> devices.v1.ListDevicesResponse
+	24, // 49: devices.v1.DeviceService.DeleteDevice:output_type -> devices.v1.DeleteDeviceResponse
+	9,  // 50: devices.v1.DeviceService.RotateDeviceKey:output_type -> devices.v1.RotateDeviceKeyResponse
+	11, // 51: devices.v1.DeviceService.RegisterPublicKey:output_type -> devices.v1.RegisterPublicKeyResponse
+	27, // 52: devices.v1.DeviceService.AddSSHKey:output_type -> devices.v1.AddSSHKeyResponse
+	29, // 53: devices.v1.DeviceService.ListSSHKeys:output_type -> devices.v1.ListSSHKeysResponse
+	31, // 54: devices.v1.DeviceService.RemoveSSHKey:output_type -> devices.v1.RemoveSSHKeyResponse
+	34, // 55: devices.v1.DeviceService.GrantPath:output_type -> devices.v1.GrantPathResponse
+	36, // 56: devices.v1.DeviceService.ListPathGrants:output_type -> devices.v1.ListPathGrantsResponse
+	38, // 57: devices.v1.DeviceService.RevokePath:output_type -> devices.v1.RevokePathResponse
+	18, // 58: devices.v1.DeviceService.ListEnrollments:output_type -> devices.v1.ListEnrollmentsResponse
+	20, // 59: devices.v1.DeviceService.ApproveEnrollment:output_type -> devices.v1.ApproveEnrollmentResponse
+	22, // 60: devices.v1.DeviceService.DenyEnrollment:output_type -> devices.v1.DenyEnrollmentResponse
+	41, // 61: devices.v1.DeviceService.ListBans:output_type -> devices.v1.ListBansResponse
+	43, // 62: devices.v1.DeviceService.ClearBans:output_type -> devices.v1.ClearBansResponse
+	51, // 63: devices.v1.DeviceService.ApproveWebLogin:output_type -> devices.v1.ApproveWebLoginResponse
+	5,  // 64: devices.v1.PairingService.ClaimDevice:output_type -> devices.v1.ClaimDeviceResponse
+	14, // 65: devices.v1.PairingService.RequestEnrollment:output_type -> devices.v1.RequestEnrollmentResponse
+	16, // 66: devices.v1.PairingService.GetEnrollment:output_type -> devices.v1.GetEnrollmentResponse
+	45, // 67: devices.v1.PairingService.StartWebLogin:output_type -> devices.v1.StartWebLoginResponse
+	47, // 68: devices.v1.PairingService.CompleteWebLogin:output_type -> devices.v1.CompleteWebLoginResponse
+	49, // 69: devices.v1.PairingService.EndWebSession:output_type -> devices.v1.EndWebSessionResponse
+	47, // [47:70] is the sub-list for method output_type
+	24, // [24:47] is the sub-list for method input_type
+	24, // [24:24] is the sub-list for extension type_name
+	24, // [24:24] is the sub-list for extension extendee
+	0,  // [0:24] is the sub-list for field type_name
 }
 
 func init() { file_devices_v1_devices_proto_init() }
@@ -386,18 +3224,24 @@ func file_devices_v1_devices_proto_init() {
 	if File_devices_v1_devices_proto != nil {
 		return
 	}
+	file_devices_v1_devices_proto_msgTypes[40].OneofWrappers = []any{
+		(*ClearBansRequest_RemoteAddr)(nil),
+		(*ClearBansRequest_DeviceId)(nil),
+		(*ClearBansRequest_All)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_devices_v1_devices_proto_rawDesc), len(file_devices_v1_devices_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      2,
+			NumMessages:   51,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_devices_v1_devices_proto_goTypes,
 		DependencyIndexes: file_devices_v1_devices_proto_depIdxs,
+		EnumInfos:         file_devices_v1_devices_proto_enumTypes,
 		MessageInfos:      file_devices_v1_devices_proto_msgTypes,
 	}.Build()
 	File_devices_v1_devices_proto = out.File
@@ -23,6 +23,8 @@ const _ = connect.IsAtLeastVersion1_13_0
 const (
 	// DeviceServiceName is the fully-qualified name of the DeviceService service.
 	DeviceServiceName = "devices.v1.DeviceService"
+	// PairingServiceName is the fully-qualified name of the PairingService service.
+	PairingServiceName = "devices.v1.PairingService"
 )
 
 // These constants are the fully-qualified names of the RPCs defined in this package. They're
@@ -42,6 +44,62 @@ const (
 	// DeviceServiceDeleteDeviceProcedure is the fully-qualified name of the DeviceService's
 	// DeleteDevice RPC.
 	DeviceServiceDeleteDeviceProcedure = "/devices.v1.DeviceService/DeleteDevice"
+	// DeviceServiceRotateDeviceKeyProcedure is the fully-qualified name of the DeviceService's
+	// RotateDeviceKey RPC.
+	DeviceServiceRotateDeviceKeyProcedure = "/devices.v1.DeviceService/RotateDeviceKey"
+	// DeviceServiceRegisterPublicKeyProcedure is the fully-qualified name of the DeviceService's
+	// RegisterPublicKey RPC.
+	DeviceServiceRegisterPublicKeyProcedure = "/devices.v1.DeviceService/RegisterPublicKey"
+	// DeviceServiceAddSSHKeyProcedure is the fully-qualified name of the DeviceService's AddSSHKey RPC.
+	DeviceServiceAddSSHKeyProcedure = "/devices.v1.DeviceService/AddSSHKey"
+	// DeviceServiceListSSHKeysProcedure is the fully-qualified name of the DeviceService's ListSSHKeys
+	// RPC.
+	DeviceServiceListSSHKeysProcedure = "/devices.v1.DeviceService/ListSSHKeys"
+	// DeviceServiceRemoveSSHKeyProcedure is the fully-qualified name of the DeviceService's
+	// RemoveSSHKey RPC.
+	DeviceServiceRemoveSSHKeyProcedure = "/devices.v1.DeviceService/RemoveSSHKey"
+	// DeviceServiceGrantPathProcedure is the fully-qualified name of the DeviceService's GrantPath RPC.
+	DeviceServiceGrantPathProcedure = "/devices.v1.DeviceService/GrantPath"
+	// DeviceServiceListPathGrantsProcedure is the fully-qualified name of the DeviceService's
+	// ListPathGrants RPC.
+	DeviceServiceListPathGrantsProcedure = "/devices.v1.DeviceService/ListPathGrants"
+	// DeviceServiceRevokePathProcedure is the fully-qualified name of the DeviceService's RevokePath
+	// RPC.
+	DeviceServiceRevokePathProcedure = "/devices.v1.DeviceService/RevokePath"
+	// DeviceServiceListEnrollmentsProcedure is the fully-qualified name of the DeviceService's
+	// ListEnrollments RPC.
+	DeviceServiceListEnrollmentsProcedure = "/devices.v1.DeviceService/ListEnrollments"
+	// DeviceServiceApproveEnrollmentProcedure is the fully-qualified name of the DeviceService's
+	// ApproveEnrollment RPC.
+	DeviceServiceApproveEnrollmentProcedure = "/devices.v1.DeviceService/ApproveEnrollment"
+	// DeviceServiceDenyEnrollmentProcedure is the fully-qualified name of the DeviceService's
+	// DenyEnrollment RPC.
+	DeviceServiceDenyEnrollmentProcedure = "/devices.v1.DeviceService/DenyEnrollment"
+	// DeviceServiceListBansProcedure is the fully-qualified name of the DeviceService's ListBans RPC.
+	DeviceServiceListBansProcedure = "/devices.v1.DeviceService/ListBans"
+	// DeviceServiceClearBansProcedure is the fully-qualified name of the DeviceService's ClearBans RPC.
+	DeviceServiceClearBansProcedure = "/devices.v1.DeviceService/ClearBans"
+	// DeviceServiceApproveWebLoginProcedure is the fully-qualified name of the DeviceService's
+	// ApproveWebLogin RPC.
+	DeviceServiceApproveWebLoginProcedure = "/devices.v1.DeviceService/ApproveWebLogin"
+	// PairingServiceClaimDeviceProcedure is the fully-qualified name of the PairingService's
+	// ClaimDevice RPC.
+	PairingServiceClaimDeviceProcedure = "/devices.v1.PairingService/ClaimDevice"
+	// PairingServiceRequestEnrollmentProcedure is the fully-qualified name of the PairingService's
+	// RequestEnrollment RPC.
+	PairingServiceRequestEnrollmentProcedure = "/devices.v1.PairingService/RequestEnrollment"
+	// PairingServiceGetEnrollmentProcedure is the fully-qualified name of the PairingService's
+	// GetEnrollment RPC.
+	PairingServiceGetEnrollmentProcedure = "/devices.v1.PairingService/GetEnrollment"
+	// PairingServiceStartWebLoginProcedure is the fully-qualified name of the PairingService's
+	// StartWebLogin RPC.
+	PairingServiceStartWebLoginProcedure = "/devices.v1.PairingService/StartWebLogin"
+	// PairingServiceCompleteWebLoginProcedure is the fully-qualified name of the PairingService's
+	// CompleteWebLogin RPC.
+	PairingServiceCompleteWebLoginProcedure = "/devices.v1.PairingService/CompleteWebLogin"
+	// PairingServiceEndWebSessionProcedure is the fully-qualified name of the PairingService's
+	// EndWebSession RPC.
+	PairingServiceEndWebSessionProcedure = "/devices.v1.PairingService/EndWebSession"
 )
 
 // DeviceServiceClient is a client for the devices.v1.DeviceService service.
@@ -49,6 +107,43 @@ type DeviceServiceClient interface {
 	CreateDevice(context.Context, *connect.Request[v1.CreateDeviceRequest]) (*connect.Response[v1.CreateDeviceResponse], error)
 	ListDevices(context.Context, *connect.Request[v1.ListDevicesRequest]) (*connect.Response[v1.ListDevicesResponse], error)
 	DeleteDevice(context.Context, *connect.Request[v1.DeleteDeviceRequest]) (*connect.Response[v1.DeleteDeviceResponse], error)
+	// RotateDeviceKey replaces the key of a device, e.g. when it leaked. The
+	// previous key stops working right away. Any device can rotate its own key
+	// while rotating the key of another device requires the admin role. Devices
+	// that registered a public key can neither be rotated nor rotate others,
+	// since they do not use the keys the new one is encrypted with.
+	RotateDeviceKey(context.Context, *connect.Request[v1.RotateDeviceKeyRequest]) (*connect.Response[v1.RotateDeviceKeyResponse], error)
+	// RegisterPublicKey registers an Ed25519 public key of the calling device.
+	// The device then authenticates with v4.public tokens signed by the
+	// matching secret key, which the server never sees, and its v4.local tokens
+	// are no longer accepted.
+	RegisterPublicKey(context.Context, *connect.Request[v1.RegisterPublicKeyRequest]) (*connect.Response[v1.RegisterPublicKeyResponse], error)
+	// SSH public keys registered to a device are accepted by the SSH server in
+	// addition to the statically configured authorized keys.
+	AddSSHKey(context.Context, *connect.Request[v1.AddSSHKeyRequest]) (*connect.Response[v1.AddSSHKeyResponse], error)
+	ListSSHKeys(context.Context, *connect.Request[v1.ListSSHKeysRequest]) (*connect.Response[v1.ListSSHKeysResponse], error)
+	RemoveSSHKey(context.Context, *connect.Request[v1.RemoveSSHKeyRequest]) (*connect.Response[v1.RemoveSSHKeyResponse], error)
+	// Path grants limit the files a device can access. Devices that were never
+	// granted a path can access every file their role allows. Revoking the last
+	// grant of a device leaves it with no files, grant "/" to give it every file
+	// again.
+	GrantPath(context.Context, *connect.Request[v1.GrantPathRequest]) (*connect.Response[v1.GrantPathResponse], error)
+	ListPathGrants(context.Context, *connect.Request[v1.ListPathGrantsRequest]) (*connect.Response[v1.ListPathGrantsResponse], error)
+	RevokePath(context.Context, *connect.Request[v1.RevokePathRequest]) (*connect.Response[v1.RevokePathResponse], error)
+	// Enrollment requests are submitted by new devices through the pairing
+	// service and wait for an admin to approve or deny them.
+	ListEnrollments(context.Context, *connect.Request[v1.ListEnrollmentsRequest]) (*connect.Response[v1.ListEnrollmentsResponse], error)
+	ApproveEnrollment(context.Context, *connect.Request[v1.ApproveEnrollmentRequest]) (*connect.Response[v1.ApproveEnrollmentResponse], error)
+	DenyEnrollment(context.Context, *connect.Request[v1.DenyEnrollmentRequest]) (*connect.Response[v1.DenyEnrollmentResponse], error)
+	// Peer addresses and devices failing to authenticate too often over either
+	// the API or SSH are temporarily banned. Bans are kept in memory, so they
+	// are lifted when the server restarts.
+	ListBans(context.Context, *connect.Request[v1.ListBansRequest]) (*connect.Response[v1.ListBansResponse], error)
+	ClearBans(context.Context, *connect.Request[v1.ClearBansRequest]) (*connect.Response[v1.ClearBansResponse], error)
+	// ApproveWebLogin logs the browser showing code in as the calling device.
+	// Browsers can then read and write the files the device can, but never
+	// manage devices, keys or the server, even when the device is an admin.
+	ApproveWebLogin(context.Context, *connect.Request[v1.ApproveWebLoginRequest]) (*connect.Response[v1.ApproveWebLoginResponse], error)
 }
 
 // NewDeviceServiceClient constructs a client for the devices.v1.DeviceService service. By default,
@@ -80,14 +175,112 @@ func NewDeviceServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(deviceServiceMethods.ByName("DeleteDevice")),
 			connect.WithClientOptions(opts...),
 		),
+		rotateDeviceKey: connect.NewClient[v1.RotateDeviceKeyRequest, v1.RotateDeviceKeyResponse](
+			httpClient,
+			baseURL+DeviceServiceRotateDeviceKeyProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("RotateDeviceKey")),
+			connect.WithClientOptions(opts...),
+		),
+		registerPublicKey: connect.NewClient[v1.RegisterPublicKeyRequest, v1.RegisterPublicKeyResponse](
+			httpClient,
+			baseURL+DeviceServiceRegisterPublicKeyProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("RegisterPublicKey")),
+			connect.WithClientOptions(opts...),
+		),
+		addSSHKey: connect.NewClient[v1.AddSSHKeyRequest, v1.AddSSHKeyResponse](
+			httpClient,
+			baseURL+DeviceServiceAddSSHKeyProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("AddSSHKey")),
+			connect.WithClientOptions(opts...),
+		),
+		listSSHKeys: connect.NewClient[v1.ListSSHKeysRequest, v1.ListSSHKeysResponse](
+			httpClient,
+			baseURL+DeviceServiceListSSHKeysProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("ListSSHKeys")),
+			connect.WithClientOptions(opts...),
+		),
+		removeSSHKey: connect.NewClient[v1.RemoveSSHKeyRequest, v1.RemoveSSHKeyResponse](
+			httpClient,
+			baseURL+DeviceServiceRemoveSSHKeyProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("RemoveSSHKey")),
+			connect.WithClientOptions(opts...),
+		),
+		grantPath: connect.NewClient[v1.GrantPathRequest, v1.GrantPathResponse](
+			httpClient,
+			baseURL+DeviceServiceGrantPathProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("GrantPath")),
+			connect.WithClientOptions(opts...),
+		),
+		listPathGrants: connect.NewClient[v1.ListPathGrantsRequest, v1.ListPathGrantsResponse](
+			httpClient,
+			baseURL+DeviceServiceListPathGrantsProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("ListPathGrants")),
+			connect.WithClientOptions(opts...),
+		),
+		revokePath: connect.NewClient[v1.RevokePathRequest, v1.RevokePathResponse](
+			httpClient,
+			baseURL+DeviceServiceRevokePathProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("RevokePath")),
+			connect.WithClientOptions(opts...),
+		),
+		listEnrollments: connect.NewClient[v1.ListEnrollmentsRequest, v1.ListEnrollmentsResponse](
+			httpClient,
+			baseURL+DeviceServiceListEnrollmentsProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("ListEnrollments")),
+			connect.WithClientOptions(opts...),
+		),
+		approveEnrollment: connect.NewClient[v1.ApproveEnrollmentRequest, v1.ApproveEnrollmentResponse](
+			httpClient,
+			baseURL+DeviceServiceApproveEnrollmentProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("ApproveEnrollment")),
+			connect.WithClientOptions(opts...),
+		),
+		denyEnrollment: connect.NewClient[v1.DenyEnrollmentRequest, v1.DenyEnrollmentResponse](
+			httpClient,
+			baseURL+DeviceServiceDenyEnrollmentProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("DenyEnrollment")),
+			connect.WithClientOptions(opts...),
+		),
+		listBans: connect.NewClient[v1.ListBansRequest, v1.ListBansResponse](
+			httpClient,
+			baseURL+DeviceServiceListBansProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("ListBans")),
+			connect.WithClientOptions(opts...),
+		),
+		clearBans: connect.NewClient[v1.ClearBansRequest, v1.ClearBansResponse](
+			httpClient,
+			baseURL+DeviceServiceClearBansProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("ClearBans")),
+			connect.WithClientOptions(opts...),
+		),
+		approveWebLogin: connect.NewClient[v1.ApproveWebLoginRequest, v1.ApproveWebLoginResponse](
+			httpClient,
+			baseURL+DeviceServiceApproveWebLoginProcedure,
+			connect.WithSchema(deviceServiceMethods.ByName("ApproveWebLogin")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // deviceServiceClient implements DeviceServiceClient.
 type deviceServiceClient struct {
-	createDevice *connect.Client[v1.CreateDeviceRequest, v1.CreateDeviceResponse]
-	listDevices  *connect.Client[v1.ListDevicesRequest, v1.ListDevicesResponse]
-	deleteDevice *connect.Client[v1.DeleteDeviceRequest, v1.DeleteDeviceResponse]
+	createDevice      *connect.Client[v1.CreateDeviceRequest, v1.CreateDeviceResponse]
+	listDevices       *connect.Client[v1.ListDevicesRequest, v1.ListDevicesResponse]
+	deleteDevice      *connect.Client[v1.DeleteDeviceRequest, v1.DeleteDeviceResponse]
+	rotateDeviceKey   *connect.Client[v1.RotateDeviceKeyRequest, v1.RotateDeviceKeyResponse]
+	registerPublicKey *connect.Client[v1.RegisterPublicKeyRequest, v1.RegisterPublicKeyResponse]
+	addSSHKey         *connect.Client[v1.AddSSHKeyRequest, v1.AddSSHKeyResponse]
+	listSSHKeys       *connect.Client[v1.ListSSHKeysRequest, v1.ListSSHKeysResponse]
+	removeSSHKey      *connect.Client[v1.RemoveSSHKeyRequest, v1.RemoveSSHKeyResponse]
+	grantPath         *connect.Client[v1.GrantPathRequest, v1.GrantPathResponse]
+	listPathGrants    *connect.Client[v1.ListPathGrantsRequest, v1.ListPathGrantsResponse]
+	revokePath        *connect.Client[v1.RevokePathRequest, v1.RevokePathResponse]
+	listEnrollments   *connect.Client[v1.ListEnrollmentsRequest, v1.ListEnrollmentsResponse]
+	approveEnrollment *connect.Client[v1.ApproveEnrollmentRequest, v1.ApproveEnrollmentResponse]
+	denyEnrollment    *connect.Client[v1.DenyEnrollmentRequest, v1.DenyEnrollmentResponse]
+	listBans          *connect.Client[v1.ListBansRequest, v1.ListBansResponse]
+	clearBans         *connect.Client[v1.ClearBansRequest, v1.ClearBansResponse]
+	approveWebLogin   *connect.Client[v1.ApproveWebLoginRequest, v1.ApproveWebLoginResponse]
 }
 
 // CreateDevice calls devices.v1.DeviceService.CreateDevice.
@@ -105,11 +298,118 @@ func (c *deviceServiceClient) DeleteDevice(ctx context.Context, req *connect.Req
 	return c.deleteDevice.CallUnary(ctx, req)
 }
 
+// RotateDeviceKey calls devices.v1.DeviceService.RotateDeviceKey.
+func (c *deviceServiceClient) RotateDeviceKey(ctx context.Context, req *connect.Request[v1.RotateDeviceKeyRequest]) (*connect.Response[v1.RotateDeviceKeyResponse], error) {
+	return c.rotateDeviceKey.CallUnary(ctx, req)
+}
+
+// RegisterPublicKey calls devices.v1.DeviceService.RegisterPublicKey.
+func (c *deviceServiceClient) RegisterPublicKey(ctx context.Context, req *connect.Request[v1.RegisterPublicKeyRequest]) (*connect.Response[v1.RegisterPublicKeyResponse], error) {
+	return c.registerPublicKey.CallUnary(ctx, req)
+}
+
+// AddSSHKey calls devices.v1.DeviceService.AddSSHKey.
+func (c *deviceServiceClient) AddSSHKey(ctx context.Context, req *connect.Request[v1.AddSSHKeyRequest]) (*connect.Response[v1.AddSSHKeyResponse], error) {
+	return c.addSSHKey.CallUnary(ctx, req)
+}
+
+// ListSSHKeys calls devices.v1.DeviceService.ListSSHKeys.
+func (c *deviceServiceClient) ListSSHKeys(ctx context.Context, req *connect.Request[v1.ListSSHKeysRequest]) (*connect.Response[v1.ListSSHKeysResponse], error) {
+	return c.listSSHKeys.CallUnary(ctx, req)
+}
+
+// RemoveSSHKey calls devices.v1.DeviceService.RemoveSSHKey.
+func (c *deviceServiceClient) RemoveSSHKey(ctx context.Context, req *connect.Request[v1.RemoveSSHKeyRequest]) (*connect.Response[v1.RemoveSSHKeyResponse], error) {
+	return c.removeSSHKey.CallUnary(ctx, req)
+}
+
+// GrantPath calls devices.v1.DeviceService.GrantPath.
+func (c *deviceServiceClient) GrantPath(ctx context.Context, req *connect.Request[v1.GrantPathRequest]) (*connect.Response[v1.GrantPathResponse], error) {
+	return c.grantPath.CallUnary(ctx, req)
+}
+
+// ListPathGrants calls devices.v1.DeviceService.ListPathGrants.
+func (c *deviceServiceClient) ListPathGrants(ctx context.Context, req *connect.Request[v1.ListPathGrantsRequest]) (*connect.Response[v1.ListPathGrantsResponse], error) {
+	return c.listPathGrants.CallUnary(ctx, req)
+}
+
+// RevokePath calls devices.v1.DeviceService.RevokePath.
+func (c *deviceServiceClient) RevokePath(ctx context.Context, req *connect.Request[v1.RevokePathRequest]) (*connect.Response[v1.RevokePathResponse], error) {
+	return c.revokePath.CallUnary(ctx, req)
+}
+
+// ListEnrollments calls devices.v1.DeviceService.ListEnrollments.
+func (c *deviceServiceClient) ListEnrollments(ctx context.Context, req *connect.Request[v1.ListEnrollmentsRequest]) (*connect.Response[v1.ListEnrollmentsResponse], error) {
+	return c.listEnrollments.CallUnary(ctx, req)
+}
+
+// ApproveEnrollment calls devices.v1.DeviceService.ApproveEnrollment.
+func (c *deviceServiceClient) ApproveEnrollment(ctx context.Context, req *connect.Request[v1.ApproveEnrollmentRequest]) (*connect.Response[v1.ApproveEnrollmentResponse], error) {
+	return c.approveEnrollment.CallUnary(ctx, req)
+}
+
+// DenyEnrollment calls devices.v1.DeviceService.DenyEnrollment.
+func (c *deviceServiceClient) DenyEnrollment(ctx context.Context, req *connect.Request[v1.DenyEnrollmentRequest]) (*connect.Response[v1.DenyEnrollmentResponse], error) {
+	return c.denyEnrollment.CallUnary(ctx, req)
+}
+
+// ListBans calls devices.v1.DeviceService.ListBans.
+func (c *deviceServiceClient) ListBans(ctx context.Context, req *connect.Request[v1.ListBansRequest]) (*connect.Response[v1.ListBansResponse], error) {
+	return c.listBans.CallUnary(ctx, req)
+}
+
+// ClearBans calls devices.v1.DeviceService.ClearBans.
+func (c *deviceServiceClient) ClearBans(ctx context.Context, req *connect.Request[v1.ClearBansRequest]) (*connect.Response[v1.ClearBansResponse], error) {
+	return c.clearBans.CallUnary(ctx, req)
+}
+
+// ApproveWebLogin calls devices.v1.DeviceService.ApproveWebLogin.
+func (c *deviceServiceClient) ApproveWebLogin(ctx context.Context, req *connect.Request[v1.ApproveWebLoginRequest]) (*connect.Response[v1.ApproveWebLoginResponse], error) {
+	return c.approveWebLogin.CallUnary(ctx, req)
+}
+
 // DeviceServiceHandler is an implementation of the devices.v1.DeviceService service.
 type DeviceServiceHandler interface {
 	CreateDevice(context.Context, *connect.Request[v1.CreateDeviceRequest]) (*connect.Response[v1.CreateDeviceResponse], error)
 	ListDevices(context.Context, *connect.Request[v1.ListDevicesRequest]) (*connect.Response[v1.ListDevicesResponse], error)
 	DeleteDevice(context.Context, *connect.Request[v1.DeleteDeviceRequest]) (*connect.Response[v1.DeleteDeviceResponse], error)
+	// RotateDeviceKey replaces the key of a device, e.g. when it leaked. The
+	// previous key stops working right away. Any device can rotate its own key
+	// while rotating the key of another device requires the admin role. Devices
+	// that registered a public key can neither be rotated nor rotate others,
+	// since they do not use the keys the new one is encrypted with.
+	RotateDeviceKey(context.Context, *connect.Request[v1.RotateDeviceKeyRequest]) (*connect.Response[v1.RotateDeviceKeyResponse], error)
+	// RegisterPublicKey registers an Ed25519 public key of the calling device.
+	// The device then authenticates with v4.public tokens signed by the
+	// matching secret key, which the server never sees, and its v4.local tokens
+	// are no longer accepted.
+	RegisterPublicKey(context.Context, *connect.Request[v1.RegisterPublicKeyRequest]) (*connect.Response[v1.RegisterPublicKeyResponse], error)
+	// SSH public keys registered to a device are accepted by the SSH server in
+	// addition to the statically configured authorized keys.
+	AddSSHKey(context.Context, *connect.Request[v1.AddSSHKeyRequest]) (*connect.Response[v1.AddSSHKeyResponse], error)
+	ListSSHKeys(context.Context, *connect.Request[v1.ListSSHKeysRequest]) (*connect.Response[v1.ListSSHKeysResponse], error)
+	RemoveSSHKey(context.Context, *connect.Request[v1.RemoveSSHKeyRequest]) (*connect.Response[v1.RemoveSSHKeyResponse], error)
+	// Path grants limit the files a device can access. Devices that were never
+	// granted a path can access every file their role allows. Revoking the last
+	// grant of a device leaves it with no files, grant "/" to give it every file
+	// again.
+	GrantPath(context.Context, *connect.Request[v1.GrantPathRequest]) (*connect.Response[v1.GrantPathResponse], error)
+	ListPathGrants(context.Context, *connect.Request[v1.ListPathGrantsRequest]) (*connect.Response[v1.ListPathGrantsResponse], error)
+	RevokePath(context.Context, *connect.Request[v1.RevokePathRequest]) (*connect.Response[v1.RevokePathResponse], error)
+	// Enrollment requests are submitted by new devices through the pairing
+	// service and wait for an admin to approve or deny them.
+	ListEnrollments(context.Context, *connect.Request[v1.ListEnrollmentsRequest]) (*connect.Response[v1.ListEnrollmentsResponse], error)
+	ApproveEnrollment(context.Context, *connect.Request[v1.ApproveEnrollmentRequest]) (*connect.Response[v1.ApproveEnrollmentResponse], error)
+	DenyEnrollment(context.Context, *connect.Request[v1.DenyEnrollmentRequest]) (*connect.Response[v1.DenyEnrollmentResponse], error)
+	// Peer addresses and devices failing to authenticate too often over either
+	// the API or SSH are temporarily banned. Bans are kept in memory, so they
+	// are lifted when the server restarts.
+	ListBans(context.Context, *connect.Request[v1.ListBansRequest]) (*connect.Response[v1.ListBansResponse], error)
+	ClearBans(context.Context, *connect.Request[v1.ClearBansRequest]) (*connect.Response[v1.ClearBansResponse], error)
+	// ApproveWebLogin logs the browser showing code in as the calling device.
+	// Browsers can then read and write the files the device can, but never
+	// manage devices, keys or the server, even when the device is an admin.
+	ApproveWebLogin(context.Context, *connect.Request[v1.ApproveWebLoginRequest]) (*connect.Response[v1.ApproveWebLoginResponse], error)
 }
 
 // NewDeviceServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -137,6 +437,90 @@ func NewDeviceServiceHandler(svc DeviceServiceHandler, opts ...connect.HandlerOp
 		connect.WithSchema(deviceServiceMethods.ByName("DeleteDevice")),
 		connect.WithHandlerOptions(opts...),
 	)
+	deviceServiceRotateDeviceKeyHandler := connect.NewUnaryHandler(
+		DeviceServiceRotateDeviceKeyProcedure,
+		svc.RotateDeviceKey,
+		connect.WithSchema(deviceServiceMethods.ByName("RotateDeviceKey")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceRegisterPublicKeyHandler := connect.NewUnaryHandler(
+		DeviceServiceRegisterPublicKeyProcedure,
+		svc.RegisterPublicKey,
+		connect.WithSchema(deviceServiceMethods.ByName("RegisterPublicKey")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceAddSSHKeyHandler := connect.NewUnaryHandler(
+		DeviceServiceAddSSHKeyProcedure,
+		svc.AddSSHKey,
+		connect.WithSchema(deviceServiceMethods.ByName("AddSSHKey")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceListSSHKeysHandler := connect.NewUnaryHandler(
+		DeviceServiceListSSHKeysProcedure,
+		svc.ListSSHKeys,
+		connect.WithSchema(deviceServiceMethods.ByName("ListSSHKeys")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceRemoveSSHKeyHandler := connect.NewUnaryHandler(
+		DeviceServiceRemoveSSHKeyProcedure,
+		svc.RemoveSSHKey,
+		connect.WithSchema(deviceServiceMethods.ByName("RemoveSSHKey")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceGrantPathHandler := connect.NewUnaryHandler(
+		DeviceServiceGrantPathProcedure,
+		svc.GrantPath,
+		connect.WithSchema(deviceServiceMethods.ByName("GrantPath")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceListPathGrantsHandler := connect.NewUnaryHandler(
+		DeviceServiceListPathGrantsProcedure,
+		svc.ListPathGrants,
+		connect.WithSchema(deviceServiceMethods.ByName("ListPathGrants")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceRevokePathHandler := connect.NewUnaryHandler(
+		DeviceServiceRevokePathProcedure,
+		svc.RevokePath,
+		connect.WithSchema(deviceServiceMethods.ByName("RevokePath")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceListEnrollmentsHandler := connect.NewUnaryHandler(
+		DeviceServiceListEnrollmentsProcedure,
+		svc.ListEnrollments,
+		connect.WithSchema(deviceServiceMethods.ByName("ListEnrollments")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceApproveEnrollmentHandler := connect.NewUnaryHandler(
+		DeviceServiceApproveEnrollmentProcedure,
+		svc.ApproveEnrollment,
+		connect.WithSchema(deviceServiceMethods.ByName("ApproveEnrollment")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceDenyEnrollmentHandler := connect.NewUnaryHandler(
+		DeviceServiceDenyEnrollmentProcedure,
+		svc.DenyEnrollment,
+		connect.WithSchema(deviceServiceMethods.ByName("DenyEnrollment")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceListBansHandler := connect.NewUnaryHandler(
+		DeviceServiceListBansProcedure,
+		svc.ListBans,
+		connect.WithSchema(deviceServiceMethods.ByName("ListBans")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceClearBansHandler := connect.NewUnaryHandler(
+		DeviceServiceClearBansProcedure,
+		svc.ClearBans,
+		connect.WithSchema(deviceServiceMethods.ByName("ClearBans")),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceApproveWebLoginHandler := connect.NewUnaryHandler(
+		DeviceServiceApproveWebLoginProcedure,
+		svc.ApproveWebLogin,
+		connect.WithSchema(deviceServiceMethods.ByName("ApproveWebLogin")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/devices.v1.DeviceService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case DeviceServiceCreateDeviceProcedure:
@@ -145,6 +529,34 @@ func NewDeviceServiceHandler(svc DeviceServiceHandler, opts ...connect.HandlerOp
 			deviceServiceListDevicesHandler.ServeHTTP(w, r)
 		case DeviceServiceDeleteDeviceProcedure:
 			deviceServiceDeleteDeviceHandler.ServeHTTP(w, r)
+		case DeviceServiceRotateDeviceKeyProcedure:
+			deviceServiceRotateDeviceKeyHandler.ServeHTTP(w, r)
+		case DeviceServiceRegisterPublicKeyProcedure:
+			deviceServiceRegisterPublicKeyHandler.ServeHTTP(w, r)
+		case DeviceServiceAddSSHKeyProcedure:
+			deviceServiceAddSSHKeyHandler.ServeHTTP(w, r)
+		case DeviceServiceListSSHKeysProcedure:
+			deviceServiceListSSHKeysHandler.ServeHTTP(w, r)
+		case DeviceServiceRemoveSSHKeyProcedure:
+			deviceServiceRemoveSSHKeyHandler.ServeHTTP(w, r)
+		case DeviceServiceGrantPathProcedure:
+			deviceServiceGrantPathHandler.ServeHTTP(w, r)
+		case DeviceServiceListPathGrantsProcedure:
+			deviceServiceListPathGrantsHandler.ServeHTTP(w, r)
+		case DeviceServiceRevokePathProcedure:
+			deviceServiceRevokePathHandler.ServeHTTP(w, r)
+		case DeviceServiceListEnrollmentsProcedure:
+			deviceServiceListEnrollmentsHandler.ServeHTTP(w, r)
+		case DeviceServiceApproveEnrollmentProcedure:
+			deviceServiceApproveEnrollmentHandler.ServeHTTP(w, r)
+		case DeviceServiceDenyEnrollmentProcedure:
+			deviceServiceDenyEnrollmentHandler.ServeHTTP(w, r)
+		case DeviceServiceListBansProcedure:
+			deviceServiceListBansHandler.ServeHTTP(w, r)
+		case DeviceServiceClearBansProcedure:
+			deviceServiceClearBansHandler.ServeHTTP(w, r)
+		case DeviceServiceApproveWebLoginProcedure:
+			deviceServiceApproveWebLoginHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -165,3 +577,295 @@ func (UnimplementedDeviceServiceHandler) ListDevices(context.Context, *connect.R
 func (UnimplementedDeviceServiceHandler) DeleteDevice(context.Context, *connect.Request[v1.DeleteDeviceRequest]) (*connect.Response[v1.DeleteDeviceResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.DeleteDevice is not implemented"))
 }
+
+func (UnimplementedDeviceServiceHandler) RotateDeviceKey(context.Context, *connect.Request[v1.RotateDeviceKeyRequest]) (*connect.Response[v1.RotateDeviceKeyResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.RotateDeviceKey is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) RegisterPublicKey(context.Context, *connect.Request[v1.RegisterPublicKeyRequest]) (*connect.Response[v1.RegisterPublicKeyResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.RegisterPublicKey is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) AddSSHKey(context.Context, *connect.Request[v1.AddSSHKeyRequest]) (*connect.Response[v1.AddSSHKeyResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.AddSSHKey is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) ListSSHKeys(context.Context, *connect.Request[v1.ListSSHKeysRequest]) (*connect.Response[v1.ListSSHKeysResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.ListSSHKeys is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) RemoveSSHKey(context.Context, *connect.Request[v1.RemoveSSHKeyRequest]) (*connect.Response[v1.RemoveSSHKeyResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.RemoveSSHKey is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) GrantPath(context.Context, *connect.Request[v1.GrantPathRequest]) (*connect.Response[v1.GrantPathResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.GrantPath is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) ListPathGrants(context.Context, *connect.Request[v1.ListPathGrantsRequest]) (*connect.Response[v1.ListPathGrantsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.ListPathGrants is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) RevokePath(context.Context, *connect.Request[v1.RevokePathRequest]) (*connect.Response[v1.RevokePathResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.RevokePath is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) ListEnrollments(context.Context, *connect.Request[v1.ListEnrollmentsRequest]) (*connect.Response[v1.ListEnrollmentsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.ListEnrollments is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) ApproveEnrollment(context.Context, *connect.Request[v1.ApproveEnrollmentRequest]) (*connect.Response[v1.ApproveEnrollmentResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.ApproveEnrollment is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) DenyEnrollment(context.Context, *connect.Request[v1.DenyEnrollmentRequest]) (*connect.Response[v1.DenyEnrollmentResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.DenyEnrollment is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) ListBans(context.Context, *connect.Request[v1.ListBansRequest]) (*connect.Response[v1.ListBansResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.ListBans is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) ClearBans(context.Context, *connect.Request[v1.ClearBansRequest]) (*connect.Response[v1.ClearBansResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.ClearBans is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) ApproveWebLogin(context.Context, *connect.Request[v1.ApproveWebLoginRequest]) (*connect.Response[v1.ApproveWebLoginResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.DeviceService.ApproveWebLogin is not implemented"))
+}
+
+// PairingServiceClient is a client for the devices.v1.PairingService service.
+type PairingServiceClient interface {
+	// ClaimDevice redeems a pairing code for the credentials of the device it
+	// was issued for. Codes can only be redeemed once, and expire shortly after
+	// they are issued.
+	ClaimDevice(context.Context, *connect.Request[v1.ClaimDeviceRequest]) (*connect.Response[v1.ClaimDeviceResponse], error)
+	// RequestEnrollment asks an admin to enroll a new device with its Ed25519
+	// public key. Requests expire if they are not decided in time, and only a
+	// few can be pending at once.
+	RequestEnrollment(context.Context, *connect.Request[v1.RequestEnrollmentRequest]) (*connect.Response[v1.RequestEnrollmentResponse], error)
+	// GetEnrollment returns the state of an enrollment request, which the new
+	// device polls until it is decided. Once approved the device authenticates
+	// with tokens signed by the secret key of its public key.
+	GetEnrollment(context.Context, *connect.Request[v1.GetEnrollmentRequest]) (*connect.Response[v1.GetEnrollmentResponse], error)
+	// StartWebLogin starts logging a browser in. It returns a code for the
+	// browser to show, e.g. as a QR code, for a paired device to approve with
+	// DeviceService.ApproveWebLogin. The browser is set an HttpOnly cookie
+	// that completing the login requires, so that whoever sees the code cannot
+	// complete it in its place.
+	StartWebLogin(context.Context, *connect.Request[v1.StartWebLoginRequest]) (*connect.Response[v1.StartWebLoginResponse], error)
+	// CompleteWebLogin is polled by the browser until its login is approved.
+	// The browser is then set an HttpOnly session cookie authenticating it as
+	// the device that approved the login until the session expires.
+	CompleteWebLogin(context.Context, *connect.Request[v1.CompleteWebLoginRequest]) (*connect.Response[v1.CompleteWebLoginResponse], error)
+	// EndWebSession logs the browser out, which ends its session.
+	EndWebSession(context.Context, *connect.Request[v1.EndWebSessionRequest]) (*connect.Response[v1.EndWebSessionResponse], error)
+}
+
+// NewPairingServiceClient constructs a client for the devices.v1.PairingService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewPairingServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) PairingServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	pairingServiceMethods := v1.File_devices_v1_devices_proto.Services().ByName("PairingService").Methods()
+	return &pairingServiceClient{
+		claimDevice: connect.NewClient[v1.ClaimDeviceRequest, v1.ClaimDeviceResponse](
+			httpClient,
+			baseURL+PairingServiceClaimDeviceProcedure,
+			connect.WithSchema(pairingServiceMethods.ByName("ClaimDevice")),
+			connect.WithClientOptions(opts...),
+		),
+		requestEnrollment: connect.NewClient[v1.RequestEnrollmentRequest, v1.RequestEnrollmentResponse](
+			httpClient,
+			baseURL+PairingServiceRequestEnrollmentProcedure,
+			connect.WithSchema(pairingServiceMethods.ByName("RequestEnrollment")),
+			connect.WithClientOptions(opts...),
+		),
+		getEnrollment: connect.NewClient[v1.GetEnrollmentRequest, v1.GetEnrollmentResponse](
+			httpClient,
+			baseURL+PairingServiceGetEnrollmentProcedure,
+			connect.WithSchema(pairingServiceMethods.ByName("GetEnrollment")),
+			connect.WithClientOptions(opts...),
+		),
+		startWebLogin: connect.NewClient[v1.StartWebLoginRequest, v1.StartWebLoginResponse](
+			httpClient,
+			baseURL+PairingServiceStartWebLoginProcedure,
+			connect.WithSchema(pairingServiceMethods.ByName("StartWebLogin")),
+			connect.WithClientOptions(opts...),
+		),
+		completeWebLogin: connect.NewClient[v1.CompleteWebLoginRequest, v1.CompleteWebLoginResponse](
+			httpClient,
+			baseURL+PairingServiceCompleteWebLoginProcedure,
+			connect.WithSchema(pairingServiceMethods.ByName("CompleteWebLogin")),
+			connect.WithClientOptions(opts...),
+		),
+		endWebSession: connect.NewClient[v1.EndWebSessionRequest, v1.EndWebSessionResponse](
+			httpClient,
+			baseURL+PairingServiceEndWebSessionProcedure,
+			connect.WithSchema(pairingServiceMethods.ByName("EndWebSession")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// pairingServiceClient implements PairingServiceClient.
+type pairingServiceClient struct {
+	claimDevice       *connect.Client[v1.ClaimDeviceRequest, v1.ClaimDeviceResponse]
+	requestEnrollment *connect.Client[v1.RequestEnrollmentRequest, v1.RequestEnrollmentResponse]
+	getEnrollment     *connect.Client[v1.GetEnrollmentRequest, v1.GetEnrollmentResponse]
+	startWebLogin     *connect.Client[v1.StartWebLoginRequest, v1.StartWebLoginResponse]
+	completeWebLogin  *connect.Client[v1.CompleteWebLoginRequest, v1.CompleteWebLoginResponse]
+	endWebSession     *connect.Client[v1.EndWebSessionRequest, v1.EndWebSessionResponse]
+}
+
+// ClaimDevice calls devices.v1.PairingService.ClaimDevice.
+func (c *pairingServiceClient) ClaimDevice(ctx context.Context, req *connect.Request[v1.ClaimDeviceRequest]) (*connect.Response[v1.ClaimDeviceResponse], error) {
+	return c.claimDevice.CallUnary(ctx, req)
+}
+
+// RequestEnrollment calls devices.v1.PairingService.RequestEnrollment.
+func (c *pairingServiceClient) RequestEnrollment(ctx context.Context, req *connect.Request[v1.RequestEnrollmentRequest]) (*connect.Response[v1.RequestEnrollmentResponse], error) {
+	return c.requestEnrollment.CallUnary(ctx, req)
+}
+
+// GetEnrollment calls devices.v1.PairingService.GetEnrollment.
+func (c *pairingServiceClient) GetEnrollment(ctx context.Context, req *connect.Request[v1.GetEnrollmentRequest]) (*connect.Response[v1.GetEnrollmentResponse], error) {
+	return c.getEnrollment.CallUnary(ctx, req)
+}
+
+// StartWebLogin calls devices.v1.PairingService.StartWebLogin.
+func (c *pairingServiceClient) StartWebLogin(ctx context.Context, req *connect.Request[v1.StartWebLoginRequest]) (*connect.Response[v1.StartWebLoginResponse], error) {
+	return c.startWebLogin.CallUnary(ctx, req)
+}
+
+// CompleteWebLogin calls devices.v1.PairingService.CompleteWebLogin.
+func (c *pairingServiceClient) CompleteWebLogin(ctx context.Context, req *connect.Request[v1.CompleteWebLoginRequest]) (*connect.Response[v1.CompleteWebLoginResponse], error) {
+	return c.completeWebLogin.CallUnary(ctx, req)
+}
+
+// EndWebSession calls devices.v1.PairingService.EndWebSession.
+func (c *pairingServiceClient) EndWebSession(ctx context.Context, req *connect.Request[v1.EndWebSessionRequest]) (*connect.Response[v1.EndWebSessionResponse], error) {
+	return c.endWebSession.CallUnary(ctx, req)
+}
+
+// PairingServiceHandler is an implementation of the devices.v1.PairingService service.
+type PairingServiceHandler interface {
+	// ClaimDevice redeems a pairing code for the credentials of the device it
+	// was issued for. Codes can only be redeemed once, and expire shortly after
+	// they are issued.
+	ClaimDevice(context.Context, *connect.Request[v1.ClaimDeviceRequest]) (*connect.Response[v1.ClaimDeviceResponse], error)
+	// RequestEnrollment asks an admin to enroll a new device with its Ed25519
+	// public key. Requests expire if they are not decided in time, and only a
+	// few can be pending at once.
+	RequestEnrollment(context.Context, *connect.Request[v1.RequestEnrollmentRequest]) (*connect.Response[v1.RequestEnrollmentResponse], error)
+	// GetEnrollment returns the state of an enrollment request, which the new
+	// device polls until it is decided. Once approved the device authenticates
+	// with tokens signed by the secret key of its public key.
+	GetEnrollment(context.Context, *connect.Request[v1.GetEnrollmentRequest]) (*connect.Response[v1.GetEnrollmentResponse], error)
+	// StartWebLogin starts logging a browser in. It returns a code for the
+	// browser to show, e.g. as a QR code, for a paired device to approve with
+	// DeviceService.ApproveWebLogin. The browser is set an HttpOnly cookie
+	// that completing the login requires, so that whoever sees the code cannot
+	// complete it in its place.
+	StartWebLogin(context.Context, *connect.Request[v1.StartWebLoginRequest]) (*connect.Response[v1.StartWebLoginResponse], error)
+	// CompleteWebLogin is polled by the browser until its login is approved.
+	// The browser is then set an HttpOnly session cookie authenticating it as
+	// the device that approved the login until the session expires.
+	CompleteWebLogin(context.Context, *connect.Request[v1.CompleteWebLoginRequest]) (*connect.Response[v1.CompleteWebLoginResponse], error)
+	// EndWebSession logs the browser out, which ends its session.
+	EndWebSession(context.Context, *connect.Request[v1.EndWebSessionRequest]) (*connect.Response[v1.EndWebSessionResponse], error)
+}
+
+// NewPairingServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewPairingServiceHandler(svc PairingServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	pairingServiceMethods := v1.File_devices_v1_devices_proto.Services().ByName("PairingService").Methods()
+	pairingServiceClaimDeviceHandler := connect.NewUnaryHandler(
+		PairingServiceClaimDeviceProcedure,
+		svc.ClaimDevice,
+		connect.WithSchema(pairingServiceMethods.ByName("ClaimDevice")),
+		connect.WithHandlerOptions(opts...),
+	)
+	pairingServiceRequestEnrollmentHandler := connect.NewUnaryHandler(
+		PairingServiceRequestEnrollmentProcedure,
+		svc.RequestEnrollment,
+		connect.WithSchema(pairingServiceMethods.ByName("RequestEnrollment")),
+		connect.WithHandlerOptions(opts...),
+	)
+	pairingServiceGetEnrollmentHandler := connect.NewUnaryHandler(
+		PairingServiceGetEnrollmentProcedure,
+		svc.GetEnrollment,
+		connect.WithSchema(pairingServiceMethods.ByName("GetEnrollment")),
+		connect.WithHandlerOptions(opts...),
+	)
+	pairingServiceStartWebLoginHandler := connect.NewUnaryHandler(
+		PairingServiceStartWebLoginProcedure,
+		svc.StartWebLogin,
+		connect.WithSchema(pairingServiceMethods.ByName("StartWebLogin")),
+		connect.WithHandlerOptions(opts...),
+	)
+	pairingServiceCompleteWebLoginHandler := connect.NewUnaryHandler(
+		PairingServiceCompleteWebLoginProcedure,
+		svc.CompleteWebLogin,
+		connect.WithSchema(pairingServiceMethods.ByName("CompleteWebLogin")),
+		connect.WithHandlerOptions(opts...),
+	)
+	pairingServiceEndWebSessionHandler := connect.NewUnaryHandler(
+		PairingServiceEndWebSessionProcedure,
+		svc.EndWebSession,
+		connect.WithSchema(pairingServiceMethods.ByName("EndWebSession")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/devices.v1.PairingService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case PairingServiceClaimDeviceProcedure:
+			pairingServiceClaimDeviceHandler.ServeHTTP(w, r)
+		case PairingServiceRequestEnrollmentProcedure:
+			pairingServiceRequestEnrollmentHandler.ServeHTTP(w, r)
+		case PairingServiceGetEnrollmentProcedure:
+			pairingServiceGetEnrollmentHandler.ServeHTTP(w, r)
+		case PairingServiceStartWebLoginProcedure:
+			pairingServiceStartWebLoginHandler.ServeHTTP(w, r)
+		case PairingServiceCompleteWebLoginProcedure:
+			pairingServiceCompleteWebLoginHandler.ServeHTTP(w, r)
+		case PairingServiceEndWebSessionProcedure:
+			pairingServiceEndWebSessionHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedPairingServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedPairingServiceHandler struct{}
+
+func (UnimplementedPairingServiceHandler) ClaimDevice(context.Context, *connect.Request[v1.ClaimDeviceRequest]) (*connect.Response[v1.ClaimDeviceResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.PairingService.ClaimDevice is not implemented"))
+}
+
+func (UnimplementedPairingServiceHandler) RequestEnrollment(context.Context, *connect.Request[v1.RequestEnrollmentRequest]) (*connect.Response[v1.RequestEnrollmentResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.PairingService.RequestEnrollment is not implemented"))
+}
+
+func (UnimplementedPairingServiceHandler) GetEnrollment(context.Context, *connect.Request[v1.GetEnrollmentRequest]) (*connect.Response[v1.GetEnrollmentResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.PairingService.GetEnrollment is not implemented"))
+}
+
+func (UnimplementedPairingServiceHandler) StartWebLogin(context.Context, *connect.Request[v1.StartWebLoginRequest]) (*connect.Response[v1.StartWebLoginResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.PairingService.StartWebLogin is not implemented"))
+}
+
+func (UnimplementedPairingServiceHandler) CompleteWebLogin(context.Context, *connect.Request[v1.CompleteWebLoginRequest]) (*connect.Response[v1.CompleteWebLoginResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.PairingService.CompleteWebLogin is not implemented"))
+}
+
+func (UnimplementedPairingServiceHandler) EndWebSession(context.Context, *connect.Request[v1.EndWebSessionRequest]) (*connect.Response[v1.EndWebSessionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("devices.v1.PairingService.EndWebSession is not implemented"))
+}
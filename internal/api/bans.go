@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"connectrpc.com/connect"
+
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/logging"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (ds *DeviceService) ListBans(
+	ctx context.Context,
+	req *connect.Request[devicesv1.ListBansRequest],
+) (*connect.Response[devicesv1.ListBansResponse], error) {
+	list := ds.Attempts.Bans(time.Now())
+
+	bans := make([]*devicesv1.Ban, len(list))
+	for i, ban := range list {
+		//nolint: gosec // bans last for a while, so there are never many in a row
+		count := int32(ban.Count)
+
+		bans[i] = &devicesv1.Ban{
+			RemoteAddr: ban.RemoteAddr,
+			DeviceId:   ban.DeviceID,
+			ExpireTime: timestamppb.New(ban.Until),
+			Count:      count,
+		}
+	}
+
+	return connect.NewResponse(&devicesv1.ListBansResponse{
+		Bans: bans,
+	}), nil
+}
+
+func (ds *DeviceService) ClearBans(
+	ctx context.Context,
+	req *connect.Request[devicesv1.ClearBansRequest],
+) (*connect.Response[devicesv1.ClearBansResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	cleared := 0
+
+	switch {
+	case req.Msg.GetAll():
+		cleared = ds.Attempts.ClearBans(time.Now())
+	case ds.Attempts.Clear(req.Msg.GetRemoteAddr(), req.Msg.GetDeviceId()):
+		cleared = 1
+	}
+
+	logger.Info(
+		"bans cleared",
+		slog.String("remote_addr", req.Msg.GetRemoteAddr()),
+		slog.String("device_id", req.Msg.GetDeviceId()),
+		slog.Bool("all", req.Msg.GetAll()),
+		slog.Int("cleared", cleared),
+	)
+
+	//nolint: gosec // there are at most DefaultAttemptLimiterSize bans
+	count := int32(cleared)
+
+	return connect.NewResponse(&devicesv1.ClearBansResponse{
+		Cleared: count,
+	}), nil
+}
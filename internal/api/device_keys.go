@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"aidanwoods.dev/go-paseto"
+	"connectrpc.com/connect"
+
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/logging"
+)
+
+func (ds *DeviceService) RotateDeviceKey(
+	ctx context.Context,
+	req *connect.Request[devicesv1.RotateDeviceKeyRequest],
+) (*connect.Response[devicesv1.RotateDeviceKeyResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	caller := auth.DeviceFromContext(ctx)
+
+	id := req.Msg.GetDeviceId()
+	if id == "" {
+		id = caller
+	}
+
+	current := auth.DeviceKeyFromContext(ctx)
+
+	if id != caller && !auth.RoleFromContext(ctx).Grants(auth.ScopeAdmin) {
+		return nil, connect.NewError(
+			connect.CodePermissionDenied,
+			errors.New("permission denied"),
+		)
+	}
+
+	device, err := ds.DB.GetDevice(ctx, id)
+	if err != nil {
+		logger.Error("failed to get device", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if device == nil {
+		return nil, connect.NewError(
+			connect.CodeNotFound,
+			fmt.Errorf("device %s not found", id),
+		)
+	}
+
+	// NB: devices that registered a public key no longer authenticate with
+	// their symmetric key, rotating it would only retire the identity of the
+	// server they sign their tokens for.
+	if device.PublicKey != nil {
+		return nil, connect.NewError(
+			connect.CodeFailedPrecondition,
+			fmt.Errorf("device %s signs its tokens with a public key", id),
+		)
+	}
+
+	if id != caller {
+		current = auth.DeviceKey{
+			Version:    device.KeyVersion,
+			Generation: device.KeyGeneration,
+		}
+	}
+
+	// NB: rotating also moves the device to the current version of the
+	// server secret.
+	next := auth.DeviceKey{
+		Version:    ds.Keyring.Current,
+		Generation: current.Generation + 1,
+	}
+
+	chain, err := ds.Keyring.ClientChain(next.Version, next.Generation, id)
+	if err != nil {
+		logger.Error("failed to derive rotated device keychain", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	// NB: the new key is encrypted before it is stored, since the device
+	// could not authenticate anymore if it never received it.
+	encrypted, err := ds.encryptForCaller(ctx, chain.Seed[:])
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := ds.DB.RotateDeviceKey(
+		ctx,
+		id,
+		current.Version,
+		current.Generation,
+		next.Version,
+	)
+	if err != nil {
+		logger.Error("failed to rotate device key", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !rotated {
+		return nil, connect.NewError(
+			connect.CodeAborted,
+			fmt.Errorf("key of device %s changed during rotation", id),
+		)
+	}
+
+	logger.Info(
+		"device key rotated",
+		slog.String("rotated_device_id", id),
+		slog.Int("key_version", next.Version),
+		slog.Int("key_generation", next.Generation),
+	)
+
+	//nolint: gosec // versions of the server secret are configured by hand
+	keyVersion := int32(next.Version)
+
+	//nolint: gosec // keys are not rotated anywhere near 2^31 times
+	keyGeneration := int32(next.Generation)
+
+	return connect.NewResponse(&devicesv1.RotateDeviceKeyResponse{
+		DeviceId:           id,
+		EncryptedDeviceKey: encrypted,
+		KeyVersion:         keyVersion,
+		KeyGeneration:      keyGeneration,
+		ServerId:           ds.Identities[next.Version],
+	}), nil
+}
+
+func (ds *DeviceService) RegisterPublicKey(
+	ctx context.Context,
+	req *connect.Request[devicesv1.RegisterPublicKeyRequest],
+) (*connect.Response[devicesv1.RegisterPublicKeyResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	id := auth.DeviceFromContext(ctx)
+
+	_, err := paseto.NewV4AsymmetricPublicKeyFromBytes(req.Msg.GetPublicKey())
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	found, err := ds.DB.SetDevicePublicKey(ctx, id, req.Msg.GetPublicKey())
+	if err != nil {
+		logger.Error("failed to set device public key", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !found {
+		return nil, connect.NewError(
+			connect.CodeNotFound,
+			fmt.Errorf("device %s not found", id),
+		)
+	}
+
+	logger.Info("device public key registered")
+
+	return connect.NewResponse(&devicesv1.RegisterPublicKeyResponse{}), nil
+}
+
+// encryptForCaller encrypts a device key with the key encryption key of the
+// calling device, so that it only ever leaves the server encrypted. Callers
+// that sign their tokens with a public key cannot be relied on to have that
+// key, so nothing is encrypted for them.
+func (ds *DeviceService) encryptForCaller(ctx context.Context, deviceKey []byte) ([]byte, error) {
+	logger := logging.FromContext(ctx)
+
+	device, err := ds.DB.GetDevice(ctx, auth.DeviceFromContext(ctx))
+	if err != nil {
+		logger.Error("failed to get device", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if device == nil || device.PublicKey != nil {
+		return nil, connect.NewError(
+			connect.CodeFailedPrecondition,
+			errors.New("keys can only be encrypted for devices without a public key"),
+		)
+	}
+
+	caller := auth.DeviceKeyFromContext(ctx)
+
+	chain, err := ds.Keyring.ClientChain(
+		caller.Version,
+		caller.Generation,
+		auth.DeviceFromContext(ctx),
+	)
+	if err != nil {
+		logger.Error("failed to derive current device keychain")
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	encrypted, err := chain.EncryptKey(deviceKey)
+	if err != nil {
+		logger.Error("failed to encrypt new device key")
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return encrypted, nil
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"aidanwoods.dev/go-paseto"
+	"connectrpc.com/connect"
+	"github.com/google/uuid"
+
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/database"
+	"github.com/cmp0st/byte/internal/key"
+)
+
+func TestRotateDeviceKey(t *testing.T) {
+	keyring, err := key.NewServerKeyring(
+		map[int][]byte{key.DefaultKeyVersion: []byte("0123456789abcdef0123456789abcdef")},
+		key.DefaultKeyVersion,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := newTestDB(t)
+	devices := &DeviceService{DB: db, Keyring: *keyring}
+
+	// newDevice adds an admin device, signing its tokens if signed, and
+	// returns a context authenticated as it.
+	newDevice := func(signed bool) (string, context.Context) {
+		id := uuid.NewString()
+
+		err := db.AddDevice(t.Context(), database.Device{
+			ID:         id,
+			Role:       string(auth.RoleAdmin),
+			KeyVersion: key.DefaultKeyVersion,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if signed {
+			_, err = db.SetDevicePublicKey(
+				t.Context(),
+				id,
+				paseto.NewV4AsymmetricSecretKey().Public().ExportBytes(),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ctx := auth.WithDevice(auth.WithRole(t.Context(), auth.RoleAdmin), id)
+
+		return id, auth.WithDeviceKey(ctx, auth.DeviceKey{Version: key.DefaultKeyVersion})
+	}
+
+	_, plainCtx := newDevice(false)
+	other, _ := newDevice(false)
+	signed, signedCtx := newDevice(true)
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		device string
+		code   connect.Code
+	}{
+		{
+			name: "signed device rotating itself",
+			ctx:  signedCtx,
+			code: connect.CodeFailedPrecondition,
+		},
+		{
+			name:   "rotating a signed device",
+			ctx:    plainCtx,
+			device: signed,
+			code:   connect.CodeFailedPrecondition,
+		},
+		{
+			name:   "signed device rotating another",
+			ctx:    signedCtx,
+			device: other,
+			code:   connect.CodeFailedPrecondition,
+		},
+		{name: "rotating another device", ctx: plainCtx, device: other},
+		{name: "rotating itself", ctx: plainCtx},
+	}
+
+	for _, test := range tests {
+		id := test.device
+		if id == "" {
+			id = auth.DeviceFromContext(test.ctx)
+		}
+
+		before, err := db.GetDevice(t.Context(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := devices.RotateDeviceKey(
+			test.ctx,
+			connect.NewRequest(&devicesv1.RotateDeviceKeyRequest{DeviceId: test.device}),
+		)
+		if (test.code == 0) != (err == nil) || (err != nil && connect.CodeOf(err) != test.code) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.code)
+
+			continue
+		}
+
+		after, err := db.GetDevice(t.Context(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// NB: refused rotations must leave the key of the device as it was.
+		if test.code != 0 {
+			if after.KeyGeneration != before.KeyGeneration {
+				t.Errorf("%s: rotated to generation %d", test.name, after.KeyGeneration)
+			}
+
+			continue
+		}
+
+		if after.KeyGeneration != before.KeyGeneration+1 {
+			t.Errorf(
+				"%s: rotated to generation %d, want %d",
+				test.name, after.KeyGeneration, before.KeyGeneration+1,
+			)
+		}
+
+		// NB: the caller decrypts the new key with its own.
+		callerChain, err := keyring.ClientChain(
+			key.DefaultKeyVersion,
+			0,
+			auth.DeviceFromContext(test.ctx),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := callerChain.DecryptKey(resp.Msg.GetEncryptedDeviceKey())
+		if err != nil {
+			t.Fatalf("%s: failed to decrypt the new key: %v", test.name, err)
+		}
+
+		want, err := keyring.ClientChain(key.DefaultKeyVersion, after.KeyGeneration, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(got, want.Seed[:]) {
+			t.Errorf("%s: got a key other than the rotated one", test.name)
+		}
+	}
+}
+
+func TestRegisterPublicKey(t *testing.T) {
+	db := newTestDB(t)
+	devices := &DeviceService{DB: db}
+	id := uuid.NewString()
+
+	err := db.AddDevice(t.Context(), database.Device{
+		ID:         id,
+		Role:       string(auth.RoleMember),
+		KeyVersion: key.DefaultKeyVersion,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := paseto.NewV4AsymmetricSecretKey().Public().ExportBytes()
+
+	tests := []struct {
+		name      string
+		device    string
+		publicKey []byte
+		code      connect.Code
+	}{
+		{
+			name:      "short key",
+			device:    id,
+			publicKey: publicKey[:16],
+			code:      connect.CodeInvalidArgument,
+		},
+		{
+			name:      "deleted device",
+			device:    uuid.NewString(),
+			publicKey: publicKey,
+			code:      connect.CodeNotFound,
+		},
+		{name: "valid key", device: id, publicKey: publicKey},
+	}
+
+	for _, test := range tests {
+		_, err := devices.RegisterPublicKey(
+			auth.WithDevice(t.Context(), test.device),
+			connect.NewRequest(&devicesv1.RegisterPublicKeyRequest{PublicKey: test.publicKey}),
+		)
+		if (test.code == 0) != (err == nil) || (err != nil && connect.CodeOf(err) != test.code) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.code)
+		}
+
+		device, err := db.GetDevice(t.Context(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if registered := bytes.Equal(device.PublicKey, publicKey); registered != (test.code == 0) {
+			t.Errorf("%s: registered %x", test.name, device.PublicKey)
+		}
+	}
+
+	resp, err := devices.ListDevices(
+		t.Context(),
+		connect.NewRequest(&devicesv1.ListDevicesRequest{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listed := resp.Msg.GetDevices()
+	if len(listed) != 1 || !listed[0].GetHasPublicKey() {
+		t.Errorf("listed %v, want the device with its public key", listed)
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"connectrpc.com/connect"
 
@@ -13,14 +14,23 @@ import (
 	"github.com/cmp0st/byte/internal/key"
 	"github.com/cmp0st/byte/internal/logging"
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var _ devicesv1connect.DeviceServiceHandler = &DeviceService{}
 
 // DeviceService implements the files v1 service.
 type DeviceService struct {
-	DB       *database.DB
-	KeyChain key.ServerChain
+	DB      *database.DB
+	Keyring key.ServerKeyring
+
+	// Identities of the server by version of the server secret, see
+	// auth.TokenPolicy.
+	Identities map[int]string
+
+	// Attempts are the failed authentication attempts shared with the SSH
+	// server, whose bans admins can list and clear.
+	Attempts *auth.AttemptLimiter
 }
 
 func (ds *DeviceService) CreateDevice(
@@ -29,6 +39,11 @@ func (ds *DeviceService) CreateDevice(
 ) (*connect.Response[devicesv1.CreateDeviceResponse], error) {
 	logger := logging.FromContext(ctx)
 
+	role := auth.RoleMember
+	if req.Msg.GetRole() != devicesv1.Role_ROLE_UNSPECIFIED {
+		role = roleFromProto(req.Msg.GetRole())
+	}
+
 	id, err := uuid.NewRandom()
 	if err != nil {
 		logger.Error("failed to create uuid", slog.Any("err", err))
@@ -36,50 +51,43 @@ func (ds *DeviceService) CreateDevice(
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	err = ds.DB.AddDevice(ctx, id.String())
+	err = ds.DB.AddDevice(ctx, database.Device{
+		ID:         id.String(),
+		Role:       string(role),
+		KeyVersion: ds.Keyring.Current,
+		Name:       req.Msg.GetName(),
+		CreatedAt:  time.Now(),
+	})
 	if err != nil {
 		logger.Error("failed to add device", slog.Any("err", err))
 
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	// Derive the new device root key
-	var newDeviceRootKey []byte
-	{
-		chain, err := ds.KeyChain.ClientChain(id.String())
-		if err != nil {
-			logger.Error("failed to derive new device token keychain")
-
-			return nil, connect.NewError(connect.CodeInternal, err)
-		}
-
-		newDeviceRootKey = chain.Seed[:]
+	logger.Info(
+		"device created",
+		slog.String("new_device_id", id.String()),
+		slog.String("name", req.Msg.GetName()),
+		slog.String("role", string(role)),
+		slog.Int("key_version", ds.Keyring.Current),
+	)
+
+	// NB: the new device claims its key with the pairing code, so the key
+	// itself is never shown to anyone.
+	code, expiresAt, err := IssuePairingCode(ctx, ds.DB, id.String(), time.Now())
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	var encrypted []byte
-	{
-		device := auth.DeviceFromContext(ctx)
-
-		chain, err := ds.KeyChain.ClientChain(device)
-		if err != nil {
-			logger.Error("failed to derive current device keychain")
-
-			return nil, connect.NewError(connect.CodeInternal, err)
-		}
-
-		enc, err := chain.EncryptKey(newDeviceRootKey)
-		if err != nil {
-			logger.Error("failed to encrypt new device key")
-
-			return nil, connect.NewError(connect.CodeInternal, err)
-		}
-
-		encrypted = enc
-	}
+	//nolint: gosec // versions of the server secret are configured by hand
+	keyVersion := int32(ds.Keyring.Current)
 
 	return connect.NewResponse(&devicesv1.CreateDeviceResponse{
-		Id:                 id.String(),
-		EncryptedDeviceKey: encrypted,
+		Id:                    id.String(),
+		KeyVersion:            keyVersion,
+		ServerId:              ds.Identities[ds.Keyring.Current],
+		PairingCode:           code,
+		PairingCodeExpireTime: timestamppb.New(expiresAt),
 	}), nil
 }
 
@@ -89,17 +97,35 @@ func (ds *DeviceService) ListDevices(
 ) (*connect.Response[devicesv1.ListDevicesResponse], error) {
 	logger := logging.FromContext(ctx)
 
-	ids, err := ds.DB.ListDevices(ctx)
+	list, err := ds.DB.ListDevices(ctx)
 	if err != nil {
 		logger.Error("failed to list devices", slog.Any("err", err))
 
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	devices := make([]*devicesv1.ListDevicesResponse_Device, len(ids))
-	for i, id := range ids {
+	devices := make([]*devicesv1.ListDevicesResponse_Device, len(list))
+	for i, device := range list {
+		//nolint: gosec // versions of the server secret are configured by hand
+		keyVersion := int32(device.KeyVersion)
+
 		devices[i] = &devicesv1.ListDevicesResponse_Device{
-			Id: id,
+			Id:           device.ID,
+			Role:         roleToProto(auth.Role(device.Role)),
+			KeyVersion:   keyVersion,
+			HasPublicKey: device.PublicKey != nil,
+			Name:         device.Name,
+			Platform:     device.Platform,
+			AppVersion:   device.AppVersion,
+			LastSeenIp:   device.LastSeenIP,
+		}
+
+		if !device.CreatedAt.IsZero() {
+			devices[i].CreateTime = timestamppb.New(device.CreatedAt)
+		}
+
+		if !device.LastSeenAt.IsZero() {
+			devices[i].LastSeenTime = timestamppb.New(device.LastSeenAt)
 		}
 	}
 
@@ -119,3 +145,34 @@ func (ds *DeviceService) DeleteDevice(
 
 	return connect.NewResponse(&devicesv1.DeleteDeviceResponse{}), nil
 }
+
+func roleFromProto(role devicesv1.Role) auth.Role {
+	switch role {
+	case devicesv1.Role_ROLE_ADMIN:
+		return auth.RoleAdmin
+	case devicesv1.Role_ROLE_MEMBER:
+		return auth.RoleMember
+	case devicesv1.Role_ROLE_READ_ONLY:
+		return auth.RoleReadOnly
+	case devicesv1.Role_ROLE_UPLOAD_ONLY:
+		return auth.RoleUploadOnly
+	case devicesv1.Role_ROLE_UNSPECIFIED:
+	}
+
+	return ""
+}
+
+func roleToProto(role auth.Role) devicesv1.Role {
+	switch role {
+	case auth.RoleAdmin:
+		return devicesv1.Role_ROLE_ADMIN
+	case auth.RoleMember:
+		return devicesv1.Role_ROLE_MEMBER
+	case auth.RoleReadOnly:
+		return devicesv1.Role_ROLE_READ_ONLY
+	case auth.RoleUploadOnly:
+		return devicesv1.Role_ROLE_UPLOAD_ONLY
+	}
+
+	return devicesv1.Role_ROLE_UNSPECIFIED
+}
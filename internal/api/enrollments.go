@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"connectrpc.com/connect"
+
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/database"
+	"github.com/cmp0st/byte/internal/key"
+	"github.com/cmp0st/byte/internal/logging"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// Enrollment requests are meant to be decided while the new device waits
+	// for it.
+	DefaultEnrollmentExpiration = time.Hour
+
+	// NB: enrollment requests are submitted without authentication, so they
+	// are bounded to keep anyone from filling the database or the list of
+	// requests admins go through.
+	DefaultMaxPendingEnrollments    = 20
+	DefaultMaxEnrollmentsPerAddress = 3
+)
+
+var ErrEnrollmentNotPending = errors.New("enrollment request is not pending")
+
+func (ds *DeviceService) ListEnrollments(
+	ctx context.Context,
+	req *connect.Request[devicesv1.ListEnrollmentsRequest],
+) (*connect.Response[devicesv1.ListEnrollmentsResponse], error) {
+	now := time.Now()
+
+	list, err := ds.DB.ListPendingEnrollments(ctx, now)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	enrollments := make([]*devicesv1.Enrollment, len(list))
+	for i, enrollment := range list {
+		enrollments[i] = enrollmentToProto(enrollment, now)
+	}
+
+	return connect.NewResponse(&devicesv1.ListEnrollmentsResponse{
+		Enrollments: enrollments,
+	}), nil
+}
+
+func (ds *DeviceService) ApproveEnrollment(
+	ctx context.Context,
+	req *connect.Request[devicesv1.ApproveEnrollmentRequest],
+) (*connect.Response[devicesv1.ApproveEnrollmentResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	role := auth.RoleMember
+	if req.Msg.GetRole() != devicesv1.Role_ROLE_UNSPECIFIED {
+		role = roleFromProto(req.Msg.GetRole())
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		logger.Error("failed to create uuid", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	now := time.Now()
+
+	approved, err := ds.DB.ApproveEnrollment(ctx, req.Msg.GetEnrollmentId(), database.Device{
+		ID:         id.String(),
+		Role:       string(role),
+		KeyVersion: ds.Keyring.Current,
+	}, now)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	enrollment, err := ds.decidedEnrollment(ctx, req.Msg.GetEnrollmentId(), approved)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(
+		"enrollment approved",
+		slog.String("enrollment_id", enrollment.ID),
+		slog.String("new_device_id", id.String()),
+		slog.String("role", string(role)),
+	)
+
+	return connect.NewResponse(&devicesv1.ApproveEnrollmentResponse{
+		Enrollment: enrollmentToProto(*enrollment, now),
+	}), nil
+}
+
+func (ds *DeviceService) DenyEnrollment(
+	ctx context.Context,
+	req *connect.Request[devicesv1.DenyEnrollmentRequest],
+) (*connect.Response[devicesv1.DenyEnrollmentResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	now := time.Now()
+
+	denied, err := ds.DB.DenyEnrollment(ctx, req.Msg.GetEnrollmentId(), now)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	enrollment, err := ds.decidedEnrollment(ctx, req.Msg.GetEnrollmentId(), denied)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("enrollment denied", slog.String("enrollment_id", enrollment.ID))
+
+	return connect.NewResponse(&devicesv1.DenyEnrollmentResponse{
+		Enrollment: enrollmentToProto(*enrollment, now),
+	}), nil
+}
+
+// decidedEnrollment returns the enrollment request that was just decided, or
+// explains why it could not be.
+func (ds *DeviceService) decidedEnrollment(
+	ctx context.Context,
+	id string,
+	decided bool,
+) (*database.Enrollment, error) {
+	enrollment, err := ds.DB.GetEnrollment(ctx, id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if enrollment == nil {
+		return nil, connect.NewError(
+			connect.CodeNotFound,
+			errors.New("enrollment request not found"),
+		)
+	}
+
+	if !decided {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, ErrEnrollmentNotPending)
+	}
+
+	return enrollment, nil
+}
+
+// enrollmentToProto describes enrollment as of now, at which pending requests
+// may have expired.
+func enrollmentToProto(enrollment database.Enrollment, now time.Time) *devicesv1.Enrollment {
+	state := devicesv1.EnrollmentState_ENROLLMENT_STATE_PENDING
+
+	switch {
+	case enrollment.State == database.EnrollmentApproved:
+		state = devicesv1.EnrollmentState_ENROLLMENT_STATE_APPROVED
+	case enrollment.State == database.EnrollmentDenied:
+		state = devicesv1.EnrollmentState_ENROLLMENT_STATE_DENIED
+	case !enrollment.ExpiresAt.After(now):
+		state = devicesv1.EnrollmentState_ENROLLMENT_STATE_EXPIRED
+	}
+
+	return &devicesv1.Enrollment{
+		Id:          enrollment.ID,
+		Name:        enrollment.Name,
+		Platform:    enrollment.Platform,
+		Fingerprint: key.Fingerprint(enrollment.PublicKey),
+		State:       state,
+		RemoteAddr:  enrollment.RemoteAddr,
+		CreateTime:  timestamppb.New(enrollment.CreatedAt),
+		ExpireTime:  timestamppb.New(enrollment.ExpiresAt),
+		DeviceId:    enrollment.DeviceID,
+	}
+}
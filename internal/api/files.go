@@ -2,8 +2,10 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"path/filepath"
 
 	"connectrpc.com/connect"
@@ -12,6 +14,8 @@ import (
 
 	filesv1 "github.com/cmp0st/byte/gen/files/v1"
 	"github.com/cmp0st/byte/gen/files/v1/filesv1connect"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/database"
 	"github.com/cmp0st/byte/internal/logging"
 	"github.com/cmp0st/byte/internal/storage"
 )
@@ -24,15 +28,34 @@ const (
 // FileService implements the files v1 service.
 type FileService struct {
 	storage storage.Interface
+	db      *database.DB
 }
 
-// NewFileService creates a new file service.
-func NewFileService(storage storage.Interface) filesv1connect.FileServiceHandler {
+// NewFileService creates a new file service. Devices see storage limited to
+// their path grants.
+func NewFileService(storage storage.Interface, db *database.DB) filesv1connect.FileServiceHandler {
 	return &FileService{
 		storage: storage,
+		db:      db,
 	}
 }
 
+// storageFor returns the storage as seen by the device making the request.
+func (s *FileService) storageFor(ctx context.Context) (storage.Interface, error) {
+	grants, restricted, err := s.db.StorageGrants(ctx, auth.DeviceFromContext(ctx))
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get path grants", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !restricted {
+		return s.storage, nil
+	}
+
+	return storage.NewGranted(s.storage, grants), nil
+}
+
 // ListDirectory lists the contents of a directory.
 func (s *FileService) ListDirectory(
 	ctx context.Context,
@@ -40,17 +63,22 @@ func (s *FileService) ListDirectory(
 ) (*connect.Response[filesv1.ListDirectoryResponse], error) {
 	logger := logging.FromContext(ctx)
 
+	fs, err := s.storageFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	if req.Msg.GetPath() == "" {
 		// Default to root
 		req.Msg.Path = "."
 	}
 
-	entries, err := afero.ReadDir(s.storage, req.Msg.GetPath())
+	entries, err := afero.ReadDir(fs, req.Msg.GetPath())
 	if err != nil {
 		logger.Error("failed to list directory", slog.Any("err", err))
 
 		return nil, connect.NewError(
-			connect.CodeNotFound,
+			errorCode(err, connect.CodeNotFound),
 			fmt.Errorf("failed to list directory: %w", err),
 		)
 	}
@@ -77,18 +105,22 @@ func (s *FileService) MakeDirectory(
 ) (*connect.Response[filesv1.MakeDirectoryResponse], error) {
 	logger := logging.FromContext(ctx)
 
-	var err error
+	fs, err := s.storageFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	if req.Msg.GetCreateParents() {
-		err = s.storage.MkdirAll(req.Msg.GetPath(), DefaultDirectoryPermission)
+		err = fs.MkdirAll(req.Msg.GetPath(), DefaultDirectoryPermission)
 	} else {
-		err = s.storage.Mkdir(req.Msg.GetPath(), DefaultDirectoryPermission)
+		err = fs.Mkdir(req.Msg.GetPath(), DefaultDirectoryPermission)
 	}
 
 	if err != nil {
 		logger.Error("failed to create directory", slog.Any("err", err))
 		// We just assume an invalid argument here, but there are plenty of
 		// other reasons this could fail.
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		return nil, connect.NewError(errorCode(err, connect.CodeInvalidArgument), err)
 	}
 
 	return connect.NewResponse(&filesv1.MakeDirectoryResponse{}), nil
@@ -100,18 +132,22 @@ func (s *FileService) RemoveDirectory(
 ) (*connect.Response[filesv1.RemoveDirectoryResponse], error) {
 	logger := logging.FromContext(ctx)
 
-	var err error
+	fs, err := s.storageFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	if req.Msg.GetRecursive() {
-		err = s.storage.RemoveAll(req.Msg.GetPath())
+		err = fs.RemoveAll(req.Msg.GetPath())
 	} else {
-		err = s.storage.Remove(req.Msg.GetPath())
+		err = fs.Remove(req.Msg.GetPath())
 	}
 
 	if err != nil {
 		logger.Error("failed to create directory", slog.Any("err", err))
 		// We just assume an invalid argument here, but there are plenty of
 		// other reasons this could fail.
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		return nil, connect.NewError(errorCode(err, connect.CodeInvalidArgument), err)
 	}
 
 	return connect.NewResponse(&filesv1.RemoveDirectoryResponse{}), nil
@@ -124,22 +160,27 @@ func (s *FileService) ReadFile(
 ) (*connect.Response[filesv1.ReadFileResponse], error) {
 	logger := logging.FromContext(ctx)
 
-	data, err := afero.ReadFile(s.storage, req.Msg.GetPath())
+	fs, err := s.storageFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := afero.ReadFile(fs, req.Msg.GetPath())
 	if err != nil {
 		logger.Error("failed to read file", slog.Any("err", err))
 
 		return nil, connect.NewError(
-			connect.CodeNotFound,
+			errorCode(err, connect.CodeNotFound),
 			fmt.Errorf("failed to read file: %w", err),
 		)
 	}
 
-	fileInfo, err := s.storage.Stat(req.Msg.GetPath())
+	fileInfo, err := fs.Stat(req.Msg.GetPath())
 	if err != nil {
 		logger.Error("failed to stat file", slog.Any("err", err))
 
 		return nil, connect.NewError(
-			connect.CodeNotFound,
+			errorCode(err, connect.CodeNotFound),
 			fmt.Errorf("failed to stat file: %w", err),
 		)
 	}
@@ -163,39 +204,51 @@ func (s *FileService) WriteFile(
 ) (*connect.Response[filesv1.WriteFileResponse], error) {
 	logger := logging.FromContext(ctx)
 
+	fs, err := s.storageFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create parent directories if requested
 	if req.Msg.GetCreateParents() {
 		dir := filepath.Dir(req.Msg.GetPath())
 
-		err := s.storage.MkdirAll(dir, DefaultDirectoryPermission)
+		err = fs.MkdirAll(dir, DefaultDirectoryPermission)
 		if err != nil {
 			logger.Error("failed to create parent directories", slog.Any("err", err))
 
 			return nil, connect.NewError(
-				connect.CodeInvalidArgument,
+				errorCode(err, connect.CodeInvalidArgument),
 				fmt.Errorf("failed to create parent directories: %w", err),
 			)
 		}
 	}
 
+	// NB: devices that may only upload must not replace or truncate the
+	// files that are already there.
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !auth.RoleFromContext(ctx).Grants(auth.ScopeWrite) {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+
 	// Write the file
-	err := afero.WriteFile(s.storage, req.Msg.GetPath(), req.Msg.GetData(), DefaultFilePermission)
+	err = writeFile(fs, req.Msg.GetPath(), req.Msg.GetData(), flag)
 	if err != nil {
 		logger.Error("failed to write file", slog.Any("err", err))
 
 		return nil, connect.NewError(
-			connect.CodeInternal,
+			errorCode(err, connect.CodeInternal),
 			fmt.Errorf("failed to write file: %w", err),
 		)
 	}
 
 	// Get file info
-	fileInfo, err := s.storage.Stat(req.Msg.GetPath())
+	fileInfo, err := fs.Stat(req.Msg.GetPath())
 	if err != nil {
 		logger.Error("failed to stat file after write", slog.Any("err", err))
 
 		return nil, connect.NewError(
-			connect.CodeInternal,
+			errorCode(err, connect.CodeInternal),
 			fmt.Errorf("failed to stat file: %w", err),
 		)
 	}
@@ -218,21 +271,57 @@ func (s *FileService) DeleteFile(
 ) (*connect.Response[filesv1.DeleteFileResponse], error) {
 	logger := logging.FromContext(ctx)
 
-	var err error
+	fs, err := s.storageFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	if req.Msg.GetRecursive() {
-		err = s.storage.RemoveAll(req.Msg.GetPath())
+		err = fs.RemoveAll(req.Msg.GetPath())
 	} else {
-		err = s.storage.Remove(req.Msg.GetPath())
+		err = fs.Remove(req.Msg.GetPath())
 	}
 
 	if err != nil {
 		logger.Error("failed to delete file", slog.Any("err", err))
 
 		return nil, connect.NewError(
-			connect.CodeNotFound,
+			errorCode(err, connect.CodeNotFound),
 			fmt.Errorf("failed to delete file: %w", err),
 		)
 	}
 
 	return connect.NewResponse(&filesv1.DeleteFileResponse{}), nil
 }
+
+// errorCode returns the code for a storage error, or fallback when there is no
+// more specific code.
+func errorCode(err error, fallback connect.Code) connect.Code {
+	if errors.Is(err, os.ErrPermission) {
+		return connect.CodePermissionDenied
+	}
+
+	if errors.Is(err, os.ErrExist) {
+		return connect.CodeAlreadyExists
+	}
+
+	return fallback
+}
+
+// writeFile writes data to the named file opened with flag, like
+// afero.WriteFile.
+func writeFile(fs afero.Fs, name string, data []byte, flag int) error {
+	file, err := fs.OpenFile(name, flag, DefaultFilePermission)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return err
+}
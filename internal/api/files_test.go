@@ -0,0 +1,160 @@
+package api
+
+import (
+	"database/sql"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/spf13/afero"
+	_ "modernc.org/sqlite"
+
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	filesv1 "github.com/cmp0st/byte/gen/files/v1"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/database"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite", t.TempDir()+"/byte.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		//nolint: errcheck
+		conn.Close()
+	})
+
+	db := &database.DB{DB: conn}
+
+	err = db.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestWriteFileReplace(t *testing.T) {
+	tests := []struct {
+		role auth.Role
+		code connect.Code
+		want string
+	}{
+		{role: auth.RoleUploadOnly, code: connect.CodeAlreadyExists, want: "old"},
+		{role: auth.RoleMember, want: "new"},
+		{role: auth.RoleAdmin, want: "new"},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.role), func(t *testing.T) {
+			st := storage.NewInMemory()
+
+			err := afero.WriteFile(st, "/existing", []byte("old"), DefaultFilePermission)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			files := NewFileService(st, newTestDB(t))
+			ctx := auth.WithDevice(auth.WithRole(t.Context(), test.role), "device")
+
+			_, err = files.WriteFile(ctx, connect.NewRequest(&filesv1.WriteFileRequest{
+				Path: "/new",
+				Data: []byte("new"),
+			}))
+			if err != nil {
+				t.Fatalf("failed to write a new file: %v", err)
+			}
+
+			_, err = files.WriteFile(ctx, connect.NewRequest(&filesv1.WriteFileRequest{
+				Path: "/existing",
+				Data: []byte("new"),
+			}))
+			if test.code != 0 && connect.CodeOf(err) != test.code {
+				t.Fatalf("got %v, want %v", err, test.code)
+			}
+
+			if test.code == 0 && err != nil {
+				t.Fatalf("failed to replace a file: %v", err)
+			}
+
+			data, err := afero.ReadFile(st, "/existing")
+			if err != nil || string(data) != test.want {
+				t.Fatalf("read %q, %v, want %q", data, err, test.want)
+			}
+		})
+	}
+}
+
+func TestRevokeLastGrant(t *testing.T) {
+	st := storage.NewInMemory()
+
+	for _, name := range []string{"/pub/f", "/secret"} {
+		err := afero.WriteFile(st, name, []byte(name), DefaultFilePermission)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db := newTestDB(t)
+
+	err := db.AddDevice(t.Context(), database.Device{
+		ID:   "device",
+		Role: string(auth.RoleMember),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	devices := &DeviceService{DB: db}
+	files := NewFileService(st, db)
+	ctx := auth.WithDevice(auth.WithRole(t.Context(), auth.RoleMember), "device")
+
+	read := func(name string) error {
+		_, err := files.ReadFile(ctx, connect.NewRequest(&filesv1.ReadFileRequest{Path: name}))
+
+		return err
+	}
+
+	err = read("/secret")
+	if err != nil {
+		t.Fatalf("failed to read a file without grants: %v", err)
+	}
+
+	_, err = devices.GrantPath(t.Context(), connect.NewRequest(&devicesv1.GrantPathRequest{
+		Grant: &devicesv1.PathGrant{DeviceId: "device", Prefix: "/pub", Read: true},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = read("/secret")
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Fatalf("read a file outside of a grant: %v", err)
+	}
+
+	_, err = devices.RevokePath(t.Context(), connect.NewRequest(&devicesv1.RevokePathRequest{
+		DeviceId: "device",
+		Prefix:   "/pub",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NB: revoking the last grant leaves the device with nothing rather than
+	// with the whole storage.
+	for _, name := range []string{"/pub/f", "/secret"} {
+		err = read(name)
+		if connect.CodeOf(err) != connect.CodeNotFound {
+			t.Errorf("read %s after revoking every grant: %v", name, err)
+		}
+	}
+
+	resp, err := files.ListDirectory(ctx, connect.NewRequest(&filesv1.ListDirectoryRequest{}))
+	if err != nil || len(resp.Msg.GetEntries()) != 0 {
+		t.Errorf("listed %v, %v after revoking every grant, want nothing", resp, err)
+	}
+}
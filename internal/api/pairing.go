@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"connectrpc.com/connect"
+
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/gen/devices/v1/devicesv1connect"
+	"github.com/cmp0st/byte/internal/database"
+	"github.com/cmp0st/byte/internal/key"
+	"github.com/cmp0st/byte/internal/logging"
+	"github.com/google/uuid"
+)
+
+var _ devicesv1connect.PairingServiceHandler = &PairingService{}
+
+// PairingService implements the pairing v1 service. It is served without
+// authentication.
+type PairingService struct {
+	DB      *database.DB
+	Keyring key.ServerKeyring
+
+	// Identities of the server by version of the server secret, see
+	// auth.TokenPolicy.
+	Identities map[int]string
+}
+
+func (ps *PairingService) ClaimDevice(
+	ctx context.Context,
+	req *connect.Request[devicesv1.ClaimDeviceRequest],
+) (*connect.Response[devicesv1.ClaimDeviceResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	id, err := ps.DB.ClaimPairingCode(
+		ctx,
+		key.HashPairingCode(req.Msg.GetPairingCode()),
+		time.Now(),
+	)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if id == "" {
+		logger.Warn("invalid or expired pairing code")
+
+		return nil, connect.NewError(
+			connect.CodeNotFound,
+			errors.New("invalid or expired pairing code"),
+		)
+	}
+
+	device, err := ps.DB.GetDevice(ctx, id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if device == nil {
+		return nil, connect.NewError(
+			connect.CodeNotFound,
+			errors.New("invalid or expired pairing code"),
+		)
+	}
+
+	// NB: the pairing code is claimed already, so the device must get its
+	// credentials even if what it reports about itself cannot be saved.
+	_, err = ps.DB.DescribeDevice(ctx, device.ID, req.Msg.GetName(), req.Msg.GetPlatform())
+	if err != nil {
+		logger.Warn("failed to describe claimed device", slog.Any("err", err))
+	}
+
+	chain, err := ps.Keyring.ClientChain(device.KeyVersion, device.KeyGeneration, device.ID)
+	if err != nil {
+		logger.Error("failed to derive device keychain", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	logger.Info(
+		"device claimed",
+		slog.String("claimed_device_id", device.ID),
+		slog.String("platform", req.Msg.GetPlatform()),
+	)
+
+	//nolint: gosec // versions of the server secret are configured by hand
+	keyVersion := int32(device.KeyVersion)
+
+	//nolint: gosec // keys are not rotated anywhere near 2^31 times
+	keyGeneration := int32(device.KeyGeneration)
+
+	return connect.NewResponse(&devicesv1.ClaimDeviceResponse{
+		DeviceId:      device.ID,
+		DeviceKey:     chain.Seed[:],
+		KeyVersion:    keyVersion,
+		KeyGeneration: keyGeneration,
+		ServerId:      ps.Identities[device.KeyVersion],
+	}), nil
+}
+
+func (ps *PairingService) RequestEnrollment(
+	ctx context.Context,
+	req *connect.Request[devicesv1.RequestEnrollmentRequest],
+) (*connect.Response[devicesv1.RequestEnrollmentResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	_, err := paseto.NewV4AsymmetricPublicKeyFromBytes(req.Msg.GetPublicKey())
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		logger.Error("failed to create uuid", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	remoteAddr := remoteHost(req.Peer().Addr)
+
+	now := time.Now()
+
+	enrollment := database.Enrollment{
+		ID:         id.String(),
+		Name:       req.Msg.GetName(),
+		Platform:   req.Msg.GetPlatform(),
+		PublicKey:  req.Msg.GetPublicKey(),
+		RemoteAddr: remoteAddr,
+		State:      database.EnrollmentPending,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(DefaultEnrollmentExpiration),
+	}
+
+	err = ps.DB.AddEnrollment(ctx, enrollment, database.EnrollmentLimits{
+		MaxPending:    DefaultMaxPendingEnrollments,
+		MaxPerAddress: DefaultMaxEnrollmentsPerAddress,
+	})
+	if errors.Is(err, database.ErrTooManyEnrollments) ||
+		errors.Is(err, database.ErrTooManyAddressEnrollments) {
+		logger.Warn(
+			"enrollment request rejected",
+			slog.String("remote_addr", remoteAddr),
+			slog.Any("err", err),
+		)
+
+		return nil, connect.NewError(connect.CodeResourceExhausted, err)
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	logger.Info(
+		"enrollment requested",
+		slog.String("enrollment_id", enrollment.ID),
+		slog.String("name", enrollment.Name),
+		slog.String("platform", enrollment.Platform),
+		slog.String("remote_addr", remoteAddr),
+		slog.String("fingerprint", key.Fingerprint(enrollment.PublicKey)),
+	)
+
+	return connect.NewResponse(&devicesv1.RequestEnrollmentResponse{
+		Enrollment: enrollmentToProto(enrollment, now),
+	}), nil
+}
+
+func (ps *PairingService) GetEnrollment(
+	ctx context.Context,
+	req *connect.Request[devicesv1.GetEnrollmentRequest],
+) (*connect.Response[devicesv1.GetEnrollmentResponse], error) {
+	enrollment, err := ps.DB.GetEnrollment(ctx, req.Msg.GetEnrollmentId())
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if enrollment == nil {
+		return nil, connect.NewError(
+			connect.CodeNotFound,
+			errors.New("enrollment request not found"),
+		)
+	}
+
+	resp := &devicesv1.GetEnrollmentResponse{
+		Enrollment: enrollmentToProto(*enrollment, time.Now()),
+	}
+
+	if enrollment.State == database.EnrollmentApproved {
+		device, err := ps.DB.GetDevice(ctx, enrollment.DeviceID)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+
+		// NB: the device may have been deleted since.
+		if device != nil {
+			//nolint: gosec // versions of the server secret are configured by hand
+			keyVersion := int32(device.KeyVersion)
+
+			resp.KeyVersion = keyVersion
+			resp.ServerId = ps.Identities[device.KeyVersion]
+		}
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// IssuePairingCode stores a new pairing code for the device and returns it
+// along with when it expires. Only its hash is stored.
+func IssuePairingCode(
+	ctx context.Context,
+	db *database.DB,
+	deviceID string,
+	now time.Time,
+) (string, time.Time, error) {
+	code, err := key.NewPairingCode()
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to generate pairing code", slog.Any("err", err))
+
+		return "", time.Time{}, err
+	}
+
+	expiresAt := now.Add(key.DefaultPairingCodeExpiration)
+
+	err = db.AddPairingCode(ctx, database.PairingCode{
+		CodeHash:  key.HashPairingCode(code),
+		DeviceID:  deviceID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return code, expiresAt, nil
+}
+
+// remoteHost returns the host of the peer address remoteAddr.
+// NB: unauthenticated requests are limited by host rather than by
+// connection, which changes with every port.
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/google/uuid"
+
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/database"
+	"github.com/cmp0st/byte/internal/key"
+)
+
+func TestClaimRotatedDevice(t *testing.T) {
+	keyring, err := key.NewServerKeyring(map[int][]byte{
+		1: []byte("0123456789abcdef0123456789abcdef"),
+		2: []byte("fedcba9876543210fedcba9876543210"),
+	}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := newTestDB(t)
+	id := uuid.NewString()
+
+	err = db.AddDevice(t.Context(), database.Device{
+		ID:         id,
+		Role:       string(auth.RoleMember),
+		KeyVersion: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NB: this is how the server secret is rotated for a device, which then
+	// claims its new key with a pairing code like a new device.
+	set, err := db.SetDeviceKeyVersion(t.Context(), id, 1, 0, 2)
+	if err != nil || !set {
+		t.Fatalf("failed to enroll the device with version 2: %v, %v", set, err)
+	}
+
+	code, _, err := IssuePairingCode(t.Context(), db, id, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pairing := &PairingService{DB: db, Keyring: *keyring}
+	claim := func() (*connect.Response[devicesv1.ClaimDeviceResponse], error) {
+		return pairing.ClaimDevice(t.Context(), connect.NewRequest(&devicesv1.ClaimDeviceRequest{
+			PairingCode: code,
+		}))
+	}
+
+	resp, err := claim()
+	if err != nil {
+		t.Fatalf("failed to claim the device: %v", err)
+	}
+
+	chain, err := keyring.ClientChain(2, 0, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Msg.GetKeyVersion() != 2 || !bytes.Equal(resp.Msg.GetDeviceKey(), chain.Seed[:]) {
+		t.Errorf("claimed key of version %d, want the key of version 2", resp.Msg.GetKeyVersion())
+	}
+
+	_, err = claim()
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("claimed a pairing code twice: %v", err)
+	}
+}
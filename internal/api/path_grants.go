@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+
+	"connectrpc.com/connect"
+
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/database"
+	"github.com/cmp0st/byte/internal/logging"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+func (ds *DeviceService) GrantPath(
+	ctx context.Context,
+	req *connect.Request[devicesv1.GrantPathRequest],
+) (*connect.Response[devicesv1.GrantPathResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	msg := req.Msg.GetGrant()
+
+	if !msg.GetRead() && !msg.GetWrite() && !msg.GetDelete() {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			errors.New("grant must allow read, write or delete"),
+		)
+	}
+
+	ok, err := ds.DB.DeviceExists(ctx, msg.GetDeviceId())
+	if err != nil {
+		logger.Error("failed to check if device exists", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !ok {
+		return nil, connect.NewError(
+			connect.CodeNotFound,
+			fmt.Errorf("device %s not found", msg.GetDeviceId()),
+		)
+	}
+
+	grant := database.PathGrant{
+		DeviceID: msg.GetDeviceId(),
+		Grant: storage.Grant{
+			Prefix: path.Clean(msg.GetPrefix()),
+			Read:   msg.GetRead(),
+			Write:  msg.GetWrite(),
+			Delete: msg.GetDelete(),
+		},
+	}
+
+	err = ds.DB.SetPathGrant(ctx, grant)
+	if err != nil {
+		logger.Error("failed to set path grant", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	logger.Info(
+		"path granted",
+		slog.String("grant_device_id", grant.DeviceID),
+		slog.String("prefix", grant.Prefix),
+		slog.Bool("read", grant.Read),
+		slog.Bool("write", grant.Write),
+		slog.Bool("delete", grant.Delete),
+	)
+
+	return connect.NewResponse(&devicesv1.GrantPathResponse{
+		Grant: pathGrantToProto(grant),
+	}), nil
+}
+
+func (ds *DeviceService) ListPathGrants(
+	ctx context.Context,
+	req *connect.Request[devicesv1.ListPathGrantsRequest],
+) (*connect.Response[devicesv1.ListPathGrantsResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	grants, err := ds.DB.ListPathGrants(ctx, req.Msg.GetDeviceId())
+	if err != nil {
+		logger.Error("failed to list path grants", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	resp := make([]*devicesv1.PathGrant, len(grants))
+	for i, grant := range grants {
+		resp[i] = pathGrantToProto(grant)
+	}
+
+	return connect.NewResponse(&devicesv1.ListPathGrantsResponse{
+		Grants: resp,
+	}), nil
+}
+
+func (ds *DeviceService) RevokePath(
+	ctx context.Context,
+	req *connect.Request[devicesv1.RevokePathRequest],
+) (*connect.Response[devicesv1.RevokePathResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	prefix := path.Clean(req.Msg.GetPrefix())
+
+	found, err := ds.DB.RemovePathGrant(ctx, req.Msg.GetDeviceId(), prefix)
+	if err != nil {
+		logger.Error("failed to remove path grant", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !found {
+		return nil, connect.NewError(
+			connect.CodeNotFound,
+			fmt.Errorf("device %s has no grant for %s", req.Msg.GetDeviceId(), prefix),
+		)
+	}
+
+	logger.Info(
+		"path revoked",
+		slog.String("grant_device_id", req.Msg.GetDeviceId()),
+		slog.String("prefix", prefix),
+	)
+
+	return connect.NewResponse(&devicesv1.RevokePathResponse{}), nil
+}
+
+func pathGrantToProto(grant database.PathGrant) *devicesv1.PathGrant {
+	return &devicesv1.PathGrant{
+		DeviceId: grant.DeviceID,
+		Prefix:   grant.Prefix,
+		Read:     grant.Read,
+		Write:    grant.Write,
+		Delete:   grant.Delete,
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"github.com/cmp0st/byte/gen/devices/v1/devicesv1connect"
+	"github.com/cmp0st/byte/gen/files/v1/filesv1connect"
+	"github.com/cmp0st/byte/internal/auth"
+)
+
+// procedureScopes is the access each procedure needs. Procedures missing here
+// are denied to every device.
+var procedureScopes = map[string]auth.Scope{
+	filesv1connect.FileServiceListDirectoryProcedure:   auth.ScopeRead,
+	filesv1connect.FileServiceReadFileProcedure:        auth.ScopeRead,
+	filesv1connect.FileServiceRemoveDirectoryProcedure: auth.ScopeWrite,
+	filesv1connect.FileServiceDeleteFileProcedure:      auth.ScopeWrite,
+
+	// NB: uploading devices need to create the directories they upload to.
+	filesv1connect.FileServiceMakeDirectoryProcedure: auth.ScopeUpload,
+	filesv1connect.FileServiceWriteFileProcedure:     auth.ScopeUpload,
+
+	// NB: the ssh server enforces the role of the device a key belongs to,
+	// but ssh keys are still only managed by admins like everything else
+	// about devices.
+	devicesv1connect.DeviceServiceCreateDeviceProcedure:   auth.ScopeAdmin,
+	devicesv1connect.DeviceServiceListDevicesProcedure:    auth.ScopeAdmin,
+	devicesv1connect.DeviceServiceDeleteDeviceProcedure:   auth.ScopeAdmin,
+	devicesv1connect.DeviceServiceAddSSHKeyProcedure:      auth.ScopeAdmin,
+	devicesv1connect.DeviceServiceListSSHKeysProcedure:    auth.ScopeAdmin,
+	devicesv1connect.DeviceServiceRemoveSSHKeyProcedure:   auth.ScopeAdmin,
+	devicesv1connect.DeviceServiceGrantPathProcedure:      auth.ScopeAdmin,
+	devicesv1connect.DeviceServiceListPathGrantsProcedure: auth.ScopeAdmin,
+	devicesv1connect.DeviceServiceRevokePathProcedure:     auth.ScopeAdmin,
+
+	devicesv1connect.DeviceServiceListEnrollmentsProcedure:   auth.ScopeAdmin,
+	devicesv1connect.DeviceServiceApproveEnrollmentProcedure: auth.ScopeAdmin,
+	devicesv1connect.DeviceServiceDenyEnrollmentProcedure:    auth.ScopeAdmin,
+
+	devicesv1connect.DeviceServiceListBansProcedure:  auth.ScopeAdmin,
+	devicesv1connect.DeviceServiceClearBansProcedure: auth.ScopeAdmin,
+
+	// NB: rotating the key of another device is checked to be admin only by
+	// the procedure itself.
+	devicesv1connect.DeviceServiceRotateDeviceKeyProcedure:   auth.ScopeSelf,
+	devicesv1connect.DeviceServiceRegisterPublicKeyProcedure: auth.ScopeSelf,
+
+	// NB: the browser is logged in as the device approving its login.
+	devicesv1connect.DeviceServiceApproveWebLoginProcedure: auth.ScopeSelf,
+}
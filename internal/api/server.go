@@ -28,7 +28,9 @@ type Server struct {
 func NewServer(
 	db *database.DB,
 	storage storage.Interface,
-	chain key.ServerChain,
+	keyring key.ServerKeyring,
+	policy auth.TokenPolicy,
+	attempts *auth.AttemptLimiter,
 	logger *slog.Logger,
 	addr string,
 ) (*Server, error) {
@@ -45,7 +47,8 @@ func NewServer(
 
 	interceptors := connect.WithInterceptors(
 		logging.NewInterceptor(logger),
-		auth.NewServerInterceptor(chain, db),
+		auth.NewServerInterceptor(keyring, policy, db, attempts),
+		auth.NewAuthorizationInterceptor(procedureScopes),
 		validateInterceptor,
 	)
 
@@ -53,15 +56,32 @@ func NewServer(
 	mux := http.NewServeMux()
 	path, handler := devicesv1connect.NewDeviceServiceHandler(
 		&DeviceService{
-			DB:       db,
-			KeyChain: chain,
+			DB:         db,
+			Keyring:    keyring,
+			Identities: policy.Identities,
+			Attempts:   attempts,
 		},
 		interceptors,
 	)
 	mux.Handle(path, handler)
 
+	// NB: devices claim their credentials with pairing codes, so the pairing
+	// service cannot require them.
+	path, handler = devicesv1connect.NewPairingServiceHandler(
+		&PairingService{
+			DB:         db,
+			Keyring:    keyring,
+			Identities: policy.Identities,
+		},
+		connect.WithInterceptors(
+			logging.NewInterceptor(logger),
+			validateInterceptor,
+		),
+	)
+	mux.Handle(path, handler)
+
 	path, handler = filesv1connect.NewFileServiceHandler(
-		NewFileService(storage),
+		NewFileService(storage, db),
 		interceptors,
 	)
 	mux.Handle(path, handler)
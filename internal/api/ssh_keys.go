@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"connectrpc.com/connect"
+	gossh "golang.org/x/crypto/ssh"
+
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/database"
+	"github.com/cmp0st/byte/internal/logging"
+)
+
+func (ds *DeviceService) AddSSHKey(
+	ctx context.Context,
+	req *connect.Request[devicesv1.AddSSHKeyRequest],
+) (*connect.Response[devicesv1.AddSSHKeyResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	deviceID := req.Msg.GetDeviceId()
+	if deviceID == "" {
+		deviceID = auth.DeviceFromContext(ctx)
+	}
+
+	device, err := ds.DB.GetDevice(ctx, deviceID)
+	if err != nil {
+		logger.Error("failed to get device", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if device == nil {
+		return nil, connect.NewError(
+			connect.CodeNotFound,
+			fmt.Errorf("device %s not found", deviceID),
+		)
+	}
+
+	// NB: the ssh server refuses the keys of such devices anyway.
+	_, err = auth.SSHRoleOptions(auth.Role(device.Role))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+
+	pub, comment, _, _, err := gossh.ParseAuthorizedKey([]byte(req.Msg.GetPublicKey()))
+	if err != nil {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("invalid ssh public key: %w", err),
+		)
+	}
+
+	key := database.SSHKey{
+		Fingerprint: gossh.FingerprintSHA256(pub),
+		DeviceID:    deviceID,
+		PublicKey:   strings.TrimSpace(string(gossh.MarshalAuthorizedKey(pub))),
+		Comment:     comment,
+	}
+
+	existing, err := ds.DB.GetSSHKey(ctx, key.Fingerprint)
+	if err != nil {
+		logger.Error("failed to get ssh key", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if existing != nil {
+		return nil, connect.NewError(
+			connect.CodeAlreadyExists,
+			errors.New("ssh key is already registered"),
+		)
+	}
+
+	err = ds.DB.AddSSHKey(ctx, key)
+	if err != nil {
+		logger.Error("failed to add ssh key", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	logger.Info(
+		"ssh key added",
+		slog.String("fingerprint", key.Fingerprint),
+		slog.String("key_device_id", key.DeviceID),
+	)
+
+	return connect.NewResponse(&devicesv1.AddSSHKeyResponse{
+		Key: sshKeyToProto(key),
+	}), nil
+}
+
+func (ds *DeviceService) ListSSHKeys(
+	ctx context.Context,
+	req *connect.Request[devicesv1.ListSSHKeysRequest],
+) (*connect.Response[devicesv1.ListSSHKeysResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	keys, err := ds.DB.ListSSHKeys(ctx, req.Msg.GetDeviceId())
+	if err != nil {
+		logger.Error("failed to list ssh keys", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	resp := make([]*devicesv1.SSHKey, len(keys))
+	for i, key := range keys {
+		resp[i] = sshKeyToProto(key)
+	}
+
+	return connect.NewResponse(&devicesv1.ListSSHKeysResponse{
+		Keys: resp,
+	}), nil
+}
+
+func (ds *DeviceService) RemoveSSHKey(
+	ctx context.Context,
+	req *connect.Request[devicesv1.RemoveSSHKeyRequest],
+) (*connect.Response[devicesv1.RemoveSSHKeyResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	err := ds.DB.RemoveSSHKey(ctx, req.Msg.GetFingerprint())
+	if err != nil {
+		logger.Error("failed to remove ssh key", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	logger.Info(
+		"ssh key removed",
+		slog.String("fingerprint", req.Msg.GetFingerprint()),
+	)
+
+	return connect.NewResponse(&devicesv1.RemoveSSHKeyResponse{}), nil
+}
+
+func sshKeyToProto(key database.SSHKey) *devicesv1.SSHKey {
+	return &devicesv1.SSHKey{
+		Fingerprint: key.Fingerprint,
+		DeviceId:    key.DeviceID,
+		PublicKey:   key.PublicKey,
+		Comment:     key.Comment,
+	}
+}
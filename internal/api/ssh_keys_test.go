@@ -0,0 +1,167 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	gossh "golang.org/x/crypto/ssh"
+
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/database"
+)
+
+// newTestSSHKey returns a new authorized key line with comment.
+func newTestSSHKey(t *testing.T, comment string) string {
+	t.Helper()
+
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := gossh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.TrimSpace(string(gossh.MarshalAuthorizedKey(key))) + " " + comment
+}
+
+func TestSSHKeys(t *testing.T) {
+	db := newTestDB(t)
+
+	for id, role := range map[string]auth.Role{
+		"admin":  auth.RoleAdmin,
+		"member": auth.RoleMember,
+		"upload": auth.RoleUploadOnly,
+	} {
+		err := db.AddDevice(t.Context(), database.Device{ID: id, Role: string(role)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	devices := &DeviceService{DB: db}
+	ctx := auth.WithDevice(auth.WithRole(t.Context(), auth.RoleAdmin), "admin")
+
+	memberKey := newTestSSHKey(t, "member@laptop")
+	adminKey := newTestSSHKey(t, "admin@laptop")
+
+	tests := []struct {
+		name   string
+		device string
+		key    string
+		code   connect.Code
+	}{
+		{name: "key of another device", device: "member", key: memberKey},
+		{name: "key of the caller", key: adminKey},
+		{
+			name:   "key already registered",
+			device: "admin",
+			key:    memberKey,
+			code:   connect.CodeAlreadyExists,
+		},
+		{
+			name:   "invalid key",
+			device: "member",
+			key:    "ssh-ed25519 AAAA",
+			code:   connect.CodeInvalidArgument,
+		},
+		{
+			name:   "unknown device",
+			device: "unknown",
+			key:    newTestSSHKey(t, ""),
+			code:   connect.CodeNotFound,
+		},
+		{
+			name:   "device that cannot use ssh",
+			device: "upload",
+			key:    newTestSSHKey(t, ""),
+			code:   connect.CodeFailedPrecondition,
+		},
+	}
+
+	for _, test := range tests {
+		resp, err := devices.AddSSHKey(ctx, connect.NewRequest(&devicesv1.AddSSHKeyRequest{
+			DeviceId:  test.device,
+			PublicKey: test.key,
+		}))
+		if (test.code == 0) != (err == nil) || (err != nil && connect.CodeOf(err) != test.code) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.code)
+
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		want := test.device
+		if want == "" {
+			want = "admin"
+		}
+
+		_, comment, _, _, _ := gossh.ParseAuthorizedKey([]byte(test.key))
+
+		key := resp.Msg.GetKey()
+		if key.GetDeviceId() != want || key.GetComment() != comment ||
+			!strings.HasPrefix(key.GetFingerprint(), "SHA256:") {
+			t.Errorf("%s: added %v", test.name, key)
+		}
+	}
+
+	list := func(device string) []string {
+		resp, err := devices.ListSSHKeys(ctx, connect.NewRequest(&devicesv1.ListSSHKeysRequest{
+			DeviceId: device,
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var comments []string
+		for _, key := range resp.Msg.GetKeys() {
+			comments = append(comments, key.GetComment())
+		}
+
+		slices.Sort(comments)
+
+		return comments
+	}
+
+	if got := list(""); !slices.Equal(got, []string{"admin@laptop", "member@laptop"}) {
+		t.Errorf("listed %v, want the keys of every device", got)
+	}
+
+	memberKeys, err := devices.ListSSHKeys(ctx, connect.NewRequest(&devicesv1.ListSSHKeysRequest{
+		DeviceId: "member",
+	}))
+	if err != nil || len(memberKeys.Msg.GetKeys()) != 1 {
+		t.Fatalf("listed %v, %v, want the key of the member", memberKeys, err)
+	}
+
+	_, err = devices.RemoveSSHKey(ctx, connect.NewRequest(&devicesv1.RemoveSSHKeyRequest{
+		Fingerprint: memberKeys.Msg.GetKeys()[0].GetFingerprint(),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := list("member"); len(got) != 0 {
+		t.Errorf("listed %v after removing the key", got)
+	}
+
+	// NB: keys go along with their device.
+	err = db.DeleteDevice(t.Context(), "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := list(""); len(got) != 0 {
+		t.Errorf("listed %v after deleting the device", got)
+	}
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/gen/devices/v1/devicesv1connect"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/database"
+	"github.com/cmp0st/byte/internal/key"
+	"github.com/cmp0st/byte/internal/logging"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// Web logins are meant to be approved while the browser shows the code.
+	DefaultWebLoginExpiration   = 2 * time.Minute
+	DefaultWebSessionExpiration = 8 * time.Hour
+
+	// NB: web logins are started without authentication, so they are
+	// bounded to keep anyone from filling the database.
+	DefaultMaxPendingWebLogins    = 100
+	DefaultMaxWebLoginsPerAddress = 5
+
+	// WebLoginCookie is the name of the cookie holding the secret of the web
+	// login a browser started. It is only sent to the pairing service.
+	WebLoginCookie = "byte_login"
+
+	// User agents are only stored to show the device approving a login, so
+	// long ones are cut.
+	maxUserAgentLength = 256
+)
+
+var errWebLoginNotFound = errors.New("web login not found")
+
+func (ps *PairingService) StartWebLogin(
+	ctx context.Context,
+	req *connect.Request[devicesv1.StartWebLoginRequest],
+) (*connect.Response[devicesv1.StartWebLoginResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	code, err := key.NewPairingCode()
+	if err != nil {
+		logger.Error("failed to generate web login code", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	secret, err := key.NewWebSecret()
+	if err != nil {
+		logger.Error("failed to generate web login secret", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	remoteAddr := remoteHost(req.Peer().Addr)
+	now := time.Now()
+
+	login := database.WebLogin{
+		SecretHash: key.HashWebSecret(secret),
+		CodeHash:   key.HashPairingCode(code),
+		RemoteAddr: remoteAddr,
+		UserAgent:  userAgent(req.Header()),
+		ExpiresAt:  now.Add(DefaultWebLoginExpiration),
+	}
+
+	err = ps.DB.AddWebLogin(ctx, login, database.WebLoginLimits{
+		MaxPending:    DefaultMaxPendingWebLogins,
+		MaxPerAddress: DefaultMaxWebLoginsPerAddress,
+	}, now)
+	if errors.Is(err, database.ErrTooManyWebLogins) ||
+		errors.Is(err, database.ErrTooManyAddressWebLogins) {
+		logger.Warn(
+			"web login rejected",
+			slog.String("remote_addr", remoteAddr),
+			slog.Any("err", err),
+		)
+
+		return nil, connect.NewError(connect.CodeResourceExhausted, err)
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	logger.Info("web login started", slog.String("remote_addr", remoteAddr))
+
+	resp := connect.NewResponse(&devicesv1.StartWebLoginResponse{
+		Code:       code,
+		ExpireTime: timestamppb.New(login.ExpiresAt),
+	})
+	loginCookie := webCookie(req.Header(), WebLoginCookie, secret, DefaultWebLoginExpiration)
+	setCookie(resp.Header(), loginCookie)
+
+	return resp, nil
+}
+
+func (ps *PairingService) CompleteWebLogin(
+	ctx context.Context,
+	req *connect.Request[devicesv1.CompleteWebLoginRequest],
+) (*connect.Response[devicesv1.CompleteWebLoginResponse], error) {
+	logger := logging.FromContext(ctx)
+
+	secret := auth.CookieFromHeader(req.Header(), WebLoginCookie)
+	if secret == "" {
+		return nil, connect.NewError(connect.CodeNotFound, errWebLoginNotFound)
+	}
+
+	token, err := key.NewWebSecret()
+	if err != nil {
+		logger.Error("failed to generate web session token", slog.Any("err", err))
+
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	now := time.Now()
+
+	session, err := ps.DB.CompleteWebLogin(ctx, key.HashWebSecret(secret), database.WebSession{
+		TokenHash:  key.HashWebSecret(token),
+		RemoteAddr: remoteHost(req.Peer().Addr),
+		UserAgent:  userAgent(req.Header()),
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(DefaultWebSessionExpiration),
+	})
+	if errors.Is(err, database.ErrWebLoginPending) {
+		return connect.NewResponse(&devicesv1.CompleteWebLoginResponse{}), nil
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if session == nil {
+		return nil, connect.NewError(connect.CodeNotFound, errWebLoginNotFound)
+	}
+
+	logger.Info(
+		"web login completed",
+		slog.String("device_id", session.DeviceID),
+		slog.String("remote_addr", session.RemoteAddr),
+	)
+
+	resp := connect.NewResponse(&devicesv1.CompleteWebLoginResponse{
+		Approved:          true,
+		DeviceId:          session.DeviceID,
+		SessionExpireTime: timestamppb.New(session.ExpiresAt),
+	})
+	sessionCookie := webCookie(req.Header(), auth.SessionCookie, token, DefaultWebSessionExpiration)
+	setCookie(resp.Header(), webCookie(req.Header(), WebLoginCookie, "", 0))
+	setCookie(resp.Header(), sessionCookie)
+
+	return resp, nil
+}
+
+func (ps *PairingService) EndWebSession(
+	ctx context.Context,
+	req *connect.Request[devicesv1.EndWebSessionRequest],
+) (*connect.Response[devicesv1.EndWebSessionResponse], error) {
+	token := auth.CookieFromHeader(req.Header(), auth.SessionCookie)
+	if token != "" {
+		ended, err := ps.DB.DeleteWebSession(ctx, key.HashWebSecret(token))
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+
+		if ended {
+			logging.FromContext(ctx).Info("web session ended")
+		}
+	}
+
+	// NB: the cookie is cleared even if the session is already gone, so that
+	// the browser stops sending it.
+	resp := connect.NewResponse(&devicesv1.EndWebSessionResponse{})
+	setCookie(resp.Header(), webCookie(req.Header(), auth.SessionCookie, "", 0))
+
+	return resp, nil
+}
+
+func (ds *DeviceService) ApproveWebLogin(
+	ctx context.Context,
+	req *connect.Request[devicesv1.ApproveWebLoginRequest],
+) (*connect.Response[devicesv1.ApproveWebLoginResponse], error) {
+	deviceID := auth.DeviceFromContext(ctx)
+
+	login, err := ds.DB.ApproveWebLogin(
+		ctx,
+		key.HashPairingCode(req.Msg.GetCode()),
+		deviceID,
+		time.Now(),
+	)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if login == nil {
+		return nil, connect.NewError(connect.CodeNotFound, errWebLoginNotFound)
+	}
+
+	logging.FromContext(ctx).Info(
+		"web login approved",
+		slog.String("remote_addr", login.RemoteAddr),
+		slog.String("user_agent", login.UserAgent),
+	)
+
+	return connect.NewResponse(&devicesv1.ApproveWebLoginResponse{
+		RemoteAddr: login.RemoteAddr,
+		UserAgent:  login.UserAgent,
+	}), nil
+}
+
+// webCookie returns the cookie with name and value for the browser that sent
+// header, which expires after maxAge, or right away if it is zero.
+// NB: the login cookie is only sent to the pairing service, which completes
+// logins, while the session cookie is sent to every service.
+func webCookie(header http.Header, name, value string, maxAge time.Duration) *http.Cookie {
+	path := "/"
+	if name == WebLoginCookie {
+		path = "/" + devicesv1connect.PairingServiceName + "/"
+	}
+
+	// NB: a negative MaxAge deletes the cookie, while zero leaves it unset.
+	maxAgeSeconds := int(maxAge.Seconds())
+	if maxAgeSeconds <= 0 {
+		maxAgeSeconds = -1
+	}
+
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAgeSeconds,
+		Secure:   strings.HasPrefix(header.Get("Origin"), "https://"),
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+func setCookie(header http.Header, cookie *http.Cookie) {
+	header.Add("Set-Cookie", cookie.String())
+}
+
+func userAgent(header http.Header) string {
+	agent := header.Get("User-Agent")
+	if len(agent) > maxUserAgentLength {
+		agent = agent[:maxUserAgentLength]
+	}
+
+	return agent
+}
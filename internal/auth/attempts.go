@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"errors"
+	"math"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultAttemptLimiterSize bounds the peer addresses and devices tracked at
+// once. A peer address is banned after a few failures, so it cannot add more
+// than a few devices to them.
+const DefaultAttemptLimiterSize = 100_000
+
+var (
+	ErrBanned     = errors.New("banned after too many failed authentication attempts")
+	ErrBackingOff = errors.New("authentication attempted too soon after a failure")
+)
+
+// AttemptPolicy bounds failed authentication attempts over both the API and
+// SSH. Failures count against the peer address and the device they are from,
+// so that spreading attempts over devices or over addresses does not help.
+// Zero values mean no limit.
+//
+// NB: failures only count against a device once its key authenticated the
+// attempt, such as a replayed or expired token, so that knowing the id of a
+// device is not enough to get it banned.
+type AttemptPolicy struct {
+	// Window is how long failures count towards MaxFailures.
+	Window time.Duration
+
+	// MaxFailures bans a peer address or device once it failed this many
+	// times within Window.
+	MaxFailures int
+
+	// Backoff is how long a peer address or device must wait after failing
+	// before trying again. It doubles with every consecutive failure, up to
+	// MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// BanDuration is how long a first ban lasts. It doubles with every ban
+	// following the previous one within MaxBanDuration, up to MaxBanDuration.
+	BanDuration    time.Duration
+	MaxBanDuration time.Duration
+}
+
+// Ban is a peer address or device whose authentication attempts are refused
+// until it expires.
+type Ban struct {
+	// RemoteAddr is the banned peer address, without port.
+	RemoteAddr string
+
+	// DeviceID is the banned device. Exactly one of RemoteAddr and DeviceID
+	// is set.
+	DeviceID string
+
+	Until time.Time
+
+	// Count is the number of bans in a row, the last one included.
+	Count int
+}
+
+// AttemptLimiter tracks failed authentication attempts by peer address and
+// device, and refuses the attempts of those that fail too often. It is shared
+// by the API and SSH servers.
+type AttemptLimiter struct {
+	policy AttemptPolicy
+	size   int
+
+	mu       sync.Mutex
+	attempts map[attemptKey]*attempts
+}
+
+// attemptKey names a peer address or a device, never both.
+type attemptKey struct {
+	remoteAddr string
+	deviceID   string
+}
+
+// attempts are the failures of a peer address or device.
+type attempts struct {
+	// failures are the times of the failures within the window.
+	failures []time.Time
+
+	// consecutive counts failures since the last success or ban.
+	consecutive int
+	retryAt     time.Time
+
+	bans        int
+	bannedUntil time.Time
+}
+
+// NewAttemptLimiter returns a limiter enforcing policy that tracks up to size
+// peer addresses and devices, see DefaultAttemptLimiterSize.
+func NewAttemptLimiter(policy AttemptPolicy, size int) *AttemptLimiter {
+	return &AttemptLimiter{
+		policy:   policy,
+		size:     size,
+		attempts: make(map[attemptKey]*attempts),
+	}
+}
+
+// Check returns ErrBanned or ErrBackingOff, along with when to try again, if
+// an attempt from remoteAddr as deviceID must be refused. remoteAddr may have
+// a port, and either may be empty when it is unknown.
+func (l *AttemptLimiter) Check(remoteAddr, deviceID string, now time.Time) (time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var (
+		retryAt time.Time
+		err     error
+	)
+
+	for _, key := range attemptKeys(remoteAddr, deviceID) {
+		a := l.attempts[key]
+		if a == nil {
+			continue
+		}
+
+		if now.Before(a.bannedUntil) {
+			return a.bannedUntil, ErrBanned
+		}
+
+		if now.Before(a.retryAt) && a.retryAt.After(retryAt) {
+			retryAt = a.retryAt
+			err = ErrBackingOff
+		}
+	}
+
+	return retryAt, err
+}
+
+// Fail records a failed attempt from remoteAddr as deviceID and returns the
+// bans it started.
+// NB: when the limiter is full the failures of new peer addresses and devices
+// are not recorded rather than forgetting bans.
+func (l *AttemptLimiter) Fail(remoteAddr, deviceID string, now time.Time) []Ban {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var bans []Ban
+
+	for _, key := range attemptKeys(remoteAddr, deviceID) {
+		a := l.attempts[key]
+		if a == nil {
+			if len(l.attempts) >= l.size {
+				l.purge(now)
+			}
+
+			if len(l.attempts) >= l.size {
+				continue
+			}
+
+			a = &attempts{}
+			l.attempts[key] = a
+		}
+
+		a.consecutive++
+		a.retryAt = now.Add(doubled(l.policy.Backoff, a.consecutive-1, l.policy.MaxBackoff))
+
+		if l.policy.MaxFailures <= 0 {
+			continue
+		}
+
+		a.failures = slices.DeleteFunc(a.failures, func(t time.Time) bool {
+			return !t.After(now.Add(-l.policy.Window))
+		})
+		a.failures = append(a.failures, now)
+
+		if len(a.failures) < l.policy.MaxFailures {
+			continue
+		}
+
+		// NB: bans only grow longer while they keep following each other,
+		// so that a device failing now and then is not banned for long.
+		if now.After(a.bannedUntil.Add(l.banDecay())) {
+			a.bans = 0
+		}
+
+		a.bans++
+		a.bannedUntil = now.Add(doubled(l.policy.BanDuration, a.bans-1, l.policy.MaxBanDuration))
+		a.failures = nil
+		a.consecutive = 0
+		a.retryAt = time.Time{}
+
+		bans = append(bans, a.ban(key))
+	}
+
+	return bans
+}
+
+// Succeed records a successful attempt from remoteAddr as deviceID, which
+// ends their backoff.
+func (l *AttemptLimiter) Succeed(remoteAddr, deviceID string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range attemptKeys(remoteAddr, deviceID) {
+		a := l.attempts[key]
+		if a == nil {
+			continue
+		}
+
+		a.consecutive = 0
+		a.retryAt = time.Time{}
+
+		if l.stale(a, now) {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+// Bans returns the bans in effect at now, the longest lasting first.
+func (l *AttemptLimiter) Bans(now time.Time) []Ban {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var bans []Ban
+
+	for key, a := range l.attempts {
+		if now.Before(a.bannedUntil) {
+			bans = append(bans, a.ban(key))
+		}
+	}
+
+	slices.SortFunc(bans, func(a, b Ban) int {
+		return b.Until.Compare(a.Until)
+	})
+
+	return bans
+}
+
+// Clear forgets the failures and bans of remoteAddr and deviceID, and
+// reports whether there were any.
+func (l *AttemptLimiter) Clear(remoteAddr, deviceID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cleared := false
+
+	for _, key := range attemptKeys(remoteAddr, deviceID) {
+		if _, found := l.attempts[key]; found {
+			delete(l.attempts, key)
+
+			cleared = true
+		}
+	}
+
+	return cleared
+}
+
+// ClearBans lifts every ban in effect at now and returns how many there were.
+func (l *AttemptLimiter) ClearBans(now time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cleared := 0
+
+	for key, a := range l.attempts {
+		if now.Before(a.bannedUntil) {
+			delete(l.attempts, key)
+
+			cleared++
+		}
+	}
+
+	return cleared
+}
+
+// purge forgets the peer addresses and devices whose failures no longer
+// matter. It must be called with l.mu held.
+func (l *AttemptLimiter) purge(now time.Time) {
+	for key, a := range l.attempts {
+		if l.stale(a, now) {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+// stale reports whether a can be forgotten without changing what happens to
+// the next attempts.
+func (l *AttemptLimiter) stale(a *attempts, now time.Time) bool {
+	if !now.After(a.retryAt) || !now.After(a.bannedUntil.Add(l.banDecay())) {
+		return false
+	}
+
+	for _, t := range a.failures {
+		if t.After(now.Add(-l.policy.Window)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// banDecay is how long after a ban ends the next one starts over at
+// BanDuration.
+func (l *AttemptLimiter) banDecay() time.Duration {
+	return max(l.policy.BanDuration, l.policy.MaxBanDuration)
+}
+
+func (a *attempts) ban(key attemptKey) Ban {
+	return Ban{
+		RemoteAddr: key.remoteAddr,
+		DeviceID:   key.deviceID,
+		Until:      a.bannedUntil,
+		Count:      a.bans,
+	}
+}
+
+func attemptKeys(remoteAddr, deviceID string) []attemptKey {
+	var keys []attemptKey
+
+	if remoteAddr != "" {
+		host, _, err := net.SplitHostPort(remoteAddr)
+		if err == nil {
+			remoteAddr = host
+		}
+
+		keys = append(keys, attemptKey{remoteAddr: remoteAddr})
+	}
+
+	if deviceID != "" {
+		keys = append(keys, attemptKey{deviceID: deviceID})
+	}
+
+	return keys
+}
+
+// doubled returns d doubled n times, capped at limit unless it is zero.
+func doubled(d time.Duration, n int, limit time.Duration) time.Duration {
+	for range n {
+		if d <= 0 || d > math.MaxInt64/2 || (limit > 0 && d >= limit) {
+			break
+		}
+
+		d *= 2
+	}
+
+	if limit > 0 {
+		d = min(d, limit)
+	}
+
+	return d
+}
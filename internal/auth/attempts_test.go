@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+var testAttemptPolicy = AttemptPolicy{
+	Window:         time.Minute,
+	MaxFailures:    3,
+	Backoff:        time.Second,
+	MaxBackoff:     4 * time.Second,
+	BanDuration:    time.Minute,
+	MaxBanDuration: 4 * time.Minute,
+}
+
+// failTimes fails n times from remoteAddr as deviceID at now and returns the
+// bans started by the last failure.
+func failTimes(l *AttemptLimiter, remoteAddr, deviceID string, n int, now time.Time) []Ban {
+	var bans []Ban
+
+	for range n {
+		bans = l.Fail(remoteAddr, deviceID, now)
+	}
+
+	return bans
+}
+
+func TestAttemptLimiterBackoff(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	policy := testAttemptPolicy
+	policy.MaxFailures = 0
+	l := NewAttemptLimiter(policy, DefaultAttemptLimiterSize)
+
+	// NB: the backoff doubles with every consecutive failure, up to
+	// MaxBackoff.
+	for _, want := range []time.Duration{1, 2, 4, 4, 4} {
+		l.Fail("192.0.2.1:1234", "device", now)
+
+		for _, attempt := range []struct{ remoteAddr, deviceID string }{
+			{remoteAddr: "192.0.2.1:5678"},
+			{deviceID: "device"},
+			{remoteAddr: "192.0.2.2:1234", deviceID: "device"},
+		} {
+			retryAt, err := l.Check(attempt.remoteAddr, attempt.deviceID, now)
+			if !errors.Is(err, ErrBackingOff) || !retryAt.Equal(now.Add(want*time.Second)) {
+				t.Fatalf(
+					"%v: got %v, %v, want backoff of %v",
+					attempt, retryAt, err, want*time.Second,
+				)
+			}
+		}
+
+		_, err := l.Check("192.0.2.2:1234", "other", now)
+		if err != nil {
+			t.Fatalf("other peer backing off: %v", err)
+		}
+
+		now = now.Add(want * time.Second)
+
+		_, err = l.Check("192.0.2.1:1234", "device", now)
+		if err != nil {
+			t.Fatalf("backing off once the backoff ended: %v", err)
+		}
+	}
+
+	l.Succeed("192.0.2.1:1234", "device", now)
+
+	_, err := l.Check("192.0.2.1:1234", "device", now)
+	if err != nil {
+		t.Fatalf("backing off after a success: %v", err)
+	}
+
+	l.Fail("192.0.2.1:1234", "device", now)
+
+	retryAt, err := l.Check("192.0.2.1:1234", "device", now)
+	if !errors.Is(err, ErrBackingOff) || !retryAt.Equal(now.Add(time.Second)) {
+		t.Fatalf("got %v, %v after a success, want backoff of 1s", retryAt, err)
+	}
+
+	if bans := l.Bans(now); len(bans) != 0 {
+		t.Fatalf("banned %v without MaxFailures", bans)
+	}
+}
+
+func TestAttemptLimiterWindow(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewAttemptLimiter(testAttemptPolicy, DefaultAttemptLimiterSize)
+
+	// NB: failures only count within the window, so spreading them out
+	// never bans.
+	for range 10 {
+		bans := failTimes(l, "", "device", 2, now)
+		if len(bans) != 0 {
+			t.Fatalf("banned %v for failures outside of the window", bans)
+		}
+
+		now = now.Add(time.Minute)
+	}
+
+	bans := failTimes(l, "192.0.2.1:1234", "device", 3, now)
+	if len(bans) != 2 {
+		t.Fatalf("got bans %v, want the peer address and the device", bans)
+	}
+
+	for _, ban := range bans {
+		if !ban.Until.Equal(now.Add(time.Minute)) || ban.Count != 1 {
+			t.Fatalf("got ban %+v, want a first ban of 1m", ban)
+		}
+	}
+
+	for _, attempt := range []struct{ remoteAddr, deviceID string }{
+		{remoteAddr: "192.0.2.1"},
+		{deviceID: "device"},
+		{remoteAddr: "192.0.2.2", deviceID: "device"},
+	} {
+		until, err := l.Check(attempt.remoteAddr, attempt.deviceID, now)
+		if !errors.Is(err, ErrBanned) || !until.Equal(now.Add(time.Minute)) {
+			t.Fatalf("%v: got %v, %v, want banned for 1m", attempt, until, err)
+		}
+	}
+
+	_, err := l.Check("", "device", now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("banned once the ban ended: %v", err)
+	}
+}
+
+func TestAttemptLimiterBanDoubling(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewAttemptLimiter(testAttemptPolicy, DefaultAttemptLimiterSize)
+
+	tests := []struct {
+		name  string
+		after time.Duration
+		want  time.Duration
+		count int
+	}{
+		{name: "first ban", want: time.Minute, count: 1},
+		{name: "right after", want: 2 * time.Minute, count: 2},
+		{name: "doubled again", want: 4 * time.Minute, count: 3},
+		{name: "capped", want: 4 * time.Minute, count: 4},
+		{name: "within the decay", after: 4 * time.Minute, want: 4 * time.Minute, count: 5},
+		{
+			name:  "after the decay",
+			after: 4*time.Minute + time.Second,
+			want:  time.Minute,
+			count: 1,
+		},
+		{name: "growing again", want: 2 * time.Minute, count: 2},
+	}
+
+	// NB: each ban starts test.after the previous one ended.
+	until := now
+
+	for _, test := range tests {
+		now = until.Add(test.after)
+
+		bans := failTimes(l, "", "device", 3, now)
+		if len(bans) != 1 {
+			t.Fatalf("%s: got bans %v, want one", test.name, bans)
+		}
+
+		want := Ban{DeviceID: "device", Until: now.Add(test.want), Count: test.count}
+		if bans[0] != want {
+			t.Fatalf("%s: got %+v, want %+v", test.name, bans[0], want)
+		}
+
+		until = bans[0].Until
+	}
+}
+
+func TestAttemptLimiterStale(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewAttemptLimiter(testAttemptPolicy, DefaultAttemptLimiterSize)
+
+	l.Fail("", "failed", now)
+	failTimes(l, "", "banned", 3, now)
+
+	tests := []struct {
+		name  string
+		after time.Duration
+		want  int
+	}{
+		{name: "failure within the window", after: time.Minute - time.Second, want: 2},
+		{name: "ban decaying", after: time.Minute + time.Second, want: 1},
+		{name: "ban decayed", after: 5*time.Minute + time.Second, want: 0},
+	}
+
+	for _, test := range tests {
+		// NB: successes forget what no longer matters.
+		l.Succeed("", "failed", now.Add(test.after))
+		l.Succeed("", "banned", now.Add(test.after))
+
+		if len(l.attempts) != test.want {
+			t.Errorf("%s: tracking %d, want %d", test.name, len(l.attempts), test.want)
+		}
+	}
+}
+
+func TestAttemptLimiterFull(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	policy := testAttemptPolicy
+	policy.MaxFailures = 1
+	l := NewAttemptLimiter(policy, 2)
+
+	bans := l.Fail("192.0.2.1:1234", "device", now)
+	if len(bans) != 2 {
+		t.Fatalf("got bans %v, want the peer address and the device", bans)
+	}
+
+	// NB: bans are kept over tracking new failures.
+	bans = l.Fail("192.0.2.2:1234", "other", now)
+	if len(bans) != 0 {
+		t.Fatalf("banned %v in a full limiter", bans)
+	}
+
+	_, err := l.Check("192.0.2.2", "other", now)
+	if err != nil {
+		t.Fatalf("failure recorded in a full limiter: %v", err)
+	}
+
+	_, err = l.Check("192.0.2.1", "device", now)
+	if !errors.Is(err, ErrBanned) {
+		t.Fatalf("ban forgotten in a full limiter: %v", err)
+	}
+
+	// NB: stale bans are purged to make room once they decayed.
+	now = now.Add(5*time.Minute + time.Second)
+
+	bans = l.Fail("192.0.2.2:1234", "other", now)
+	if len(bans) != 2 || len(l.attempts) != 2 {
+		t.Fatalf("got bans %v tracking %v, want the new ones", bans, l.attempts)
+	}
+
+	if l.Clear("192.0.2.1", "device") {
+		t.Fatal("cleared purged failures")
+	}
+
+	if n := l.ClearBans(now); n != 2 || len(l.attempts) != 0 {
+		t.Fatalf("cleared %d bans leaving %v, want 2", n, l.attempts)
+	}
+}
+
+func TestDoubled(t *testing.T) {
+	tests := []struct {
+		d     time.Duration
+		n     int
+		limit time.Duration
+		want  time.Duration
+	}{
+		{d: time.Second, n: 0, want: time.Second},
+		{d: time.Second, n: 3, want: 8 * time.Second},
+		{d: time.Second, n: 3, limit: 5 * time.Second, want: 5 * time.Second},
+		{d: time.Second, n: 2, limit: 4 * time.Second, want: 4 * time.Second},
+		{d: 5 * time.Second, n: 0, limit: 4 * time.Second, want: 4 * time.Second},
+		{d: time.Second, n: math.MaxInt, limit: time.Hour, want: time.Hour},
+		{d: 0, n: 10, want: 0},
+		{d: -time.Second, n: 10, want: -time.Second},
+		{d: math.MaxInt64 / 2, n: 1, want: math.MaxInt64 - 1},
+		{d: math.MaxInt64/2 + 1, n: 1, want: math.MaxInt64/2 + 1},
+		{d: 1, n: 100, want: 1 << 62},
+		{d: 1, n: 100, limit: math.MaxInt64, want: 1 << 62},
+	}
+
+	for _, test := range tests {
+		got := doubled(test.d, test.n, test.limit)
+		if got != test.want {
+			t.Errorf(
+				"doubled(%v, %d, %v) = %v, want %v",
+				test.d, test.n, test.limit, got, test.want,
+			)
+		}
+	}
+}
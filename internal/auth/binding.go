@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"aidanwoods.dev/go-paseto"
+	"connectrpc.com/connect"
+	"github.com/cmp0st/byte/internal/key"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrBindingMismatch = errors.New("token bound to a different request")
+
+// requestBinding describes req for binding a token to it. The message is
+// hashed in its deterministic binary encoding, which does not depend on the
+// codec or compression used on the wire.
+func requestBinding(req connect.AnyRequest) (key.TokenBinding, error) {
+	msg, ok := req.Any().(proto.Message)
+	if !ok {
+		return key.TokenBinding{}, errors.New("request message is not a protobuf message")
+	}
+
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return key.TokenBinding{}, fmt.Errorf("failed to encode request message: %w", err)
+	}
+
+	sum := sha256.Sum256(body)
+
+	return key.TokenBinding{
+		Procedure:  req.Spec().Procedure,
+		BodySHA256: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// streamBinding describes a stream for binding a token to it. Tokens are sent
+// before any message of the stream, so they only bind the procedure.
+func streamBinding(spec connect.Spec) key.TokenBinding {
+	return key.TokenBinding{Procedure: spec.Procedure}
+}
+
+// binder describes the request a token is presented for. It is only called
+// for bound tokens, which spares hashing the message of every request.
+type binder func() (key.TokenBinding, error)
+
+func unaryBinder(req connect.AnyRequest) binder {
+	return func() (key.TokenBinding, error) {
+		return requestBinding(req)
+	}
+}
+
+func streamBinder(spec connect.Spec) binder {
+	return func() (key.TokenBinding, error) {
+		return streamBinding(spec), nil
+	}
+}
+
+// verifyBinding checks that a token with footer may be used for the request
+// described by bind. Tokens without a binding in their footer are not bound
+// to any request.
+func verifyBinding(footer []byte, bind binder) error {
+	decoded, err := decodeFooter(footer)
+	if err != nil {
+		return err
+	}
+
+	if decoded.TokenBinding == nil {
+		return nil
+	}
+
+	actual, err := bind()
+	if err != nil {
+		return err
+	}
+
+	if *decoded.TokenBinding != actual {
+		return ErrBindingMismatch
+	}
+
+	return nil
+}
+
+func decodeFooter(footer []byte) (key.TokenFooter, error) {
+	var decoded key.TokenFooter
+
+	if len(footer) == 0 {
+		return decoded, nil
+	}
+
+	err := json.Unmarshal(footer, &decoded)
+	if err != nil {
+		return decoded, fmt.Errorf("failed to decode token footer: %w", err)
+	}
+
+	return decoded, nil
+}
+
+// tokenKey returns the device key that tokenStr claims to be minted with. The
+// footer is read before the token is decrypted, so the key is only trusted
+// once the token decrypts with it.
+func tokenKey(tokenStr string) (DeviceKey, error) {
+	footer, err := paseto.NewParser().UnsafeParseFooter(paseto.V4Local, tokenStr)
+	if err != nil {
+		return DeviceKey{}, fmt.Errorf("failed to parse token footer: %w", err)
+	}
+
+	decoded, err := decodeFooter(footer)
+	if err != nil {
+		return DeviceKey{}, err
+	}
+
+	version, generation, err := key.ParseKeyID(decoded.KeyID)
+	if err != nil {
+		return DeviceKey{}, err
+	}
+
+	return DeviceKey{Version: version, Generation: generation}, nil
+}
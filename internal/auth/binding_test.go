@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/cmp0st/byte/internal/key"
+)
+
+// testToken returns a token minted by device for the server identified by
+// audience at now, with footer.
+func testToken(t *testing.T, device, audience string, now time.Time, footer any) *paseto.Token {
+	t.Helper()
+
+	token := paseto.NewToken()
+	token.SetExpiration(now.Add(DefaultTokenExpiration))
+	token.SetIssuedAt(now)
+	token.SetNotBefore(now)
+	token.SetJti("jti")
+	token.SetAudience(audience)
+	token.SetIssuer(device)
+
+	if footer != nil {
+		raw, err := json.Marshal(footer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token.SetFooter(raw)
+	}
+
+	return &token
+}
+
+func TestCheckTokenBinding(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	policy := TokenPolicy{Identities: map[int]string{1: "server"}, Leeway: time.Second}
+
+	binding := key.TokenBinding{
+		Procedure:  "/byte.v1.FileService/WriteFile",
+		BodySHA256: "ab",
+	}
+
+	bind := func(b key.TokenBinding) binder {
+		return func() (key.TokenBinding, error) {
+			return b, nil
+		}
+	}
+
+	otherProcedure := binding
+	otherProcedure.Procedure = "/byte.v1.FileService/DeleteFile"
+
+	otherBody := binding
+	otherBody.BodySHA256 = "cd"
+
+	tests := []struct {
+		name   string
+		footer any
+		bind   binder
+		err    error
+	}{
+		{name: "unbound", bind: bind(binding)},
+		{
+			name:   "unbound with key id",
+			footer: key.TokenFooter{KeyID: "1.1"},
+			bind:   bind(binding),
+		},
+		{
+			name:   "bound to the request",
+			footer: key.TokenFooter{TokenBinding: &binding},
+			bind:   bind(binding),
+		},
+		{
+			name:   "bound to another procedure",
+			footer: key.TokenFooter{TokenBinding: &binding},
+			bind:   bind(otherProcedure),
+			err:    ErrBindingMismatch,
+		},
+		{
+			name:   "bound to another body",
+			footer: key.TokenFooter{TokenBinding: &binding},
+			bind:   bind(otherBody),
+			err:    ErrBindingMismatch,
+		},
+		{
+			name:   "bound to a stream",
+			footer: key.TokenFooter{TokenBinding: &key.TokenBinding{Procedure: binding.Procedure}},
+			bind:   bind(binding),
+			err:    ErrBindingMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := testToken(t, "device", "server", now, test.footer)
+
+			jti, err := policy.checkToken(token, 1, test.bind, "device", now)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got %v, want %v", err, test.err)
+			}
+
+			if err == nil && jti != "jti" {
+				t.Fatalf("got jti %q, want jti", jti)
+			}
+		})
+	}
+
+	token := testToken(t, "device", "server", now, "binding")
+
+	_, err := policy.checkToken(token, 1, bind(binding), "device", now)
+	if err == nil {
+		t.Fatal("accepted a token with a malformed footer")
+	}
+}
+
+func TestCheckTokenClaims(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	policy := TokenPolicy{Identities: map[int]string{1: "server"}, Leeway: time.Second}
+
+	unbound := func() (key.TokenBinding, error) {
+		return key.TokenBinding{}, errors.New("unbound tokens are not bound")
+	}
+
+	tests := []struct {
+		name    string
+		issuer  string
+		version int
+		minted  time.Time
+		err     error
+	}{
+		{name: "valid", issuer: "device", version: 1, minted: now},
+		{name: "other device", issuer: "other", version: 1, minted: now, err: ErrWrongIssuer},
+		{name: "other version", issuer: "device", version: 2, minted: now, err: ErrWrongAudience},
+		{
+			name:    "expired",
+			issuer:  "device",
+			version: 1,
+			minted:  now.Add(-DefaultTokenExpiration - time.Second),
+			err:     ErrTokenExpired,
+		},
+		{
+			name:    "from the future",
+			issuer:  "device",
+			version: 1,
+			minted:  now.Add(2 * time.Second),
+			err:     ErrTokenNotYetValid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := testToken(t, test.issuer, "server", test.minted, nil)
+
+			_, err := policy.checkToken(token, test.version, unbound, "device", now)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got %v, want %v", err, test.err)
+			}
+		})
+	}
+}
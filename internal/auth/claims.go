@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+)
+
+var (
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrWrongAudience    = errors.New("token minted for another server")
+	ErrWrongIssuer      = errors.New("token issued by another device")
+)
+
+// TokenPolicy is what the server requires of device tokens beyond being
+// encrypted with the device key.
+type TokenPolicy struct {
+	// Identities of the server by version of the server secret. Tokens must
+	// name the identity of the version their key is derived from as their
+	// audience. See key.ServerChain.Identity.
+	Identities map[int]string
+
+	// Leeway is how far device clocks may be off.
+	Leeway time.Duration
+}
+
+// checkToken checks the claims of a decrypted token and returns its jti.
+// Tokens must be minted by clientID with a key of version for this server, be
+// valid at now, and be unique and short lived for replays to be detectable.
+// Bound tokens must match the request described by bind.
+func (p TokenPolicy) checkToken(
+	token *paseto.Token,
+	version int,
+	bind binder,
+	clientID string,
+	now time.Time,
+) (string, error) {
+	identity, ok := p.Identities[version]
+
+	audience, err := token.GetAudience()
+	if err != nil || !ok || audience != identity {
+		return "", ErrWrongAudience
+	}
+
+	issuer, err := token.GetIssuer()
+	if err != nil || issuer != clientID {
+		return "", ErrWrongIssuer
+	}
+
+	err = p.checkTimes(token, now)
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := token.GetJti()
+	if err != nil || jti == "" {
+		return "", errors.New("token has no jti claim")
+	}
+
+	err = verifyBinding(token.Footer(), bind)
+	if err != nil {
+		return "", err
+	}
+
+	return jti, nil
+}
+
+// checkTimes checks that token is valid at now, give or take the leeway.
+// Every time claim is required.
+func (p TokenPolicy) checkTimes(token *paseto.Token, now time.Time) error {
+	issuedAt, err := token.GetIssuedAt()
+	if err != nil {
+		return fmt.Errorf("token has no issued at claim: %w", err)
+	}
+
+	notBefore, err := token.GetNotBefore()
+	if err != nil {
+		return fmt.Errorf("token has no not before claim: %w", err)
+	}
+
+	expiration, err := token.GetExpiration()
+	if err != nil {
+		return fmt.Errorf("token has no expiration claim: %w", err)
+	}
+
+	early := now.Add(p.Leeway)
+	late := now.Add(-p.Leeway)
+
+	if issuedAt.After(early) || notBefore.After(early) {
+		return ErrTokenNotYetValid
+	}
+
+	if !expiration.After(late) {
+		return ErrTokenExpired
+	}
+
+	// NB: a token living longer than a freshly minted one would outlive its
+	// entry in the replay cache.
+	if expiration.After(early.Add(DefaultTokenExpiration)) {
+		return ErrTokenLivesTooLong
+	}
+
+	return nil
+}
@@ -1,8 +1,17 @@
 package auth
 
-import "context"
+import (
+	"context"
 
-type contextKey struct{}
+	"github.com/charmbracelet/ssh"
+)
+
+type (
+	contextKey    struct{}
+	roleKey       struct{}
+	deviceKeyKey  struct{}
+	webSessionKey struct{}
+)
 
 func DeviceFromContext(ctx context.Context) string {
 	device, ok := ctx.Value(contextKey{}).(string)
@@ -16,3 +25,60 @@ func DeviceFromContext(ctx context.Context) string {
 func WithDevice(ctx context.Context, device string) context.Context {
 	return context.WithValue(ctx, contextKey{}, device)
 }
+
+// RoleFromContext returns the role of the device authenticated by the server
+// auth interceptor, or an empty role granting nothing.
+func RoleFromContext(ctx context.Context) Role {
+	role, ok := ctx.Value(roleKey{}).(Role)
+	if !ok {
+		return ""
+	}
+
+	return role
+}
+
+func WithRole(ctx context.Context, role Role) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// DeviceKey names the key a device authenticated with.
+type DeviceKey struct {
+	// Version of the server secret the key is derived from.
+	Version int
+
+	// Generation of the key, which counts its rotations.
+	Generation int
+}
+
+// DeviceKeyFromContext returns the key of the device authenticated by the
+// server auth interceptor.
+func DeviceKeyFromContext(ctx context.Context) DeviceKey {
+	deviceKey, ok := ctx.Value(deviceKeyKey{}).(DeviceKey)
+	if !ok {
+		return DeviceKey{}
+	}
+
+	return deviceKey
+}
+
+func WithDeviceKey(ctx context.Context, deviceKey DeviceKey) context.Context {
+	return context.WithValue(ctx, deviceKeyKey{}, deviceKey)
+}
+
+// WebSessionFromContext reports whether the server auth interceptor
+// authenticated a browser by its web session rather than a device by its
+// token.
+func WebSessionFromContext(ctx context.Context) bool {
+	webSession, _ := ctx.Value(webSessionKey{}).(bool)
+
+	return webSession
+}
+
+func WithWebSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, webSessionKey{}, true)
+}
+
+func SSHContextWithDevice(ctx ssh.Context, device string) {
+	// ssh.Context is a weird mutable version of context.Context
+	ctx.SetValue(contextKey{}, device)
+}
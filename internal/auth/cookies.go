@@ -0,0 +1,18 @@
+package auth
+
+import "net/http"
+
+// SessionCookie is the name of the cookie holding the token of the web
+// session of a browser.
+const SessionCookie = "byte_session"
+
+// CookieFromHeader returns the value of the cookie with name sent in header,
+// or an empty string if there is none.
+func CookieFromHeader(header http.Header, name string) string {
+	cookie, err := (&http.Request{Header: header}).Cookie(name)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,138 +23,620 @@ import (
 // token leak.
 const DefaultTokenExpiration = 30 * time.Second
 
-func NewClientInterceptor(chain key.ClientChain) connect.UnaryInterceptorFunc {
-	interceptor := func(next connect.UnaryFunc) connect.UnaryFunc {
-		return connect.UnaryFunc(func(
-			ctx context.Context,
-			req connect.AnyRequest,
-		) (connect.AnyResponse, error) {
-			// Client implementation
-			if !req.Spec().IsClient {
-				return nil, errors.New("cannot use client auth interceptor on server")
-			}
-
-			token, err := chain.Token()
-			if err != nil {
-				return nil, connect.NewError(
-					connect.CodeFailedPrecondition,
-					fmt.Errorf("failed to generate token: %w", err),
-				)
-			}
-
-			req.Header().Set("Authorization", "Bearer "+*token)
-			req.Header().Set("Device-ID", chain.ClientID)
-
-			return next(ctx, req)
-		})
-	}
-
-	return connect.UnaryInterceptorFunc(interceptor)
-}
-
-func NewServerInterceptor(chain key.ServerChain, db *database.DB) connect.UnaryInterceptorFunc {
-	interceptor := func(next connect.UnaryFunc) connect.UnaryFunc {
-		return connect.UnaryFunc(func(
-			ctx context.Context,
-			req connect.AnyRequest,
-		) (connect.AnyResponse, error) {
-			logger := logging.FromContext(ctx)
-
-			authHeader := req.Header().Get(`Authorization`)
-
-			tokenStr, found := strings.CutPrefix(authHeader, "Bearer ")
-			if !found {
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					errors.New(`unauthenticated`),
-				)
-			}
-
-			clientID := req.Header().Get(`Device-ID`)
-			if clientID == "" {
-				logger.ErrorContext(ctx, "server auth interceptor: missing client id header")
-
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					errors.New(`unauthenticated`),
-				)
-			}
-
-			clientChain, err := chain.ClientChain(clientID)
-			if err != nil {
-				logger.ErrorContext(ctx, "server auth interceptor: failed to load client chain",
-					slog.String("device_id", clientID),
-					slog.Any("err", err))
-
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					errors.New(`unauthenticated`),
-				)
-			}
-
-			tokenKey, err := clientChain.TokenKey()
-			if err != nil {
-				logger.ErrorContext(
-					ctx,
-					"server auth interceptor: failed to derive client token key",
-					slog.String("device_id", clientID),
-					slog.Any("err", err),
-				)
-
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					errors.New("unauthenticated"),
-				)
-			}
-
-			token, err := paseto.NewParserForValidNow().
-				ParseV4Local(*tokenKey, tokenStr, []byte(clientID))
-			if err != nil {
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					errors.New("unauthenticated"),
-				)
-			}
-
-			// NB: It is important to check device existence here only
-			// after the token is authenticated to prevent pre-auth data
-			// from touching the database layer
-			ok, err := db.DeviceExists(ctx, clientID)
-			if err != nil {
-				logger.ErrorContext(
-					ctx,
-					"failed to check if device exists",
-					slog.Any("err", err),
-				)
-
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					errors.New("unauthenticated"),
-				)
-			}
-
-			if !ok {
-				logger.WarnContext(
-					ctx,
-					"device does not exist",
-					slog.String("device_id", clientID),
-				)
-
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					errors.New("unauthenticated"),
-				)
-			}
-
-			// TODO: check additional claims like audience here perhaps. At this point though we've checked time
-			// and clientID and that is sufficient
-			_ = token
-
-			ctx = WithDevice(ctx, clientID)
-
-			return next(ctx, req)
-		})
-	}
-
-	return connect.UnaryInterceptorFunc(interceptor)
+type clientInterceptor struct {
+	chain key.ClientChain
+}
+
+// NewClientInterceptor authenticates the requests and streams of a client as
+// the device of chain.
+func NewClientInterceptor(chain key.ClientChain) connect.Interceptor {
+	return &clientInterceptor{chain: chain}
+}
+
+func (i *clientInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		// Client implementation
+		if !req.Spec().IsClient {
+			return nil, errors.New("cannot use client auth interceptor on server")
+		}
+
+		binding, err := requestBinding(req)
+		if err != nil {
+			return nil, connect.NewError(
+				connect.CodeInternal,
+				fmt.Errorf("failed to bind token to request: %w", err),
+			)
+		}
+
+		err = i.authorize(req.Header(), binding)
+		if err != nil {
+			return nil, err
+		}
+
+		return next(ctx, req)
+	}
+}
+
+func (i *clientInterceptor) WrapStreamingClient(
+	next connect.StreamingClientFunc,
+) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+
+		// NB: the headers are sent with the first message, so setting them
+		// here is early enough.
+		err := i.authorize(conn.RequestHeader(), streamBinding(spec))
+		if err != nil {
+			return &failedClientConn{StreamingClientConn: conn, err: err}
+		}
+
+		return conn
+	}
+}
+
+func (i *clientInterceptor) WrapStreamingHandler(
+	_ connect.StreamingHandlerFunc,
+) connect.StreamingHandlerFunc {
+	return func(context.Context, connect.StreamingHandlerConn) error {
+		return errors.New("cannot use client auth interceptor on server")
+	}
+}
+
+// authorize sets the headers authenticating a request bound by binding.
+func (i *clientInterceptor) authorize(header http.Header, binding key.TokenBinding) error {
+	token, err := i.chain.BoundToken(binding)
+	if err != nil {
+		return connect.NewError(
+			connect.CodeFailedPrecondition,
+			fmt.Errorf("failed to generate token: %w", err),
+		)
+	}
+
+	header.Set("Authorization", "Bearer "+*token)
+	header.Set("Device-ID", i.chain.ClientID)
+
+	if version := appVersion(); version != "" {
+		header.Set(AppVersionHeader, version)
+	}
+
+	return nil
+}
+
+// failedClientConn is a stream that could not be authenticated. It fails
+// without using the wrapped stream, since even closing it would send the
+// request.
+type failedClientConn struct {
+	connect.StreamingClientConn
+
+	err error
+}
+
+func (c *failedClientConn) Send(any) error {
+	return c.err
+}
+
+func (c *failedClientConn) Receive(any) error {
+	return c.err
+}
+
+func (c *failedClientConn) CloseRequest() error {
+	return c.err
+}
+
+func (c *failedClientConn) CloseResponse() error {
+	return nil
+}
+
+type serverInterceptor struct {
+	keyring  key.ServerKeyring
+	policy   TokenPolicy
+	db       *database.DB
+	seen     *replayCache
+	attempts *AttemptLimiter
+	lastSeen *lastSeenCache
+}
+
+// NewServerInterceptor authenticates the requests and streams served to
+// devices and puts the device, its role and its key on their context, see
+// DeviceFromContext, RoleFromContext and DeviceKeyFromContext. Failed attempts
+// are recorded in attempts, and the peers and devices it bans are refused.
+// When and from where devices authenticate is recorded in db, see
+// DefaultLastSeenInterval.
+func NewServerInterceptor(
+	keyring key.ServerKeyring,
+	policy TokenPolicy,
+	db *database.DB,
+	attempts *AttemptLimiter,
+) connect.Interceptor {
+	return &serverInterceptor{
+		keyring:  keyring,
+		policy:   policy,
+		db:       db,
+		seen:     newReplayCache(DefaultReplayCacheSize, DefaultReplayCacheDeviceSize),
+		attempts: attempts,
+		lastSeen: newLastSeenCache(DefaultLastSeenInterval, DefaultLastSeenCacheSize),
+	}
+}
+
+func (i *serverInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, err := i.authenticate(ctx, req.Peer(), req.Header(), unaryBinder(req))
+		if err != nil {
+			return nil, err
+		}
+
+		return next(ctx, req)
+	}
+}
+
+func (i *serverInterceptor) WrapStreamingClient(
+	next connect.StreamingClientFunc,
+) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *serverInterceptor) WrapStreamingHandler(
+	next connect.StreamingHandlerFunc,
+) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := i.authenticate(
+			ctx,
+			conn.Peer(),
+			conn.RequestHeader(),
+			streamBinder(conn.Spec()),
+		)
+		if err != nil {
+			return err
+		}
+
+		return next(ctx, conn)
+	}
+}
+
+// authenticate checks the token presented by peer in header, unless peer or
+// the device it claims to be is banned or backing off, and records the
+// outcome. See verify.
+//
+// NB: anyone can claim to be any device, so failures only count against a
+// device once its key authenticated the token. Until then they only count
+// against the peer address.
+func (i *serverInterceptor) authenticate(
+	ctx context.Context,
+	peer connect.Peer,
+	header http.Header,
+	bind binder,
+) (context.Context, error) {
+	logger := logging.FromContext(ctx)
+
+	clientID := header.Get(`Device-ID`)
+
+	retryAt, err := i.attempts.Check(peer.Addr, clientID, time.Now())
+	if err != nil {
+		logger.WarnContext(
+			ctx,
+			"server auth interceptor: attempt refused",
+			slog.String("device_id", clientID),
+			slog.Time("retry_at", retryAt),
+			slog.Any("err", err),
+		)
+
+		connectErr := connect.NewError(connect.CodeResourceExhausted, err)
+
+		// NB: Retry-After is rounded up so that clients honoring it do not
+		// try again too early.
+		retryAfter := time.Until(retryAt) + time.Second - 1
+		connectErr.Meta().Set("Retry-After", strconv.Itoa(int(retryAfter/time.Second)))
+
+		return ctx, connectErr
+	}
+
+	ctx, minter, err := i.verify(ctx, header, bind)
+
+	switch {
+	case err == nil:
+		i.attempts.Succeed(peer.Addr, minter, time.Now())
+		i.recordLastSeen(ctx, peer.Addr, header)
+	case connect.CodeOf(err) == connect.CodeUnauthenticated:
+		for _, ban := range i.attempts.Fail(peer.Addr, minter, time.Now()) {
+			logger.WarnContext(
+				ctx,
+				"server auth interceptor: too many failed attempts, banned",
+				slog.String("remote_addr", ban.RemoteAddr),
+				slog.String("device_id", ban.DeviceID),
+				slog.Time("until", ban.Until),
+				slog.Int("count", ban.Count),
+			)
+		}
+	}
+
+	return ctx, err
+}
+
+// recordLastSeen records that the device on ctx authenticated from
+// remoteAddr with the app version in header, unless it was recorded recently.
+// Failing to record it does not fail the request.
+func (i *serverInterceptor) recordLastSeen(
+	ctx context.Context,
+	remoteAddr string,
+	header http.Header,
+) {
+	// NB: a browser logged in as a device is not the device.
+	deviceID := DeviceFromContext(ctx)
+	if deviceID == "" || WebSessionFromContext(ctx) {
+		return
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err == nil {
+		remoteAddr = host
+	}
+
+	version := header.Get(AppVersionHeader)
+	if len(version) > maxAppVersionLength {
+		version = version[:maxAppVersionLength]
+	}
+
+	seen := lastSeen{at: time.Now(), remoteAddr: remoteAddr, appVersion: version}
+	if !i.lastSeen.due(deviceID, seen) {
+		return
+	}
+
+	_, err = i.db.SetDeviceLastSeen(ctx, deviceID, seen.at, seen.remoteAddr, seen.appVersion)
+	if err != nil {
+		i.lastSeen.forget(deviceID)
+	}
+}
+
+// verify checks the token in header and returns ctx with the device it was
+// minted by and its role. bind describes the request the token is presented
+// for. It also returns the device whose key authenticated the token, even if
+// the token is then rejected, and nothing for web sessions.
+func (i *serverInterceptor) verify(
+	ctx context.Context,
+	header http.Header,
+	bind binder,
+) (context.Context, string, error) {
+	logger := logging.FromContext(ctx)
+
+	authHeader := header.Get(`Authorization`)
+
+	tokenStr, found := strings.CutPrefix(authHeader, "Bearer ")
+	if !found {
+		// NB: browsers cannot hold device keys, so they present the session
+		// cookie of their web session instead.
+		sessionToken := CookieFromHeader(header, SessionCookie)
+		if authHeader == "" && sessionToken != "" {
+			ctx, err := i.verifySession(ctx, sessionToken)
+
+			return ctx, "", err
+		}
+
+		return ctx, "", connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New(`unauthenticated`),
+		)
+	}
+
+	clientID := header.Get(`Device-ID`)
+	if clientID == "" {
+		logger.ErrorContext(ctx, "server auth interceptor: missing client id header")
+
+		return ctx, "", connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New(`unauthenticated`),
+		)
+	}
+
+	verify := i.verifyEncrypted
+	if strings.HasPrefix(tokenStr, paseto.V4Public.Header()) {
+		verify = i.verifySigned
+	}
+
+	token, device, deviceKey, err := verify(ctx, tokenStr, clientID)
+	if token == nil {
+		return ctx, "", err
+	}
+
+	if err != nil {
+		return ctx, clientID, err
+	}
+
+	jti, err := i.policy.checkToken(token, deviceKey.Version, bind, clientID, time.Now())
+	if err != nil {
+		logger.WarnContext(
+			ctx,
+			"server auth interceptor: token rejected",
+			slog.String("device_id", clientID),
+			slog.Any("err", err),
+		)
+
+		return ctx, clientID, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	// NB: the token is only recorded once it is known to be valid so
+	// that forged tokens cannot fill the cache.
+	expiration, _ := token.GetExpiration()
+
+	err = i.seen.add(clientID, jti, expiration.Add(i.policy.Leeway), time.Now())
+	if errors.Is(err, ErrReplayCacheFull) {
+		logger.ErrorContext(
+			ctx,
+			"server auth interceptor: replay cache full",
+			slog.String("device_id", clientID),
+		)
+
+		return ctx, clientID, connect.NewError(
+			connect.CodeResourceExhausted,
+			errors.New("too many requests"),
+		)
+	}
+
+	if err != nil {
+		logger.WarnContext(
+			ctx,
+			"server auth interceptor: token replayed",
+			slog.String("device_id", clientID),
+			slog.String("jti", jti),
+		)
+
+		return ctx, clientID, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	ctx = WithDevice(ctx, clientID)
+	ctx = WithDeviceKey(ctx, deviceKey)
+
+	return WithRole(ctx, Role(device.Role)), clientID, nil
+}
+
+// verifyEncrypted decrypts a v4.local token minted by clientID with the key
+// named in its footer, and returns it along with the device and its key. The
+// token is also returned once decrypted when it is rejected anyway.
+func (i *serverInterceptor) verifyEncrypted(
+	ctx context.Context,
+	tokenStr string,
+	clientID string,
+) (*paseto.Token, *database.Device, DeviceKey, error) {
+	logger := logging.FromContext(ctx)
+
+	deviceKey, err := tokenKey(tokenStr)
+	if err != nil {
+		logger.WarnContext(
+			ctx,
+			"server auth interceptor: invalid token footer",
+			slog.String("device_id", clientID),
+			slog.Any("err", err),
+		)
+
+		return nil, nil, DeviceKey{}, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	clientChain, err := i.keyring.ClientChain(
+		deviceKey.Version,
+		deviceKey.Generation,
+		clientID,
+	)
+	if err != nil {
+		logger.ErrorContext(ctx, "server auth interceptor: failed to load client chain",
+			slog.String("device_id", clientID),
+			slog.Any("err", err))
+
+		return nil, nil, DeviceKey{}, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New(`unauthenticated`),
+		)
+	}
+
+	tokenKey, err := clientChain.TokenKey()
+	if err != nil {
+		logger.ErrorContext(
+			ctx,
+			"server auth interceptor: failed to derive client token key",
+			slog.String("device_id", clientID),
+			slog.Any("err", err),
+		)
+
+		return nil, nil, DeviceKey{}, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	// NB: the time claims are checked by checkToken, which allows for
+	// the configured leeway.
+	token, err := paseto.NewParserWithoutExpiryCheck().
+		ParseV4Local(*tokenKey, tokenStr, []byte(clientID))
+	if err != nil {
+		return nil, nil, DeviceKey{}, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	// NB: It is important to check device existence here only
+	// after the token is authenticated to prevent pre-auth data
+	// from touching the database layer
+	device, err := i.device(ctx, clientID)
+	if err != nil {
+		return token, nil, DeviceKey{}, err
+	}
+
+	// NB: once a device is enrolled with another version of the server
+	// secret or its key is rotated, its previous keys must stop working.
+	if device.KeyVersion != deviceKey.Version || device.KeyGeneration != deviceKey.Generation {
+		logger.WarnContext(
+			ctx,
+			"server auth interceptor: device key retired",
+			slog.String("device_id", clientID),
+			slog.Int("key_version", deviceKey.Version),
+			slog.Int("key_generation", deviceKey.Generation),
+		)
+
+		return token, nil, DeviceKey{}, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	// NB: the server can derive the symmetric key of every device, so it
+	// must not be accepted anymore once the device registered a public key.
+	if device.PublicKey != nil {
+		logger.WarnContext(
+			ctx,
+			"server auth interceptor: device must sign its tokens",
+			slog.String("device_id", clientID),
+		)
+
+		return token, nil, DeviceKey{}, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	return token, device, deviceKey, nil
+}
+
+// verifySigned verifies a v4.public token signed by clientID with the secret
+// key of its registered public key, and returns it along with the device and
+// its key. The token is only returned once verified.
+func (i *serverInterceptor) verifySigned(
+	ctx context.Context,
+	tokenStr string,
+	clientID string,
+) (*paseto.Token, *database.Device, DeviceKey, error) {
+	logger := logging.FromContext(ctx)
+
+	// NB: unlike encrypted tokens, the key of signed tokens is stored with
+	// the device, so the device has to be looked up before the token is
+	// authenticated. It is a lookup by primary key which is no more than
+	// any other request does.
+	device, err := i.device(ctx, clientID)
+	if err != nil {
+		return nil, nil, DeviceKey{}, err
+	}
+
+	if device.PublicKey == nil {
+		logger.WarnContext(
+			ctx,
+			"server auth interceptor: device has no public key",
+			slog.String("device_id", clientID),
+		)
+
+		return nil, nil, DeviceKey{}, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	publicKey, err := paseto.NewV4AsymmetricPublicKeyFromBytes(device.PublicKey)
+	if err != nil {
+		logger.ErrorContext(
+			ctx,
+			"server auth interceptor: invalid device public key",
+			slog.String("device_id", clientID),
+			slog.Any("err", err),
+		)
+
+		return nil, nil, DeviceKey{}, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	// NB: the time claims are checked by checkToken, which allows for
+	// the configured leeway.
+	token, err := paseto.NewParserWithoutExpiryCheck().
+		ParseV4Public(publicKey, tokenStr, []byte(clientID))
+	if err != nil {
+		return nil, nil, DeviceKey{}, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	// NB: the identity of the server that signed tokens are minted for is
+	// still the one of the version of the server secret the device is
+	// enrolled with.
+	return token, device, DeviceKey{
+		Version:    device.KeyVersion,
+		Generation: device.KeyGeneration,
+	}, nil
+}
+
+// verifySession looks up the web session of sessionToken and returns ctx with
+// the device that approved it and its role.
+func (i *serverInterceptor) verifySession(
+	ctx context.Context,
+	sessionToken string,
+) (context.Context, error) {
+	logger := logging.FromContext(ctx)
+
+	session, err := i.db.GetWebSession(ctx, key.HashWebSecret(sessionToken), time.Now())
+	if err != nil {
+		return ctx, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	if session == nil {
+		logger.WarnContext(ctx, "server auth interceptor: unknown or expired web session")
+
+		return ctx, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	device, err := i.device(ctx, session.DeviceID)
+	if err != nil {
+		return ctx, err
+	}
+
+	ctx = WithDevice(ctx, device.ID)
+	ctx = WithWebSession(ctx)
+
+	return WithRole(ctx, Role(device.Role)), nil
+}
+
+// device returns the device with id, failing if it does not exist.
+func (i *serverInterceptor) device(ctx context.Context, id string) (*database.Device, error) {
+	logger := logging.FromContext(ctx)
+
+	device, err := i.db.GetDevice(ctx, id)
+	if err != nil {
+		logger.ErrorContext(
+			ctx,
+			"failed to get device",
+			slog.Any("err", err),
+		)
+
+		return nil, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	if device == nil {
+		logger.WarnContext(
+			ctx,
+			"device does not exist",
+			slog.String("device_id", id),
+		)
+
+		return nil, connect.NewError(
+			connect.CodeUnauthenticated,
+			errors.New("unauthenticated"),
+		)
+	}
+
+	return device, nil
 }
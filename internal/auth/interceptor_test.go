@@ -0,0 +1,445 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"connectrpc.com/connect"
+	"github.com/cmp0st/byte/internal/database"
+	"github.com/cmp0st/byte/internal/key"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/emptypb"
+	_ "modernc.org/sqlite"
+)
+
+const testProcedure = "/byte.v1.TestService/Watch"
+
+// newTestInterceptor returns a server interceptor limiting attempts with
+// attempts, along with the key chain of a device it accepts and its database.
+func newTestInterceptor(
+	t *testing.T,
+	attempts *AttemptLimiter,
+) (connect.Interceptor, *key.ClientChain, *database.DB) {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite", t.TempDir()+"/byte.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		//nolint: errcheck
+		conn.Close()
+	})
+
+	db := &database.DB{DB: conn}
+
+	err = db.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := key.NewServerKeyring(
+		map[int][]byte{key.DefaultKeyVersion: []byte("0123456789abcdef0123456789abcdef")},
+		key.DefaultKeyVersion,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := keyring.CurrentChain().Identity("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	device := database.Device{
+		ID:         uuid.NewString(),
+		Role:       string(RoleAdmin),
+		KeyVersion: key.DefaultKeyVersion,
+	}
+
+	err = db.AddDevice(t.Context(), device)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := keyring.ClientChain(device.KeyVersion, device.KeyGeneration, device.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain.ServerID = identity
+
+	interceptor := NewServerInterceptor(
+		*keyring,
+		TokenPolicy{
+			Identities: map[int]string{key.DefaultKeyVersion: identity},
+			Leeway:     time.Second,
+		},
+		db,
+		attempts,
+	)
+
+	return interceptor, chain, db
+}
+
+// testHandlerConn is the server side of a stream that presents header, from
+// addr if set.
+type testHandlerConn struct {
+	connect.StreamingHandlerConn
+
+	header http.Header
+	addr   string
+}
+
+func (c *testHandlerConn) Spec() connect.Spec {
+	return connect.Spec{Procedure: testProcedure, StreamType: connect.StreamTypeServer}
+}
+
+func (c *testHandlerConn) Peer() connect.Peer {
+	addr := c.addr
+	if addr == "" {
+		addr = "192.0.2.1:1234"
+	}
+
+	return connect.Peer{Addr: addr, Protocol: connect.ProtocolConnect}
+}
+
+func (c *testHandlerConn) RequestHeader() http.Header {
+	return c.header
+}
+
+func TestStreamingHandlerAuth(t *testing.T) {
+	interceptor, chain, _ := newTestInterceptor(
+		t,
+		NewAttemptLimiter(AttemptPolicy{}, DefaultAttemptLimiterSize),
+	)
+
+	bound := func(procedure string) http.Header {
+		token, err := chain.BoundToken(key.TokenBinding{Procedure: procedure})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return http.Header{
+			"Authorization": {"Bearer " + *token},
+			"Device-Id":     {chain.ClientID},
+		}
+	}
+
+	valid := bound(testProcedure)
+
+	// NB: a token for another deployment sharing the secret, say staging,
+	// is encrypted with the same device key.
+	staging := *chain
+	staging.ServerID = key.ServerIdentityPrefix + "staging"
+
+	stagingToken, err := staging.BoundToken(key.TokenBinding{Procedure: testProcedure})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		code   connect.Code
+	}{
+		{name: "no token", header: http.Header{}, code: connect.CodeUnauthenticated},
+		{
+			name: "no device",
+			header: http.Header{
+				"Authorization": valid["Authorization"],
+			},
+			code: connect.CodeUnauthenticated,
+		},
+		{
+			name: "bad token",
+			header: http.Header{
+				"Authorization": {"Bearer v4.local.bad"},
+				"Device-Id":     {chain.ClientID},
+			},
+			code: connect.CodeUnauthenticated,
+		},
+		{
+			name:   "other procedure",
+			header: bound("/byte.v1.TestService/Other"),
+			code:   connect.CodeUnauthenticated,
+		},
+		{
+			name: "other server",
+			header: http.Header{
+				"Authorization": {"Bearer " + *stagingToken},
+				"Device-Id":     {chain.ClientID},
+			},
+			code: connect.CodeUnauthenticated,
+		},
+		{name: "valid token", header: valid},
+		{name: "replayed token", header: valid, code: connect.CodeUnauthenticated},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var device string
+
+			handler := interceptor.WrapStreamingHandler(
+				func(ctx context.Context, _ connect.StreamingHandlerConn) error {
+					device = DeviceFromContext(ctx)
+
+					return nil
+				},
+			)
+
+			err := handler(t.Context(), &testHandlerConn{header: test.header})
+
+			if test.code != 0 {
+				if connect.CodeOf(err) != test.code || device != "" {
+					t.Fatalf("got %v as %q, want %v", err, device, test.code)
+				}
+
+				return
+			}
+
+			if err != nil || device != chain.ClientID {
+				t.Fatalf("got %v as %q, want %q", err, device, chain.ClientID)
+			}
+		})
+	}
+}
+
+func TestStreamingClientAuth(t *testing.T) {
+	interceptor, chain, _ := newTestInterceptor(
+		t,
+		NewAttemptLimiter(AttemptPolicy{}, DefaultAttemptLimiterSize),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle(testProcedure, connect.NewServerStreamHandler(
+		testProcedure,
+		func(
+			ctx context.Context,
+			_ *connect.Request[emptypb.Empty],
+			stream *connect.ServerStream[emptypb.Empty],
+		) error {
+			if DeviceFromContext(ctx) != chain.ClientID {
+				return errors.New("wrong device")
+			}
+
+			return stream.Send(&emptypb.Empty{})
+		},
+		connect.WithInterceptors(interceptor),
+	))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	noServer := *chain
+	noServer.ServerID = ""
+
+	tests := []struct {
+		name string
+		opts []connect.ClientOption
+		code connect.Code
+	}{
+		{name: "no token", code: connect.CodeUnauthenticated},
+		{
+			name: "authenticated",
+			opts: []connect.ClientOption{connect.WithInterceptors(NewClientInterceptor(*chain))},
+		},
+		{
+			name: "no server id",
+			opts: []connect.ClientOption{connect.WithInterceptors(NewClientInterceptor(noServer))},
+			code: connect.CodeFailedPrecondition,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := connect.NewClient[emptypb.Empty, emptypb.Empty](
+				server.Client(),
+				server.URL+testProcedure,
+				test.opts...,
+			)
+
+			received := 0
+
+			req := connect.NewRequest(&emptypb.Empty{})
+
+			stream, err := client.CallServerStream(t.Context(), req)
+			if err == nil {
+				for stream.Receive() {
+					received++
+				}
+
+				err = stream.Err()
+			}
+
+			if test.code != 0 {
+				if connect.CodeOf(err) != test.code {
+					t.Fatalf("got %v, want %v", err, test.code)
+				}
+
+				return
+			}
+
+			if err != nil || received != 1 {
+				t.Fatalf("received %d messages, %v, want 1", received, err)
+			}
+		})
+	}
+}
+
+func TestAuthFailuresByDevice(t *testing.T) {
+	attempts := NewAttemptLimiter(
+		AttemptPolicy{Window: time.Minute, MaxFailures: 2, BanDuration: time.Minute},
+		DefaultAttemptLimiterSize,
+	)
+	interceptor, chain, _ := newTestInterceptor(t, attempts)
+
+	handler := interceptor.WrapStreamingHandler(
+		func(context.Context, connect.StreamingHandlerConn) error {
+			return nil
+		},
+	)
+
+	valid := func() http.Header {
+		token, err := chain.BoundToken(key.TokenBinding{Procedure: testProcedure})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return http.Header{
+			"Authorization": {"Bearer " + *token},
+			"Device-Id":     {chain.ClientID},
+		}
+	}
+
+	forged := http.Header{
+		"Authorization": {"Bearer v4.local.bad"},
+		"Device-Id":     {chain.ClientID},
+	}
+
+	// NB: tokens that no key of the device authenticated ban the peers
+	// presenting them, never the device they claim to be.
+	for _, addr := range []string{"192.0.2.1:1234", "192.0.2.2:1234", "192.0.2.3:1234"} {
+		for range 3 {
+			err := handler(t.Context(), &testHandlerConn{header: forged, addr: addr})
+			if connect.CodeOf(err) == 0 {
+				t.Fatal("accepted a forged token")
+			}
+		}
+
+		_, err := attempts.Check(addr, "", time.Now())
+		if !errors.Is(err, ErrBanned) {
+			t.Fatalf("peer %s not banned after forging tokens: %v", addr, err)
+		}
+	}
+
+	_, err := attempts.Check("", chain.ClientID, time.Now())
+	if err != nil {
+		t.Fatalf("device banned by forged tokens: %v", err)
+	}
+
+	// NB: replays are authenticated by the key of the device, so they count
+	// against it.
+	replayed := valid()
+
+	for _, addr := range []string{"192.0.2.4:1234", "192.0.2.5:1234", "192.0.2.6:1234"} {
+		err = handler(t.Context(), &testHandlerConn{header: replayed, addr: addr})
+		if addr == "192.0.2.4:1234" && err != nil {
+			t.Fatalf("failed to authenticate: %v", err)
+		}
+	}
+
+	_, err = attempts.Check("", chain.ClientID, time.Now())
+	if !errors.Is(err, ErrBanned) {
+		t.Fatalf("device not banned after replaying tokens: %v", err)
+	}
+
+	err = handler(t.Context(), &testHandlerConn{header: valid(), addr: "192.0.2.7:1234"})
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Fatalf("got %v from a banned device, want %v", err, connect.CodeResourceExhausted)
+	}
+}
+
+func TestSignedTokens(t *testing.T) {
+	interceptor, chain, db := newTestInterceptor(
+		t,
+		NewAttemptLimiter(AttemptPolicy{}, DefaultAttemptLimiterSize),
+	)
+
+	handler := interceptor.WrapStreamingHandler(
+		func(context.Context, connect.StreamingHandlerConn) error {
+			return nil
+		},
+	)
+
+	secretKey := paseto.NewV4AsymmetricSecretKey()
+
+	signing, err := key.NewSigningClientChain(secretKey, chain.ClientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signing.ServerID = chain.ServerID
+
+	forger, err := key.NewSigningClientChain(paseto.NewV4AsymmetricSecretKey(), chain.ClientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forger.ServerID = chain.ServerID
+
+	staging := *signing
+	staging.ServerID = key.ServerIdentityPrefix + "staging"
+
+	// authenticate presents a fresh token minted by c and reports whether it
+	// was accepted.
+	authenticate := func(c *key.ClientChain) bool {
+		token, err := c.BoundToken(key.TokenBinding{Procedure: testProcedure})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = handler(t.Context(), &testHandlerConn{header: http.Header{
+			"Authorization": {"Bearer " + *token},
+			"Device-Id":     {chain.ClientID},
+		}})
+		if err != nil && connect.CodeOf(err) != connect.CodeUnauthenticated {
+			t.Fatalf("got %v, want %v", err, connect.CodeUnauthenticated)
+		}
+
+		return err == nil
+	}
+
+	if !authenticate(chain) || authenticate(signing) {
+		t.Fatal("want encrypted tokens only before a public key is registered")
+	}
+
+	_, err = db.SetDevicePublicKey(t.Context(), chain.ClientID, secretKey.Public().ExportBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		chain    *key.ClientChain
+		accepted bool
+	}{
+		{name: "signed", chain: signing, accepted: true},
+		{name: "encrypted", chain: chain},
+		{name: "signed with another key", chain: forger},
+		{name: "signed for another server", chain: &staging},
+	}
+
+	for _, test := range tests {
+		if got := authenticate(test.chain); got != test.accepted {
+			t.Errorf("%s token accepted: got %v, want %v", test.name, got, test.accepted)
+		}
+	}
+}
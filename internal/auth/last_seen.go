@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLastSeenInterval is how often the last-seen time of a device is
+	// written at most, so that busy devices do not write to the database on
+	// every request.
+	DefaultLastSeenInterval = 5 * time.Minute
+
+	// DefaultLastSeenCacheSize bounds the devices whose last write is
+	// remembered at once.
+	DefaultLastSeenCacheSize = 100_000
+
+	// AppVersionHeader is the header devices report the version of their app
+	// in, which is recorded with their last-seen time.
+	AppVersionHeader = "App-Version"
+
+	// Versions are only stored to be shown, so long ones are cut.
+	maxAppVersionLength = 64
+)
+
+// appVersion is the version of the module this binary was built from, e.g.
+// v1.2.3, or (devel) when built from a checkout.
+var appVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	return info.Main.Version
+})
+
+// lastSeenCache remembers the last-seen writes of devices, so that the
+// requests of a device within an interval are coalesced into a single write.
+type lastSeenCache struct {
+	interval time.Duration
+	size     int
+
+	mu      sync.Mutex
+	written map[string]lastSeen
+}
+
+// lastSeen is what was last written for a device.
+type lastSeen struct {
+	at         time.Time
+	remoteAddr string
+	appVersion string
+}
+
+func newLastSeenCache(interval time.Duration, size int) *lastSeenCache {
+	return &lastSeenCache{
+		interval: interval,
+		size:     size,
+		written:  make(map[string]lastSeen),
+	}
+}
+
+// due reports whether the device must be written as seen, and records it as
+// written if so. It is due once the interval passed since it was last
+// written, or right away if it moved or changed app version.
+func (c *lastSeenCache) due(deviceID string, seen lastSeen) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last, found := c.written[deviceID]
+	if found &&
+		seen.at.Before(last.at.Add(c.interval)) &&
+		seen.remoteAddr == last.remoteAddr &&
+		seen.appVersion == last.appVersion {
+		return false
+	}
+
+	if !found && len(c.written) >= c.size {
+		c.purge(seen.at)
+	}
+
+	// NB: forgetting a device only costs an early write, unlike forgetting
+	// a token of the replay cache.
+	if !found && len(c.written) >= c.size {
+		clear(c.written)
+	}
+
+	c.written[deviceID] = seen
+
+	return true
+}
+
+// forget makes the next request of the device due, e.g. after its write
+// failed.
+func (c *lastSeenCache) forget(deviceID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.written, deviceID)
+}
+
+// purge forgets the devices whose next request is due anyway. It must be
+// called with c.mu held.
+func (c *lastSeenCache) purge(now time.Time) {
+	for deviceID, last := range c.written {
+		if !now.Before(last.at.Add(c.interval)) {
+			delete(c.written, deviceID)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/cmp0st/byte/internal/key"
+)
+
+func TestLastSeenCache(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	seen := lastSeen{at: now, remoteAddr: "192.0.2.1", appVersion: "v1.0.0"}
+	c := newLastSeenCache(time.Minute, 2)
+
+	moved := seen
+	moved.remoteAddr = "192.0.2.2"
+
+	upgraded := moved
+	upgraded.appVersion = "v1.1.0"
+
+	later := upgraded
+	later.at = now.Add(time.Minute)
+
+	tests := []struct {
+		name string
+		seen lastSeen
+		due  bool
+	}{
+		{name: "first request", seen: seen, due: true},
+		{name: "same request", seen: seen},
+		{name: "within interval", seen: lastSeen{
+			at:         now.Add(time.Minute - time.Second),
+			remoteAddr: seen.remoteAddr,
+			appVersion: seen.appVersion,
+		}},
+		{name: "moved", seen: moved, due: true},
+		{name: "upgraded", seen: upgraded, due: true},
+		{name: "after interval", seen: later, due: true},
+	}
+
+	for _, test := range tests {
+		if got := c.due("a", test.seen); got != test.due {
+			t.Errorf("%s: got due %v, want %v", test.name, got, test.due)
+		}
+	}
+
+	c.forget("a")
+
+	if !c.due("a", later) {
+		t.Error("forgotten device not due")
+	}
+
+	// NB: a full cache makes room, at worst by forgetting every device.
+	for _, deviceID := range []string{"b", "c", "d"} {
+		if !c.due(deviceID, later) {
+			t.Errorf("new device %s not due", deviceID)
+		}
+
+		if len(c.written) > c.size {
+			t.Errorf("cache holds %d devices over its size of %d", len(c.written), c.size)
+		}
+	}
+
+	if _, found := c.written["a"]; found {
+		t.Error("full cache kept its devices")
+	}
+
+	// NB: devices due anyway are forgotten first.
+	c = newLastSeenCache(time.Minute, 2)
+	c.due("a", seen)
+	c.due("b", later)
+
+	if !c.due("c", later) || len(c.written) != 2 || !c.due("a", later) {
+		t.Errorf("cache holds %v, want the devices seen recently", c.written)
+	}
+}
+
+func TestLastSeen(t *testing.T) {
+	interceptor, chain, db := newTestInterceptor(
+		t,
+		NewAttemptLimiter(AttemptPolicy{}, DefaultAttemptLimiterSize),
+	)
+
+	handler := interceptor.WrapStreamingHandler(
+		func(context.Context, connect.StreamingHandlerConn) error { return nil },
+	)
+
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{name: "version", version: "v1.2.3", want: "v1.2.3"},
+		{
+			name:    "long version",
+			version: strings.Repeat("v", maxAppVersionLength+1),
+			want:    strings.Repeat("v", maxAppVersionLength),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := chain.BoundToken(key.TokenBinding{Procedure: testProcedure})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now().Truncate(time.Second)
+
+			err = handler(t.Context(), &testHandlerConn{header: http.Header{
+				"Authorization":  {"Bearer " + *token},
+				"Device-Id":      {chain.ClientID},
+				AppVersionHeader: {test.version},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			device, err := db.GetDevice(t.Context(), chain.ClientID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if device.AppVersion != test.want || device.LastSeenIP != "192.0.2.1" ||
+				device.LastSeenAt.Before(start) {
+				t.Errorf(
+					"seen with %q from %s at %v, want %q from 192.0.2.1 since %v",
+					device.AppVersion, device.LastSeenIP, device.LastSeenAt, test.want, start,
+				)
+			}
+		})
+	}
+}
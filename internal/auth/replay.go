@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultReplayCacheSize bounds the tokens remembered at once. Tokens are
+	// remembered for at most DefaultTokenExpiration, so this allows thousands
+	// of requests per second.
+	DefaultReplayCacheSize = 100_000
+
+	// DefaultReplayCacheDeviceSize bounds the tokens remembered at once for a
+	// single device, so that one device cannot fill the cache and lock out
+	// every other one.
+	DefaultReplayCacheDeviceSize = 5_000
+)
+
+var (
+	ErrTokenReplayed     = errors.New("token replayed")
+	ErrReplayCacheFull   = errors.New("replay cache full")
+	ErrTokenLivesTooLong = errors.New("token expires too far in the future")
+)
+
+// replayCache remembers the tokens it has seen until they expire.
+type replayCache struct {
+	size       int
+	deviceSize int
+
+	mu      sync.Mutex
+	seen    map[tokenID]time.Time
+	devices map[string]int
+	expires expiryHeap
+}
+
+// tokenID is the jti of a token along with the device that minted it, since
+// devices pick their jti themselves.
+type tokenID struct {
+	device string
+	jti    string
+}
+
+func newReplayCache(size, deviceSize int) *replayCache {
+	return &replayCache{
+		size:       size,
+		deviceSize: deviceSize,
+		seen:       make(map[tokenID]time.Time),
+		devices:    make(map[string]int),
+	}
+}
+
+// add records the token jti of device until expiration and fails if it was
+// seen before.
+// NB: when the cache is full new tokens are refused rather than forgetting
+// tokens that are still valid, since that would allow replaying them.
+func (c *replayCache) add(device, jti string, expiration time.Time, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.purge(now)
+
+	id := tokenID{device: device, jti: jti}
+
+	if _, found := c.seen[id]; found {
+		return ErrTokenReplayed
+	}
+
+	if len(c.seen) >= c.size || c.devices[device] >= c.deviceSize {
+		return ErrReplayCacheFull
+	}
+
+	c.seen[id] = expiration
+	c.devices[device]++
+	heap.Push(&c.expires, expiry{id: id, at: expiration})
+
+	return nil
+}
+
+// purge forgets the tokens that expired by now, which can no longer be used.
+func (c *replayCache) purge(now time.Time) {
+	for len(c.expires) > 0 && !c.expires[0].at.After(now) {
+		e, _ := heap.Pop(&c.expires).(expiry)
+		delete(c.seen, e.id)
+
+		c.devices[e.id.device]--
+		if c.devices[e.id.device] <= 0 {
+			delete(c.devices, e.id.device)
+		}
+	}
+}
+
+type expiry struct {
+	id tokenID
+	at time.Time
+}
+
+// expiryHeap implements heap.Interface with the earliest expiry first.
+type expiryHeap []expiry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h expiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *expiryHeap) Push(x any) {
+	e, _ := x.(expiry)
+	*h = append(*h, e)
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return e
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReplayCacheReplays(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	expiration := now.Add(DefaultTokenExpiration)
+	c := newReplayCache(10, 10)
+
+	err := c.add("a", "1", expiration, now)
+	if err != nil {
+		t.Fatalf("failed to add a new token: %v", err)
+	}
+
+	err = c.add("a", "1", expiration, now.Add(time.Second))
+	if !errors.Is(err, ErrTokenReplayed) {
+		t.Fatalf("replayed token: %v", err)
+	}
+
+	// NB: devices pick their jti themselves, so they cannot replay the
+	// tokens of one another by picking the same one.
+	err = c.add("b", "1", expiration, now)
+	if err != nil {
+		t.Fatalf("failed to add the jti of another device: %v", err)
+	}
+}
+
+func TestReplayCacheBounds(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	expiration := now.Add(DefaultTokenExpiration)
+	c := newReplayCache(5, 3)
+
+	for i := range 3 {
+		err := c.add("a", fmt.Sprint(i), expiration, now)
+		if err != nil {
+			t.Fatalf("failed to add token %d: %v", i, err)
+		}
+	}
+
+	err := c.add("a", "3", expiration, now)
+	if !errors.Is(err, ErrReplayCacheFull) {
+		t.Fatalf("added a token over the bound of a device: %v", err)
+	}
+
+	for i := range 2 {
+		err = c.add("b", fmt.Sprint(i), expiration, now)
+		if err != nil {
+			t.Fatalf("device locked out by another one: %v", err)
+		}
+	}
+
+	err = c.add("c", "0", expiration, now)
+	if !errors.Is(err, ErrReplayCacheFull) {
+		t.Fatalf("added a token over the bound of the cache: %v", err)
+	}
+
+	// NB: a full cache still detects replays.
+	err = c.add("a", "0", expiration, now)
+	if !errors.Is(err, ErrTokenReplayed) {
+		t.Fatalf("replayed token in a full cache: %v", err)
+	}
+}
+
+func TestReplayCacheExpiry(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	c := newReplayCache(10, 2)
+
+	err := c.add("a", "early", now.Add(time.Second), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.add("a", "late", now.Add(time.Minute), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.add("a", "next", now.Add(time.Minute), now.Add(time.Second/2))
+	if !errors.Is(err, ErrReplayCacheFull) {
+		t.Fatalf("added a token before any expired: %v", err)
+	}
+
+	// NB: tokens are forgotten once they expire, so the earliest one makes
+	// room for the next.
+	err = c.add("a", "next", now.Add(time.Minute), now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("failed to add a token once another expired: %v", err)
+	}
+
+	if _, found := c.seen[tokenID{device: "a", jti: "early"}]; found {
+		t.Fatal("expired token still remembered")
+	}
+
+	err = c.add("a", "late", now.Add(time.Minute), now.Add(time.Second))
+	if !errors.Is(err, ErrTokenReplayed) {
+		t.Fatalf("replayed token that has not expired: %v", err)
+	}
+
+	err = c.add("b", "0", now.Add(2*time.Minute), now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.seen) != 1 || len(c.devices) != 1 || c.devices["b"] != 1 {
+		t.Fatalf("remembered %v for %v after every other token expired", c.seen, c.devices)
+	}
+}
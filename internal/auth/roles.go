@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"connectrpc.com/connect"
+	"github.com/cmp0st/byte/internal/logging"
+)
+
+// Role is what a device is trusted with.
+type Role string
+
+const (
+	// RoleAdmin devices can do anything, managing devices included.
+	RoleAdmin Role = "admin"
+	// RoleMember devices can read and modify files.
+	RoleMember Role = "member"
+	// RoleReadOnly devices can only read files.
+	RoleReadOnly Role = "read-only"
+	// RoleUploadOnly devices can only add files, which suits devices backing
+	// up photos.
+	RoleUploadOnly Role = "upload-only"
+)
+
+var Roles = []Role{RoleAdmin, RoleMember, RoleReadOnly, RoleUploadOnly}
+
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if !slices.Contains(Roles, role) {
+		return "", fmt.Errorf("unknown role %q", s)
+	}
+
+	return role, nil
+}
+
+// Scope is the kind of access a procedure needs.
+type Scope int
+
+const (
+	ScopeRead Scope = iota
+	ScopeUpload
+	ScopeWrite
+	ScopeAdmin
+
+	// ScopeSelf is for procedures that only concern the calling device, such
+	// as rotating its own key.
+	ScopeSelf
+)
+
+var roleScopes = map[Role][]Scope{
+	RoleAdmin:      {ScopeRead, ScopeUpload, ScopeWrite, ScopeAdmin, ScopeSelf},
+	RoleMember:     {ScopeRead, ScopeUpload, ScopeWrite, ScopeSelf},
+	RoleReadOnly:   {ScopeRead, ScopeSelf},
+	RoleUploadOnly: {ScopeUpload, ScopeSelf},
+}
+
+// webSessionScopes are the most a browser logged in as a device may do,
+// whatever the role of the device. A browser must not be able to take the
+// device over, by managing its keys, or to manage the server.
+var webSessionScopes = []Scope{ScopeRead, ScopeUpload, ScopeWrite}
+
+// Grants reports whether devices with the role have the scope.
+func (r Role) Grants(scope Scope) bool {
+	return slices.Contains(roleScopes[r], scope)
+}
+
+type authorizationInterceptor struct {
+	scopes map[string]Scope
+}
+
+// NewAuthorizationInterceptor allows a request or stream when the role of the
+// authenticated device grants the scope of its procedure. It must come after
+// the server auth interceptor. Procedures missing from scopes are denied.
+func NewAuthorizationInterceptor(scopes map[string]Scope) connect.Interceptor {
+	return &authorizationInterceptor{scopes: scopes}
+}
+
+func (i *authorizationInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		err := i.authorize(ctx, req.Spec().Procedure)
+		if err != nil {
+			return nil, err
+		}
+
+		return next(ctx, req)
+	}
+}
+
+func (i *authorizationInterceptor) WrapStreamingClient(
+	next connect.StreamingClientFunc,
+) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *authorizationInterceptor) WrapStreamingHandler(
+	next connect.StreamingHandlerFunc,
+) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		err := i.authorize(ctx, conn.Spec().Procedure)
+		if err != nil {
+			return err
+		}
+
+		return next(ctx, conn)
+	}
+}
+
+func (i *authorizationInterceptor) authorize(ctx context.Context, procedure string) error {
+	role := RoleFromContext(ctx)
+
+	scope, found := i.scopes[procedure]
+	if found && role.Grants(scope) &&
+		(!WebSessionFromContext(ctx) || slices.Contains(webSessionScopes, scope)) {
+		return nil
+	}
+
+	logging.FromContext(ctx).WarnContext(
+		ctx,
+		"authorization interceptor: procedure denied",
+		slog.String("role", string(role)),
+	)
+
+	return connect.NewError(
+		connect.CodePermissionDenied,
+		errors.New("permission denied"),
+	)
+}
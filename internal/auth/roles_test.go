@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"slices"
+	"testing"
+
+	"connectrpc.com/connect"
+)
+
+func TestAuthorize(t *testing.T) {
+	scopes := map[string]Scope{
+		"read":   ScopeRead,
+		"upload": ScopeUpload,
+		"write":  ScopeWrite,
+		"admin":  ScopeAdmin,
+		"self":   ScopeSelf,
+	}
+
+	tests := []struct {
+		role       Role
+		allowed    []string
+		webAllowed []string
+	}{
+		{
+			role:       RoleAdmin,
+			allowed:    []string{"read", "upload", "write", "admin", "self"},
+			webAllowed: []string{"read", "upload", "write"},
+		},
+		{
+			role:       RoleMember,
+			allowed:    []string{"read", "upload", "write", "self"},
+			webAllowed: []string{"read", "upload", "write"},
+		},
+		{
+			role:       RoleReadOnly,
+			allowed:    []string{"read", "self"},
+			webAllowed: []string{"read"},
+		},
+		{
+			role:       RoleUploadOnly,
+			allowed:    []string{"upload", "self"},
+			webAllowed: []string{"upload"},
+		},
+		{role: Role("unknown")},
+	}
+
+	i := &authorizationInterceptor{scopes: scopes}
+
+	for _, test := range tests {
+		for _, web := range []bool{false, true} {
+			ctx := WithRole(t.Context(), test.role)
+
+			allowed := test.allowed
+			if web {
+				ctx = WithWebSession(ctx)
+				allowed = test.webAllowed
+			}
+
+			for _, procedure := range []string{"read", "upload", "write", "admin", "self", "none"} {
+				err := i.authorize(ctx, procedure)
+
+				want := connect.CodePermissionDenied
+				if slices.Contains(allowed, procedure) {
+					want = 0
+				}
+
+				if (err == nil) != (want == 0) ||
+					(err != nil && connect.CodeOf(err) != want) {
+					t.Errorf(
+						"%s (web session %v) calling %s: got %v, want %v",
+						test.role, web, procedure, err, want,
+					)
+				}
+			}
+		}
+	}
+}
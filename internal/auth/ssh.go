@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/ssh"
+	"github.com/cmp0st/byte/internal/database"
 	"github.com/cmp0st/byte/internal/logging"
 	gossh "golang.org/x/crypto/ssh"
 )
@@ -15,7 +16,16 @@ const (
 	SSHKeyMinFields = 2
 )
 
-func SSHPublicKey(authorizedKeys []string) func(ctx ssh.Context, key ssh.PublicKey) bool {
+// SSHPublicKey authenticates SSH clients against the statically configured
+// authorized keys, the keys registered to devices in the database and user
+// certificates signed by one of userCAs. When a device key matches, the owning
+// device is stored on the SSH context and its role applies to the session,
+// see SSHRoleOptions.
+func SSHPublicKey(
+	authorizedKeys []string,
+	userCAs []gossh.PublicKey,
+	db *database.DB,
+) func(ctx ssh.Context, key ssh.PublicKey) bool {
 	return func(ctx ssh.Context, key ssh.PublicKey) bool {
 		keyType := key.Type()
 		keyFingerprint := gossh.FingerprintSHA256(key)
@@ -25,10 +35,30 @@ func SSHPublicKey(authorizedKeys []string) func(ctx ssh.Context, key ssh.PublicK
 			slog.String("fingerprint", keyFingerprint),
 		)
 
-		if len(authorizedKeys) == 0 {
-			logger.Warn("authentication denied: no authorized keys configured")
+		// Start every attempt from a clean slate since a client may offer
+		// several keys on the same connection.
+		SSHContextWithDevice(ctx, "")
+		SSHContextWithOptions(ctx, &SSHOptions{})
 
-			return false
+		cert, ok := key.(*gossh.Certificate)
+		if ok {
+			logger = logger.With(
+				slog.String("key_id", cert.KeyId),
+				slog.Uint64("serial", cert.Serial),
+			)
+
+			opts, err := CheckSSHCertificate(cert, ctx.User(), ctx.RemoteAddr(), userCAs)
+			if err != nil {
+				logger.Warn("Authentication denied: invalid certificate", slog.Any("err", err))
+
+				return false
+			}
+
+			SSHContextWithOptions(ctx, opts)
+
+			logger.Info("Authentication successful")
+
+			return true
 		}
 
 		keyData := key.Marshal()
@@ -44,7 +74,7 @@ func SSHPublicKey(authorizedKeys []string) func(ctx ssh.Context, key ssh.PublicK
 				continue
 			}
 
-			parsedKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(authKey))
+			parsedKey, _, options, _, err := gossh.ParseAuthorizedKey([]byte(authKey))
 			if err != nil {
 				logger.Debug(
 					"failed to parse authorized key",
@@ -55,15 +85,130 @@ func SSHPublicKey(authorizedKeys []string) func(ctx ssh.Context, key ssh.PublicK
 				continue
 			}
 
-			if parsedKey.Type() == keyType && string(parsedKey.Marshal()) == string(keyData) {
-				logger.Info("Authentication successful")
+			if parsedKey.Type() != keyType || string(parsedKey.Marshal()) != string(keyData) {
+				continue
+			}
+
+			opts, err := ParseSSHKeyOptions(options)
+			if err != nil {
+				logger.Warn(
+					"Authentication denied: invalid authorized key options",
+					slog.Int("index", i),
+					slog.Any("err", err),
+				)
 
-				return true
+				return false
 			}
+
+			if opts.From != "" {
+				err = CheckFrom(ctx.RemoteAddr(), opts.From)
+				if err != nil {
+					logger.Warn(
+						"Authentication denied: source address not allowed",
+						slog.Int("index", i),
+						slog.Any("err", err),
+					)
+
+					return false
+				}
+			}
+
+			SSHContextWithOptions(ctx, opts)
+
+			logger.Info(
+				"Authentication successful",
+				slog.String("root", opts.Root),
+				slog.Bool("read_only", opts.ReadOnly),
+			)
+
+			return true
+		}
+
+		if db == nil {
+			logger.Warn("Authentication denied: key not found in authorized keys")
+
+			return false
+		}
+
+		deviceKey, err := db.GetSSHKey(ctx, keyFingerprint)
+		if err != nil {
+			logger.Error("failed to look up device ssh key", slog.Any("err", err))
+
+			return false
 		}
 
-		logger.Warn("Authentication denied: key not found in authorized keys")
+		if deviceKey == nil {
+			logger.Warn("Authentication denied: key not found in authorized keys or device keys")
+
+			return false
+		}
+
+		parsedKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(deviceKey.PublicKey))
+		if err != nil {
+			logger.Error(
+				"failed to parse device ssh key",
+				slog.String("device_id", deviceKey.DeviceID),
+				slog.Any("err", err),
+			)
+
+			return false
+		}
+
+		if parsedKey.Type() != keyType || string(parsedKey.Marshal()) != string(keyData) {
+			logger.Warn(
+				"Authentication denied: device key does not match fingerprint",
+				slog.String("device_id", deviceKey.DeviceID),
+			)
+
+			return false
+		}
+
+		device, err := db.GetDevice(ctx, deviceKey.DeviceID)
+		if err != nil || device == nil {
+			logger.Error(
+				"failed to look up device of ssh key",
+				slog.String("device_id", deviceKey.DeviceID),
+				slog.Any("err", err),
+			)
+
+			return false
+		}
+
+		opts, err := SSHRoleOptions(Role(device.Role))
+		if err != nil {
+			logger.Warn(
+				"Authentication denied: device role not allowed over ssh",
+				slog.String("device_id", deviceKey.DeviceID),
+				slog.String("role", device.Role),
+			)
+
+			return false
+		}
+
+		grants, restricted, err := db.StorageGrants(ctx, deviceKey.DeviceID)
+		if err != nil {
+			logger.Error(
+				"failed to look up device path grants",
+				slog.String("device_id", deviceKey.DeviceID),
+				slog.Any("err", err),
+			)
+
+			return false
+		}
+
+		opts.Restricted = restricted
+		opts.Grants = grants
+
+		SSHContextWithDevice(ctx, deviceKey.DeviceID)
+		SSHContextWithOptions(ctx, opts)
+
+		logger.Info(
+			"Authentication successful",
+			slog.String("device_id", deviceKey.DeviceID),
+			slog.String("role", device.Role),
+			slog.Bool("read_only", opts.ReadOnly),
+		)
 
-		return false
+		return true
 	}
 }
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Critical options understood on SSH user certificates. Certificates carrying
+// any other critical option are rejected.
+const (
+	SSHCertOptionForceCommand  = "force-command"
+	SSHCertOptionSourceAddress = "source-address"
+)
+
+// SSHCertExtensionPermitPTY allows a certificate to request a terminal.
+const SSHCertExtensionPermitPTY = "permit-pty"
+
+var (
+	ErrSSHCertNotUserCert      = errors.New("ssh: certificate is not a user certificate")
+	ErrSSHCertUnknownAuthority = errors.New("ssh: certificate signed by unrecognized authority")
+	ErrSSHCertNoPrincipals     = errors.New("ssh: certificate has no principals")
+	ErrSSHCertSourceAddress    = errors.New("ssh: source address not allowed by certificate")
+)
+
+// CheckSSHCertificate validates an SSH user certificate presented by user
+// from remoteAddr against the trusted certificate authorities and returns the
+// options that apply to the resulting session.
+func CheckSSHCertificate(
+	cert *gossh.Certificate,
+	user string,
+	remoteAddr net.Addr,
+	authorities []gossh.PublicKey,
+) (*SSHOptions, error) {
+	if cert.CertType != gossh.UserCert {
+		return nil, ErrSSHCertNotUserCert
+	}
+
+	trusted := false
+
+	for _, authority := range authorities {
+		if bytes.Equal(authority.Marshal(), cert.SignatureKey.Marshal()) {
+			trusted = true
+
+			break
+		}
+	}
+
+	if !trusted {
+		return nil, ErrSSHCertUnknownAuthority
+	}
+
+	// NB: x/crypto treats a certificate without principals as valid for
+	// every user. That is far too broad for short lived access so require at
+	// least one.
+	if len(cert.ValidPrincipals) == 0 {
+		return nil, ErrSSHCertNoPrincipals
+	}
+
+	checker := gossh.CertChecker{
+		SupportedCriticalOptions: []string{
+			SSHCertOptionForceCommand,
+			SSHCertOptionSourceAddress,
+		},
+	}
+
+	// CheckCert validates principals, the validity window, critical
+	// options and the CA signature.
+	err := checker.CheckCert(user, cert)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceAddress, ok := cert.CriticalOptions[SSHCertOptionSourceAddress]
+	if ok {
+		err = checkSourceAddress(remoteAddr, sourceAddress)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	forceCommand, ok := cert.CriticalOptions[SSHCertOptionForceCommand]
+	if ok {
+		err = checkForceCommand(forceCommand)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, permitPTY := cert.Extensions[SSHCertExtensionPermitPTY]
+
+	return &SSHOptions{
+		ForceCommand: forceCommand,
+		NoPTY:        !permitPTY,
+	}, nil
+}
+
+// checkSourceAddress checks addr against a comma separated list of addresses
+// and CIDR ranges as used by the source-address critical option.
+func checkSourceAddress(addr net.Addr, sourceAddress string) error {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return fmt.Errorf("%w: unsupported address %v", ErrSSHCertSourceAddress, addr)
+	}
+
+	for _, entry := range strings.Split(sourceAddress, ",") {
+		entry = strings.TrimSpace(entry)
+
+		if strings.Contains(entry, "/") {
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return fmt.Errorf("%w: invalid entry %q", ErrSSHCertSourceAddress, entry)
+			}
+
+			if ipNet.Contains(tcpAddr.IP) {
+				return nil
+			}
+
+			continue
+		}
+
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return fmt.Errorf("%w: invalid entry %q", ErrSSHCertSourceAddress, entry)
+		}
+
+		if ip.Equal(tcpAddr.IP) {
+			return nil
+		}
+	}
+
+	return ErrSSHCertSourceAddress
+}
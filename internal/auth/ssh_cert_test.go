@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// newTestSSHSigner returns a signer of a new ed25519 key.
+func newTestSSHSigner(t *testing.T) gossh.Signer {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := gossh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer
+}
+
+func TestCheckSSHCertificate(t *testing.T) {
+	ca := newTestSSHSigner(t)
+	otherCA := newTestSSHSigner(t)
+	user := newTestSSHSigner(t)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		ca     gossh.Signer
+		modify func(cert *gossh.Certificate)
+		want   SSHOptions
+		err    error
+		fails  bool
+	}{
+		{name: "valid", want: SSHOptions{NoPTY: true}},
+		{
+			name: "terminal permitted",
+			modify: func(cert *gossh.Certificate) {
+				cert.Extensions = map[string]string{SSHCertExtensionPermitPTY: ""}
+			},
+		},
+		{
+			name: "forced command",
+			modify: func(cert *gossh.Certificate) {
+				cert.CriticalOptions = map[string]string{SSHCertOptionForceCommand: "ls photos"}
+			},
+			want: SSHOptions{ForceCommand: "ls photos", NoPTY: true},
+		},
+		{
+			name: "invalid forced command",
+			modify: func(cert *gossh.Certificate) {
+				cert.CriticalOptions = map[string]string{SSHCertOptionForceCommand: `ls "photos`}
+			},
+			err: ErrSSHForceCommand,
+		},
+		{
+			name: "allowed source address",
+			modify: func(cert *gossh.Certificate) {
+				cert.CriticalOptions = map[string]string{
+					SSHCertOptionSourceAddress: "198.51.100.1, 192.0.2.0/24",
+				}
+			},
+			want: SSHOptions{NoPTY: true},
+		},
+		{
+			name: "other source address",
+			modify: func(cert *gossh.Certificate) {
+				cert.CriticalOptions = map[string]string{
+					SSHCertOptionSourceAddress: "198.51.100.0/24",
+				}
+			},
+			err: ErrSSHCertSourceAddress,
+		},
+		{
+			name: "unknown critical option",
+			modify: func(cert *gossh.Certificate) {
+				cert.CriticalOptions = map[string]string{"verify-required": ""}
+			},
+			fails: true,
+		},
+		{name: "untrusted authority", ca: otherCA, err: ErrSSHCertUnknownAuthority},
+		{
+			name:   "host certificate",
+			modify: func(cert *gossh.Certificate) { cert.CertType = gossh.HostCert },
+			err:    ErrSSHCertNotUserCert,
+		},
+		{
+			name:   "no principals",
+			modify: func(cert *gossh.Certificate) { cert.ValidPrincipals = nil },
+			err:    ErrSSHCertNoPrincipals,
+		},
+		{
+			name:   "other principal",
+			modify: func(cert *gossh.Certificate) { cert.ValidPrincipals = []string{"bob"} },
+			fails:  true,
+		},
+		{
+			name: "expired",
+			modify: func(cert *gossh.Certificate) {
+				cert.ValidBefore = uint64(now.Add(-time.Minute).Unix())
+			},
+			fails: true,
+		},
+	}
+
+	for _, test := range tests {
+		cert := &gossh.Certificate{
+			Key:             user.PublicKey(),
+			CertType:        gossh.UserCert,
+			KeyId:           test.name,
+			ValidPrincipals: []string{"alice"},
+			ValidAfter:      uint64(now.Add(-time.Minute).Unix()),
+			ValidBefore:     uint64(now.Add(time.Hour).Unix()),
+		}
+
+		if test.modify != nil {
+			test.modify(cert)
+		}
+
+		signer := ca
+		if test.ca != nil {
+			signer = test.ca
+		}
+
+		err := cert.SignCert(rand.Reader, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts, err := CheckSSHCertificate(
+			cert,
+			"alice",
+			&net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 1234},
+			[]gossh.PublicKey{ca.PublicKey()},
+		)
+		if test.err != nil || test.fails {
+			if err == nil || !errors.Is(err, test.err) && test.err != nil {
+				t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+
+			continue
+		}
+
+		if !reflect.DeepEqual(*opts, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, *opts, test.want)
+		}
+	}
+}
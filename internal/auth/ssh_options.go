@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/anmitsu/go-shlex"
+	"github.com/charmbracelet/ssh"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+// InternalSFTPCommand is the force-command value that restricts a session to
+// the sftp subsystem, matching OpenSSH.
+const InternalSFTPCommand = "internal-sftp"
+
+// Options understood on authorized_keys entries. The byte- options are
+// specific to this server and are ignored by OpenSSH style tooling.
+const (
+	SSHKeyOptionCommand  = "command"
+	SSHKeyOptionFrom     = "from"
+	SSHKeyOptionRestrict = "restrict"
+	SSHKeyOptionNoPTY    = "no-pty"
+	SSHKeyOptionPTY      = "pty"
+	SSHKeyOptionRoot     = "byte-root"
+	SSHKeyOptionReadOnly = "byte-readonly"
+)
+
+var (
+	ErrSSHKeyUnknownOption = errors.New("ssh: unknown authorized key option")
+	ErrSSHKeyFrom          = errors.New("ssh: source address not allowed by authorized key")
+	ErrSSHForceCommand     = errors.New("ssh: invalid forced command")
+	ErrSSHRole             = errors.New("ssh: role cannot be enforced over ssh")
+)
+
+// SSHOptions are the restrictions that apply to an authenticated SSH session.
+type SSHOptions struct {
+	// ForceCommand, when set, is the command line the session runs whatever
+	// the client asks for, see Command.
+	ForceCommand string
+
+	// From is an OpenSSH pattern list of client addresses allowed to use
+	// the key.
+	From string
+
+	// NoPTY denies pseudo terminal allocation, which rules out the
+	// interactive browser.
+	NoPTY bool
+
+	// Root confines the session to this directory of the storage root.
+	Root string
+
+	// ReadOnly denies every operation that modifies storage.
+	ReadOnly bool
+
+	// Restricted limits the session to Grants, the paths granted to the
+	// device of the key, see storage.Granted. A restricted session without
+	// grants sees nothing.
+	Restricted bool
+	Grants     []storage.Grant
+}
+
+// SSHRoleOptions returns the options enforcing role on the sessions of the
+// keys of a device.
+// NB: sessions cannot be limited to adding files, so roles that cannot read
+// files cannot use ssh at all.
+func SSHRoleOptions(role Role) (*SSHOptions, error) {
+	if !role.Grants(ScopeRead) {
+		return nil, fmt.Errorf("%w: %q", ErrSSHRole, role)
+	}
+
+	return &SSHOptions{ReadOnly: !role.Grants(ScopeWrite)}, nil
+}
+
+// AllowsSFTP reports whether the session may use the sftp subsystem.
+func (o SSHOptions) AllowsSFTP() bool {
+	return o.ForceCommand == "" || o.ForceCommand == InternalSFTPCommand
+}
+
+// AllowsInteractive reports whether the session may open the interactive
+// browser, which needs a terminal and no forced command.
+func (o SSHOptions) AllowsInteractive() bool {
+	return o.ForceCommand == "" && !o.NoPTY
+}
+
+// Command returns the command line the session runs when the client asks to
+// run cmd. Like OpenSSH, a forced command replaces whatever the client asked
+// for, arguments included, except for sessions forced to use sftp, which
+// AllowsCommand refuses every command.
+func (o SSHOptions) Command(cmd []string) []string {
+	if o.ForceCommand == "" || o.ForceCommand == InternalSFTPCommand {
+		return cmd
+	}
+
+	forced, err := shlex.Split(o.ForceCommand, true)
+	if err != nil {
+		// NB: options are checked when they are parsed, so this is never
+		// a command that runs.
+		return []string{o.ForceCommand}
+	}
+
+	return forced
+}
+
+// AllowsCommand reports whether the session may exec the whole command line
+// cmd, which is only the forced command if there is one, see Command.
+func (o SSHOptions) AllowsCommand(cmd []string) bool {
+	switch o.ForceCommand {
+	case "":
+		return true
+	case InternalSFTPCommand:
+		return false
+	}
+
+	return slices.Equal(cmd, o.Command(cmd))
+}
+
+// checkForceCommand returns an error if command cannot be split into a
+// command line.
+func checkForceCommand(command string) error {
+	cmd, err := shlex.Split(command, true)
+	if err != nil || len(cmd) == 0 {
+		return fmt.Errorf("%w: %q", ErrSSHForceCommand, command)
+	}
+
+	return nil
+}
+
+// ParseSSHKeyOptions parses the options of an authorized_keys entry as
+// returned by ssh.ParseAuthorizedKey. Unknown options are rejected rather
+// than ignored so a typo never silently widens access.
+func ParseSSHKeyOptions(options []string) (*SSHOptions, error) {
+	var opts SSHOptions
+
+	for _, option := range options {
+		name, value, hasValue := strings.Cut(option, "=")
+		name = strings.ToLower(name)
+
+		if hasValue {
+			unquoted, err := strconv.Unquote(value)
+			if err == nil {
+				value = unquoted
+			}
+		}
+
+		switch {
+		case name == SSHKeyOptionCommand && hasValue:
+			err := checkForceCommand(value)
+			if err != nil {
+				return nil, err
+			}
+
+			opts.ForceCommand = value
+		case name == SSHKeyOptionFrom && hasValue:
+			opts.From = value
+		case name == SSHKeyOptionRestrict && !hasValue:
+			opts.NoPTY = true
+		case name == SSHKeyOptionNoPTY && !hasValue:
+			opts.NoPTY = true
+		case name == SSHKeyOptionPTY && !hasValue:
+			// NB: pty re-enables terminal allocation after restrict.
+			opts.NoPTY = false
+		case name == SSHKeyOptionRoot && hasValue:
+			opts.Root = path.Clean("/" + value)
+		case name == SSHKeyOptionReadOnly && !hasValue:
+			opts.ReadOnly = true
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrSSHKeyUnknownOption, option)
+		}
+	}
+
+	return &opts, nil
+}
+
+// CheckFrom checks addr against an OpenSSH from= pattern list. Entries are
+// comma separated addresses, wildcard patterns or CIDR ranges, and may be
+// negated with "!". A negated match always denies.
+func CheckFrom(addr net.Addr, patterns string) error {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return fmt.Errorf("%w: unsupported address %v", ErrSSHKeyFrom, addr)
+	}
+
+	ip := tcpAddr.IP.String()
+	allowed := false
+
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+
+		var matched bool
+
+		if strings.Contains(pattern, "/") {
+			_, ipNet, err := net.ParseCIDR(pattern)
+			if err != nil {
+				return fmt.Errorf("%w: invalid pattern %q", ErrSSHKeyFrom, pattern)
+			}
+
+			matched = ipNet.Contains(tcpAddr.IP)
+		} else {
+			m, err := path.Match(pattern, ip)
+			if err != nil {
+				return fmt.Errorf("%w: invalid pattern %q", ErrSSHKeyFrom, pattern)
+			}
+
+			matched = m
+		}
+
+		if matched && negated {
+			return ErrSSHKeyFrom
+		}
+
+		if matched {
+			allowed = true
+		}
+	}
+
+	if !allowed {
+		return ErrSSHKeyFrom
+	}
+
+	return nil
+}
+
+// SSHPty is an ssh.PtyCallback that refuses terminal allocation to sessions
+// whose key carries no-pty or restrict.
+func SSHPty(ctx ssh.Context, _ ssh.Pty) bool {
+	return !SSHOptionsFromContext(ctx).NoPTY
+}
+
+type sshOptionsKey struct{}
+
+func SSHOptionsFromContext(ctx context.Context) SSHOptions {
+	opts, ok := ctx.Value(sshOptionsKey{}).(*SSHOptions)
+	if !ok {
+		return SSHOptions{}
+	}
+
+	return *opts
+}
+
+func SSHContextWithOptions(ctx ssh.Context, opts *SSHOptions) {
+	// ssh.Context is a weird mutable version of context.Context
+	ctx.SetValue(sshOptionsKey{}, opts)
+}
@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestSSHOptionsCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		force   string
+		cmd     []string
+		want    []string
+		allowed bool
+	}{
+		{
+			name:    "no forced command",
+			cmd:     []string{"ls", "/photos"},
+			want:    []string{"ls", "/photos"},
+			allowed: true,
+		},
+		{
+			name:    "forced command without arguments",
+			force:   "ls",
+			cmd:     []string{"ls", "/secret"},
+			want:    []string{"ls"},
+			allowed: true,
+		},
+		{
+			name:    "forced command with arguments",
+			force:   "du photos",
+			cmd:     []string{"du"},
+			want:    []string{"du", "photos"},
+			allowed: true,
+		},
+		{
+			name:    "other command",
+			force:   "du photos",
+			cmd:     []string{"rm", "-r", "/"},
+			want:    []string{"du", "photos"},
+			allowed: true,
+		},
+		{
+			name:    "no command",
+			force:   "du photos",
+			want:    []string{"du", "photos"},
+			allowed: true,
+		},
+		{
+			name:    "quoted arguments",
+			force:   `cat "my photos/a.jpg"`,
+			cmd:     []string{"cat", "my", "photos/a.jpg"},
+			want:    []string{"cat", "my photos/a.jpg"},
+			allowed: true,
+		},
+		{
+			name:  "sftp only",
+			force: InternalSFTPCommand,
+			cmd:   []string{"ls"},
+			want:  []string{"ls"},
+		},
+	}
+
+	for _, test := range tests {
+		opts := SSHOptions{ForceCommand: test.force}
+
+		got := opts.Command(test.cmd)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+
+		if opts.AllowsCommand(got) != test.allowed {
+			t.Errorf("%s: allowed %q, want %v", test.name, got, test.allowed)
+		}
+	}
+}
+
+func TestSSHOptionsAllowsCommand(t *testing.T) {
+	tests := []struct {
+		force   string
+		cmd     []string
+		allowed bool
+	}{
+		{cmd: []string{"rm", "/f"}, allowed: true},
+		{force: "ls", cmd: []string{"ls"}, allowed: true},
+		{force: "ls", cmd: []string{"ls", "/secret"}},
+		{force: "du photos", cmd: []string{"du", "photos"}, allowed: true},
+		{force: "du photos", cmd: []string{"du"}},
+		{force: "du photos", cmd: []string{"du", "photos", "videos"}},
+		{force: "du photos", cmd: []string{"du photos"}},
+		{force: InternalSFTPCommand, cmd: []string{InternalSFTPCommand}},
+	}
+
+	for _, test := range tests {
+		opts := SSHOptions{ForceCommand: test.force}
+
+		if opts.AllowsCommand(test.cmd) != test.allowed {
+			t.Errorf(
+				"force-command %q allowed %q, want %v",
+				test.force, test.cmd, test.allowed,
+			)
+		}
+	}
+}
+
+func TestParseSSHKeyOptionsCommand(t *testing.T) {
+	tests := []struct {
+		option string
+		want   string
+		err    error
+	}{
+		{option: `command="du photos"`, want: "du photos"},
+		{option: `command="cat \"my photos\""`, want: `cat "my photos"`},
+		{option: `command="cat \"my photos"`, err: ErrSSHForceCommand},
+		{option: `command=""`, err: ErrSSHForceCommand},
+	}
+
+	for _, test := range tests {
+		opts, err := ParseSSHKeyOptions([]string{test.option})
+		if !errors.Is(err, test.err) {
+			t.Errorf("ParseSSHKeyOptions(%q) = %v, want %v", test.option, err, test.err)
+
+			continue
+		}
+
+		if err == nil && opts.ForceCommand != test.want {
+			t.Errorf(
+				"ParseSSHKeyOptions(%q) forces %q, want %q",
+				test.option, opts.ForceCommand, test.want,
+			)
+		}
+	}
+}
+
+func TestParseSSHKeyOptions(t *testing.T) {
+	tests := []struct {
+		options []string
+		want    SSHOptions
+		err     error
+	}{
+		{},
+		{options: []string{`byte-root="photos/../2024/"`}, want: SSHOptions{Root: "/2024"}},
+		{options: []string{`byte-root="/../.."`}, want: SSHOptions{Root: "/"}},
+		{options: []string{"byte-readonly"}, want: SSHOptions{ReadOnly: true}},
+		{options: []string{`from="192.0.2.*"`}, want: SSHOptions{From: "192.0.2.*"}},
+		{options: []string{"restrict"}, want: SSHOptions{NoPTY: true}},
+		{options: []string{"NO-PTY"}, want: SSHOptions{NoPTY: true}},
+		{options: []string{"restrict", "pty"}},
+		{options: []string{"byte-readonly=yes"}, err: ErrSSHKeyUnknownOption},
+		{options: []string{"byte-root"}, err: ErrSSHKeyUnknownOption},
+		{options: []string{"no-port-forwarding"}, err: ErrSSHKeyUnknownOption},
+	}
+
+	for _, test := range tests {
+		opts, err := ParseSSHKeyOptions(test.options)
+		if !errors.Is(err, test.err) {
+			t.Errorf("ParseSSHKeyOptions(%q) = %v, want %v", test.options, err, test.err)
+
+			continue
+		}
+
+		if err == nil && !reflect.DeepEqual(*opts, test.want) {
+			t.Errorf("ParseSSHKeyOptions(%q) = %+v, want %+v", test.options, *opts, test.want)
+		}
+	}
+}
+
+func TestCheckFrom(t *testing.T) {
+	tests := []struct {
+		patterns string
+		err      error
+	}{
+		{patterns: "192.0.2.1"},
+		{patterns: "192.0.2.*"},
+		{patterns: "198.51.100.1, 192.0.2.0/24"},
+		{patterns: "192.0.2.*,!192.0.2.2"},
+		{patterns: "198.51.100.1", err: ErrSSHKeyFrom},
+		{patterns: "192.0.2.*,!192.0.2.1", err: ErrSSHKeyFrom},
+		{patterns: "!192.0.2.1,192.0.2.0/24", err: ErrSSHKeyFrom},
+		{patterns: "!198.51.100.1", err: ErrSSHKeyFrom},
+		{patterns: "192.0.2.0/33", err: ErrSSHKeyFrom},
+	}
+
+	addr := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 1234}
+
+	for _, test := range tests {
+		err := CheckFrom(addr, test.patterns)
+		if !errors.Is(err, test.err) {
+			t.Errorf("CheckFrom(%v, %q) = %v, want %v", addr, test.patterns, err, test.err)
+		}
+	}
+
+	err := CheckFrom(&net.UnixAddr{Name: "/run/byte.sock", Net: "unix"}, "*")
+	if !errors.Is(err, ErrSSHKeyFrom) {
+		t.Errorf("CheckFrom(unix address) = %v, want %v", err, ErrSSHKeyFrom)
+	}
+}
+
+func TestSSHRoleOptions(t *testing.T) {
+	tests := []struct {
+		role     Role
+		readOnly bool
+		err      error
+	}{
+		{role: RoleAdmin},
+		{role: RoleMember},
+		{role: RoleReadOnly, readOnly: true},
+		{role: RoleUploadOnly, err: ErrSSHRole},
+		{role: Role("unknown"), err: ErrSSHRole},
+	}
+
+	for _, test := range tests {
+		opts, err := SSHRoleOptions(test.role)
+		if !errors.Is(err, test.err) {
+			t.Errorf("SSHRoleOptions(%q) = %v, want %v", test.role, err, test.err)
+
+			continue
+		}
+
+		if err == nil && opts.ReadOnly != test.readOnly {
+			t.Errorf(
+				"SSHRoleOptions(%q) read only = %v, want %v",
+				test.role, opts.ReadOnly, test.readOnly,
+			)
+		}
+	}
+}
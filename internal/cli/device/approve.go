@@ -0,0 +1,165 @@
+package device
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newApproveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "approve [enrollment-id]",
+		Long: `approve or deny an enrollment request
+
+Without an enrollment id the pending requests are listed. Check that the
+fingerprint matches the one printed by "byte device enroll" before approving.`,
+		Run:  approve,
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	cmd.Flags().Bool("deny", false, "deny the enrollment request")
+	cmd.Flags().String(
+		"role",
+		string(auth.RoleMember),
+		"role of the device: admin, member, read-only or upload-only",
+	)
+
+	return cmd
+}
+
+func approve(cmd *cobra.Command, args []string) {
+	deny, err := cmd.Flags().GetBool("deny")
+	if err != nil {
+		fmt.Println("failed to read deny flag:", err)
+
+		return
+	}
+
+	roleFlag, err := cmd.Flags().GetString("role")
+	if err != nil {
+		fmt.Println("failed to read role flag:", err)
+
+		return
+	}
+
+	role, err := auth.ParseRole(roleFlag)
+	if err != nil {
+		fmt.Println("invalid role:", err)
+
+		return
+	}
+
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	if len(args) == 0 {
+		listEnrollments(cmd, c)
+
+		return
+	}
+
+	if deny {
+		_, err = c.Devices.DenyEnrollment(
+			cmd.Context(),
+			connect.NewRequest(&devicesv1.DenyEnrollmentRequest{
+				EnrollmentId: args[0],
+			}),
+		)
+		if err != nil {
+			fmt.Println("failed to deny enrollment:", err)
+
+			return
+		}
+
+		fmt.Println("Enrollment denied")
+
+		return
+	}
+
+	resp, err := c.Devices.ApproveEnrollment(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.ApproveEnrollmentRequest{
+			EnrollmentId: args[0],
+			Role:         roleToProto(role),
+		}),
+	)
+	if err != nil {
+		fmt.Println("failed to approve enrollment:", err)
+
+		return
+	}
+
+	fmt.Println("Enrollment approved")
+	fmt.Println("Device ID:  ", resp.Msg.GetEnrollment().GetDeviceId())
+	fmt.Println("Device Role:", role)
+}
+
+func listEnrollments(cmd *cobra.Command, c *client.Client) {
+	resp, err := c.Devices.ListEnrollments(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.ListEnrollmentsRequest{}),
+	)
+	if err != nil {
+		fmt.Println("failed to list enrollments:", err)
+
+		return
+	}
+
+	if len(resp.Msg.GetEnrollments()) == 0 {
+		fmt.Println("No pending enrollment requests")
+
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+
+	_, err = fmt.Fprintln(w, "ID\tName\tPlatform\tFingerprint\tAddress\tExpires")
+	if err != nil {
+		fmt.Println("failed to write table header")
+
+		return
+	}
+
+	for _, enrollment := range resp.Msg.GetEnrollments() {
+		row := strings.Join([]string{
+			enrollment.GetId(),
+			enrollment.GetName(),
+			enrollment.GetPlatform(),
+			enrollment.GetFingerprint(),
+			enrollment.GetRemoteAddr(),
+			enrollment.GetExpireTime().AsTime().Local().Format(time.DateTime),
+		}, "\t")
+
+		_, err = fmt.Fprintln(w, row)
+		if err != nil {
+			fmt.Println("failed to write table rows")
+
+			return
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		fmt.Println("failed to write table")
+	}
+}
@@ -0,0 +1,80 @@
+package device
+
+import (
+	"fmt"
+	"os"
+
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+func newBanClearCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "clear [address|device]",
+		Long: `lift the ban of a peer address or device
+
+Its failed attempts are forgotten as well, so it is not banned again on its
+next failure. With --all every ban is lifted.`,
+		Run:  banClear,
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	cmd.Flags().Bool("all", false, "lift every ban")
+
+	return cmd
+}
+
+func banClear(cmd *cobra.Command, args []string) {
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		fmt.Println("failed to get flag: all")
+		os.Exit(1)
+
+		return
+	}
+
+	req := &devicesv1.ClearBansRequest{}
+
+	switch {
+	case all && len(args) == 0:
+		req.Target = &devicesv1.ClearBansRequest_All{All: true}
+	case !all && len(args) == 1:
+		// NB: device ids are UUIDs, which are never valid addresses.
+		if uuid.Validate(args[0]) == nil {
+			req.Target = &devicesv1.ClearBansRequest_DeviceId{DeviceId: args[0]}
+		} else {
+			req.Target = &devicesv1.ClearBansRequest_RemoteAddr{RemoteAddr: args[0]}
+		}
+	default:
+		fmt.Println("either an address or device, or --all is required")
+
+		return
+	}
+
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	resp, err := c.Devices.ClearBans(cmd.Context(), connect.NewRequest(req))
+	if err != nil {
+		fmt.Println("failed to clear bans:", err)
+
+		return
+	}
+
+	fmt.Println("Cleared:", resp.Msg.GetCleared())
+}
@@ -0,0 +1,90 @@
+package device
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newBanListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "list",
+		Long: "list the banned peer addresses and devices",
+		Run:  banList,
+	}
+
+	return cmd
+}
+
+func banList(cmd *cobra.Command, args []string) {
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	resp, err := c.Devices.ListBans(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.ListBansRequest{}),
+	)
+	if err != nil {
+		fmt.Println("failed to list bans:", err)
+
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+
+	_, err = fmt.Fprintln(w, "Address\tDevice\tUntil\tBans")
+	if err != nil {
+		fmt.Println("failed to write table header")
+
+		return
+	}
+
+	for _, ban := range resp.Msg.GetBans() {
+		row := strings.Join([]string{
+			orDash(ban.GetRemoteAddr()),
+			orDash(ban.GetDeviceId()),
+			ban.GetExpireTime().AsTime().Local().Format(time.DateTime),
+			strconv.Itoa(int(ban.GetCount())),
+		}, "\t")
+
+		_, err = fmt.Fprintln(w, row)
+		if err != nil {
+			fmt.Println("failed to write table rows")
+
+			return
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		fmt.Println("failed to write table")
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
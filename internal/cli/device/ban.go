@@ -0,0 +1,18 @@
+package device
+
+import "github.com/spf13/cobra"
+
+func newBanCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "ban",
+		Long: `manage the bans of peers and devices failing to authenticate
+
+Peer addresses and devices failing to authenticate too often over either the
+API or SSH are banned for a while, see authLimits in the server configuration.`,
+	}
+
+	cmd.AddCommand(newBanListCommand())
+	cmd.AddCommand(newBanClearCommand())
+
+	return cmd
+}
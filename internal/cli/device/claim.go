@@ -0,0 +1,93 @@
+package device
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/validate"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/gen/devices/v1/devicesv1connect"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newClaimCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "claim <server-url> <pairing-code>",
+		Long: `claim the credentials of a new device
+
+The pairing code is printed when the device is created, and can only be
+claimed once. The credentials are saved to the config file, which must not
+exist yet.`,
+		Run:  claim,
+		Args: cobra.ExactArgs(2),
+	}
+
+	return cmd
+}
+
+func claim(cmd *cobra.Command, args []string) {
+	serverURL := args[0]
+	code := args[1]
+
+	// NB: the name only matters if the device was created without one, so
+	// failing to get it is not worth failing the claim.
+	name, _ := os.Hostname()
+
+	validateInterceptor, err := validate.NewInterceptor()
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	// NB: the device has no credentials yet, so the pairing service is the
+	// only one it can call.
+	pairing := devicesv1connect.NewPairingServiceClient(
+		http.DefaultClient,
+		serverURL,
+		connect.WithGRPC(),
+		connect.WithInterceptors(validateInterceptor),
+	)
+
+	resp, err := pairing.ClaimDevice(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.ClaimDeviceRequest{
+			PairingCode: code,
+			Name:        name,
+			Platform:    runtime.GOOS,
+		}),
+	)
+	if err != nil {
+		fmt.Println("failed to claim device:", err)
+
+		return
+	}
+
+	err = config.SaveClient(config.Client{
+		ID:            resp.Msg.GetDeviceId(),
+		Secret:        base64.StdEncoding.EncodeToString(resp.Msg.GetDeviceKey()),
+		ServerURL:     serverURL,
+		ServerID:      resp.Msg.GetServerId(),
+		KeyVersion:    int(resp.Msg.GetKeyVersion()),
+		KeyGeneration: int(resp.Msg.GetKeyGeneration()),
+	})
+	if err != nil {
+		// NB: the pairing code cannot be claimed again, so the credentials
+		// are printed for them not to be lost.
+		fmt.Println("failed to save config:", err)
+		fmt.Println("Device ID:    ", resp.Msg.GetDeviceId())
+		fmt.Println("Device Secret:", base64.StdEncoding.EncodeToString(resp.Msg.GetDeviceKey()))
+		fmt.Println("Key Version:  ", resp.Msg.GetKeyVersion())
+		fmt.Println("Key Gen:      ", resp.Msg.GetKeyGeneration())
+		fmt.Println("Server ID:    ", resp.Msg.GetServerId())
+
+		return
+	}
+
+	fmt.Println("Device claimed:", resp.Msg.GetDeviceId())
+}
@@ -9,8 +9,17 @@ func NewCommand() *cobra.Command {
 	}
 
 	cmd.AddCommand(newCreateCommand())
+	cmd.AddCommand(newClaimCommand())
+	cmd.AddCommand(newEnrollCommand())
+	cmd.AddCommand(newApproveCommand())
 	cmd.AddCommand(newListCommand())
 	cmd.AddCommand(newDeleteCommand())
+	cmd.AddCommand(newSSHKeyCommand())
+	cmd.AddCommand(newGrantCommand())
+	cmd.AddCommand(newBanCommand())
+	cmd.AddCommand(newRotateKeyCommand())
+	cmd.AddCommand(newRegisterKeyCommand())
+	cmd.AddCommand(newWebLoginCommand())
 
 	return cmd
 }
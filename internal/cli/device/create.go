@@ -1,15 +1,16 @@
 package device
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"connectrpc.com/connect"
 	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/auth"
 	"github.com/cmp0st/byte/internal/client"
 	"github.com/cmp0st/byte/internal/config"
-	"github.com/cmp0st/byte/internal/key"
 	qrcode "github.com/skip2/go-qrcode"
 	"github.com/spf13/cobra"
 )
@@ -21,63 +22,74 @@ func newCreateCommand() *cobra.Command {
 		Run:  create,
 	}
 
+	cmd.Flags().String(
+		"role",
+		string(auth.RoleMember),
+		"role of the device: admin, member, read-only or upload-only",
+	)
+	cmd.Flags().String("name", "", "name of the device, defaults to the one it reports")
+
 	return cmd
 }
 
 func create(cmd *cobra.Command, args []string) {
-	conf, err := config.LoadClient()
+	roleFlag, err := cmd.Flags().GetString("role")
 	if err != nil {
-		fmt.Println("failed to load client config")
+		fmt.Println("failed to read role flag:", err)
 
 		return
 	}
 
-	c, err := client.New(*conf)
+	role, err := auth.ParseRole(roleFlag)
 	if err != nil {
-		fmt.Println("failed to initialize client")
+		fmt.Println("invalid role:", err)
 
 		return
 	}
 
-	resp, err := c.Devices.CreateDevice(
-		cmd.Context(),
-		connect.NewRequest(&devicesv1.CreateDeviceRequest{}),
-	)
+	name, err := cmd.Flags().GetString("name")
 	if err != nil {
-		fmt.Println("failed to create device:", err)
+		fmt.Println("failed to read name flag:", err)
 
 		return
 	}
 
-	rawKey, err := base64.StdEncoding.DecodeString(conf.Secret)
+	conf, err := config.LoadClient()
 	if err != nil {
-		fmt.Println("failed to load client secret", err)
+		fmt.Println("failed to load client config")
 
 		return
 	}
 
-	keychain, err := key.NewClientChain(rawKey, conf.ID)
+	c, err := client.New(*conf)
 	if err != nil {
-		fmt.Println("failed to derive keychain", err)
+		fmt.Println("failed to initialize client")
 
 		return
 	}
 
-	plaintext, err := keychain.DecryptKey(resp.Msg.GetEncryptedDeviceKey())
+	resp, err := c.Devices.CreateDevice(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.CreateDeviceRequest{
+			Role: roleToProto(role),
+			Name: name,
+		}),
+	)
 	if err != nil {
-		fmt.Println("failed to decrypt device key:", err)
+		fmt.Println("failed to create device:", err)
 
 		return
 	}
 
-	deviceConfig := map[string]string{
-		"serverUrl": conf.ServerURL,
-		"deviceId":  resp.Msg.GetId(),
-		"secret":    base64.StdEncoding.EncodeToString(plaintext),
+	// NB: the new device claims its credentials with the pairing code, so
+	// they are never shown here.
+	pairingConfig := map[string]string{
+		"serverUrl":   conf.ServerURL,
+		"pairingCode": resp.Msg.GetPairingCode(),
 	}
 
 	// Generate QR code with low recovery level for smaller size
-	configJSON, err := json.Marshal(deviceConfig)
+	configJSON, err := json.Marshal(pairingConfig)
 	if err != nil {
 		fmt.Println("failed to marshal config:", err)
 
@@ -91,10 +103,31 @@ func create(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	expiresAt := resp.Msg.GetPairingCodeExpireTime().AsTime().Local()
+
 	fmt.Println("\nDevice created successfully!")
 	fmt.Println("Device ID:    ", resp.Msg.GetId())
-	fmt.Println("Device Secret:", base64.StdEncoding.EncodeToString(plaintext))
+	fmt.Println("Device Role:  ", role)
+	fmt.Println("Pairing Code: ", resp.Msg.GetPairingCode())
+	fmt.Println("Expires:      ", expiresAt.Format(time.RFC3339))
 	fmt.Println("Server URL:   ", conf.ServerURL)
-	fmt.Println("\nScan this QR code with the Byte iOS app:")
+	fmt.Println("\nScan this QR code with the Byte iOS app, or run:")
+	fmt.Println("\n  byte device claim", conf.ServerURL, resp.Msg.GetPairingCode())
+	fmt.Println()
 	fmt.Println(qr.ToString(false))
 }
+
+// roleToProto names role the way the devices API does, e.g. upload-only is
+// ROLE_UPLOAD_ONLY.
+func roleToProto(role auth.Role) devicesv1.Role {
+	name := "ROLE_" + strings.ToUpper(strings.ReplaceAll(string(role), "-", "_"))
+
+	return devicesv1.Role(devicesv1.Role_value[name])
+}
+
+// roleFromProto is the inverse of roleToProto.
+func roleFromProto(role devicesv1.Role) auth.Role {
+	name := strings.TrimPrefix(role.String(), "ROLE_")
+
+	return auth.Role(strings.ToLower(strings.ReplaceAll(name, "_", "-")))
+}
@@ -0,0 +1,174 @@
+package device
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"connectrpc.com/connect"
+	"connectrpc.com/validate"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/gen/devices/v1/devicesv1connect"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/key"
+	"github.com/spf13/cobra"
+)
+
+// How often an enrollment request is checked while waiting for an admin.
+const enrollmentPollInterval = 3 * time.Second
+
+func newEnrollCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "enroll <server-url>",
+		Long: `request to enroll this device
+
+An Ed25519 key pair is generated and its public key sent to the server with an
+enrollment request. An admin approves or denies it with "byte device approve",
+after checking that the fingerprint printed here matches. Once approved, the
+device signs its tokens with the secret key, which never leaves it, and the
+configuration is saved to the config file, which must not exist yet.`,
+		Run:  enroll,
+		Args: cobra.ExactArgs(1),
+	}
+
+	cmd.Flags().String("name", "", "name of the device, defaults to the hostname")
+
+	return cmd
+}
+
+func enroll(cmd *cobra.Command, args []string) {
+	serverURL := args[0]
+
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		fmt.Println("failed to read name flag:", err)
+
+		return
+	}
+
+	if name == "" {
+		name, err = os.Hostname()
+		if err != nil {
+			fmt.Println("failed to get hostname, set --name:", err)
+
+			return
+		}
+	}
+
+	// NB: the signing key is only saved once the request is approved, so
+	// fail before asking an admin to approve it.
+	if _, err := config.LoadClient(); err == nil {
+		fmt.Println("this device is already configured")
+
+		return
+	}
+
+	validateInterceptor, err := validate.NewInterceptor()
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	pairing := devicesv1connect.NewPairingServiceClient(
+		http.DefaultClient,
+		serverURL,
+		connect.WithGRPC(),
+		connect.WithInterceptors(validateInterceptor),
+	)
+
+	signingKey := paseto.NewV4AsymmetricSecretKey()
+
+	resp, err := pairing.RequestEnrollment(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.RequestEnrollmentRequest{
+			Name:      name,
+			Platform:  runtime.GOOS,
+			PublicKey: signingKey.Public().ExportBytes(),
+		}),
+	)
+	if err != nil {
+		fmt.Println("failed to request enrollment:", err)
+
+		return
+	}
+
+	enrollment := resp.Msg.GetEnrollment()
+
+	fmt.Println("Enrollment ID:", enrollment.GetId())
+	fmt.Println("Fingerprint:  ", key.Fingerprint(signingKey.Public().ExportBytes()))
+	fmt.Println("Expires:      ", enrollment.GetExpireTime().AsTime().Local().Format(time.DateTime))
+	fmt.Println("\nWaiting for an admin to run:")
+	fmt.Println("\n  byte device approve", enrollment.GetId())
+
+	ticker := time.NewTicker(enrollmentPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cmd.Context().Done():
+			fmt.Println("enrollment canceled")
+
+			return
+		case <-ticker.C:
+		}
+
+		resp, err := pairing.GetEnrollment(
+			cmd.Context(),
+			connect.NewRequest(&devicesv1.GetEnrollmentRequest{
+				EnrollmentId: enrollment.GetId(),
+			}),
+		)
+		if err != nil {
+			fmt.Println("failed to get enrollment:", err)
+
+			return
+		}
+
+		switch resp.Msg.GetEnrollment().GetState() {
+		case devicesv1.EnrollmentState_ENROLLMENT_STATE_PENDING:
+			continue
+		case devicesv1.EnrollmentState_ENROLLMENT_STATE_APPROVED:
+		case devicesv1.EnrollmentState_ENROLLMENT_STATE_DENIED:
+			fmt.Println("enrollment denied")
+
+			return
+		case devicesv1.EnrollmentState_ENROLLMENT_STATE_EXPIRED:
+			fmt.Println("enrollment expired")
+
+			return
+		default:
+			fmt.Println("unexpected enrollment state:", resp.Msg.GetEnrollment().GetState())
+
+			return
+		}
+
+		deviceID := resp.Msg.GetEnrollment().GetDeviceId()
+
+		err = config.SaveClient(config.Client{
+			ID:         deviceID,
+			ServerURL:  serverURL,
+			ServerID:   resp.Msg.GetServerId(),
+			KeyVersion: int(resp.Msg.GetKeyVersion()),
+			SigningKey: signingKey.ExportHex(),
+		})
+		if err != nil {
+			// NB: the device cannot authenticate without the signing key, so
+			// it is printed in case it cannot be saved.
+			fmt.Println("failed to save config:", err)
+			fmt.Println("Device ID:  ", deviceID)
+			fmt.Println("Signing Key:", signingKey.ExportHex())
+			fmt.Println("Key Version:", resp.Msg.GetKeyVersion())
+			fmt.Println("Server ID:  ", resp.Msg.GetServerId())
+
+			return
+		}
+
+		fmt.Println("Device enrolled:", deviceID)
+
+		return
+	}
+}
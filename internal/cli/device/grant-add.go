@@ -0,0 +1,102 @@
+package device
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newGrantAddCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "add <device> <path>",
+		Long: "grant a device access to a path, limiting it to the paths it is granted",
+		Run:  grantAdd,
+		Args: cobra.ExactArgs(2),
+	}
+
+	cmd.Flags().Bool("read", false, "allow listing and reading files")
+	cmd.Flags().Bool("write", false, "allow creating and modifying files")
+	cmd.Flags().Bool("delete", false, "allow deleting files")
+
+	return cmd
+}
+
+func grantAdd(cmd *cobra.Command, args []string) {
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	var access [3]bool
+
+	for i, flag := range []string{"read", "write", "delete"} {
+		access[i], err = cmd.Flags().GetBool(flag)
+		if err != nil {
+			fmt.Println("failed to get flag:", flag)
+			os.Exit(1)
+
+			return
+		}
+	}
+
+	resp, err := c.Devices.GrantPath(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.GrantPathRequest{
+			Grant: &devicesv1.PathGrant{
+				DeviceId: args[0],
+				Prefix:   args[1],
+				Read:     access[0],
+				Write:    access[1],
+				Delete:   access[2],
+			},
+		}),
+	)
+	if err != nil {
+		fmt.Println("failed to grant path:", err)
+
+		return
+	}
+
+	grant := resp.Msg.GetGrant()
+
+	fmt.Printf(
+		"device %s granted %s on %s\n",
+		grant.GetDeviceId(),
+		grantAccess(grant),
+		grant.GetPrefix(),
+	)
+}
+
+// grantAccess describes what a grant allows, e.g. "read,write".
+func grantAccess(grant *devicesv1.PathGrant) string {
+	var access []string
+
+	if grant.GetRead() {
+		access = append(access, "read")
+	}
+
+	if grant.GetWrite() {
+		access = append(access, "write")
+	}
+
+	if grant.GetDelete() {
+		access = append(access, "delete")
+	}
+
+	return strings.Join(access, ",")
+}
@@ -0,0 +1,91 @@
+package device
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newGrantListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "list",
+		Long: "list the paths devices are granted",
+		Run:  grantList,
+	}
+
+	cmd.Flags().String("device", "", "only list grants of this device")
+
+	return cmd
+}
+
+func grantList(cmd *cobra.Command, args []string) {
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	device, err := cmd.Flags().GetString("device")
+	if err != nil {
+		fmt.Println("failed to get flag: device")
+		os.Exit(1)
+
+		return
+	}
+
+	resp, err := c.Devices.ListPathGrants(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.ListPathGrantsRequest{
+			DeviceId: device,
+		}),
+	)
+	if err != nil {
+		fmt.Println("failed to list path grants:", err)
+
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+
+	_, err = fmt.Fprintln(w, "Device\tPath\tAccess")
+	if err != nil {
+		fmt.Println("failed to write table header")
+
+		return
+	}
+
+	for _, grant := range resp.Msg.GetGrants() {
+		row := strings.Join([]string{
+			grant.GetDeviceId(),
+			grant.GetPrefix(),
+			grantAccess(grant),
+		}, "\t")
+
+		_, err = fmt.Fprintln(w, row)
+		if err != nil {
+			fmt.Println("failed to write table rows")
+
+			return
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		fmt.Println("failed to write table")
+	}
+}
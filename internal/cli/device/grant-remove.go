@@ -0,0 +1,53 @@
+package device
+
+import (
+	"fmt"
+
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newGrantRemoveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "remove <device> <path>",
+		Long: "revoke the grant of a device for a path, a device without grants left sees nothing",
+		Run:  grantRemove,
+		Args: cobra.ExactArgs(2),
+	}
+
+	return cmd
+}
+
+func grantRemove(cmd *cobra.Command, args []string) {
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	_, err = c.Devices.RevokePath(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.RevokePathRequest{
+			DeviceId: args[0],
+			Prefix:   args[1],
+		}),
+	)
+	if err != nil {
+		fmt.Println("failed to revoke path:", err)
+
+		return
+	}
+
+	fmt.Printf("grant of device %s on %s revoked\n", args[0], args[1])
+}
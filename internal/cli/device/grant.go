@@ -0,0 +1,16 @@
+package device
+
+import "github.com/spf13/cobra"
+
+func newGrantCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "grant",
+		Long: "manage the paths devices are limited to",
+	}
+
+	cmd.AddCommand(newGrantAddCommand())
+	cmd.AddCommand(newGrantListCommand())
+	cmd.AddCommand(newGrantRemoveCommand())
+
+	return cmd
+}
@@ -2,19 +2,28 @@ package device
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"connectrpc.com/connect"
 	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
 	"github.com/cmp0st/byte/internal/client"
 	"github.com/cmp0st/byte/internal/config"
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func newListCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:  "list",
-		Long: "list devices",
-		Run:  list,
+		Use: "list",
+		Long: `list devices
+
+Devices are listed with the name and platform they were created or enrolled
+with, and when and from where they last authenticated. The last-seen time is
+only recorded every few minutes, so it can be that late.`,
+		Run: list,
 	}
 
 	return cmd
@@ -45,5 +54,51 @@ func list(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	fmt.Println(resp)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+
+	_, err = fmt.Fprintln(w, "ID\tName\tRole\tPlatform\tVersion\tCreated\tLast Seen\tLast IP")
+	if err != nil {
+		fmt.Println("failed to write table header")
+
+		return
+	}
+
+	for _, device := range resp.Msg.GetDevices() {
+		name := device.GetName()
+		if device.GetId() == conf.ID {
+			name += " (this device)"
+		}
+
+		row := strings.Join([]string{
+			device.GetId(),
+			orDash(strings.TrimSpace(name)),
+			string(roleFromProto(device.GetRole())),
+			orDash(device.GetPlatform()),
+			orDash(device.GetAppVersion()),
+			formatTime(device.GetCreateTime()),
+			formatTime(device.GetLastSeenTime()),
+			orDash(device.GetLastSeenIp()),
+		}, "\t")
+
+		_, err = fmt.Fprintln(w, row)
+		if err != nil {
+			fmt.Println("failed to write table rows")
+
+			return
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		fmt.Println("failed to write table")
+	}
+}
+
+// formatTime formats t in local time, or as a dash if it is unset.
+func formatTime(t *timestamppb.Timestamp) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.AsTime().Local().Format(time.DateTime)
 }
@@ -0,0 +1,70 @@
+package device
+
+import (
+	"fmt"
+
+	"aidanwoods.dev/go-paseto"
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newRegisterKeyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "register-key",
+		Long: `register a public key for this device
+
+An Ed25519 key pair is generated and its public key registered with the
+server. The secret key is saved to the config file, and the device then signs
+its tokens with it. Tokens encrypted with the device secret stop working right
+away.`,
+		Run:  registerKey,
+		Args: cobra.NoArgs,
+	}
+
+	return cmd
+}
+
+func registerKey(cmd *cobra.Command, args []string) {
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	signingKey := paseto.NewV4AsymmetricSecretKey()
+
+	_, err = c.Devices.RegisterPublicKey(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.RegisterPublicKeyRequest{
+			PublicKey: signingKey.Public().ExportBytes(),
+		}),
+	)
+	if err != nil {
+		fmt.Println("failed to register public key:", err)
+
+		return
+	}
+
+	// NB: the device cannot authenticate without the signing key anymore, so
+	// it is printed in case it cannot be saved.
+	err = config.SaveClientSigningKey(signingKey.ExportHex())
+	if err != nil {
+		fmt.Println("failed to save signing key:", err)
+		fmt.Println("Signing Key:", signingKey.ExportHex())
+
+		return
+	}
+
+	fmt.Println("Public key registered and signing key saved")
+}
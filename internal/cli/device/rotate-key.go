@@ -0,0 +1,145 @@
+package device
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/key"
+	qrcode "github.com/skip2/go-qrcode"
+	"github.com/spf13/cobra"
+)
+
+func newRotateKeyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "rotate-key [device]",
+		Long: `rotate the key of a device
+
+Without a device the key of this device is rotated and saved to the config
+file. Rotating the key of another device requires an admin device, and prints
+the credentials to configure it with. The old key stops working right away.`,
+		Run:  rotateKey,
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	return cmd
+}
+
+func rotateKey(cmd *cobra.Command, args []string) {
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	var id string
+	if len(args) > 0 {
+		id = args[0]
+	}
+
+	resp, err := c.Devices.RotateDeviceKey(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.RotateDeviceKeyRequest{
+			DeviceId: id,
+		}),
+	)
+	if err != nil {
+		fmt.Println("failed to rotate device key:", err)
+
+		return
+	}
+
+	rawKey, err := base64.StdEncoding.DecodeString(conf.Secret)
+	if err != nil {
+		fmt.Println("failed to load client secret", err)
+
+		return
+	}
+
+	keychain, err := key.NewClientChain(rawKey, conf.ID)
+	if err != nil {
+		fmt.Println("failed to derive keychain", err)
+
+		return
+	}
+
+	plaintext, err := keychain.DecryptKey(resp.Msg.GetEncryptedDeviceKey())
+	if err != nil {
+		fmt.Println("failed to decrypt device key:", err)
+
+		return
+	}
+
+	secret := base64.StdEncoding.EncodeToString(plaintext)
+	keyVersion := int(resp.Msg.GetKeyVersion())
+	keyGeneration := int(resp.Msg.GetKeyGeneration())
+
+	if resp.Msg.GetDeviceId() == conf.ID {
+		// NB: the old key no longer works, so the new one is printed in case
+		// it cannot be saved.
+		err = config.SaveClientKey(secret, keyVersion, keyGeneration)
+		if err != nil {
+			fmt.Println("failed to save device key:", err)
+			fmt.Println("Device Secret:", secret)
+			fmt.Println("Key Version:  ", keyVersion)
+			fmt.Println("Key Gen:      ", keyGeneration)
+
+			return
+		}
+
+		fmt.Println("Device key rotated and saved")
+
+		return
+	}
+
+	deviceConfig := map[string]string{
+		"serverUrl":     conf.ServerURL,
+		"serverId":      resp.Msg.GetServerId(),
+		"deviceId":      resp.Msg.GetDeviceId(),
+		"secret":        secret,
+		"keyGeneration": strconv.Itoa(keyGeneration),
+	}
+
+	// NB: devices without a key version use the first one.
+	if keyVersion > key.DefaultKeyVersion {
+		deviceConfig["keyVersion"] = strconv.Itoa(keyVersion)
+	}
+
+	// Generate QR code with low recovery level for smaller size
+	configJSON, err := json.Marshal(deviceConfig)
+	if err != nil {
+		fmt.Println("failed to marshal config:", err)
+
+		return
+	}
+
+	qr, err := qrcode.New(string(configJSON), qrcode.Low)
+	if err != nil {
+		fmt.Println("failed to generate QR code:", err)
+
+		return
+	}
+
+	fmt.Println("\nDevice key rotated, the device must be reconfigured:")
+	fmt.Println("Device ID:    ", resp.Msg.GetDeviceId())
+	fmt.Println("Device Secret:", secret)
+	fmt.Println("Key Version:  ", max(keyVersion, key.DefaultKeyVersion))
+	fmt.Println("Key Gen:      ", keyGeneration)
+	fmt.Println("Server URL:   ", conf.ServerURL)
+	fmt.Println("Server ID:    ", resp.Msg.GetServerId())
+	fmt.Println("\nScan this QR code with the Byte iOS app:")
+	fmt.Println(qr.ToString(false))
+}
@@ -0,0 +1,75 @@
+package device
+
+import (
+	"fmt"
+	"os"
+
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newSSHKeyAddCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "add <public key file>",
+		Long: "register an ssh public key to a device",
+		Run:  sshKeyAdd,
+		Args: cobra.ExactArgs(1),
+	}
+
+	cmd.Flags().String("device", "", "device to register the key to (defaults to this device)")
+
+	return cmd
+}
+
+func sshKeyAdd(cmd *cobra.Command, args []string) {
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	device, err := cmd.Flags().GetString("device")
+	if err != nil {
+		fmt.Println("failed to get flag: device")
+		os.Exit(1)
+
+		return
+	}
+
+	publicKey, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Println("failed to read public key file:", err)
+
+		return
+	}
+
+	resp, err := c.Devices.AddSSHKey(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.AddSSHKeyRequest{
+			DeviceId:  device,
+			PublicKey: string(publicKey),
+		}),
+	)
+	if err != nil {
+		fmt.Println("failed to add ssh key:", err)
+
+		return
+	}
+
+	fmt.Printf(
+		"ssh key %s added to device %s\n",
+		resp.Msg.GetKey().GetFingerprint(),
+		resp.Msg.GetKey().GetDeviceId(),
+	)
+}
@@ -0,0 +1,91 @@
+package device
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newSSHKeyListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "list",
+		Long: "list ssh public keys registered to devices",
+		Run:  sshKeyList,
+	}
+
+	cmd.Flags().String("device", "", "only list keys registered to this device")
+
+	return cmd
+}
+
+func sshKeyList(cmd *cobra.Command, args []string) {
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	device, err := cmd.Flags().GetString("device")
+	if err != nil {
+		fmt.Println("failed to get flag: device")
+		os.Exit(1)
+
+		return
+	}
+
+	resp, err := c.Devices.ListSSHKeys(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.ListSSHKeysRequest{
+			DeviceId: device,
+		}),
+	)
+	if err != nil {
+		fmt.Println("failed to list ssh keys:", err)
+
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+
+	_, err = fmt.Fprintln(w, "Device\tFingerprint\tComment")
+	if err != nil {
+		fmt.Println("failed to write table header")
+
+		return
+	}
+
+	for _, key := range resp.Msg.GetKeys() {
+		row := strings.Join([]string{
+			key.GetDeviceId(),
+			key.GetFingerprint(),
+			key.GetComment(),
+		}, "\t")
+
+		_, err = fmt.Fprintln(w, row)
+		if err != nil {
+			fmt.Println("failed to write table rows")
+
+			return
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		fmt.Println("failed to write table")
+	}
+}
@@ -0,0 +1,54 @@
+package device
+
+import (
+	"fmt"
+
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newSSHKeyRemoveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "remove <fingerprint>",
+		Long: "remove a registered ssh public key",
+		Run:  sshKeyRemove,
+		Args: cobra.ExactArgs(1),
+	}
+
+	return cmd
+}
+
+func sshKeyRemove(cmd *cobra.Command, args []string) {
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	fingerprint := args[0]
+
+	_, err = c.Devices.RemoveSSHKey(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.RemoveSSHKeyRequest{
+			Fingerprint: fingerprint,
+		}),
+	)
+	if err != nil {
+		fmt.Println("failed to remove ssh key:", err)
+
+		return
+	}
+
+	fmt.Printf("ssh key %s removed\n", fingerprint)
+}
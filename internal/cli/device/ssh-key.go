@@ -0,0 +1,16 @@
+package device
+
+import "github.com/spf13/cobra"
+
+func newSSHKeyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "ssh-key",
+		Long: "manage ssh public keys registered to devices",
+	}
+
+	cmd.AddCommand(newSSHKeyAddCommand())
+	cmd.AddCommand(newSSHKeyListCommand())
+	cmd.AddCommand(newSSHKeyRemoveCommand())
+
+	return cmd
+}
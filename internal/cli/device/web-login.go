@@ -0,0 +1,55 @@
+package device
+
+import (
+	"fmt"
+
+	"connectrpc.com/connect"
+	devicesv1 "github.com/cmp0st/byte/gen/devices/v1"
+	"github.com/cmp0st/byte/internal/client"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newWebLoginCommand() *cobra.Command {
+	return &cobra.Command{
+		Use: "web-login <code>",
+		Long: `log a browser in as this device
+
+The code is the one the browser shows, usually as a QR code. The browser can
+then do what this device can until its session expires, except managing the
+keys of devices and logging other browsers in. Check that the printed address
+and user agent are those of your browser.`,
+		Run:  webLogin,
+		Args: cobra.ExactArgs(1),
+	}
+}
+
+func webLogin(cmd *cobra.Command, args []string) {
+	conf, err := config.LoadClient()
+	if err != nil {
+		fmt.Println("failed to load client config")
+
+		return
+	}
+
+	c, err := client.New(*conf)
+	if err != nil {
+		fmt.Println("failed to initialize client")
+
+		return
+	}
+
+	resp, err := c.Devices.ApproveWebLogin(
+		cmd.Context(),
+		connect.NewRequest(&devicesv1.ApproveWebLoginRequest{Code: args[0]}),
+	)
+	if err != nil {
+		fmt.Println("failed to approve web login:", err)
+
+		return
+	}
+
+	fmt.Println("Browser logged in")
+	fmt.Println("Address:   ", resp.Msg.GetRemoteAddr())
+	fmt.Println("User Agent:", resp.Msg.GetUserAgent())
+}
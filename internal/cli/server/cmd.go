@@ -10,6 +10,8 @@ func NewCommand() *cobra.Command {
 
 	cmd.AddCommand(newRunCommand())
 	cmd.AddCommand(newNewDeviceCommand())
+	cmd.AddCommand(newSignSSHKeyCommand())
+	cmd.AddCommand(newRotateSecretCommand())
 
 	return cmd
 }
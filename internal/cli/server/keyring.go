@@ -0,0 +1,44 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/key"
+)
+
+// newKeyring derives the key chains of every configured version of the
+// server secret.
+func newKeyring(conf *config.Server) (*key.ServerKeyring, error) {
+	secrets, current, err := conf.SecretVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	keyring, err := key.NewServerKeyring(secrets, current)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server secret, must be at least 32 characters: %w", err)
+	}
+
+	return keyring, nil
+}
+
+// identities derives the identity of the server for every version of the
+// server secret.
+func identities(keyring *key.ServerKeyring, name string) (map[int]string, error) {
+	ids := make(map[int]string)
+
+	for _, version := range keyring.Versions() {
+		chain, err := keyring.Chain(version)
+		if err != nil {
+			return nil, err
+		}
+
+		ids[version], err = chain.Identity(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
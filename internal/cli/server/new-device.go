@@ -2,33 +2,45 @@ package server
 
 import (
 	"database/sql"
-	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net"
 	"strconv"
+	"time"
 
+	"github.com/cmp0st/byte/internal/api"
+	"github.com/cmp0st/byte/internal/auth"
 	"github.com/cmp0st/byte/internal/config"
 	"github.com/cmp0st/byte/internal/database"
-	"github.com/cmp0st/byte/internal/key"
 	"github.com/google/uuid"
 	qrcode "github.com/skip2/go-qrcode"
 	"github.com/spf13/cobra"
 )
 
 func newNewDeviceCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "new-device",
 		Short: "create new device",
 		RunE:  newDevice,
 	}
+
+	// NB: this is how the first device gets created, so it defaults to a
+	// device that can create the others.
+	cmd.Flags().String(
+		"role",
+		string(auth.RoleAdmin),
+		"role of the device: admin, member, read-only or upload-only",
+	)
+	cmd.Flags().String("name", "", "name of the device, defaults to the one it reports")
+
+	return cmd
 }
 
-type DeviceConfig struct {
-	ServerURL string `json:"serverUrl"`
-	DeviceID  string `json:"deviceId"`
-	Secret    string `json:"secret"`
+// PairingConfig is what a new device needs to claim its credentials, see
+// api.PairingService.
+type PairingConfig struct {
+	ServerURL   string `json:"serverUrl"`
+	PairingCode string `json:"pairingCode"`
 }
 
 func newDevice(cmd *cobra.Command, args []string) error {
@@ -37,11 +49,22 @@ func newDevice(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	if conf.Secret == "" || len(conf.Secret) < 32 {
-		return errors.New("invalid server secret, must be more than 32 characters")
+	roleFlag, err := cmd.Flags().GetString("role")
+	if err != nil {
+		return err
+	}
+
+	role, err := auth.ParseRole(roleFlag)
+	if err != nil {
+		return err
+	}
+
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return err
 	}
 
-	keychain, err := key.NewServerChain([]byte(conf.Secret))
+	keyring, err := newKeyring(conf)
 	if err != nil {
 		return err
 	}
@@ -66,27 +89,44 @@ func newDevice(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	err = db.AddDevice(cmd.Context(), deviceID.String())
+	err = db.AddDevice(cmd.Context(), database.Device{
+		ID:         deviceID.String(),
+		Role:       string(role),
+		KeyVersion: keyring.Current,
+		Name:       name,
+		CreatedAt:  time.Now(),
+	})
 	if err != nil {
 		return err
 	}
 
-	deviceKeyChain, err := keychain.ClientChain(deviceID.String())
+	code, expiresAt, err := api.IssuePairingCode(cmd.Context(), db, deviceID.String(), time.Now())
 	if err != nil {
 		return err
 	}
 
-	// Construct server URL
-	serverURL := "http://" + net.JoinHostPort(conf.HTTP.Host, strconv.Itoa(conf.HTTP.Port))
-
-	deviceConfig := DeviceConfig{
-		ServerURL: serverURL,
-		DeviceID:  deviceID.String(),
-		Secret:    base64.StdEncoding.EncodeToString(deviceKeyChain.Seed[:]),
+	pairingConfig := PairingConfig{
+		ServerURL:   serverURL(conf),
+		PairingCode: code,
 	}
 
-	// Generate QR code
-	configJSON, err := json.Marshal(deviceConfig)
+	fmt.Println("\nDevice created successfully!")
+	fmt.Println("Device ID:    ", deviceID)
+	fmt.Println("Device Role:  ", role)
+	fmt.Println("Pairing Code: ", code)
+	fmt.Println("Expires:      ", expiresAt.Format(time.RFC3339))
+
+	return printPairingConfig(pairingConfig)
+}
+
+func serverURL(conf *config.Server) string {
+	return "http://" + net.JoinHostPort(conf.HTTP.Host, strconv.Itoa(conf.HTTP.Port))
+}
+
+// printPairingConfig prints how to claim a device with the pairing code of
+// pairingConfig, as a command and as a QR code for the app to scan.
+func printPairingConfig(pairingConfig PairingConfig) error {
+	configJSON, err := json.Marshal(pairingConfig)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -97,11 +137,10 @@ func newDevice(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to generate QR code: %w", err)
 	}
 
-	fmt.Println("\nDevice created successfully!")
-	fmt.Println("Device ID:    ", deviceID.String())
-	fmt.Println("Device Secret:", base64.StdEncoding.EncodeToString(deviceKeyChain.Seed[:]))
-	fmt.Println("Server URL:   ", serverURL)
-	fmt.Println("\nScan this QR code with the Byte iOS app:")
+	fmt.Println("Server URL:   ", pairingConfig.ServerURL)
+	fmt.Println("\nScan this QR code with the Byte iOS app, or run:")
+	fmt.Println("\n  byte device claim", pairingConfig.ServerURL, pairingConfig.PairingCode)
+	fmt.Println()
 	fmt.Println(qr.ToString(false))
 
 	return nil
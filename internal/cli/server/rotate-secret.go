@@ -0,0 +1,320 @@
+package server
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cmp0st/byte/internal/api"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/database"
+	"github.com/cmp0st/byte/internal/key"
+	"github.com/spf13/cobra"
+)
+
+// Size of generated server secrets before base64 encoding.
+const GeneratedSecretSize = 32
+
+func newRotateSecretCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rotate-secret",
+		Short: "rotate the server secret",
+		Long: `rotate the server secret
+
+Device keys are derived from the version of the server secret the device was
+enrolled with. To rotate the secret:
+
+ 1. run with --generate and add the new version to the secrets of the
+    configuration. Keeping secretVersion on the old version for a while lets
+    SSH clients learn the new host keys before they are used.
+ 2. set secretVersion to the new version and restart the server. New devices
+    are enrolled with it, and devices of other versions keep working.
+ 3. run with --device or --all to enroll devices with the new version and
+    pair them again with the printed pairing codes. Their old credentials
+    stop working right away. Pairing codes expire, run with --device again
+    to issue a new one to a device already enrolled with the new version.
+ 4. remove versions that no device uses anymore from the configuration.
+
+Without flags the versions and the devices still using old ones are listed.`,
+		RunE: rotateSecret,
+	}
+
+	cmd.Flags().Bool("generate", false, "print a new version of the server secret to configure")
+	cmd.Flags().StringSlice(
+		"device",
+		nil,
+		"enroll the device with the current version of the server secret",
+	)
+	cmd.Flags().Bool(
+		"all",
+		false,
+		"enroll every device with the current version of the server secret",
+	)
+
+	return cmd
+}
+
+func rotateSecret(cmd *cobra.Command, args []string) error {
+	conf, err := config.LoadServer()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	generate, err := cmd.Flags().GetBool("generate")
+	if err != nil {
+		return err
+	}
+
+	deviceIDs, err := cmd.Flags().GetStringSlice("device")
+	if err != nil {
+		return err
+	}
+
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return err
+	}
+
+	keyring, err := newKeyring(conf)
+	if err != nil {
+		return err
+	}
+
+	if generate {
+		return generateSecret(keyring)
+	}
+
+	var db *database.DB
+	{
+		sqlitedb, err := sql.Open("sqlite", conf.Database)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+
+		db = &database.DB{DB: sqlitedb}
+	}
+
+	err = db.Migrate()
+	if err != nil {
+		return err
+	}
+
+	devices, err := db.ListDevices(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if !all && len(deviceIDs) == 0 {
+		return printSecretVersions(keyring, devices)
+	}
+
+	for _, id := range deviceIDs {
+		if !slices.ContainsFunc(devices, func(d database.Device) bool { return d.ID == id }) {
+			return fmt.Errorf("device %s not found", id)
+		}
+	}
+
+	enrolled := 0
+
+	for _, device := range devices {
+		named := slices.Contains(deviceIDs, device.ID)
+		if !all && !named {
+			continue
+		}
+
+		// NB: pairing codes expire quickly, so naming the device is how it
+		// gets a new one after missing the first.
+		if device.KeyVersion == keyring.Current && named {
+			fmt.Printf(
+				"\nDevice %s already uses version %d, it can pair again with:\n",
+				device.ID,
+				keyring.Current,
+			)
+
+			err = printRotatedDevice(cmd, conf, db, device)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if device.KeyVersion == keyring.Current {
+			fmt.Printf("\nDevice %s already uses version %d\n", device.ID, keyring.Current)
+
+			continue
+		}
+
+		set, err := db.SetDeviceKeyVersion(
+			cmd.Context(),
+			device.ID,
+			device.KeyVersion,
+			device.KeyGeneration,
+			keyring.Current,
+		)
+		if err != nil {
+			return err
+		}
+
+		if !set {
+			return fmt.Errorf("device %s was changed or removed, try again", device.ID)
+		}
+
+		fmt.Printf(
+			"\nDevice %s enrolled with version %d, it must pair again:\n",
+			device.ID,
+			keyring.Current,
+		)
+
+		err = printRotatedDevice(cmd, conf, db, device)
+		if err != nil {
+			return err
+		}
+
+		enrolled++
+	}
+
+	fmt.Printf("\nEnrolled %d device(s) with version %d\n", enrolled, keyring.Current)
+
+	return nil
+}
+
+// printRotatedDevice issues a pairing code for the device to claim its new key
+// with and prints it.
+// NB: like new devices, the key itself is never shown to anyone.
+func printRotatedDevice(
+	cmd *cobra.Command,
+	conf *config.Server,
+	db *database.DB,
+	device database.Device,
+) error {
+	code, expiresAt, err := api.IssuePairingCode(cmd.Context(), db, device.ID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to issue pairing code for device %s: %w", device.ID, err)
+	}
+
+	fmt.Println("Device ID:    ", device.ID)
+	fmt.Println("Device Role:  ", device.Role)
+	fmt.Println("Pairing Code: ", code)
+	fmt.Println("Expires:      ", expiresAt.Format(time.RFC3339))
+
+	return printPairingConfig(PairingConfig{
+		ServerURL:   serverURL(conf),
+		PairingCode: code,
+	})
+}
+
+func generateSecret(keyring *key.ServerKeyring) error {
+	var raw [GeneratedSecretSize]byte
+
+	_, err := rand.Read(raw[:])
+	if err != nil {
+		return fmt.Errorf("failed to generate server secret: %w", err)
+	}
+
+	version := slices.Max(keyring.Versions()) + 1
+
+	fmt.Println("Add this version to the configuration:")
+	fmt.Println()
+	fmt.Println("secrets:")
+	fmt.Println("  - version:", version)
+	fmt.Println("    secret:", base64.StdEncoding.EncodeToString(raw[:]))
+
+	return nil
+}
+
+// printSecretVersions lists the versions of the server secret with the number
+// of devices using them, followed by the devices using old versions.
+func printSecretVersions(keyring *key.ServerKeyring, devices []database.Device) error {
+	counts := map[int]int{}
+	for _, device := range devices {
+		counts[device.KeyVersion]++
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+
+	_, err := fmt.Fprintln(w, "Version\tDevices\tStatus")
+	if err != nil {
+		return fmt.Errorf("failed to write table header: %w", err)
+	}
+
+	for _, version := range keyring.Versions() {
+		status := "in use"
+
+		switch {
+		case version == keyring.Current:
+			status = "current"
+		case version > keyring.Current:
+			status = "next"
+		case counts[version] == 0:
+			status = "unused, can be removed"
+		}
+
+		row := strings.Join([]string{
+			strconv.Itoa(version),
+			strconv.Itoa(counts[version]),
+			status,
+		}, "\t")
+
+		_, err = fmt.Fprintln(w, row)
+		if err != nil {
+			return fmt.Errorf("failed to write table rows: %w", err)
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to write table: %w", err)
+	}
+
+	var old []string
+
+	for _, device := range devices {
+		if device.KeyVersion != keyring.Current {
+			old = append(old, fmt.Sprintf("%s\t%s\t%d", device.ID, device.Role, device.KeyVersion))
+		}
+	}
+
+	if len(old) == 0 {
+		return nil
+	}
+
+	fmt.Println("\nDevices to enroll with the current version:")
+
+	_, err = fmt.Fprintln(w, "Device\tRole\tVersion")
+	if err != nil {
+		return fmt.Errorf("failed to write table header: %w", err)
+	}
+
+	for _, row := range old {
+		_, err = fmt.Fprintln(w, row)
+		if err != nil {
+			return fmt.Errorf("failed to write table rows: %w", err)
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to write table: %w", err)
+	}
+
+	// NB: devices of versions that are no longer configured cannot
+	// authenticate until they are enrolled again.
+	for version := range counts {
+		if !slices.Contains(keyring.Versions(), version) {
+			fmt.Printf(
+				"\nVersion %d is no longer configured, its devices cannot connect\n",
+				version,
+			)
+		}
+	}
+
+	return nil
+}
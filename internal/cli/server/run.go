@@ -13,9 +13,9 @@ import (
 
 	"github.com/charmbracelet/ssh"
 	"github.com/cmp0st/byte/internal/api"
+	"github.com/cmp0st/byte/internal/auth"
 	"github.com/cmp0st/byte/internal/config"
 	"github.com/cmp0st/byte/internal/database"
-	"github.com/cmp0st/byte/internal/key"
 	"github.com/cmp0st/byte/internal/logging"
 	"github.com/cmp0st/byte/internal/sftp"
 	"github.com/cmp0st/byte/internal/storage"
@@ -55,7 +55,12 @@ func run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	keychain, err := key.NewServerChain([]byte(conf.Secret))
+	keyring, err := newKeyring(conf)
+	if err != nil {
+		return err
+	}
+
+	ids, err := identities(keyring, conf.Name)
 	if err != nil {
 		return err
 	}
@@ -63,17 +68,39 @@ func run(cmd *cobra.Command, args []string) error {
 	logger := logging.NewFromConfig(*conf)
 	ctx := logging.ContextWith(cmd.Context(), logger)
 
+	for _, version := range keyring.Versions() {
+		logger.Info(
+			"Server identity derived",
+			slog.Int("secret_version", version),
+			slog.Bool("current", version == keyring.Current),
+			slog.String("server_id", ids[version]),
+		)
+	}
+
 	store, err := storage.NewFromConfig(conf.Storage)
 	if err != nil {
 		return err
 	}
 
+	// NB: the API and SSH servers share failed authentication attempts, so
+	// that a peer or device banned by one is banned by both.
+	attempts := auth.NewAttemptLimiter(auth.AttemptPolicy{
+		Window:         conf.AuthLimits.Window,
+		MaxFailures:    conf.AuthLimits.MaxFailures,
+		Backoff:        conf.AuthLimits.Backoff,
+		MaxBackoff:     conf.AuthLimits.MaxBackoff,
+		BanDuration:    conf.AuthLimits.BanDuration,
+		MaxBanDuration: conf.AuthLimits.MaxBanDuration,
+	}, auth.DefaultAttemptLimiterSize)
+
 	// Create SFTP server
 	sftpServer, err := sftp.NewServer(
 		ctx,
 		conf.SFTP,
 		store,
-		*keychain,
+		*keyring,
+		db,
+		attempts,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create SSH server: %w", err)
@@ -83,7 +110,12 @@ func run(cmd *cobra.Command, args []string) error {
 	apiServer, err := api.NewServer(
 		db,
 		store,
-		*keychain,
+		*keyring,
+		auth.TokenPolicy{
+			Identities: ids,
+			Leeway:     conf.HTTP.TokenLeeway,
+		},
+		attempts,
 		logger,
 		fmt.Sprintf("%s:%d", conf.HTTP.Host, conf.HTTP.Port),
 	)
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// NB: Certificates are meant for short lived access so the default validity
+// is deliberately small.
+const DefaultSSHCertValidity = time.Hour
+
+const SSHCertClockSkew = time.Minute
+
+func newSignSSHKeyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sign-ssh-key <public key file>",
+		Short: "sign an ssh user certificate with the server certificate authority",
+		RunE:  signSSHKey,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.Flags().StringSliceP(
+		"principal",
+		"n",
+		nil,
+		"principals (usernames) the certificate is valid for",
+	)
+	cmd.Flags().DurationP(
+		"validity",
+		"V",
+		DefaultSSHCertValidity,
+		"how long the certificate is valid for",
+	)
+	cmd.Flags().StringP(
+		"identity",
+		"I",
+		"",
+		"key identity recorded in the certificate and server logs",
+	)
+	cmd.Flags().String(
+		"force-command",
+		"",
+		"run this command whatever the client asks for, e.g. internal-sftp",
+	)
+	cmd.Flags().String(
+		"source-address",
+		"",
+		"comma separated addresses or CIDR ranges allowed to use the certificate",
+	)
+	cmd.Flags().StringP("output", "o", "", "certificate output path (defaults to <key>-cert.pub)")
+
+	return cmd
+}
+
+func signSSHKey(cmd *cobra.Command, args []string) error {
+	conf, err := config.LoadServer()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	keyring, err := newKeyring(conf)
+	if err != nil {
+		return err
+	}
+
+	principals, err := cmd.Flags().GetStringSlice("principal")
+	if err != nil {
+		return err
+	}
+
+	if len(principals) == 0 {
+		return errors.New("at least one principal is required")
+	}
+
+	validity, err := cmd.Flags().GetDuration("validity")
+	if err != nil {
+		return err
+	}
+
+	if validity <= 0 {
+		return errors.New("validity must be positive")
+	}
+
+	identity, err := cmd.Flags().GetString("identity")
+	if err != nil {
+		return err
+	}
+
+	forceCommand, err := cmd.Flags().GetString("force-command")
+	if err != nil {
+		return err
+	}
+
+	sourceAddress, err := cmd.Flags().GetString("source-address")
+	if err != nil {
+		return err
+	}
+
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		output = strings.TrimSuffix(args[0], ".pub") + "-cert.pub"
+	}
+
+	raw, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %w", err)
+	}
+
+	pub, comment, _, _, err := gossh.ParseAuthorizedKey(raw)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	if identity == "" {
+		identity = comment
+	}
+
+	caKey, err := keyring.CurrentChain().SSHUserCAKey()
+	if err != nil {
+		return err
+	}
+
+	signer, err := gossh.NewSignerFromKey(caKey)
+	if err != nil {
+		return fmt.Errorf("failed to create ssh signer: %w", err)
+	}
+
+	var serial [8]byte
+
+	_, err = rand.Read(serial[:])
+	if err != nil {
+		return fmt.Errorf("failed to generate certificate serial: %w", err)
+	}
+
+	criticalOptions := map[string]string{}
+	if forceCommand != "" {
+		criticalOptions[auth.SSHCertOptionForceCommand] = forceCommand
+	}
+
+	if sourceAddress != "" {
+		criticalOptions[auth.SSHCertOptionSourceAddress] = sourceAddress
+	}
+
+	now := time.Now()
+
+	cert := &gossh.Certificate{
+		Key:             pub,
+		Serial:          binary.BigEndian.Uint64(serial[:]),
+		CertType:        gossh.UserCert,
+		KeyId:           identity,
+		ValidPrincipals: principals,
+		// Backdate slightly to tolerate clock skew between client and server.
+		//nolint: gosec
+		ValidAfter: uint64(now.Add(-SSHCertClockSkew).Unix()),
+		//nolint: gosec
+		ValidBefore: uint64(now.Add(validity).Unix()),
+		Permissions: gossh.Permissions{
+			CriticalOptions: criticalOptions,
+			Extensions: map[string]string{
+				auth.SSHCertExtensionPermitPTY: "",
+			},
+		},
+	}
+
+	err = cert.SignCert(rand.Reader, signer)
+	if err != nil {
+		return fmt.Errorf("failed to sign certificate: %w", err)
+	}
+
+	err = os.WriteFile(output, gossh.MarshalAuthorizedKey(cert), 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write certificate: %w", err)
+	}
+
+	fmt.Println("Certificate:", output)
+	fmt.Println("Serial:     ", cert.Serial)
+	fmt.Println("Principals: ", strings.Join(principals, ", "))
+	fmt.Println("Valid until:", now.Add(validity).Format(time.RFC3339))
+
+	return nil
+}
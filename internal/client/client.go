@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 
+	"aidanwoods.dev/go-paseto"
 	"connectrpc.com/connect"
 	"connectrpc.com/validate"
 	"github.com/cmp0st/byte/gen/devices/v1/devicesv1connect"
@@ -20,15 +21,14 @@ type Client struct {
 }
 
 func New(c config.Client) (*Client, error) {
-	rawKey, err := base64.StdEncoding.DecodeString(c.Secret)
+	keychain, err := clientChain(c)
 	if err != nil {
 		return nil, err
 	}
 
-	keychain, err := key.NewClientChain(rawKey, c.ID)
-	if err != nil {
-		return nil, err
-	}
+	keychain.ServerID = c.ServerID
+	keychain.KeyVersion = c.KeyVersion
+	keychain.KeyGeneration = c.KeyGeneration
 
 	validateInterceptor, err := validate.NewInterceptor()
 	if err != nil {
@@ -59,3 +59,36 @@ func New(c config.Client) (*Client, error) {
 		),
 	}, nil
 }
+
+// clientChain returns the key chain of the device configured by c. Devices
+// enrolled with their public key have no secret.
+func clientChain(c config.Client) (*key.ClientChain, error) {
+	var signingKey *paseto.V4AsymmetricSecretKey
+
+	if c.SigningKey != "" {
+		k, err := paseto.NewV4AsymmetricSecretKeyFromHex(c.SigningKey)
+		if err != nil {
+			return nil, err
+		}
+
+		signingKey = &k
+	}
+
+	if c.Secret == "" && signingKey != nil {
+		return key.NewSigningClientChain(*signingKey, c.ID)
+	}
+
+	rawKey, err := base64.StdEncoding.DecodeString(c.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	keychain, err := key.NewClientChain(rawKey, c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	keychain.SigningKey = signingKey
+
+	return keychain, nil
+}
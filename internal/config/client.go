@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/viper"
 )
@@ -10,9 +12,122 @@ type Client struct {
 	ID        string
 	Secret    string
 	ServerURL string `mapstructure:"serverUrl" yaml:"serverUrl"`
+
+	// ServerID is the identity of the server, which tokens are bound to. It
+	// is printed when a device is created.
+	ServerID string `mapstructure:"serverId" yaml:"serverId"`
+
+	// KeyVersion is the version of the server secret the device secret is
+	// derived from. It is printed when it is not the first one.
+	KeyVersion int `mapstructure:"keyVersion" yaml:"keyVersion"`
+
+	// KeyGeneration counts how many times the device secret was rotated.
+	KeyGeneration int `mapstructure:"keyGeneration" yaml:"keyGeneration"`
+
+	// SigningKey is the hex encoded Ed25519 secret key the device signs its
+	// tokens with once its public key is registered.
+	SigningKey string `mapstructure:"signingKey" yaml:"signingKey"`
 }
 
 func LoadClient() (*Client, error) {
+	v, err := readClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Client
+
+	err = v.Unmarshal(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// SaveClient writes the config file of a newly claimed or enrolled device. It refuses to
+// replace the config file of another device.
+func SaveClient(c Client) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("error finding home directory: %w", err)
+	}
+
+	dir := filepath.Join(home, ".byte")
+
+	err = os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+
+	// NB: the config file holds the device secret.
+	v.SetConfigPermissions(0o600)
+
+	v.Set("id", c.ID)
+	v.Set("serverUrl", c.ServerURL)
+	v.Set("serverId", c.ServerID)
+	v.Set("keyVersion", c.KeyVersion)
+	v.Set("keyGeneration", c.KeyGeneration)
+
+	// NB: devices enrolled with their public key have no secret, and the
+	// others no signing key until they register one.
+	if c.Secret != "" {
+		v.Set("secret", c.Secret)
+	}
+
+	if c.SigningKey != "" {
+		v.Set("signingKey", c.SigningKey)
+	}
+
+	err = v.SafeWriteConfigAs(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+
+	return nil
+}
+
+// SaveClientKey replaces the device secret in the config file, e.g. after it
+// was rotated.
+func SaveClientKey(secret string, keyVersion int, keyGeneration int) error {
+	v, err := readClient()
+	if err != nil {
+		return err
+	}
+
+	v.Set("secret", secret)
+	v.Set("keyVersion", keyVersion)
+	v.Set("keyGeneration", keyGeneration)
+
+	err = v.WriteConfig()
+	if err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+
+	return nil
+}
+
+// SaveClientSigningKey sets the signing key in the config file.
+func SaveClientSigningKey(signingKey string) error {
+	v, err := readClient()
+	if err != nil {
+		return err
+	}
+
+	v.Set("signingKey", signingKey)
+
+	err = v.WriteConfig()
+	if err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+
+	return nil
+}
+
+func readClient() (*viper.Viper, error) {
 	v := viper.New()
 
 	// Set defaults
@@ -31,12 +146,5 @@ func LoadClient() (*Client, error) {
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
 
-	var cfg Client
-
-	err = v.Unmarshal(&cfg)
-	if err != nil {
-		return nil, fmt.Errorf("error unmarshaling config: %w", err)
-	}
-
-	return &cfg, nil
+	return v, nil
 }
@@ -1,31 +1,137 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Server struct {
 	LogLevel string `mapstructure:"logLevel" yaml:"logLevel"`
-	Secret   string `mapstructure:"secret"   yaml:"secret"`
+
+	// Secret is the first version of the server secret. It can be listed in
+	// Secrets instead.
+	Secret string `mapstructure:"secret" yaml:"secret"`
+
+	// Secrets are the versions of the server secret. Device keys are derived
+	// from the version the device was enrolled with, so versions must be
+	// kept until no device uses them anymore, see byte server rotate-secret.
+	Secrets []Secret `mapstructure:"secrets" yaml:"secrets"`
+
+	// SecretVersion is the version new devices are enrolled with and SSH
+	// host keys and user certificates are derived from. It defaults to the
+	// latest version.
+	SecretVersion int `mapstructure:"secretVersion" yaml:"secretVersion"`
+
+	// Name sets apart deployments sharing a secret, such as staging and
+	// production, so that device tokens minted for one are refused by the
+	// other. Changing it requires reconfiguring every device.
+	Name string `mapstructure:"name" yaml:"name"`
 
 	SFTP SFTP `mapstructure:"sftp" yaml:"sftp"`
 	HTTP HTTP `mapstructure:"http" yaml:"http"`
 
+	// AuthLimits slow down and ban clients failing to authenticate over
+	// either the API or SSH.
+	AuthLimits AuthLimits `mapstructure:"authLimits" yaml:"authLimits"`
+
 	Storage  Storage `mapstructure:"storage" yaml:"storage"`
 	Database string
 }
 
+type Secret struct {
+	Version int    `mapstructure:"version" yaml:"version"`
+	Secret  string `mapstructure:"secret"  yaml:"secret"`
+}
+
 type SFTP struct {
-	Host           string   `mapstructure:"host"           yaml:"host"`
-	Port           int      `mapstructure:"port"           yaml:"port"`
+	Host string `mapstructure:"host" yaml:"host"`
+	Port int    `mapstructure:"port" yaml:"port"`
+
+	// AuthorizedKeys are keys in authorized_keys format. Besides the OpenSSH
+	// command=, from=, restrict, no-pty and pty options, byte-root="/dir"
+	// confines the key to a directory and byte-readonly denies writes.
 	AuthorizedKeys []string `mapstructure:"authorizedKeys" yaml:"authorizedKeys"`
+
+	// TrustedUserCAKeys are additional certificate authorities, in
+	// authorized_keys format, whose user certificates are accepted. The CA
+	// derived from the server secret is always trusted.
+	TrustedUserCAKeys []string `mapstructure:"trustedUserCAKeys" yaml:"trustedUserCAKeys"`
+
+	Limits SSHLimits `mapstructure:"limits" yaml:"limits"`
+
+	HostKeys SSHHostKeys `mapstructure:"hostKeys" yaml:"hostKeys"`
+}
+
+// SSHHostKeys selects the versions of the host keys derived from the server
+// secret. To rotate, first add the next version to Announce so that clients
+// with UpdateHostKeys learn it, then switch Version to it once they had time
+// to connect. Clients forget keys that are no longer announced.
+type SSHHostKeys struct {
+	// Version of the host keys the server authenticates with.
+	Version int `mapstructure:"version" yaml:"version"`
+
+	// Announce lists other versions sent to clients through the
+	// hostkeys-00@openssh.com extension alongside the current keys.
+	Announce []int `mapstructure:"announce" yaml:"announce"`
+}
+
+// SSHLimits bound the resources SSH clients may use. Zero values mean no
+// limit.
+type SSHLimits struct {
+	// MaxConnections caps concurrent connections across all clients.
+	MaxConnections int `mapstructure:"maxConnections" yaml:"maxConnections"`
+
+	// MaxConnectionsPerKey caps concurrent connections authenticated with
+	// the same public key or certificate.
+	MaxConnectionsPerKey int `mapstructure:"maxConnectionsPerKey" yaml:"maxConnectionsPerKey"`
+
+	// IdleTimeout closes connections without any traffic for this long.
+	IdleTimeout time.Duration `mapstructure:"idleTimeout" yaml:"idleTimeout"`
+
+	// MaxSessionDuration closes connections this long after they were
+	// opened, whether they are idle or not.
+	MaxSessionDuration time.Duration `mapstructure:"maxSessionDuration" yaml:"maxSessionDuration"`
+
+	// ReadBytesPerSecond and WriteBytesPerSecond throttle SFTP file
+	// transfers. They are shared by every connection of a key.
+	ReadBytesPerSecond  int64 `mapstructure:"readBytesPerSecond"  yaml:"readBytesPerSecond"`
+	WriteBytesPerSecond int64 `mapstructure:"writeBytesPerSecond" yaml:"writeBytesPerSecond"`
 }
 
 type HTTP struct {
 	Host string `mapstructure:"host" yaml:"host"`
 	Port int    `mapstructure:"port" yaml:"port"`
+
+	// TokenLeeway is how far device clocks may be off when checking the
+	// time claims of their tokens.
+	TokenLeeway time.Duration `mapstructure:"tokenLeeway" yaml:"tokenLeeway"`
+}
+
+// AuthLimits bound failed authentication attempts, which count against both
+// the peer address and the device they claim to be. Zero values mean no
+// limit.
+type AuthLimits struct {
+	// Window is how long failures count towards MaxFailures.
+	Window time.Duration `mapstructure:"window" yaml:"window"`
+
+	// MaxFailures bans a peer address or device once it failed this many
+	// times within Window.
+	MaxFailures int `mapstructure:"maxFailures" yaml:"maxFailures"`
+
+	// Backoff is how long to wait after a failure before trying again. It
+	// doubles with every consecutive failure, up to MaxBackoff.
+	Backoff    time.Duration `mapstructure:"backoff"    yaml:"backoff"`
+	MaxBackoff time.Duration `mapstructure:"maxBackoff" yaml:"maxBackoff"`
+
+	// BanDuration is how long a first ban lasts. It doubles with every ban
+	// following the previous one, up to MaxBanDuration.
+	BanDuration    time.Duration `mapstructure:"banDuration"    yaml:"banDuration"`
+	MaxBanDuration time.Duration `mapstructure:"maxBanDuration" yaml:"maxBanDuration"`
 }
 
 type Storage struct {
@@ -42,6 +148,22 @@ type InMemory struct{}
 const (
 	DefaultHTTPPort = 8080
 	DefaultSSHPort  = 8022
+
+	DefaultTokenLeeway = 5 * time.Second
+
+	DefaultSSHIdleTimeout = 15 * time.Minute
+
+	DefaultSSHHostKeyVersion = 1
+
+	DefaultAuthWindow         = 10 * time.Minute
+	DefaultAuthMaxFailures    = 10
+	DefaultAuthBackoff        = time.Second
+	DefaultAuthMaxBackoff     = time.Minute
+	DefaultAuthBanDuration    = 15 * time.Minute
+	DefaultAuthMaxBanDuration = 24 * time.Hour
+
+	// Version of Secret.
+	DefaultSecretVersion = 1
 )
 
 func LoadServer() (*Server, error) {
@@ -50,8 +172,17 @@ func LoadServer() (*Server, error) {
 	// Set defaults
 	v.SetDefault("sftp.host", "localhost")
 	v.SetDefault("sftp.port", DefaultSSHPort)
+	v.SetDefault("sftp.limits.idleTimeout", DefaultSSHIdleTimeout)
+	v.SetDefault("sftp.hostKeys.version", DefaultSSHHostKeyVersion)
 	v.SetDefault("http.host", "localhost")
 	v.SetDefault("http.port", DefaultHTTPPort)
+	v.SetDefault("http.tokenLeeway", DefaultTokenLeeway)
+	v.SetDefault("authLimits.window", DefaultAuthWindow)
+	v.SetDefault("authLimits.maxFailures", DefaultAuthMaxFailures)
+	v.SetDefault("authLimits.backoff", DefaultAuthBackoff)
+	v.SetDefault("authLimits.maxBackoff", DefaultAuthMaxBackoff)
+	v.SetDefault("authLimits.banDuration", DefaultAuthBanDuration)
+	v.SetDefault("authLimits.maxBanDuration", DefaultAuthMaxBanDuration)
 	v.SetDefault("posix.root", "./data")
 	v.SetDefault("database", "byte.db")
 
@@ -78,3 +209,40 @@ func LoadServer() (*Server, error) {
 
 	return &cfg, nil
 }
+
+// SecretVersions returns the configured versions of the server secret and the
+// current one.
+func (s Server) SecretVersions() (map[int][]byte, int, error) {
+	secrets := make(map[int][]byte, len(s.Secrets)+1)
+
+	if s.Secret != "" {
+		secrets[DefaultSecretVersion] = []byte(s.Secret)
+	}
+
+	for _, secret := range s.Secrets {
+		if secret.Version < DefaultSecretVersion {
+			return nil, 0, fmt.Errorf("invalid server secret version %d", secret.Version)
+		}
+
+		if _, ok := secrets[secret.Version]; ok {
+			return nil, 0, fmt.Errorf("server secret version %d is set twice", secret.Version)
+		}
+
+		secrets[secret.Version] = []byte(secret.Secret)
+	}
+
+	if len(secrets) == 0 {
+		return nil, 0, errors.New("missing server secret")
+	}
+
+	current := s.SecretVersion
+	if current == 0 {
+		current = slices.Max(slices.Collect(maps.Keys(secrets)))
+	}
+
+	if _, ok := secrets[current]; !ok {
+		return nil, 0, fmt.Errorf("server secret version %d is not configured", current)
+	}
+
+	return secrets, current, nil
+}
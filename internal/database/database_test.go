@@ -0,0 +1,31 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite", t.TempDir()+"/byte.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		//nolint: errcheck
+		conn.Close()
+	})
+
+	db := &DB{DB: conn}
+
+	err = db.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
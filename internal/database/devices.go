@@ -2,14 +2,63 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/cmp0st/byte/internal/logging"
 )
 
-func (db *DB) AddDevice(ctx context.Context, id string) error {
-	_, err := db.ExecContext(ctx, "INSERT INTO devices (id) VALUES (?)", id)
+type Device struct {
+	ID   string
+	Role string
+
+	// KeyVersion is the version of the server secret the device key is
+	// derived from, see key.ServerKeyring.
+	KeyVersion int
+
+	// KeyGeneration counts the rotations of the device key.
+	KeyGeneration int
+
+	// PublicKey is the Ed25519 public key the device signs its tokens with,
+	// if it registered one.
+	PublicKey []byte
+
+	Name     string
+	Platform string
+
+	// AppVersion is the version of the app the device last authenticated
+	// with, if it reports it.
+	AppVersion string
+
+	// CreatedAt is zero for devices created before it was recorded.
+	CreatedAt time.Time
+
+	// LastSeenAt and LastSeenIP are when and from where the device last
+	// authenticated, see SetDeviceLastSeen. LastSeenAt is zero if it never
+	// did.
+	LastSeenAt time.Time
+	LastSeenIP string
+}
+
+// deviceColumns are the columns scanned by scanDevice.
+const deviceColumns = `id, role, key_version, key_generation, public_key,
+	name, platform, app_version, created_at, last_seen_at, last_seen_ip`
+
+func (db *DB) AddDevice(ctx context.Context, device Device) error {
+	_, err := db.ExecContext(
+		ctx,
+		`INSERT INTO devices (id, role, key_version, name, platform, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		device.ID,
+		device.Role,
+		device.KeyVersion,
+		device.Name,
+		device.Platform,
+		unixOrNull(device.CreatedAt),
+	)
 	if err != nil {
 		logging.FromContext(ctx).Error(
 			"failed to insert device",
@@ -36,8 +85,28 @@ func (db *DB) DeviceExists(ctx context.Context, id string) (bool, error) {
 	return count == 1, nil
 }
 
-func (db *DB) ListDevices(ctx context.Context) ([]string, error) {
-	rows, err := db.QueryContext(ctx, "SELECT id FROM devices")
+// GetDevice returns the device with the given id or nil if there is none.
+func (db *DB) GetDevice(ctx context.Context, id string) (*Device, error) {
+	device, err := scanDevice(db.QueryRowContext(
+		ctx,
+		"SELECT "+deviceColumns+" FROM devices WHERE id=?",
+		id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get device", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+
+	return &device, nil
+}
+
+func (db *DB) ListDevices(ctx context.Context) ([]Device, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+deviceColumns+" FROM devices")
 	if err != nil {
 		logging.FromContext(ctx).Error(
 			"failed to list devices",
@@ -49,10 +118,7 @@ func (db *DB) ListDevices(ctx context.Context) ([]string, error) {
 	//nolint: errcheck
 	defer rows.Close()
 
-	var (
-		id  string
-		ids []string
-	)
+	var devices []Device
 
 	for rows.Next() {
 		if rows.Err() != nil {
@@ -64,26 +130,310 @@ func (db *DB) ListDevices(ctx context.Context) ([]string, error) {
 			return nil, fmt.Errorf("failed to read row while listing devices: %w", err)
 		}
 
-		err = rows.Scan(&id)
+		device, err := scanDevice(rows)
 		if err != nil {
 			logging.FromContext(ctx).Error(
 				"failed to scan row",
 				slog.Any("err", err),
 			)
 
-			return nil, fmt.Errorf("failed to scan row for device id: %w", err)
+			return nil, fmt.Errorf("failed to scan row for device: %w", err)
 		}
 
-		ids = append(ids, id)
+		devices = append(devices, device)
 	}
 
-	return ids, nil
+	return devices, nil
 }
 
-func (db *DB) DeleteDevice(ctx context.Context, id string) error {
-	_, err := db.ExecContext(ctx, "DELETE FROM devices WHERE id=?", id)
+// scanDevice scans the deviceColumns of row.
+func scanDevice(row interface{ Scan(dest ...any) error }) (Device, error) {
+	var (
+		device                Device
+		createdAt, lastSeenAt sql.NullInt64
+	)
+
+	err := row.Scan(
+		&device.ID,
+		&device.Role,
+		&device.KeyVersion,
+		&device.KeyGeneration,
+		&device.PublicKey,
+		&device.Name,
+		&device.Platform,
+		&device.AppVersion,
+		&createdAt,
+		&lastSeenAt,
+		&device.LastSeenIP,
+	)
+	if err != nil {
+		return Device{}, err
+	}
+
+	if createdAt.Valid {
+		device.CreatedAt = time.Unix(createdAt.Int64, 0)
+	}
+
+	if lastSeenAt.Valid {
+		device.LastSeenAt = time.Unix(lastSeenAt.Int64, 0)
+	}
+
+	return device, nil
+}
+
+// unixOrNull returns t as a unix time, or NULL if it is zero.
+func unixOrNull(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: t.Unix(), Valid: true}
+}
+
+// DescribeDevice sets the platform the device reports, and its name unless
+// it already has one, reporting whether the device exists. Empty values are
+// ignored.
+func (db *DB) DescribeDevice(ctx context.Context, id, name, platform string) (bool, error) {
+	res, err := db.ExecContext(
+		ctx,
+		`UPDATE devices SET
+		name=CASE WHEN name='' THEN ? ELSE name END,
+		platform=COALESCE(NULLIF(?, ''), platform)
+		WHERE id=?`,
+		name,
+		platform,
+		id,
+	)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to describe device",
+			slog.Any("err", err),
+		)
+
+		return false, fmt.Errorf("failed to describe device: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to describe device: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// SetDeviceLastSeen records that the device authenticated at seenAt from
+// remoteAddr with appVersion, reporting whether the device exists. An empty
+// appVersion leaves the recorded one as is.
+func (db *DB) SetDeviceLastSeen(
+	ctx context.Context,
+	id string,
+	seenAt time.Time,
+	remoteAddr string,
+	appVersion string,
+) (bool, error) {
+	res, err := db.ExecContext(
+		ctx,
+		`UPDATE devices SET
+		last_seen_at=?, last_seen_ip=?, app_version=COALESCE(NULLIF(?, ''), app_version)
+		WHERE id=?`,
+		seenAt.Unix(),
+		remoteAddr,
+		appVersion,
+		id,
+	)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to set device last seen",
+			slog.Any("err", err),
+		)
+
+		return false, fmt.Errorf("failed to set device last seen: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to set device last seen: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// SetDeviceKeyVersion enrolls the device with the key of version and
+// generation with newVersion of the server secret, keeping its generation. It
+// reports whether the device still had that key, so that it cannot undo a
+// concurrent rotation.
+func (db *DB) SetDeviceKeyVersion(
+	ctx context.Context,
+	id string,
+	version int,
+	generation int,
+	newVersion int,
+) (bool, error) {
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE devices SET key_version=? WHERE id=? AND key_version=? AND key_generation=?",
+		newVersion,
+		id,
+		version,
+		generation,
+	)
 	if err != nil {
 		logging.FromContext(ctx).Error(
+			"failed to set device key version",
+			slog.Any("err", err),
+		)
+
+		return false, fmt.Errorf("failed to set device key version: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to set device key version: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// RotateDeviceKey moves the device from the key of version and generation to
+// the next generation of a key of newVersion. It reports whether the device
+// still had that key, so that concurrent rotations cannot both succeed.
+func (db *DB) RotateDeviceKey(
+	ctx context.Context,
+	id string,
+	version int,
+	generation int,
+	newVersion int,
+) (bool, error) {
+	res, err := db.ExecContext(
+		ctx,
+		`UPDATE devices SET key_version=?, key_generation=key_generation+1
+		WHERE id=? AND key_version=? AND key_generation=?`,
+		newVersion,
+		id,
+		version,
+		generation,
+	)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to rotate device key",
+			slog.Any("err", err),
+		)
+
+		return false, fmt.Errorf("failed to rotate device key: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate device key: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// SetDevicePublicKey registers the public key the device signs its tokens
+// with, reporting whether the device exists.
+func (db *DB) SetDevicePublicKey(ctx context.Context, id string, publicKey []byte) (bool, error) {
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE devices SET public_key=? WHERE id=?",
+		publicKey,
+		id,
+	)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to set device public key",
+			slog.Any("err", err),
+		)
+
+		return false, fmt.Errorf("failed to set device public key: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to set device public key: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+func (db *DB) DeleteDevice(ctx context.Context, id string) error {
+	logger := logging.FromContext(ctx)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("err", err))
+
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	//nolint: errcheck
+	defer tx.Rollback()
+
+	// NB: A device's ssh keys must go with it, otherwise they would keep
+	// authenticating after the device is deleted.
+	_, err = tx.ExecContext(ctx, "DELETE FROM ssh_keys WHERE device_id=?", id)
+	if err != nil {
+		logger.Error(
+			"failed to delete device ssh keys",
+			slog.Any("err", err),
+		)
+
+		return fmt.Errorf("failed to delete device ssh keys: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM path_grants WHERE device_id=?", id)
+	if err != nil {
+		logger.Error(
+			"failed to delete device path grants",
+			slog.Any("err", err),
+		)
+
+		return fmt.Errorf("failed to delete device path grants: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM pairing_codes WHERE device_id=?", id)
+	if err != nil {
+		logger.Error(
+			"failed to delete device pairing codes",
+			slog.Any("err", err),
+		)
+
+		return fmt.Errorf("failed to delete device pairing codes: %w", err)
+	}
+
+	// NB: browsers logged in as the device must be logged out with it.
+	_, err = tx.ExecContext(ctx, "DELETE FROM web_sessions WHERE device_id=?", id)
+	if err != nil {
+		logger.Error(
+			"failed to delete device web sessions",
+			slog.Any("err", err),
+		)
+
+		return fmt.Errorf("failed to delete device web sessions: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM web_logins WHERE device_id=?", id)
+	if err != nil {
+		logger.Error(
+			"failed to delete device web logins",
+			slog.Any("err", err),
+		)
+
+		return fmt.Errorf("failed to delete device web logins: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM enrollments WHERE device_id=?", id)
+	if err != nil {
+		logger.Error(
+			"failed to delete device enrollments",
+			slog.Any("err", err),
+		)
+
+		return fmt.Errorf("failed to delete device enrollments: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM devices WHERE id=?", id)
+	if err != nil {
+		logger.Error(
 			"failed to delete device",
 			slog.Any("err", err),
 		)
@@ -91,5 +441,12 @@ func (db *DB) DeleteDevice(ctx context.Context, id string) error {
 		return fmt.Errorf("failed to delete device: %w", err)
 	}
 
+	err = tx.Commit()
+	if err != nil {
+		logger.Error("failed to commit transaction", slog.Any("err", err))
+
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+
 	return nil
 }
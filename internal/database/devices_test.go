@@ -0,0 +1,107 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeviceMetadata(t *testing.T) {
+	db := newTestDB(t)
+	created := time.Unix(1_700_000_000, 0)
+
+	for _, device := range []Device{
+		{ID: "laptop", Role: "member", Name: "Laptop", Platform: "linux", CreatedAt: created},
+		{ID: "legacy", Role: "member"},
+	} {
+		err := db.AddDevice(t.Context(), device)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(id string) Device {
+		t.Helper()
+
+		device, err := db.GetDevice(t.Context(), id)
+		if err != nil || device == nil {
+			t.Fatalf("GetDevice(%q) = %v, %v", id, device, err)
+		}
+
+		return *device
+	}
+
+	laptop := get("laptop")
+	if laptop.Name != "Laptop" || laptop.Platform != "linux" || !laptop.CreatedAt.Equal(created) {
+		t.Errorf("laptop = %+v, want its name, platform and creation time", laptop)
+	}
+
+	// NB: devices created before metadata was recorded have none.
+	legacy := get("legacy")
+	if legacy.Name != "" || !legacy.CreatedAt.IsZero() || !legacy.LastSeenAt.IsZero() {
+		t.Errorf("legacy = %+v, want no metadata", legacy)
+	}
+
+	// NB: names chosen when the device was created win over the name it
+	// reports, while the platform it reports is more accurate.
+	for _, describe := range []struct{ id, name, platform string }{
+		{id: "laptop", name: "My laptop", platform: "darwin"},
+		{id: "legacy", name: "Phone", platform: "ios"},
+		{id: "legacy", name: "Tablet"},
+	} {
+		found, err := db.DescribeDevice(t.Context(), describe.id, describe.name, describe.platform)
+		if err != nil || !found {
+			t.Fatalf("DescribeDevice(%q) = %v, %v", describe.id, found, err)
+		}
+	}
+
+	if laptop = get("laptop"); laptop.Name != "Laptop" || laptop.Platform != "darwin" {
+		t.Errorf("described laptop = %q on %q, want Laptop on darwin", laptop.Name, laptop.Platform)
+	}
+
+	if legacy = get("legacy"); legacy.Name != "Phone" || legacy.Platform != "ios" {
+		t.Errorf("described legacy = %q on %q, want Phone on ios", legacy.Name, legacy.Platform)
+	}
+
+	seen := created.Add(time.Hour)
+
+	for _, version := range []string{"v1.2.3", ""} {
+		found, err := db.SetDeviceLastSeen(t.Context(), "laptop", seen, "192.0.2.1", version)
+		if err != nil || !found {
+			t.Fatalf("SetDeviceLastSeen = %v, %v", found, err)
+		}
+	}
+
+	// NB: an app that stops reporting its version keeps the last one known.
+	laptop = get("laptop")
+	if !laptop.LastSeenAt.Equal(seen) || laptop.LastSeenIP != "192.0.2.1" ||
+		laptop.AppVersion != "v1.2.3" {
+		t.Errorf("seen laptop = %+v, want seen at %v from 192.0.2.1 with v1.2.3", laptop, seen)
+	}
+
+	for _, update := range []func() (bool, error){
+		func() (bool, error) { return db.DescribeDevice(t.Context(), "missing", "name", "") },
+		func() (bool, error) {
+			return db.SetDeviceLastSeen(t.Context(), "missing", seen, "192.0.2.1", "")
+		},
+	} {
+		found, err := update()
+		if err != nil || found {
+			t.Errorf("updated a missing device: %v, %v", found, err)
+		}
+	}
+
+	devices, err := db.ListDevices(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, device := range devices {
+		if device.ID == "laptop" && device.AppVersion != "v1.2.3" {
+			t.Errorf("listed laptop = %+v, want its metadata", device)
+		}
+	}
+
+	if len(devices) != 2 {
+		t.Errorf("listed %d devices, want 2", len(devices))
+	}
+}
@@ -0,0 +1,332 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cmp0st/byte/internal/logging"
+)
+
+const (
+	EnrollmentPending  = "pending"
+	EnrollmentApproved = "approved"
+	EnrollmentDenied   = "denied"
+)
+
+var (
+	ErrTooManyEnrollments        = errors.New("too many pending enrollment requests")
+	ErrTooManyAddressEnrollments = errors.New("too many enrollment requests from address")
+)
+
+// Enrollment is a request of a new device to be enrolled. Requests are
+// pending until an admin approves or denies them. Pending and denied requests
+// are forgotten once they expire, approved ones are kept along with their
+// device so that it learns it was approved whenever it asks.
+type Enrollment struct {
+	ID         string
+	Name       string
+	Platform   string
+	PublicKey  []byte
+	RemoteAddr string
+	State      string
+
+	// DeviceID is the device created for the request once it is approved.
+	DeviceID string
+
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// EnrollmentLimits bound the enrollment requests that can be submitted
+// without authentication.
+type EnrollmentLimits struct {
+	// MaxPending bounds the requests waiting for a decision at once.
+	MaxPending int
+
+	// MaxPerAddress bounds the requests submitted from the same address
+	// that have not expired yet.
+	MaxPerAddress int
+}
+
+// AddEnrollment stores a new enrollment request, unless it would exceed
+// limits. Expired requests that were not approved are deleted along the way.
+func (db *DB) AddEnrollment(
+	ctx context.Context,
+	enrollment Enrollment,
+	limits EnrollmentLimits,
+) error {
+	logger := logging.FromContext(ctx)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("err", err))
+
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	//nolint: errcheck
+	defer tx.Rollback()
+
+	now := enrollment.CreatedAt.Unix()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM enrollments WHERE expires_at<=? AND state<>?",
+		now,
+		EnrollmentApproved,
+	)
+	if err != nil {
+		logger.Error("failed to delete expired enrollments", slog.Any("err", err))
+
+		return fmt.Errorf("failed to delete expired enrollments: %w", err)
+	}
+
+	var pending, fromAddress int
+
+	// NB: approved requests outlive their expiry, which must not count
+	// against their address forever.
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT count(CASE WHEN state=? THEN 1 END),
+			count(CASE WHEN remote_addr=? AND expires_at>? THEN 1 END)
+		FROM enrollments`,
+		EnrollmentPending,
+		enrollment.RemoteAddr,
+		now,
+	).Scan(&pending, &fromAddress)
+	if err != nil {
+		logger.Error("failed to count enrollments", slog.Any("err", err))
+
+		return fmt.Errorf("failed to count enrollments: %w", err)
+	}
+
+	if pending >= limits.MaxPending {
+		return ErrTooManyEnrollments
+	}
+
+	if fromAddress >= limits.MaxPerAddress {
+		return ErrTooManyAddressEnrollments
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO enrollments
+		(id, name, platform, public_key, remote_addr, state, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		enrollment.ID,
+		enrollment.Name,
+		enrollment.Platform,
+		enrollment.PublicKey,
+		enrollment.RemoteAddr,
+		EnrollmentPending,
+		now,
+		enrollment.ExpiresAt.Unix(),
+	)
+	if err != nil {
+		logger.Error("failed to insert enrollment", slog.Any("err", err))
+
+		return fmt.Errorf("failed to insert enrollment: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Error("failed to commit transaction", slog.Any("err", err))
+
+		return fmt.Errorf("failed to insert enrollment: %w", err)
+	}
+
+	return nil
+}
+
+// GetEnrollment returns the enrollment request with the given id or nil if
+// there is none.
+func (db *DB) GetEnrollment(ctx context.Context, id string) (*Enrollment, error) {
+	rows, err := db.QueryContext(ctx, selectEnrollments+" WHERE id=?", id)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get enrollment", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to get enrollment: %w", err)
+	}
+
+	enrollments, err := scanEnrollments(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(enrollments) == 0 {
+		return nil, nil
+	}
+
+	return &enrollments[0], nil
+}
+
+// ListPendingEnrollments returns the enrollment requests waiting for a
+// decision at now.
+func (db *DB) ListPendingEnrollments(ctx context.Context, now time.Time) ([]Enrollment, error) {
+	rows, err := db.QueryContext(
+		ctx,
+		selectEnrollments+" WHERE state=? AND expires_at>? ORDER BY created_at",
+		EnrollmentPending,
+		now.Unix(),
+	)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list enrollments", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to list enrollments: %w", err)
+	}
+
+	return scanEnrollments(ctx, rows)
+}
+
+// ApproveEnrollment approves a pending enrollment request and adds device,
+// which is enrolled with the public key, name and platform of the request. It
+// reports whether the request was pending at now.
+func (db *DB) ApproveEnrollment(
+	ctx context.Context,
+	id string,
+	device Device,
+	now time.Time,
+) (bool, error) {
+	logger := logging.FromContext(ctx)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("err", err))
+
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	//nolint: errcheck
+	defer tx.Rollback()
+
+	// NB: the state is checked as it is updated so that a request cannot be
+	// approved twice by concurrent requests.
+	err = tx.QueryRowContext(
+		ctx,
+		`UPDATE enrollments SET state=?, device_id=?
+		WHERE id=? AND state=? AND expires_at>? RETURNING public_key, name, platform`,
+		EnrollmentApproved,
+		device.ID,
+		id,
+		EnrollmentPending,
+		now.Unix(),
+	).Scan(&device.PublicKey, &device.Name, &device.Platform)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		logger.Error("failed to approve enrollment", slog.Any("err", err))
+
+		return false, fmt.Errorf("failed to approve enrollment: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO devices (id, role, key_version, public_key, name, platform, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		device.ID,
+		device.Role,
+		device.KeyVersion,
+		device.PublicKey,
+		device.Name,
+		device.Platform,
+		now.Unix(),
+	)
+	if err != nil {
+		logger.Error("failed to insert device", slog.Any("err", err))
+
+		return false, fmt.Errorf("failed to insert device: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Error("failed to commit transaction", slog.Any("err", err))
+
+		return false, fmt.Errorf("failed to approve enrollment: %w", err)
+	}
+
+	return true, nil
+}
+
+// DenyEnrollment denies a pending enrollment request, reporting whether it
+// was pending at now.
+func (db *DB) DenyEnrollment(ctx context.Context, id string, now time.Time) (bool, error) {
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE enrollments SET state=? WHERE id=? AND state=? AND expires_at>?",
+		EnrollmentDenied,
+		id,
+		EnrollmentPending,
+		now.Unix(),
+	)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to deny enrollment",
+			slog.Any("err", err),
+		)
+
+		return false, fmt.Errorf("failed to deny enrollment: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to deny enrollment: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+const selectEnrollments = `SELECT id, name, platform, public_key, remote_addr, state,
+	coalesce(device_id, ''), created_at, expires_at FROM enrollments`
+
+func scanEnrollments(ctx context.Context, rows *sql.Rows) ([]Enrollment, error) {
+	//nolint: errcheck
+	defer rows.Close()
+
+	var enrollments []Enrollment
+
+	for rows.Next() {
+		var (
+			enrollment Enrollment
+			createdAt  int64
+			expiresAt  int64
+		)
+
+		err := rows.Scan(
+			&enrollment.ID,
+			&enrollment.Name,
+			&enrollment.Platform,
+			&enrollment.PublicKey,
+			&enrollment.RemoteAddr,
+			&enrollment.State,
+			&enrollment.DeviceID,
+			&createdAt,
+			&expiresAt,
+		)
+		if err != nil {
+			logging.FromContext(ctx).Error(
+				"failed to scan row",
+				slog.Any("err", err),
+			)
+
+			return nil, fmt.Errorf("failed to scan row for enrollment: %w", err)
+		}
+
+		enrollment.CreatedAt = time.Unix(createdAt, 0)
+		enrollment.ExpiresAt = time.Unix(expiresAt, 0)
+
+		enrollments = append(enrollments, enrollment)
+	}
+
+	err := rows.Err()
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to read rows", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to read rows for enrollments: %w", err)
+	}
+
+	return enrollments, nil
+}
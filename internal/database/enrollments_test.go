@@ -0,0 +1,108 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var testEnrollmentLimits = EnrollmentLimits{MaxPending: 10, MaxPerAddress: 2}
+
+// addTestEnrollment requests the enrollment of a device named id from
+// remoteAddr at now, expiring an hour later.
+func addTestEnrollment(t *testing.T, db *DB, id, remoteAddr string, now time.Time) error {
+	t.Helper()
+
+	return db.AddEnrollment(t.Context(), Enrollment{
+		ID:         id,
+		Name:       id,
+		PublicKey:  []byte(id),
+		RemoteAddr: remoteAddr,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(time.Hour),
+	}, testEnrollmentLimits)
+}
+
+func TestEnrollmentExpiry(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Unix(1_700_000_000, 0)
+
+	for _, id := range []string{"approved", "denied"} {
+		err := addTestEnrollment(t, db, id, "192.0.2.1", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// NB: the third request from the same address is over the limit.
+	err := addTestEnrollment(t, db, "over", "192.0.2.1", now)
+	if !errors.Is(err, ErrTooManyAddressEnrollments) {
+		t.Fatalf("got %v, want %v", err, ErrTooManyAddressEnrollments)
+	}
+
+	approved, err := db.ApproveEnrollment(t.Context(), "approved", Device{
+		ID:         "device",
+		Role:       "member",
+		KeyVersion: 1,
+	}, now)
+	if err != nil || !approved {
+		t.Fatalf("failed to approve: %v, %v", approved, err)
+	}
+
+	denied, err := db.DenyEnrollment(t.Context(), "denied", now)
+	if err != nil || !denied {
+		t.Fatalf("failed to deny: %v, %v", denied, err)
+	}
+
+	// NB: requests are purged as new ones are added, and the address may
+	// request again once its requests expired.
+	later := now.Add(2 * time.Hour)
+
+	for _, id := range []string{"first", "second"} {
+		err = addTestEnrollment(t, db, id, "192.0.2.1", later)
+		if err != nil {
+			t.Fatalf("failed to request after the expiry: %v", err)
+		}
+	}
+
+	tests := []struct {
+		id    string
+		state string
+	}{
+		{id: "approved", state: EnrollmentApproved},
+		{id: "denied"},
+		{id: "first", state: EnrollmentPending},
+	}
+
+	for _, test := range tests {
+		enrollment, err := db.GetEnrollment(t.Context(), test.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var state string
+		if enrollment != nil {
+			state = enrollment.State
+		}
+
+		if state != test.state {
+			t.Errorf("%s: got state %q, want %q", test.id, state, test.state)
+		}
+	}
+
+	enrollment, err := db.GetEnrollment(t.Context(), "approved")
+	if err != nil || enrollment == nil || enrollment.DeviceID != "device" {
+		t.Fatalf("got %+v, %v, want the approved device", enrollment, err)
+	}
+
+	// NB: the approved request goes along with its device.
+	err = db.DeleteDevice(t.Context(), "device")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enrollment, err = db.GetEnrollment(t.Context(), "approved")
+	if err != nil || enrollment != nil {
+		t.Errorf("got %+v, %v after deleting its device, want nothing", enrollment, err)
+	}
+}
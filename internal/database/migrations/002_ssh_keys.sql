@@ -0,0 +1,12 @@
+-- +goose up
+CREATE TABLE ssh_keys (
+  fingerprint TEXT NOT NULL PRIMARY KEY,
+  device_id TEXT NOT NULL REFERENCES devices(id),
+  public_key TEXT NOT NULL,
+  comment TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX ssh_keys_device_id ON ssh_keys (device_id);
+
+-- +goose down
+DROP TABLE ssh_keys;
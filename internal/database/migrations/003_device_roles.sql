@@ -0,0 +1,7 @@
+-- +goose up
+-- NB: devices created before roles existed could do anything, so they stay
+-- admins.
+ALTER TABLE devices ADD COLUMN role TEXT NOT NULL DEFAULT 'admin';
+
+-- +goose down
+ALTER TABLE devices DROP COLUMN role;
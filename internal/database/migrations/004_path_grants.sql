@@ -0,0 +1,12 @@
+-- +goose up
+CREATE TABLE path_grants (
+  device_id TEXT NOT NULL REFERENCES devices(id),
+  prefix TEXT NOT NULL,
+  can_read INTEGER NOT NULL DEFAULT 0,
+  can_write INTEGER NOT NULL DEFAULT 0,
+  can_delete INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (device_id, prefix)
+);
+
+-- +goose down
+DROP TABLE path_grants;
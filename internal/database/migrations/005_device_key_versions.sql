@@ -0,0 +1,7 @@
+-- +goose up
+-- NB: devices enrolled before the server secret had versions derive their
+-- keys from the first version.
+ALTER TABLE devices ADD COLUMN key_version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose down
+ALTER TABLE devices DROP COLUMN key_version;
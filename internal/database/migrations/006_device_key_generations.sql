@@ -0,0 +1,7 @@
+-- +goose up
+-- NB: devices created before keys could be rotated keep the first
+-- generation of their key.
+ALTER TABLE devices ADD COLUMN key_generation INTEGER NOT NULL DEFAULT 0;
+
+-- +goose down
+ALTER TABLE devices DROP COLUMN key_generation;
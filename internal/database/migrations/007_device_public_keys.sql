@@ -0,0 +1,7 @@
+-- +goose up
+-- NB: devices without a public key authenticate with tokens encrypted with
+-- their symmetric key.
+ALTER TABLE devices ADD COLUMN public_key BLOB;
+
+-- +goose down
+ALTER TABLE devices DROP COLUMN public_key;
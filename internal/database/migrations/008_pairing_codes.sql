@@ -0,0 +1,11 @@
+-- +goose up
+-- NB: only hashes of pairing codes are stored, so that reading the database
+-- is not enough to claim a device.
+CREATE TABLE pairing_codes (
+  code_hash TEXT PRIMARY KEY,
+  device_id TEXT NOT NULL REFERENCES devices(id),
+  expires_at INTEGER NOT NULL
+);
+
+-- +goose down
+DROP TABLE pairing_codes;
@@ -0,0 +1,15 @@
+-- +goose up
+CREATE TABLE enrollments (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  platform TEXT NOT NULL,
+  public_key BLOB NOT NULL,
+  remote_addr TEXT NOT NULL,
+  state TEXT NOT NULL,
+  device_id TEXT,
+  created_at INTEGER NOT NULL,
+  expires_at INTEGER NOT NULL
+);
+
+-- +goose down
+DROP TABLE enrollments;
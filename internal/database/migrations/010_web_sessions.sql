@@ -0,0 +1,26 @@
+-- +goose up
+-- NB: only hashes of the secrets held by browsers are stored, so that reading
+-- the database is not enough to log in.
+CREATE TABLE web_logins (
+  secret_hash TEXT PRIMARY KEY,
+  code_hash TEXT NOT NULL UNIQUE,
+  device_id TEXT REFERENCES devices(id),
+  remote_addr TEXT NOT NULL,
+  user_agent TEXT NOT NULL,
+  expires_at INTEGER NOT NULL
+);
+
+CREATE TABLE web_sessions (
+  token_hash TEXT PRIMARY KEY,
+  device_id TEXT NOT NULL REFERENCES devices(id),
+  remote_addr TEXT NOT NULL,
+  user_agent TEXT NOT NULL,
+  created_at INTEGER NOT NULL,
+  expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX web_sessions_device_id ON web_sessions (device_id);
+
+-- +goose down
+DROP TABLE web_sessions;
+DROP TABLE web_logins;
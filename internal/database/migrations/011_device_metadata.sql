@@ -0,0 +1,17 @@
+-- +goose up
+-- NB: devices created before their metadata was recorded have no name or
+-- platform, and no creation time.
+ALTER TABLE devices ADD COLUMN name TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN platform TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN app_version TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN created_at INTEGER;
+ALTER TABLE devices ADD COLUMN last_seen_at INTEGER;
+ALTER TABLE devices ADD COLUMN last_seen_ip TEXT NOT NULL DEFAULT '';
+
+-- +goose down
+ALTER TABLE devices DROP COLUMN last_seen_ip;
+ALTER TABLE devices DROP COLUMN last_seen_at;
+ALTER TABLE devices DROP COLUMN created_at;
+ALTER TABLE devices DROP COLUMN app_version;
+ALTER TABLE devices DROP COLUMN platform;
+ALTER TABLE devices DROP COLUMN name;
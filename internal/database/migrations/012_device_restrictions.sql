@@ -0,0 +1,8 @@
+-- +goose up
+-- NB: a device stays limited to its path grants once it had one, so that
+-- revoking its last grant does not give it the whole storage.
+ALTER TABLE devices ADD COLUMN restricted INTEGER NOT NULL DEFAULT 0;
+UPDATE devices SET restricted=1 WHERE id IN (SELECT device_id FROM path_grants);
+
+-- +goose down
+ALTER TABLE devices DROP COLUMN restricted;
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cmp0st/byte/internal/logging"
+)
+
+type PairingCode struct {
+	// CodeHash is the hash of the code, see key.HashPairingCode.
+	CodeHash  string
+	DeviceID  string
+	ExpiresAt time.Time
+}
+
+func (db *DB) AddPairingCode(ctx context.Context, code PairingCode) error {
+	_, err := db.ExecContext(
+		ctx,
+		"INSERT INTO pairing_codes (code_hash, device_id, expires_at) VALUES (?, ?, ?)",
+		code.CodeHash,
+		code.DeviceID,
+		code.ExpiresAt.Unix(),
+	)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to insert pairing code",
+			slog.Any("err", err),
+		)
+
+		return fmt.Errorf("failed to insert pairing code: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimPairingCode deletes the pairing code with the given hash and returns
+// the id of its device, or an empty id if there is no such code or it expired
+// at now. Expired codes are deleted along the way.
+func (db *DB) ClaimPairingCode(
+	ctx context.Context,
+	codeHash string,
+	now time.Time,
+) (string, error) {
+	logger := logging.FromContext(ctx)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("err", err))
+
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	//nolint: errcheck
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM pairing_codes WHERE expires_at<=?", now.Unix())
+	if err != nil {
+		logger.Error("failed to delete expired pairing codes", slog.Any("err", err))
+
+		return "", fmt.Errorf("failed to delete expired pairing codes: %w", err)
+	}
+
+	// NB: the code is deleted as it is read so that it cannot be claimed
+	// twice by concurrent requests.
+	var deviceID string
+
+	err = tx.QueryRowContext(
+		ctx,
+		"DELETE FROM pairing_codes WHERE code_hash=? RETURNING device_id",
+		codeHash,
+	).Scan(&deviceID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.Error("failed to claim pairing code", slog.Any("err", err))
+
+		return "", fmt.Errorf("failed to claim pairing code: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Error("failed to commit transaction", slog.Any("err", err))
+
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return deviceID, nil
+}
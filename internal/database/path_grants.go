@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/cmp0st/byte/internal/logging"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+// PathGrant limits a device to the paths it is granted. Devices are not
+// limited until they are granted a path, and stay limited once their grants
+// are revoked, see StorageGrants. Granting "/" gives a limited device the
+// whole storage again.
+type PathGrant struct {
+	storage.Grant
+
+	DeviceID string
+}
+
+// SetPathGrant adds the grant or replaces the grant of the device with the same
+// prefix.
+func (db *DB) SetPathGrant(ctx context.Context, grant PathGrant) error {
+	logger := logging.FromContext(ctx)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("err", err))
+
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	//nolint: errcheck
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO path_grants (device_id, prefix, can_read, can_write, can_delete)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (device_id, prefix) DO UPDATE SET
+			can_read=excluded.can_read,
+			can_write=excluded.can_write,
+			can_delete=excluded.can_delete`,
+		grant.DeviceID,
+		grant.Prefix,
+		grant.Read,
+		grant.Write,
+		grant.Delete,
+	)
+	if err != nil {
+		logger.Error(
+			"failed to set path grant",
+			slog.Any("err", err),
+		)
+
+		return fmt.Errorf("failed to set path grant: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE devices SET restricted=1 WHERE id=?", grant.DeviceID)
+	if err != nil {
+		logger.Error(
+			"failed to restrict device",
+			slog.Any("err", err),
+		)
+
+		return fmt.Errorf("failed to restrict device: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Error("failed to commit transaction", slog.Any("err", err))
+
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListPathGrants lists the grants of deviceID, or every grant when deviceID is
+// empty.
+func (db *DB) ListPathGrants(ctx context.Context, deviceID string) ([]PathGrant, error) {
+	query := "SELECT device_id, prefix, can_read, can_write, can_delete FROM path_grants"
+	args := []any{}
+
+	if deviceID != "" {
+		query += " WHERE device_id=?"
+		args = append(args, deviceID)
+	}
+
+	query += " ORDER BY device_id, prefix"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to list path grants",
+			slog.Any("err", err),
+		)
+
+		return nil, fmt.Errorf("failed to list path grants: %w", err)
+	}
+	//nolint: errcheck
+	defer rows.Close()
+
+	var grants []PathGrant
+
+	for rows.Next() {
+		var grant PathGrant
+
+		err = rows.Scan(
+			&grant.DeviceID,
+			&grant.Prefix,
+			&grant.Read,
+			&grant.Write,
+			&grant.Delete,
+		)
+		if err != nil {
+			logging.FromContext(ctx).Error(
+				"failed to scan row",
+				slog.Any("err", err),
+			)
+
+			return nil, fmt.Errorf("failed to scan row for path grant: %w", err)
+		}
+
+		grants = append(grants, grant)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to read rows",
+			slog.Any("err", err),
+		)
+
+		return nil, fmt.Errorf("failed to read rows while listing path grants: %w", err)
+	}
+
+	return grants, nil
+}
+
+// RemovePathGrant removes the grant of deviceID for prefix, reporting whether
+// there was one.
+func (db *DB) RemovePathGrant(ctx context.Context, deviceID, prefix string) (bool, error) {
+	res, err := db.ExecContext(
+		ctx,
+		"DELETE FROM path_grants WHERE device_id=? AND prefix=?",
+		deviceID,
+		prefix,
+	)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to delete path grant",
+			slog.Any("err", err),
+		)
+
+		return false, fmt.Errorf("failed to delete path grant: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete path grant: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// StorageGrants returns the grants of deviceID to limit its storage with, see
+// storage.NewGranted, and whether it is limited at all. A limited device may
+// have no grants left, in which case it sees nothing.
+func (db *DB) StorageGrants(ctx context.Context, deviceID string) ([]storage.Grant, bool, error) {
+	// NB: an empty device would list the grants of every device.
+	if deviceID == "" {
+		return nil, false, nil
+	}
+
+	var restricted bool
+
+	err := db.QueryRowContext(
+		ctx,
+		"SELECT restricted FROM devices WHERE id=?",
+		deviceID,
+	).Scan(&restricted)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logging.FromContext(ctx).Error(
+			"failed to get device restriction",
+			slog.Any("err", err),
+		)
+
+		return nil, false, fmt.Errorf("failed to get device restriction: %w", err)
+	}
+
+	if !restricted {
+		return nil, false, nil
+	}
+
+	grants, err := db.ListPathGrants(ctx, deviceID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	limits := make([]storage.Grant, len(grants))
+	for i, grant := range grants {
+		limits[i] = grant.Grant
+	}
+
+	return limits, true, nil
+}
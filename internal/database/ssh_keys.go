@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/cmp0st/byte/internal/logging"
+)
+
+type SSHKey struct {
+	Fingerprint string
+	DeviceID    string
+	PublicKey   string
+	Comment     string
+}
+
+func (db *DB) AddSSHKey(ctx context.Context, key SSHKey) error {
+	_, err := db.ExecContext(
+		ctx,
+		"INSERT INTO ssh_keys (fingerprint, device_id, public_key, comment) VALUES (?, ?, ?, ?)",
+		key.Fingerprint,
+		key.DeviceID,
+		key.PublicKey,
+		key.Comment,
+	)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to insert ssh key",
+			slog.Any("err", err),
+		)
+
+		return fmt.Errorf("failed to insert ssh key: %w", err)
+	}
+
+	return nil
+}
+
+// GetSSHKey returns the key with the given fingerprint or nil if no device has
+// registered it.
+func (db *DB) GetSSHKey(ctx context.Context, fingerprint string) (*SSHKey, error) {
+	var key SSHKey
+
+	err := db.QueryRowContext(
+		ctx,
+		"SELECT fingerprint, device_id, public_key, comment FROM ssh_keys WHERE fingerprint=?",
+		fingerprint,
+	).Scan(&key.Fingerprint, &key.DeviceID, &key.PublicKey, &key.Comment)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get ssh key", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to get ssh key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// ListSSHKeys lists the keys registered to deviceID, or every key when
+// deviceID is empty.
+func (db *DB) ListSSHKeys(ctx context.Context, deviceID string) ([]SSHKey, error) {
+	query := "SELECT fingerprint, device_id, public_key, comment FROM ssh_keys"
+	args := []any{}
+
+	if deviceID != "" {
+		query += " WHERE device_id=?"
+		args = append(args, deviceID)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to list ssh keys",
+			slog.Any("err", err),
+		)
+
+		return nil, fmt.Errorf("failed to list ssh keys: %w", err)
+	}
+	//nolint: errcheck
+	defer rows.Close()
+
+	var keys []SSHKey
+
+	for rows.Next() {
+		var key SSHKey
+
+		err = rows.Scan(&key.Fingerprint, &key.DeviceID, &key.PublicKey, &key.Comment)
+		if err != nil {
+			logging.FromContext(ctx).Error(
+				"failed to scan row",
+				slog.Any("err", err),
+			)
+
+			return nil, fmt.Errorf("failed to scan row for ssh key: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to read rows",
+			slog.Any("err", err),
+		)
+
+		return nil, fmt.Errorf("failed to read rows while listing ssh keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (db *DB) RemoveSSHKey(ctx context.Context, fingerprint string) error {
+	_, err := db.ExecContext(ctx, "DELETE FROM ssh_keys WHERE fingerprint=?", fingerprint)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to delete ssh key",
+			slog.Any("err", err),
+		)
+
+		return fmt.Errorf("failed to delete ssh key: %w", err)
+	}
+
+	return nil
+}
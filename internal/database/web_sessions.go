@@ -0,0 +1,316 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cmp0st/byte/internal/logging"
+)
+
+var (
+	ErrTooManyWebLogins        = errors.New("too many pending web logins")
+	ErrTooManyAddressWebLogins = errors.New("too many web logins from address")
+	ErrWebLoginPending         = errors.New("web login not approved yet")
+)
+
+// WebLogin is a browser waiting for a paired device to approve its code.
+type WebLogin struct {
+	// SecretHash is the hash of the secret the browser holds, which it
+	// completes the login with.
+	SecretHash string
+
+	// CodeHash is the hash of the code the browser shows, which a device
+	// approves the login with.
+	CodeHash string
+
+	RemoteAddr string
+	UserAgent  string
+	ExpiresAt  time.Time
+}
+
+// WebLoginLimits bound the web logins that can be started without
+// authentication.
+type WebLoginLimits struct {
+	// MaxPending bounds the logins that have not expired yet.
+	MaxPending int
+
+	// MaxPerAddress bounds the logins started from the same address that
+	// have not expired yet.
+	MaxPerAddress int
+}
+
+// WebSession authenticates a browser as the device that approved its login.
+type WebSession struct {
+	TokenHash  string
+	DeviceID   string
+	RemoteAddr string
+	UserAgent  string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// AddWebLogin stores a new web login, unless it would exceed limits. Expired
+// logins are deleted along the way.
+func (db *DB) AddWebLogin(
+	ctx context.Context,
+	login WebLogin,
+	limits WebLoginLimits,
+	now time.Time,
+) error {
+	logger := logging.FromContext(ctx)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("err", err))
+
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	//nolint: errcheck
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM web_logins WHERE expires_at<=?", now.Unix())
+	if err != nil {
+		logger.Error("failed to delete expired web logins", slog.Any("err", err))
+
+		return fmt.Errorf("failed to delete expired web logins: %w", err)
+	}
+
+	var pending, fromAddress int
+
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT count(*), count(CASE WHEN remote_addr=? THEN 1 END) FROM web_logins",
+		login.RemoteAddr,
+	).Scan(&pending, &fromAddress)
+	if err != nil {
+		logger.Error("failed to count web logins", slog.Any("err", err))
+
+		return fmt.Errorf("failed to count web logins: %w", err)
+	}
+
+	if pending >= limits.MaxPending {
+		return ErrTooManyWebLogins
+	}
+
+	if fromAddress >= limits.MaxPerAddress {
+		return ErrTooManyAddressWebLogins
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO web_logins
+		(secret_hash, code_hash, remote_addr, user_agent, expires_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		login.SecretHash,
+		login.CodeHash,
+		login.RemoteAddr,
+		login.UserAgent,
+		login.ExpiresAt.Unix(),
+	)
+	if err != nil {
+		logger.Error("failed to insert web login", slog.Any("err", err))
+
+		return fmt.Errorf("failed to insert web login: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Error("failed to commit transaction", slog.Any("err", err))
+
+		return fmt.Errorf("failed to insert web login: %w", err)
+	}
+
+	return nil
+}
+
+// ApproveWebLogin approves the pending web login with codeHash for
+// deviceID, and returns it, or nil if there is no such login.
+func (db *DB) ApproveWebLogin(
+	ctx context.Context,
+	codeHash string,
+	deviceID string,
+	now time.Time,
+) (*WebLogin, error) {
+	login := WebLogin{CodeHash: codeHash}
+
+	var expiresAt int64
+
+	// NB: a login can only be approved once, so that a device cannot take
+	// over the login another device approved.
+	err := db.QueryRowContext(
+		ctx,
+		`UPDATE web_logins SET device_id=?
+		WHERE code_hash=? AND device_id IS NULL AND expires_at>?
+		RETURNING secret_hash, remote_addr, user_agent, expires_at`,
+		deviceID,
+		codeHash,
+		now.Unix(),
+	).Scan(&login.SecretHash, &login.RemoteAddr, &login.UserAgent, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to approve web login", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to approve web login: %w", err)
+	}
+
+	login.ExpiresAt = time.Unix(expiresAt, 0)
+
+	return &login, nil
+}
+
+// CompleteWebLogin exchanges the approved web login with secretHash for
+// session, which is stored for the device that approved it. It returns the
+// stored session, ErrWebLoginPending if the login is not approved yet, or nil
+// if there is no such login. Expired logins and sessions are deleted along the
+// way.
+func (db *DB) CompleteWebLogin(
+	ctx context.Context,
+	secretHash string,
+	session WebSession,
+) (*WebSession, error) {
+	logger := logging.FromContext(ctx)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	//nolint: errcheck
+	defer tx.Rollback()
+
+	now := session.CreatedAt.Unix()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM web_logins WHERE expires_at<=?", now)
+	if err != nil {
+		logger.Error("failed to delete expired web logins", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to delete expired web logins: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM web_sessions WHERE expires_at<=?", now)
+	if err != nil {
+		logger.Error("failed to delete expired web sessions", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to delete expired web sessions: %w", err)
+	}
+
+	var deviceID sql.NullString
+
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT device_id FROM web_logins WHERE secret_hash=?",
+		secretHash,
+	).Scan(&deviceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// NB: the expired logins deleted above are committed all the same.
+		return nil, tx.Commit()
+	}
+
+	if err != nil {
+		logger.Error("failed to get web login", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to get web login: %w", err)
+	}
+
+	if !deviceID.Valid {
+		return nil, ErrWebLoginPending
+	}
+
+	// NB: the login is deleted so that its secret is only ever exchanged
+	// for a single session.
+	_, err = tx.ExecContext(ctx, "DELETE FROM web_logins WHERE secret_hash=?", secretHash)
+	if err != nil {
+		logger.Error("failed to delete web login", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to delete web login: %w", err)
+	}
+
+	session.DeviceID = deviceID.String
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO web_sessions
+		(token_hash, device_id, remote_addr, user_agent, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		session.TokenHash,
+		session.DeviceID,
+		session.RemoteAddr,
+		session.UserAgent,
+		now,
+		session.ExpiresAt.Unix(),
+	)
+	if err != nil {
+		logger.Error("failed to insert web session", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to insert web session: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Error("failed to commit transaction", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to insert web session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// GetWebSession returns the web session with tokenHash, or nil if there is
+// none or it expired by now.
+func (db *DB) GetWebSession(
+	ctx context.Context,
+	tokenHash string,
+	now time.Time,
+) (*WebSession, error) {
+	session := WebSession{TokenHash: tokenHash}
+
+	var createdAt, expiresAt int64
+
+	err := db.QueryRowContext(
+		ctx,
+		`SELECT device_id, remote_addr, user_agent, created_at, expires_at
+		FROM web_sessions WHERE token_hash=? AND expires_at>?`,
+		tokenHash,
+		now.Unix(),
+	).Scan(&session.DeviceID, &session.RemoteAddr, &session.UserAgent, &createdAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get web session", slog.Any("err", err))
+
+		return nil, fmt.Errorf("failed to get web session: %w", err)
+	}
+
+	session.CreatedAt = time.Unix(createdAt, 0)
+	session.ExpiresAt = time.Unix(expiresAt, 0)
+
+	return &session, nil
+}
+
+// DeleteWebSession deletes the web session with tokenHash, and reports
+// whether there was one.
+func (db *DB) DeleteWebSession(ctx context.Context, tokenHash string) (bool, error) {
+	res, err := db.ExecContext(ctx, "DELETE FROM web_sessions WHERE token_hash=?", tokenHash)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to delete web session", slog.Any("err", err))
+
+		return false, fmt.Errorf("failed to delete web session: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete web session: %w", err)
+	}
+
+	return n > 0, nil
+}
@@ -0,0 +1,133 @@
+// Package git serves git fetches and pushes over SSH from bare repositories
+// kept in a storage backend, using a pure Go git implementation.
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+// Commands a git client runs on the remote end of an SSH connection.
+const (
+	UploadPackCommand  = "git-upload-pack"
+	ReceivePackCommand = "git-receive-pack"
+)
+
+// RepositorySuffix is the conventional suffix of bare repository directories.
+// It is added to repository paths that do not exist without it, and new
+// repositories are only created on push for paths that carry it.
+const RepositorySuffix = ".git"
+
+var (
+	ErrInvalidRepositoryPath = errors.New("invalid repository path")
+	ErrRepositoryNotFound    = transport.ErrRepositoryNotFound
+)
+
+// CleanRepositoryPath validates a repository path as sent by a git client,
+// e.g. '/photos.git' or '~/photos.git', and returns it as an absolute storage
+// path.
+func CleanRepositoryPath(name string) (string, error) {
+	// NB: scp-like URLs such as user@host:~/repo.git are relative to the
+	// login directory, which is the storage root.
+	name = strings.TrimPrefix(name, "~")
+
+	if name == "" || strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRepositoryPath, name)
+	}
+
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("%w: %q", ErrInvalidRepositoryPath, name)
+		}
+	}
+
+	cleaned := path.Clean("/" + name)
+	if cleaned == "/" || strings.TrimSuffix(path.Base(cleaned), RepositorySuffix) == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRepositoryPath, name)
+	}
+
+	return cleaned, nil
+}
+
+// openRepository returns the storer of the bare repository at name, trying
+// name with RepositorySuffix appended if name does not exist.
+func openRepository(s storage.Interface, name string) (storer.Storer, error) {
+	candidates := []string{name}
+	if !strings.HasSuffix(name, RepositorySuffix) {
+		candidates = append(candidates, name+RepositorySuffix)
+	}
+
+	fs := storage.NewBilly(s)
+
+	for _, candidate := range candidates {
+		// NB: every bare repository has a config file at its root, which
+		// also rules out plain directories and work trees.
+		info, err := fs.Stat(path.Join(candidate, "config"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat repository: %w", err)
+		}
+
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		root, err := fs.Chroot(candidate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open repository: %w", err)
+		}
+
+		return filesystem.NewStorage(root, cache.NewObjectLRUDefault()), nil
+	}
+
+	return nil, ErrRepositoryNotFound
+}
+
+// initRepository creates an empty bare repository at name.
+func initRepository(s storage.Interface, name string) (storer.Storer, error) {
+	if !strings.HasSuffix(name, RepositorySuffix) {
+		return nil, fmt.Errorf(
+			"%w: new repositories must end in %s",
+			ErrRepositoryNotFound,
+			RepositorySuffix,
+		)
+	}
+
+	_, err := s.Stat(name)
+	if err == nil {
+		return nil, fmt.Errorf(
+			"%w: %s exists and is not a repository",
+			ErrInvalidRepositoryPath,
+			name,
+		)
+	}
+
+	root, err := storage.NewBilly(s).Chroot(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository: %w", err)
+	}
+
+	st := filesystem.NewStorage(root, cache.NewObjectLRUDefault())
+
+	_, err = gogit.InitWithOptions(st, nil, gogit.InitOptions{DefaultBranch: plumbing.Main})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository: %w", err)
+	}
+
+	return st, nil
+}
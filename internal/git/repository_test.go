@@ -0,0 +1,106 @@
+package git
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+func TestCleanRepositoryPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		err  error
+	}{
+		{name: "/photos.git", want: "/photos.git"},
+		{name: "~/photos.git", want: "/photos.git"},
+		{name: "photos", want: "/photos"},
+		{name: "/backup/./photos.git/", want: "/backup/photos.git"},
+		{name: "", err: ErrInvalidRepositoryPath},
+		{name: "~", err: ErrInvalidRepositoryPath},
+		{name: "/", err: ErrInvalidRepositoryPath},
+		{name: "/.git", err: ErrInvalidRepositoryPath},
+		{name: "/../photos.git", err: ErrInvalidRepositoryPath},
+		{name: "/photos/../../etc", err: ErrInvalidRepositoryPath},
+		{name: "/photos\x00.git", err: ErrInvalidRepositoryPath},
+	}
+
+	for _, test := range tests {
+		got, err := CleanRepositoryPath(test.name)
+		if !errors.Is(err, test.err) || got != test.want {
+			t.Errorf(
+				"CleanRepositoryPath(%q) = %q, %v, want %q, %v",
+				test.name, got, err, test.want, test.err,
+			)
+		}
+	}
+}
+
+func TestOpenRepository(t *testing.T) {
+	st := storage.NewInMemory()
+
+	_, err := initRepository(st, "/photos.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = st.MkdirAll("/plain", 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = afero.WriteFile(st, "/file.git", []byte("data"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "/photos.git"},
+		{name: "/photos"},
+		{name: "/plain", err: ErrRepositoryNotFound},
+		{name: "/missing", err: ErrRepositoryNotFound},
+	}
+
+	for _, test := range tests {
+		_, err := openRepository(st, test.name)
+		if !errors.Is(err, test.err) {
+			t.Errorf("openRepository(%q) = %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestInitRepository(t *testing.T) {
+	st := storage.NewInMemory()
+
+	err := afero.WriteFile(st, "/file.git", []byte("data"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "/backup/photos.git"},
+		{name: "/photos", err: ErrRepositoryNotFound},
+		{name: "/file.git", err: ErrInvalidRepositoryPath},
+	}
+
+	for _, test := range tests {
+		_, err := initRepository(st, test.name)
+		if !errors.Is(err, test.err) {
+			t.Errorf("initRepository(%q) = %v, want %v", test.name, err, test.err)
+		}
+	}
+
+	head, err := afero.ReadFile(st, "/backup/photos.git/HEAD")
+	if err != nil || string(head) != "ref: refs/heads/main\n" {
+		t.Errorf("HEAD of new repository = %q, %v, want main", head, err)
+	}
+}
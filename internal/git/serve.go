@@ -0,0 +1,198 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-git/go-git/v5/plumbing/format/pktline"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+// UploadPack serves a fetch or clone of the repository at name, speaking the
+// git pack protocol on stdin and stdout.
+func UploadPack(
+	ctx context.Context,
+	s storage.Interface,
+	name string,
+	stdin io.Reader,
+	stdout io.Writer,
+) error {
+	st, err := openRepository(s, name)
+	if err != nil {
+		return err
+	}
+
+	srv := server.NewServer(storerLoader{st})
+
+	sess, err := srv.NewUploadPackSession(&transport.Endpoint{Path: name}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start upload-pack: %w", err)
+	}
+	//nolint: errcheck
+	defer sess.Close()
+
+	refs, err := sess.AdvertisedReferencesContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to advertise references: %w", err)
+	}
+
+	err = refs.Encode(stdout)
+	if err != nil {
+		return fmt.Errorf("failed to advertise references: %w", err)
+	}
+
+	r := bufio.NewReader(stdin)
+
+	done, err := flushed(r)
+	if done || err != nil {
+		return err
+	}
+
+	req := packp.NewUploadPackRequest()
+
+	err = req.Decode(r)
+	if err != nil {
+		return fmt.Errorf("failed to decode upload-pack request: %w", err)
+	}
+
+	resp, err := sess.UploadPack(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to upload pack: %w", err)
+	}
+	//nolint: errcheck
+	defer resp.Close()
+
+	err = resp.Encode(stdout)
+	if err != nil {
+		return fmt.Errorf("failed to send pack: %w", err)
+	}
+
+	return nil
+}
+
+// ReceivePack serves a push to the repository at name, speaking the git pack
+// protocol on stdin and stdout. A missing repository is created if its name
+// ends in RepositorySuffix.
+func ReceivePack(
+	ctx context.Context,
+	s storage.Interface,
+	name string,
+	stdin io.Reader,
+	stdout io.Writer,
+) error {
+	st, err := openRepository(s, name)
+	if errors.Is(err, ErrRepositoryNotFound) {
+		st, err = initRepository(s, name)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	srv := server.NewServer(storerLoader{st})
+
+	sess, err := srv.NewReceivePackSession(&transport.Endpoint{Path: name}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start receive-pack: %w", err)
+	}
+	//nolint: errcheck
+	defer sess.Close()
+
+	refs, err := sess.AdvertisedReferencesContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to advertise references: %w", err)
+	}
+
+	err = refs.Encode(stdout)
+	if err != nil {
+		return fmt.Errorf("failed to advertise references: %w", err)
+	}
+
+	r := bufio.NewReader(stdin)
+
+	done, err := flushed(r)
+	if done || err != nil {
+		return err
+	}
+
+	req := packp.NewReferenceUpdateRequest()
+
+	err = req.Decode(r)
+	if err != nil {
+		return fmt.Errorf("failed to decode receive-pack request: %w", err)
+	}
+
+	// NB: the decoder hands over the rest of stdin as the packfile, but
+	// clients send none for pushes that only delete references and an empty
+	// one for pushes that only move references to existing objects.
+	if deletesOnly(req) || empty(r) {
+		req.Packfile = nil
+	}
+
+	status, err := sess.ReceivePack(ctx, req)
+	if status != nil {
+		// NB: the status is reported even on failure so the client can tell
+		// which references were rejected.
+		encodeErr := status.Encode(stdout)
+		if encodeErr != nil && err == nil {
+			err = fmt.Errorf("failed to report status: %w", encodeErr)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to receive pack: %w", err)
+	}
+
+	return nil
+}
+
+// flushed reports whether the client ended the conversation right after the
+// reference advertisement, as git ls-remote and up to date fetches do.
+func flushed(r *bufio.Reader) (bool, error) {
+	prefix, err := r.Peek(len(pktline.FlushPkt))
+	if errors.Is(err, io.EOF) {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to read request: %w", err)
+	}
+
+	return bytes.Equal(prefix, pktline.FlushPkt), nil
+}
+
+// deletesOnly reports whether every command of req deletes a reference.
+func deletesOnly(req *packp.ReferenceUpdateRequest) bool {
+	for _, cmd := range req.Commands {
+		if cmd.Action() != packp.Delete {
+			return false
+		}
+	}
+
+	return true
+}
+
+// empty reports whether the client closed stdin without sending a packfile.
+func empty(r *bufio.Reader) bool {
+	_, err := r.Peek(1)
+
+	return errors.Is(err, io.EOF)
+}
+
+// storerLoader hands an already opened repository to the go-git server.
+type storerLoader struct {
+	storer storer.Storer
+}
+
+func (l storerLoader) Load(*transport.Endpoint) (storer.Storer, error) {
+	return l.storer, nil
+}
@@ -0,0 +1,76 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/format/pktline"
+
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+func TestUploadPackAdvertisement(t *testing.T) {
+	st := storage.NewInMemory()
+
+	_, err := initRepository(st, "/photos.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		stdin []byte
+	}{
+		{name: "closed", stdin: nil},
+		{name: "flushed", stdin: pktline.FlushPkt},
+	}
+
+	for _, test := range tests {
+		var stdout bytes.Buffer
+
+		err := UploadPack(t.Context(), st, "/photos", bytes.NewReader(test.stdin), &stdout)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+
+			continue
+		}
+
+		// NB: an empty repository advertises its capabilities alone.
+		if !strings.Contains(stdout.String(), "capabilities^{}") {
+			t.Errorf("%s: advertised %q, want capabilities", test.name, stdout.String())
+		}
+	}
+}
+
+func TestServeMissingRepository(t *testing.T) {
+	st := storage.NewInMemory()
+
+	var stdout bytes.Buffer
+
+	err := UploadPack(t.Context(), st, "/photos.git", bytes.NewReader(nil), &stdout)
+	if !errors.Is(err, ErrRepositoryNotFound) {
+		t.Errorf("UploadPack of missing repository = %v, want %v", err, ErrRepositoryNotFound)
+	}
+
+	err = ReceivePack(t.Context(), st, "/photos", bytes.NewReader(nil), &stdout)
+	if !errors.Is(err, ErrRepositoryNotFound) {
+		t.Errorf("ReceivePack to missing /photos = %v, want %v", err, ErrRepositoryNotFound)
+	}
+
+	if stdout.Len() != 0 {
+		t.Errorf("served %q from a missing repository", stdout.String())
+	}
+
+	// NB: pushing to a name with the suffix creates the repository.
+	err = ReceivePack(t.Context(), st, "/photos.git", bytes.NewReader(nil), &stdout)
+	if err != nil {
+		t.Fatalf("ReceivePack to missing /photos.git = %v", err)
+	}
+
+	_, err = openRepository(st, "/photos.git")
+	if err != nil {
+		t.Errorf("repository created by push: %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/hkdf"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -43,6 +44,7 @@ const (
 var (
 	ErrInvalidClientRootKey = errors.New("valid client root key")
 	ErrInvalidClientID      = errors.New("invalid client id")
+	ErrMissingServerID      = errors.New("missing server id")
 )
 
 type ClientChain struct {
@@ -50,6 +52,25 @@ type ClientChain struct {
 
 	// ClientID is optionally set if this is a client key chain
 	ClientID string
+
+	// ServerID is the identity of the server tokens are minted for, see
+	// ServerChain.Identity. It is only needed to mint tokens.
+	ServerID string
+
+	// KeyVersion is the version of the server secret the chain is derived
+	// from, see ServerKeyring. Tokens name it in their footer unless it is
+	// DefaultKeyVersion, which servers assume when it is missing.
+	KeyVersion int
+
+	// KeyGeneration counts the rotations of the device key, see
+	// ServerChain.ClientChain. Tokens name it in their footer along with
+	// KeyVersion unless it is 0.
+	KeyGeneration int
+
+	// SigningKey is set for devices that registered their public key with the
+	// server. Tokens are then signed v4.public tokens instead of v4.local
+	// tokens encrypted with a key the server can derive.
+	SigningKey *paseto.V4AsymmetricSecretKey
 }
 
 func NewClientChain(root []byte, clientID string) (*ClientChain, error) {
@@ -57,13 +78,9 @@ func NewClientChain(root []byte, clientID string) (*ClientChain, error) {
 		return nil, ErrInvalidClientRootKey
 	}
 
-	id, err := uuid.Parse(clientID)
+	err := validateClientID(clientID)
 	if err != nil {
-		return nil, ErrInvalidClientID
-	}
-
-	if id.Version() != ClientIDUUIDVersion {
-		return nil, ErrInvalidClientID
+		return nil, err
 	}
 
 	chain := ClientChain{
@@ -78,6 +95,37 @@ func NewClientChain(root []byte, clientID string) (*ClientChain, error) {
 	return &chain, nil
 }
 
+// NewSigningClientChain returns the chain of a device that was enrolled with
+// its public key, see SigningKey. It has no root key, so it can only mint
+// tokens.
+func NewSigningClientChain(
+	signingKey paseto.V4AsymmetricSecretKey,
+	clientID string,
+) (*ClientChain, error) {
+	err := validateClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClientChain{
+		ClientID:   clientID,
+		SigningKey: &signingKey,
+	}, nil
+}
+
+func validateClientID(clientID string) error {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return ErrInvalidClientID
+	}
+
+	if id.Version() != ClientIDUUIDVersion {
+		return ErrInvalidClientID
+	}
+
+	return nil
+}
+
 func (c ClientChain) TokenKey() (*paseto.V4SymmetricKey, error) {
 	if c.ClientID == "" {
 		return nil, errors.New("cannot derive client token key for server key chain")
@@ -102,13 +150,67 @@ func (c ClientChain) TokenKey() (*paseto.V4SymmetricKey, error) {
 	return &tokenKey, nil
 }
 
+// TokenBinding ties a token to a single request, so that an intercepted
+// token cannot be used for any other request even before the server has seen
+// it. It is sent as the token footer, which the token authenticates.
+type TokenBinding struct {
+	// Procedure is the full RPC procedure name, e.g. /byte.v1.FileService/Stat.
+	Procedure string `json:"procedure"`
+
+	// BodySHA256 is the hex encoded SHA-256 hash of the request message.
+	BodySHA256 string `json:"body_sha256"`
+}
+
+// TokenFooter is the footer of device tokens. Unlike the claims it can be
+// read before the token is decrypted, which lets the server pick the key.
+type TokenFooter struct {
+	// KeyID names the version of the server secret the device key is
+	// derived from and the generation of the key, see KeyID.
+	KeyID string `json:"kid,omitempty"`
+
+	// TokenBinding is set for tokens bound to a request.
+	*TokenBinding
+}
+
+// Token mints a token for a single request to the server identified by
+// ServerID. Every token carries a random jti claim so the server can refuse
+// to accept it twice.
 func (c ClientChain) Token() (*string, error) {
+	return c.token(nil)
+}
+
+// BoundToken mints a token like Token that is only valid for the request
+// described by binding.
+func (c ClientChain) BoundToken(binding TokenBinding) (*string, error) {
+	return c.token(&binding)
+}
+
+func (c ClientChain) token(binding *TokenBinding) (*string, error) {
+	if c.ServerID == "" {
+		return nil, ErrMissingServerID
+	}
+
+	footer, err := c.footer(binding)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 
 	token := paseto.NewToken()
 	token.SetExpiration(now.Add(DefaultTokenExpiration))
 	token.SetIssuedAt(now)
 	token.SetNotBefore(now)
+	token.SetJti(uuid.NewString())
+	token.SetAudience(c.ServerID)
+	token.SetIssuer(c.ClientID)
+	token.SetFooter(footer)
+
+	if c.SigningKey != nil {
+		tokenStr := token.V4Sign(*c.SigningKey, []byte(c.ClientID))
+
+		return &tokenStr, nil
+	}
 
 	tokenKey, err := c.TokenKey()
 	if err != nil {
@@ -120,6 +222,29 @@ func (c ClientChain) Token() (*string, error) {
 	return &tokenStr, nil
 }
 
+// footer encodes the footer of a token. Tokens that are neither bound nor
+// minted with a later key version or generation have none, as before either
+// existed. Signed tokens never name a key, since a device has a single
+// public key.
+func (c ClientChain) footer(binding *TokenBinding) ([]byte, error) {
+	footer := TokenFooter{TokenBinding: binding}
+
+	if c.SigningKey == nil && (c.KeyVersion > DefaultKeyVersion || c.KeyGeneration > 0) {
+		footer.KeyID = KeyID(max(c.KeyVersion, DefaultKeyVersion), c.KeyGeneration)
+	}
+
+	if footer == (TokenFooter{}) {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(footer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token footer: %w", err)
+	}
+
+	return raw, nil
+}
+
 func (c ClientChain) EncryptKey(plaintext []byte) ([]byte, error) {
 	encKey, err := hkdf.Key(
 		sha256.New,
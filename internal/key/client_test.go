@@ -0,0 +1,71 @@
+package key
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
+)
+
+func TestClientToken(t *testing.T) {
+	chain, err := newTestServerChain(t, "0123456789abcdef0123456789abcdef").
+		ClientChain(uuid.NewString(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = chain.Token()
+	if !errors.Is(err, ErrMissingServerID) {
+		t.Fatalf("minted a token without a server id: %v", err)
+	}
+
+	chain.ServerID = ServerIdentityPrefix + "server"
+
+	tokenStr, err := chain.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenKey, err := chain.TokenKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NB: the client id is the implicit assertion, so a token cannot be
+	// presented as coming from another device.
+	_, err = paseto.NewParser().ParseV4Local(*tokenKey, *tokenStr, []byte(uuid.NewString()))
+	if err == nil {
+		t.Error("decrypted a token as another device")
+	}
+
+	token, err := paseto.NewParser().ParseV4Local(*tokenKey, *tokenStr, []byte(chain.ClientID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audience, err := token.GetAudience()
+	if err != nil || audience != chain.ServerID {
+		t.Errorf("audience: got %q, %v, want %q", audience, err, chain.ServerID)
+	}
+
+	issuer, err := token.GetIssuer()
+	if err != nil || issuer != chain.ClientID {
+		t.Errorf("issuer: got %q, %v, want %q", issuer, err, chain.ClientID)
+	}
+
+	expiration, err := token.GetExpiration()
+	if err != nil || expiration.After(time.Now().Add(DefaultTokenExpiration)) {
+		t.Errorf("expiration: got %v, %v, want within %v", expiration, err, DefaultTokenExpiration)
+	}
+
+	other, err := chain.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *other == *tokenStr {
+		t.Error("minted the same token twice")
+	}
+}
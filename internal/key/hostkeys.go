@@ -0,0 +1,221 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// HKDF domain separators for the versioned SSH host keys. Version 1 of a
+	// key uses the separator as is and later versions append ".<version>".
+	// Version 1 of the Ed25519 key uses ServerSSHHostKeyDomainSeparator,
+	// which predates rotation, so existing known_hosts entries stay valid.
+	ServerSSHHostKeyEd25519DomainSeparator = `server.ssh.host-key.ed25519.v1`
+	ServerSSHHostKeyECDSADomainSeparator   = `server.ssh.host-key.ecdsa-p256.v1`
+	ServerSSHHostKeyRSADomainSeparator     = `server.ssh.host-key.rsa.v1`
+
+	// The first host key version, which is used unless configured otherwise.
+	DefaultSSHHostKeyVersion = 1
+
+	// NB: 8 bytes more than the P-256 order so reducing the derived scalar
+	// modulo the order is not measurably biased, see FIPS 186-5 A.2.1.
+	ServerSSHHostKeyECDSASeedSize = 40
+
+	// Same size OpenSSH uses for new RSA host keys.
+	ServerSSHHostKeyRSABits = 3072
+
+	// Public exponent of the RSA host key.
+	ServerSSHHostKeyRSAExponent = 65537
+
+	// Miller-Rabin rounds when searching RSA primes. Together with the
+	// Baillie-PSW test big.Int always runs this is far beyond what is
+	// needed to rule out composites.
+	ServerSSHHostKeyRSAPrimeRounds = 20
+)
+
+var ErrInvalidSSHHostKeyVersion = errors.New("invalid ssh host key version")
+
+// SSHHostKeys derives the Ed25519, ECDSA P-256 and RSA host keys of version,
+// in that order. The same server secret and version always yield the same
+// keys, so they never need to be stored.
+func (c ServerChain) SSHHostKeys(version int) ([]crypto.Signer, error) {
+	ed25519Key, err := c.SSHHostKeyEd25519(version)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaKey, err := c.SSHHostKeyECDSA(version)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, err := c.SSHHostKeyRSA(version)
+	if err != nil {
+		return nil, err
+	}
+
+	return []crypto.Signer{ed25519Key, ecdsaKey, rsaKey}, nil
+}
+
+func (c ServerChain) SSHHostKeyEd25519(version int) (ed25519.PrivateKey, error) {
+	if version == DefaultSSHHostKeyVersion {
+		return c.SSHHostKey()
+	}
+
+	domain, err := hostKeyDomain(ServerSSHHostKeyEd25519DomainSeparator, version)
+	if err != nil {
+		return nil, err
+	}
+
+	keyseed, err := hkdf.Key(sha256.New, c.Seed[:], nil, domain, ed25519.SeedSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive ssh ed25519 host key: %w", err)
+	}
+
+	return ed25519.NewKeyFromSeed(keyseed), nil
+}
+
+func (c ServerChain) SSHHostKeyECDSA(version int) (*ecdsa.PrivateKey, error) {
+	domain, err := hostKeyDomain(ServerSSHHostKeyECDSADomainSeparator, version)
+	if err != nil {
+		return nil, err
+	}
+
+	keyseed, err := hkdf.Key(sha256.New, c.Seed[:], nil, domain, ServerSSHHostKeyECDSASeedSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive ssh ecdsa host key: %w", err)
+	}
+
+	curve := elliptic.P256()
+
+	// NB: ecdsa.GenerateKey does not promise to be deterministic for a
+	// given reader, so the scalar is derived directly in [1, n-1].
+	order := new(big.Int).Sub(curve.Params().N, big.NewInt(1))
+	d := new(big.Int).SetBytes(keyseed)
+	d.Mod(d, order)
+	d.Add(d, big.NewInt(1))
+
+	priv, err := ecdh.P256().NewPrivateKey(d.FillBytes(make([]byte, 32)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive ssh ecdsa host key: %w", err)
+	}
+
+	// NB: the uncompressed point is 0x04 || X || Y.
+	point := priv.PublicKey().Bytes()
+	size := (len(point) - 1) / 2
+
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(point[1 : 1+size]),
+			Y:     new(big.Int).SetBytes(point[1+size:]),
+		},
+		D: d,
+	}, nil
+}
+
+// SSHHostKeyRSA derives the RSA host key of version. Finding the primes takes
+// a noticeable amount of CPU time, so callers should derive it once.
+func (c ServerChain) SSHHostKeyRSA(version int) (*rsa.PrivateKey, error) {
+	domain, err := hostKeyDomain(ServerSSHHostKeyRSADomainSeparator, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// NB: rsa.GenerateKey does not promise to be deterministic for a given
+	// reader either, so the primes are searched for here.
+	p, err := c.rsaPrime(domain + ".p")
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := c.rsaPrime(domain + ".q")
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Cmp(q) == 0 {
+		return nil, errors.New("failed to derive ssh rsa host key: equal primes")
+	}
+
+	e := big.NewInt(ServerSSHHostKeyRSAExponent)
+	one := big.NewInt(1)
+
+	phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+
+	d := new(big.Int).ModInverse(e, phi)
+	if d == nil {
+		return nil, errors.New("failed to derive ssh rsa host key: exponent not invertible")
+	}
+
+	key := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{
+			N: new(big.Int).Mul(p, q),
+			E: ServerSSHHostKeyRSAExponent,
+		},
+		D:      d,
+		Primes: []*big.Int{p, q},
+	}
+
+	key.Precompute()
+
+	err = key.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive ssh rsa host key: %w", err)
+	}
+
+	return key, nil
+}
+
+// rsaPrime derives a starting point from domain and returns the first prime
+// after it that is usable with the public exponent. The top two bits are set
+// so the product of two such primes has exactly ServerSSHHostKeyRSABits bits.
+func (c ServerChain) rsaPrime(domain string) (*big.Int, error) {
+	bits := ServerSSHHostKeyRSABits / 2
+
+	keyseed, err := hkdf.Key(sha256.New, c.Seed[:], nil, domain, bits/8)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive ssh rsa host key: %w", err)
+	}
+
+	keyseed[0] |= 0xc0
+	keyseed[len(keyseed)-1] |= 1
+
+	candidate := new(big.Int).SetBytes(keyseed)
+	e := big.NewInt(ServerSSHHostKeyRSAExponent)
+	one := big.NewInt(1)
+	two := big.NewInt(2)
+	gcd := new(big.Int)
+
+	for candidate.BitLen() == bits {
+		if candidate.ProbablyPrime(ServerSSHHostKeyRSAPrimeRounds) &&
+			gcd.GCD(nil, nil, e, new(big.Int).Sub(candidate, one)).Cmp(one) == 0 {
+			return candidate, nil
+		}
+
+		candidate.Add(candidate, two)
+	}
+
+	return nil, errors.New("failed to derive ssh rsa host key: no prime found")
+}
+
+func hostKeyDomain(separator string, version int) (string, error) {
+	if version < DefaultSSHHostKeyVersion {
+		return "", fmt.Errorf("%w: %d", ErrInvalidSSHHostKeyVersion, version)
+	}
+
+	if version == DefaultSSHHostKeyVersion {
+		return separator, nil
+	}
+
+	return fmt.Sprintf("%s.%d", separator, version), nil
+}
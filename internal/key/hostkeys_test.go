@@ -0,0 +1,140 @@
+package key
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"errors"
+	"slices"
+	"testing"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func newTestServerChain(t *testing.T, secret string) ServerChain {
+	t.Helper()
+
+	chain, err := NewServerChain([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return *chain
+}
+
+func fingerprint(t *testing.T, signer crypto.Signer) string {
+	t.Helper()
+
+	public, err := gossh.NewPublicKey(signer.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return gossh.FingerprintSHA256(public)
+}
+
+func fingerprints(t *testing.T, chain ServerChain, version int) []string {
+	t.Helper()
+
+	keys, err := chain.SSHHostKeys(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var prints []string
+	for _, k := range keys {
+		prints = append(prints, fingerprint(t, k))
+	}
+
+	return prints
+}
+
+func TestSSHHostKeys(t *testing.T) {
+	chain := newTestServerChain(t, "0123456789abcdef0123456789abcdef")
+	first := fingerprints(t, chain, DefaultSSHHostKeyVersion)
+
+	// NB: host keys are never stored, so any change to how they are derived
+	// would make every client see the server change identity.
+	want := []string{
+		"SHA256:llBCwGllmvBMay6od3ZAHfTTM9j0258GCWvNn6O0uXo",
+		"SHA256:T+Z9qg5x1b3Xtnil0qrP6gpU6LbjIHk3bxeMAqFesD0",
+		"SHA256:Zvwqm/oOVxYTZwE1nvsqU9ZZlzhWMhd5GUh6VldOYMQ",
+	}
+	if !slices.Equal(first, want) {
+		t.Errorf("host keys: got %v, want %v", first, want)
+	}
+
+	seen := map[string]bool{}
+	for _, prints := range [][]string{
+		first,
+		fingerprints(t, chain, 2),
+		fingerprints(t, newTestServerChain(t, "fedcba9876543210fedcba9876543210"), 1),
+	} {
+		for _, fp := range prints {
+			if seen[fp] {
+				t.Errorf("key %s derived twice for different versions or secrets", fp)
+			}
+
+			seen[fp] = true
+		}
+	}
+
+	legacy, err := chain.SSHHostKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := first[0], fingerprint(t, legacy); got != want {
+		t.Errorf("first ed25519 host key: got %s, want the original host key %s", got, want)
+	}
+
+	keys, err := chain.SSHHostKeys(DefaultSSHHostKeyVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []string{gossh.KeyAlgoED25519, gossh.KeyAlgoECDSA256, gossh.KeyAlgoRSA} {
+		public, err := gossh.NewPublicKey(keys[i].Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if public.Type() != want {
+			t.Errorf("key %d: got %s, want %s", i, public.Type(), want)
+		}
+	}
+
+	rsaKey, ok := keys[2].(*rsa.PrivateKey)
+	if !ok || rsaKey.N.BitLen() != ServerSSHHostKeyRSABits {
+		t.Errorf("rsa host key is %T, want %d bits", keys[2], ServerSSHHostKeyRSABits)
+	}
+}
+
+func TestSSHHostKeyVersions(t *testing.T) {
+	chain := newTestServerChain(t, "0123456789abcdef0123456789abcdef")
+
+	for _, version := range []int{0, -1} {
+		_, err := chain.SSHHostKeyEd25519(version)
+		if !errors.Is(err, ErrInvalidSSHHostKeyVersion) {
+			t.Errorf(
+				"ed25519 key version %d: got %v, want %v",
+				version, err, ErrInvalidSSHHostKeyVersion,
+			)
+		}
+
+		_, err = chain.SSHHostKeyECDSA(version)
+		if !errors.Is(err, ErrInvalidSSHHostKeyVersion) {
+			t.Errorf(
+				"ecdsa key version %d: got %v, want %v",
+				version, err, ErrInvalidSSHHostKeyVersion,
+			)
+		}
+
+		_, err = chain.SSHHostKeys(version)
+		if !errors.Is(err, ErrInvalidSSHHostKeyVersion) {
+			t.Errorf(
+				"host keys version %d: got %v, want %v",
+				version, err, ErrInvalidSSHHostKeyVersion,
+			)
+		}
+	}
+}
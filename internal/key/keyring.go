@@ -0,0 +1,146 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Version of the server secret that devices enrolled before secrets had
+	// versions derive their keys from.
+	DefaultKeyVersion = 1
+)
+
+var (
+	ErrUnknownKeyVersion = errors.New("unknown server secret version")
+	ErrInvalidKeyID      = errors.New("invalid key id")
+)
+
+// ServerKeyring holds every configured version of the server secret. Device
+// keys are derived from the version the device was enrolled with, so the
+// secret can be rotated by enrolling devices with a new version and retiring
+// the old one once no device uses it anymore.
+type ServerKeyring struct {
+	// Current is the version new device keys, SSH host keys and SSH user
+	// certificates are derived from.
+	Current int
+
+	chains map[int]ServerChain
+}
+
+// NewServerKeyring derives a chain for every version of the server secret.
+// current must be one of them.
+func NewServerKeyring(secrets map[int][]byte, current int) (*ServerKeyring, error) {
+	k := ServerKeyring{
+		Current: current,
+		chains:  make(map[int]ServerChain, len(secrets)),
+	}
+
+	for version, secret := range secrets {
+		if version < DefaultKeyVersion {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownKeyVersion, version)
+		}
+
+		chain, err := NewServerChain(secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server secret version %d: %w", version, err)
+		}
+
+		k.chains[version] = *chain
+	}
+
+	if _, ok := k.chains[current]; !ok {
+		return nil, fmt.Errorf("%w: current version %d", ErrUnknownKeyVersion, current)
+	}
+
+	return &k, nil
+}
+
+// Versions returns the versions of the server secret in ascending order.
+func (k ServerKeyring) Versions() []int {
+	return slices.Sorted(maps.Keys(k.chains))
+}
+
+// Chain returns the chain of a version of the server secret.
+func (k ServerKeyring) Chain(version int) (ServerChain, error) {
+	chain, ok := k.chains[version]
+	if !ok {
+		return ServerChain{}, fmt.Errorf("%w: %d", ErrUnknownKeyVersion, version)
+	}
+
+	return chain, nil
+}
+
+// CurrentChain returns the chain of the current version.
+func (k ServerKeyring) CurrentChain() ServerChain {
+	return k.chains[k.Current]
+}
+
+// ClientChain derives the key chain of generation of a device key enrolled
+// with version.
+func (k ServerKeyring) ClientChain(
+	version int,
+	generation int,
+	clientID string,
+) (*ClientChain, error) {
+	chain, err := k.Chain(version)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := chain.ClientChain(clientID, generation)
+	if err != nil {
+		return nil, err
+	}
+
+	client.KeyVersion = version
+
+	return client, nil
+}
+
+// KeyID returns the key id naming a device key in token footers: the version
+// of the server secret, followed by the generation of the device key unless
+// it is the first one.
+func KeyID(version, generation int) string {
+	if generation == 0 {
+		return strconv.Itoa(version)
+	}
+
+	return strconv.Itoa(version) + "." + strconv.Itoa(generation)
+}
+
+// ParseKeyID returns the version and generation named by a key id. Tokens
+// without a key id are minted with the first generation of a key of
+// DefaultKeyVersion.
+func ParseKeyID(kid string) (int, int, error) {
+	if kid == "" {
+		return DefaultKeyVersion, 0, nil
+	}
+
+	rawVersion, rawGeneration, found := strings.Cut(kid, ".")
+	if !found {
+		rawGeneration = "0"
+	}
+
+	version, err := strconv.Atoi(rawVersion)
+	if err != nil || version < DefaultKeyVersion {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidKeyID, kid)
+	}
+
+	generation, err := strconv.Atoi(rawGeneration)
+	if err != nil || generation < 0 {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidKeyID, kid)
+	}
+
+	// NB: every key has a single id, so that the same key cannot be named
+	// in different ways.
+	if KeyID(version, generation) != kid {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidKeyID, kid)
+	}
+
+	return version, generation, nil
+}
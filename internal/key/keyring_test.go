@@ -0,0 +1,60 @@
+package key
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseKeyID(t *testing.T) {
+	tests := []struct {
+		kid        string
+		version    int
+		generation int
+		err        error
+	}{
+		{kid: "", version: DefaultKeyVersion},
+		{kid: "1", version: 1},
+		{kid: "2", version: 2},
+		{kid: "1.1", version: 1, generation: 1},
+		{kid: "12.34", version: 12, generation: 34},
+		{kid: "1.0", err: ErrInvalidKeyID},
+		{kid: "01", err: ErrInvalidKeyID},
+		{kid: "1.01", err: ErrInvalidKeyID},
+		{kid: "+1", err: ErrInvalidKeyID},
+		{kid: "1.+1", err: ErrInvalidKeyID},
+		{kid: "0", err: ErrInvalidKeyID},
+		{kid: "-1", err: ErrInvalidKeyID},
+		{kid: "1.-1", err: ErrInvalidKeyID},
+		{kid: "1.", err: ErrInvalidKeyID},
+		{kid: ".1", err: ErrInvalidKeyID},
+		{kid: "1.1.1", err: ErrInvalidKeyID},
+		{kid: " 1", err: ErrInvalidKeyID},
+		{kid: "a", err: ErrInvalidKeyID},
+	}
+
+	for _, test := range tests {
+		version, generation, err := ParseKeyID(test.kid)
+		if !errors.Is(err, test.err) || version != test.version || generation != test.generation {
+			t.Errorf(
+				"ParseKeyID(%q) = %d, %d, %v, want %d, %d, %v",
+				test.kid, version, generation, err, test.version, test.generation, test.err,
+			)
+		}
+	}
+}
+
+func TestKeyIDRoundTrip(t *testing.T) {
+	for version := DefaultKeyVersion; version < 12; version++ {
+		for generation := range 12 {
+			kid := KeyID(version, generation)
+
+			gotVersion, gotGeneration, err := ParseKeyID(kid)
+			if err != nil || gotVersion != version || gotGeneration != generation {
+				t.Errorf(
+					"ParseKeyID(KeyID(%d, %d)) = %d, %d, %v",
+					version, generation, gotVersion, gotGeneration, err,
+				)
+			}
+		}
+	}
+}
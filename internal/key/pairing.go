@@ -0,0 +1,63 @@
+package key
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// 15 random bytes == 120 bit security, 24 characters once encoded.
+	PairingCodeSize = 15
+
+	// Pairing codes are meant to be claimed right after they are shown.
+	DefaultPairingCodeExpiration = 10 * time.Minute
+
+	// Pairing codes are shown in groups of characters to be easier to type.
+	pairingCodeGroupSize = 4
+)
+
+// NewPairingCode returns a random code that a new device claims its
+// credentials with. The server only stores its hash, see HashPairingCode.
+func NewPairingCode() (string, error) {
+	var raw [PairingCodeSize]byte
+
+	_, err := rand.Read(raw[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to generate pairing code: %w", err)
+	}
+
+	encoded := base32.StdEncoding.EncodeToString(raw[:])
+
+	groups := make([]string, 0, len(encoded)/pairingCodeGroupSize)
+	for i := 0; i < len(encoded); i += pairingCodeGroupSize {
+		groups = append(groups, encoded[i:i+pairingCodeGroupSize])
+	}
+
+	return strings.Join(groups, "-"), nil
+}
+
+// HashPairingCode returns the hex encoded SHA-256 hash of a pairing code.
+// Codes are hashed regardless of case, dashes and spaces so that typos in
+// their formatting do not matter. They are random enough to not need a slow
+// hash.
+func HashPairingCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Fingerprint returns the SHA256 fingerprint of a public key the way
+// ssh-keygen -l prints them, so that it can be compared at a glance.
+func Fingerprint(publicKey []byte) string {
+	sum := sha256.Sum256(publicKey)
+
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
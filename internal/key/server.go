@@ -7,6 +7,8 @@ import (
 	"crypto/hkdf"
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -23,6 +25,18 @@ const (
 	// Size of Ed25519 private key.
 	ServerSSHHostKeySize            = 32
 	ServerSSHHostKeyDomainSeparator = `server.ssh.host-key.v1`
+
+	// Size of Ed25519 private key.
+	ServerSSHUserCAKeySize            = 32
+	ServerSSHUserCAKeyDomainSeparator = `server.ssh.user-ca.v1`
+
+	// 16 bytes are plenty to tell servers apart. The identity is public and
+	// does not protect anything on its own.
+	ServerIdentitySize            = 16
+	ServerIdentityDomainSeparator = `server.identity.v1`
+
+	// Prefix of server identities so they are recognizable in configuration.
+	ServerIdentityPrefix = `byte:`
 )
 
 var (
@@ -60,19 +74,55 @@ func NewServerChain(rawSeed []byte) (*ServerChain, error) {
 	return &c, nil
 }
 
-func (c ServerChain) ClientChain(clientID string) (*ClientChain, error) {
+// ClientChain derives the key chain of generation of a device key. Rotating
+// the key of a device moves it to the next generation, and generation 0 is
+// the key it was created with.
+func (c ServerChain) ClientChain(clientID string, generation int) (*ClientChain, error) {
+	// NB: the generation salts the derivation, except for the first one
+	// which predates rotation so that existing device keys stay valid.
+	var salt []byte
+	if generation > 0 {
+		//nolint: gosec // generation is positive
+		salt = binary.BigEndian.AppendUint64(nil, uint64(generation))
+	}
+
 	clientSeed, err := hkdf.Key(
 		sha256.New,
 		c.Seed[:],
-		nil,
+		salt,
 		"client.root.v1."+clientID,
 		int(ClientRootKeySize),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to derive ssh host private key: %w", err)
+		return nil, fmt.Errorf("failed to derive client root key: %w", err)
+	}
+
+	chain, err := NewClientChain(clientSeed, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	chain.KeyGeneration = generation
+
+	return chain, nil
+}
+
+// Identity returns the public identity of the server that device tokens are
+// minted for. Servers with different secrets have different identities, and
+// name tells apart deployments that share a secret, such as staging and
+// production.
+func (c ServerChain) Identity(name string) (string, error) {
+	domain := ServerIdentityDomainSeparator
+	if name != "" {
+		domain += "." + name
+	}
+
+	id, err := hkdf.Key(sha256.New, c.Seed[:], nil, domain, ServerIdentitySize)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive server identity: %w", err)
 	}
 
-	return NewClientChain(clientSeed, clientID)
+	return ServerIdentityPrefix + hex.EncodeToString(id), nil
 }
 
 func (c ServerChain) SSHHostKey() (ed25519.PrivateKey, error) {
@@ -90,6 +140,23 @@ func (c ServerChain) SSHHostKey() (ed25519.PrivateKey, error) {
 	return ed25519.NewKeyFromSeed(keyseed), nil
 }
 
+// SSHUserCAKey derives the certificate authority used to sign SSH user
+// certificates.
+func (c ServerChain) SSHUserCAKey() (ed25519.PrivateKey, error) {
+	keyseed, err := hkdf.Key(
+		sha256.New,
+		c.Seed[:],
+		nil,
+		string(ServerSSHUserCAKeyDomainSeparator),
+		int(ServerSSHUserCAKeySize),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive ssh user ca private key: %w", err)
+	}
+
+	return ed25519.NewKeyFromSeed(keyseed), nil
+}
+
 func ToPEM(key crypto.PrivateKey) ([]byte, error) {
 	raw, err := x509.MarshalPKCS8PrivateKey(key)
 	if err != nil {
@@ -0,0 +1,41 @@
+package key
+
+import "testing"
+
+func TestServerIdentity(t *testing.T) {
+	chain := newTestServerChain(t, "0123456789abcdef0123456789abcdef")
+
+	identity := func(chain ServerChain, name string) string {
+		t.Helper()
+
+		id, err := chain.Identity(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return id
+	}
+
+	id := identity(chain, "")
+
+	// NB: the identity is derived, not stored, so devices keep working as
+	// long as the secret and name stay the same.
+	if want := "byte:f1dea9b9ff67d90a1ffc79685dcc4fe1"; id != want {
+		t.Errorf("identity: got %q, want %q", id, want)
+	}
+
+	others := map[string]string{
+		"named":        identity(chain, "staging"),
+		"other name":   identity(chain, "production"),
+		"other secret": identity(newTestServerChain(t, "fedcba9876543210fedcba9876543210"), ""),
+	}
+
+	seen := map[string]string{id: "unnamed"}
+	for name, other := range others {
+		if previous, ok := seen[other]; ok {
+			t.Errorf("%s server has the identity of the %s one", name, previous)
+		}
+
+		seen[other] = name
+	}
+}
@@ -0,0 +1,33 @@
+package key
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// 32 random bytes == 256 bit security, the size of device keys.
+const WebSecretSize = 32
+
+// NewWebSecret returns a random secret that a browser holds in a cookie, such
+// as its session token. The server only stores its hash, see HashWebSecret.
+func NewWebSecret() (string, error) {
+	var raw [WebSecretSize]byte
+
+	_, err := rand.Read(raw[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to generate web secret: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw[:]), nil
+}
+
+// HashWebSecret returns the hex encoded SHA-256 hash of a web secret. They are
+// random enough to not need a slow hash.
+func HashWebSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
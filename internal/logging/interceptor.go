@@ -3,52 +3,91 @@ package logging
 import (
 	"context"
 	"log/slog"
+	"net/http"
 	"time"
 
 	"connectrpc.com/connect"
 )
 
-func NewInterceptor(logger *slog.Logger) connect.UnaryInterceptorFunc {
-	interceptor := func(next connect.UnaryFunc) connect.UnaryFunc {
-		return connect.UnaryFunc(func(
-			ctx context.Context,
-			req connect.AnyRequest,
-		) (connect.AnyResponse, error) {
-			start := time.Now()
-
-			requestLogger := logger.With(
-				slog.String("procedure", req.Spec().Procedure),
-				slog.String("peer_addr", req.Peer().Addr),
-			)
-
-			clientID := req.Header().Get(`Device-ID`)
-			if clientID != "" {
-				requestLogger = requestLogger.With(
-					slog.String("device_id", clientID),
-				)
-			}
-
-			ctx = ContextWith(ctx, requestLogger)
-
-			res, err := next(ctx, req)
-			duration := time.Since(start)
-
-			requestLogger = requestLogger.With(
-				slog.Duration("duration", duration),
-			)
-			if err != nil {
-				requestLogger.ErrorContext(ctx, "request failed",
-					slog.Any("err", err),
-				)
-
-				return res, err
-			}
-
-			requestLogger.InfoContext(ctx, "request succeeded")
-
-			return res, nil
-		})
+type interceptor struct {
+	logger *slog.Logger
+}
+
+// NewInterceptor logs the requests and streams served and puts a logger
+// describing them on their context, see FromContext.
+func NewInterceptor(logger *slog.Logger) connect.Interceptor {
+	return &interceptor{logger: logger}
+}
+
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		start := time.Now()
+
+		requestLogger := i.requestLogger(req.Spec(), req.Peer(), req.Header())
+		ctx = ContextWith(ctx, requestLogger)
+
+		res, err := next(ctx, req)
+		logResult(ctx, requestLogger, start, err)
+
+		return res, err
+	}
+}
+
+// NB: the interceptor is only installed on handlers, streaming clients are
+// left alone.
+func (i *interceptor) WrapStreamingClient(
+	next connect.StreamingClientFunc,
+) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *interceptor) WrapStreamingHandler(
+	next connect.StreamingHandlerFunc,
+) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		start := time.Now()
+
+		requestLogger := i.requestLogger(conn.Spec(), conn.Peer(), conn.RequestHeader())
+		ctx = ContextWith(ctx, requestLogger)
+
+		err := next(ctx, conn)
+		logResult(ctx, requestLogger, start, err)
+
+		return err
+	}
+}
+
+func (i *interceptor) requestLogger(
+	spec connect.Spec,
+	peer connect.Peer,
+	header http.Header,
+) *slog.Logger {
+	requestLogger := i.logger.With(
+		slog.String("procedure", spec.Procedure),
+		slog.String("peer_addr", peer.Addr),
+	)
+
+	clientID := header.Get(`Device-ID`)
+	if clientID != "" {
+		requestLogger = requestLogger.With(
+			slog.String("device_id", clientID),
+		)
+	}
+
+	return requestLogger
+}
+
+func logResult(ctx context.Context, requestLogger *slog.Logger, start time.Time, err error) {
+	requestLogger = requestLogger.With(
+		slog.Duration("duration", time.Since(start)),
+	)
+	if err != nil {
+		requestLogger.ErrorContext(ctx, "request failed",
+			slog.Any("err", err),
+		)
+
+		return
 	}
 
-	return connect.UnaryInterceptorFunc(interceptor)
+	requestLogger.InfoContext(ctx, "request succeeded")
 }
@@ -0,0 +1,192 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const testProcedure = "/byte.v1.TestService/Test"
+
+// testHandlerConn is the server side of a stream from 192.0.2.1 that
+// presents header.
+type testHandlerConn struct {
+	connect.StreamingHandlerConn
+
+	header http.Header
+}
+
+func (c *testHandlerConn) Spec() connect.Spec {
+	return connect.Spec{Procedure: testProcedure, StreamType: connect.StreamTypeServer}
+}
+
+func (c *testHandlerConn) Peer() connect.Peer {
+	return connect.Peer{Addr: "192.0.2.1:1234", Protocol: connect.ProtocolConnect}
+}
+
+func (c *testHandlerConn) RequestHeader() http.Header {
+	return c.header
+}
+
+// records decodes the JSON log records in out.
+func records(t *testing.T, out *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+
+	decoder := json.NewDecoder(out)
+	for decoder.More() {
+		var record map[string]any
+
+		err := decoder.Decode(&record)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestStreamingInterceptor(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name   string
+		header http.Header
+		err    error
+		want   map[string]any
+	}{
+		{
+			name:   "success",
+			header: http.Header{"Device-Id": {"device"}},
+			want: map[string]any{
+				"msg":       "request succeeded",
+				"level":     "INFO",
+				"procedure": testProcedure,
+				"peer_addr": "192.0.2.1:1234",
+				"device_id": "device",
+			},
+		},
+		{
+			name:   "failure",
+			header: http.Header{},
+			err:    errFailed,
+			want: map[string]any{
+				"msg":       "request failed",
+				"level":     "ERROR",
+				"procedure": testProcedure,
+				"peer_addr": "192.0.2.1:1234",
+				"err":       "failed",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			interceptor := NewInterceptor(slog.New(slog.NewJSONHandler(&out, nil)))
+
+			handler := interceptor.WrapStreamingHandler(
+				func(ctx context.Context, _ connect.StreamingHandlerConn) error {
+					FromContext(ctx).InfoContext(ctx, "handling")
+
+					return test.err
+				},
+			)
+
+			err := handler(t.Context(), &testHandlerConn{header: test.header})
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got %v, want %v", err, test.err)
+			}
+
+			logged := records(t, &out)
+			if len(logged) != 2 {
+				t.Fatalf("logged %d records, want 2", len(logged))
+			}
+
+			// NB: the handler logs with the attributes of the request.
+			if logged[0]["procedure"] != testProcedure {
+				t.Errorf("handler logged %v, want the procedure", logged[0])
+			}
+
+			got := logged[1]
+			for k, want := range test.want {
+				if got[k] != want {
+					t.Errorf("%s: got %v, want %v", k, got[k], want)
+				}
+			}
+
+			_, found := got["device_id"]
+			if want := test.header.Get("Device-Id") != ""; found != want {
+				t.Errorf("device_id logged: got %v, want %v", found, want)
+			}
+
+			if _, found := got["duration"]; !found {
+				t.Error("duration not logged")
+			}
+		})
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	var out bytes.Buffer
+
+	mux := http.NewServeMux()
+	mux.Handle(testProcedure, connect.NewUnaryHandler(
+		testProcedure,
+		func(
+			context.Context,
+			*connect.Request[emptypb.Empty],
+		) (*connect.Response[emptypb.Empty], error) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("missing"))
+		},
+		connect.WithInterceptors(NewInterceptor(slog.New(slog.NewJSONHandler(&out, nil)))),
+	))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	req := connect.NewRequest(&emptypb.Empty{})
+	req.Header().Set("Device-ID", "device")
+
+	_, err := connect.NewClient[emptypb.Empty, emptypb.Empty](
+		server.Client(),
+		server.URL+testProcedure,
+	).CallUnary(t.Context(), req)
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Fatalf("got %v, want %v", err, connect.CodeNotFound)
+	}
+
+	logged := records(t, &out)
+	if len(logged) != 1 {
+		t.Fatalf("logged %d records, want 1", len(logged))
+	}
+
+	got := logged[0]
+	if got["msg"] != "request failed" || got["procedure"] != testProcedure ||
+		got["device_id"] != "device" || got["peer_addr"] == "" {
+		t.Errorf("logged %v, want the failed request", got)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if FromContext(t.Context()) != slog.Default() {
+		t.Error("context without a logger: want the default logger")
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	if FromContext(ContextWith(t.Context(), logger)) != logger {
+		t.Error("context with a logger: want that logger")
+	}
+}
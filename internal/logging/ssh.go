@@ -12,19 +12,29 @@ func SSHContextWith(ctx ssh.Context, logger *slog.Logger) {
 	ctx.SetValue(loggerKey{}, logger)
 }
 
+// SSHAttrs returns the fields that identify an SSH connection in logs. They
+// are only known once the handshake completed, before that nil is returned.
+func SSHAttrs(ctx ssh.Context) []any {
+	if ctx.Value(ssh.ContextKeySessionID) == nil {
+		return nil
+	}
+
+	return []any{
+		slog.String("user", ctx.User()),
+		slog.String("client_ver", ctx.ClientVersion()),
+		slog.String("server_ver", ctx.ServerVersion()),
+		slog.String("sess_id", ctx.SessionID()),
+		slog.Any("local_addr", ctx.LocalAddr()),
+		slog.Any("remote_addr", ctx.RemoteAddr()),
+	}
+}
+
 func SSHMiddleware(logger *slog.Logger) wish.Middleware {
 	return func(next ssh.Handler) ssh.Handler {
 		return func(sess ssh.Session) {
 			ctx := sess.Context()
 
-			sessionLogger := logger.With(
-				slog.String("user", ctx.User()),
-				slog.String("client_ver", ctx.ClientVersion()),
-				slog.String("server_ver", ctx.ServerVersion()),
-				slog.String("sess_id", ctx.SessionID()),
-				slog.Any("local_addr", ctx.LocalAddr()),
-				slog.Any("remote_addr", ctx.RemoteAddr()),
-			)
+			sessionLogger := logger.With(SSHAttrs(ctx)...)
 
 			// Mutate session context with logger
 			SSHContextWith(ctx, sessionLogger)
@@ -0,0 +1,60 @@
+package sftp
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	bm "github.com/charmbracelet/wish/bubbletea"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/storage"
+	"github.com/cmp0st/byte/internal/tui"
+)
+
+// browserMiddleware serves the interactive file browser to sessions that
+// request a terminal without a command. Sessions with a command are passed on
+// to next.
+func browserMiddleware(s storage.Interface) wish.Middleware {
+	serve := bm.Middleware(func(sess ssh.Session) (tea.Model, []tea.ProgramOption) {
+		browser := tui.NewBrowser(
+			sessionStorage(sess.Context(), s),
+			bm.MakeRenderer(sess),
+			sessionLogger(sess),
+		)
+
+		return browser, []tea.ProgramOption{tea.WithAltScreen()}
+	})
+
+	return func(next ssh.Handler) ssh.Handler {
+		browse := serve(next)
+
+		return func(sess ssh.Session) {
+			if len(sess.Command()) > 0 {
+				next(sess)
+
+				return
+			}
+
+			logger := sessionLogger(sess)
+
+			if !auth.SSHOptionsFromContext(sess.Context()).AllowsInteractive() {
+				logger.Warn("interactive session denied by key options")
+				wish.Fatalln(sess, "byte: interactive sessions are not allowed for this key")
+
+				return
+			}
+
+			_, _, active := sess.Pty()
+			if !active {
+				wish.Fatalln(sess, "byte: the file browser requires a terminal, try ssh -t")
+
+				return
+			}
+
+			logger.Info("browser session started")
+
+			browse(sess)
+
+			logger.Info("browser session ended")
+		}
+	}
+}
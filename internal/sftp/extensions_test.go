@@ -0,0 +1,327 @@
+package sftp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/cmp0st/byte/internal/storage"
+	"github.com/pkg/sftp"
+)
+
+// pipeConn joins the ends of two pipes into the connection of one side.
+type pipeConn struct {
+	io.Reader
+	io.WriteCloser
+}
+
+// newTestClient serves storage over an in-process sftp session. The returned
+// channel is closed once the session ended and its handles were closed.
+func newTestClient(t testing.TB, st storage.Interface) (*sftp.Client, *Handlers, <-chan struct{}) {
+	t.Helper()
+
+	h := &Handlers{
+		Storage: st,
+		Logger:  slog.New(slog.DiscardHandler),
+	}
+
+	client, done := serveTestClient(t, func(rwc io.ReadWriteCloser) *sftp.RequestServer {
+		return sftp.NewRequestServer(
+			newExtensionConn(rwc, h),
+			sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h},
+		)
+	}, h.Close)
+
+	return client, h, done
+}
+
+// serveTestClient connects a client to the request server returned by
+// newServer, and calls closeHandles once the server is done.
+func serveTestClient(
+	t testing.TB,
+	newServer func(rwc io.ReadWriteCloser) *sftp.RequestServer,
+	closeHandles func() error,
+) (*sftp.Client, <-chan struct{}) {
+	t.Helper()
+
+	clientRead, serverWrite := io.Pipe()
+	serverRead, clientWrite := io.Pipe()
+
+	server := newServer(pipeConn{serverRead, serverWrite})
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		//nolint: errcheck
+		server.Serve()
+		//nolint: errcheck
+		server.Close()
+		//nolint: errcheck
+		closeHandles()
+	}()
+
+	client, err := sftp.NewClientPipe(clientRead, clientWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		//nolint: errcheck
+		client.Close()
+		<-done
+	})
+
+	return client, done
+}
+
+// testConn is the channel of an extensionConn, reading what the client sent
+// and recording what was written back.
+type testConn struct {
+	in  *bytes.Reader
+	out bytes.Buffer
+}
+
+func (c *testConn) Read(p []byte) (int, error) {
+	return c.in.Read(p)
+}
+
+func (c *testConn) Write(p []byte) (int, error) {
+	return c.out.Write(p)
+}
+
+func (c *testConn) Close() error {
+	return nil
+}
+
+func newTestConn(packets ...[]byte) (*extensionConn, *testConn) {
+	rwc := &testConn{in: bytes.NewReader(bytes.Join(packets, nil))}
+	h := &Handlers{
+		Storage: storage.NewInMemory(),
+		Logger:  slog.New(slog.DiscardHandler),
+	}
+
+	return newExtensionConn(rwc, h), rwc
+}
+
+// packet builds a packet of the given type and request id, followed by body.
+func packet(typ byte, id uint32, body ...[]byte) []byte {
+	pkt := make([]byte, 4, 64)
+	pkt = append(pkt, typ)
+	pkt = binary.BigEndian.AppendUint32(pkt, id)
+
+	for _, b := range body {
+		pkt = append(pkt, b...)
+	}
+
+	//nolint: gosec
+	binary.BigEndian.PutUint32(pkt, uint32(len(pkt)-4))
+
+	return pkt
+}
+
+func str(s string) []byte {
+	return marshalString(nil, s)
+}
+
+// readStatus returns the id and code of the status packet at the start of b.
+func readStatus(t *testing.T, b []byte) (uint32, uint32) {
+	t.Helper()
+
+	pkt, err := readPacket(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+
+	if len(pkt) < 13 || pkt[4] != packetTypeStatus {
+		t.Fatalf("want a status packet, got %v", pkt)
+	}
+
+	return binary.BigEndian.Uint32(pkt[5:]), binary.BigEndian.Uint32(pkt[9:])
+}
+
+func TestExtensionConnClientPackets(t *testing.T) {
+	tests := []struct {
+		name string
+		pkt  []byte
+
+		// forwarded is whether the packet reaches the request server, and
+		// status the code answered otherwise.
+		forwarded bool
+		status    uint32
+	}{
+		{
+			name:      "short packet",
+			pkt:       []byte{0, 0, 0, 1, packetTypeExtended},
+			forwarded: true,
+		},
+		{
+			name:      "other extension",
+			pkt:       packet(packetTypeExtended, 1, str(StatVFSExtension), str("/")),
+			forwarded: true,
+		},
+		{
+			name:      "truncated extension name",
+			pkt:       packet(packetTypeExtended, 1, []byte{0, 0, 0, 99, 'f'}),
+			forwarded: true,
+		},
+		{
+			name:   "fsync without handle",
+			pkt:    packet(packetTypeExtended, 7, str(FsyncExtension)),
+			status: statusBadMessage,
+		},
+		{
+			name:   "fsync with truncated handle",
+			pkt:    packet(packetTypeExtended, 7, str(FsyncExtension), []byte{0, 0, 0, 4, '1'}),
+			status: statusBadMessage,
+		},
+		{
+			name:   "fsync of unknown handle",
+			pkt:    packet(packetTypeExtended, 7, str(FsyncExtension), str("1")),
+			status: statusFailure,
+		},
+		{
+			name:      "open without path",
+			pkt:       packet(packetTypeOpen, 1, []byte{0, 0}),
+			forwarded: true,
+		},
+		{
+			name:      "close without handle",
+			pkt:       packet(packetTypeClose, 1),
+			forwarded: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, rwc := newTestConn(test.pkt)
+
+			got, err := io.ReadAll(c)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+
+			if test.forwarded {
+				if !bytes.Equal(got, test.pkt) || rwc.out.Len() != 0 {
+					t.Fatalf("forwarded %v and answered %v", got, rwc.out.Bytes())
+				}
+
+				return
+			}
+
+			if len(got) != 0 {
+				t.Fatalf("forwarded %v", got)
+			}
+
+			id, code := readStatus(t, rwc.out.Bytes())
+			if id != 7 || code != test.status {
+				t.Fatalf("status %d for request %d, want %d for request 7", code, id, test.status)
+			}
+		})
+	}
+}
+
+func TestExtensionConnMalformedFraming(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		err  error
+	}{
+		{name: "empty packet", in: []byte{0, 0, 0, 0}, err: errMalformedPacket},
+		{name: "oversized packet", in: []byte{0, 4, 0, 1}, err: errMalformedPacket},
+		{name: "short header", in: []byte{0, 0}, err: io.ErrUnexpectedEOF},
+		{name: "short body", in: []byte{0, 0, 0, 9, packetTypeExtended}, err: io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := newTestConn(test.in)
+
+			_, err := c.Read(make([]byte, 16))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestExtensionConnServerPackets(t *testing.T) {
+	c, rwc := newTestConn(packet(packetTypeOpen, 3, str("a/../f"), []byte{0, 0, 0, 2}))
+
+	_, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	// NB: pkg/sftp writes the header and payload of a packet separately.
+	handle := packet(packetTypeHandle, 3, str("h"))
+	for _, part := range [][]byte{handle[:4], handle[4:]} {
+		_, err = c.Write(part)
+		if err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+
+	if !bytes.Equal(rwc.out.Bytes(), handle) {
+		t.Fatalf("forwarded %v, want %v", rwc.out.Bytes(), handle)
+	}
+
+	if got := c.handles["h"]; got != "/f" {
+		t.Fatalf("handle refers to %q, want /f", got)
+	}
+
+	rwc.out.Reset()
+
+	malformed := packet(packetTypeHandle, 4, []byte{0, 0, 0, 9})
+
+	_, err = c.Write(malformed)
+	if err != nil || !bytes.Equal(rwc.out.Bytes(), malformed) {
+		t.Fatalf("forwarded malformed handle as %v: %v", rwc.out.Bytes(), err)
+	}
+
+	rwc.out.Reset()
+
+	_, err = c.Write(packet(packetTypeVersion, 3))
+	if err != nil {
+		t.Fatalf("failed to write version: %v", err)
+	}
+
+	want := packet(packetTypeVersion, 3, str(FsyncExtension), str("1"))
+	if !bytes.Equal(rwc.out.Bytes(), want) {
+		t.Fatalf("version %v, want %v", rwc.out.Bytes(), want)
+	}
+}
+
+func TestFsyncWriteOnly(t *testing.T) {
+	// NB: the file cannot be reopened for the sync, as it is not readable.
+	st := storage.NewGranted(storage.NewPosix(t.TempDir()), []storage.Grant{
+		{Prefix: "/", Write: true},
+	})
+
+	client, _, _ := newTestClient(t, st)
+
+	file, err := client.OpenFile("/f", os.O_WRONLY|os.O_CREATE)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	_, err = file.Write([]byte("data"))
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	err = file.Sync()
+	if err != nil {
+		t.Fatalf("failed to sync file: %v", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		t.Fatalf("failed to close file: %v", err)
+	}
+}
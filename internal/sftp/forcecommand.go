@@ -0,0 +1,48 @@
+package sftp
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/cmp0st/byte/internal/auth"
+)
+
+// forceCommandMiddleware runs the command forced by the key of a session
+// instead of whatever the client asked to run, like OpenSSH.
+func forceCommandMiddleware() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(sess ssh.Session) {
+			opts := auth.SSHOptionsFromContext(sess.Context())
+			if opts.ForceCommand == "" || opts.ForceCommand == auth.InternalSFTPCommand {
+				next(sess)
+
+				return
+			}
+
+			sessionLogger(sess).Info(
+				"running forced command",
+				slog.String("requested", sess.RawCommand()),
+				slog.String("command", opts.ForceCommand),
+			)
+
+			next(&forcedSession{Session: sess, command: opts.Command(sess.Command())})
+		}
+	}
+}
+
+// forcedSession is a session that asked to run command.
+type forcedSession struct {
+	ssh.Session
+
+	command []string
+}
+
+func (s *forcedSession) Command() []string {
+	return append([]string(nil), s.command...)
+}
+
+func (s *forcedSession) RawCommand() string {
+	return strings.Join(s.command, " ")
+}
@@ -0,0 +1,79 @@
+package sftp
+
+import (
+	"log/slog"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/git"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+// gitMiddleware serves git-upload-pack and git-receive-pack exec requests
+// from bare repositories in storage. Other commands are passed on to next.
+func gitMiddleware(s storage.Interface) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(sess ssh.Session) {
+			cmd := sess.Command()
+			if len(cmd) == 0 ||
+				(cmd[0] != git.UploadPackCommand && cmd[0] != git.ReceivePackCommand) {
+				next(sess)
+
+				return
+			}
+
+			logger := sessionLogger(sess).With(slog.String("command", cmd[0]))
+
+			if len(cmd) != 2 {
+				wish.Fatalf(sess, "usage: %s <repository>\n", cmd[0])
+
+				return
+			}
+
+			opts := auth.SSHOptionsFromContext(sess.Context())
+			if !opts.AllowsCommand(cmd) {
+				logger.Warn("git denied by force-command")
+				wish.Fatalln(sess, "byte: command not allowed")
+
+				return
+			}
+
+			repo, err := git.CleanRepositoryPath(cmd[1])
+			if err != nil {
+				logger.Warn("git denied", slog.String("repo", cmd[1]), slog.Any("err", err))
+				wish.Fatalln(sess, "byte:", err)
+
+				return
+			}
+
+			logger = logger.With(slog.String("repo", repo))
+
+			if cmd[0] == git.ReceivePackCommand && opts.ReadOnly {
+				logger.Warn("git push denied for read-only key")
+				wish.Fatalln(sess, "byte: push not allowed for this key")
+
+				return
+			}
+
+			logger.Info("git session started")
+
+			fs := sessionStorage(sess.Context(), s)
+
+			if cmd[0] == git.UploadPackCommand {
+				err = git.UploadPack(sess.Context(), fs, repo, sess, sess)
+			} else {
+				err = git.ReceivePack(sess.Context(), fs, repo, sess, sess)
+			}
+
+			if err != nil {
+				logger.Error("git session failed", slog.Any("err", err))
+				wish.Fatalln(sess, "byte:", err)
+
+				return
+			}
+
+			logger.Info("git session ended")
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package sftp
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	memstorage "github.com/go-git/go-git/v5/storage/memory"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+func gitTestAuth(signer gossh.Signer) *gitssh.PublicKeys {
+	return &gitssh.PublicKeys{
+		User:   "test",
+		Signer: signer,
+		HostKeyCallbackHelper: gitssh.HostKeyCallbackHelper{
+			//nolint: gosec
+			HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		},
+	}
+}
+
+// newTestCommit returns an in memory repository with a commit adding name.
+func newTestCommit(t *testing.T, name, data string) *gogit.Repository {
+	t.Helper()
+
+	repo, err := gogit.Init(memstorage.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := tree.Filesystem.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = io.WriteString(file, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//nolint: errcheck
+	file.Close()
+
+	_, err = tree.Add(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tree.Commit("add "+name, &gogit.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return repo
+}
+
+func TestGitPushAndClone(t *testing.T) {
+	st := storage.NewInMemory()
+	db := newTestDB(t)
+	_, member := newTestDevice(t, db, auth.RoleMember)
+	_, reader := newTestDevice(t, db, auth.RoleReadOnly)
+	url := "ssh://" + newTestServer(t, config.SFTP{}, st, db) + "/notes.git"
+
+	repo := newTestCommit(t, "todo.txt", "water the plants\n")
+
+	_, err := repo.CreateRemote(&gitconfig.RemoteConfig{Name: "byte", URLs: []string{url}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = repo.PushContext(t.Context(), &gogit.PushOptions{
+		RemoteName: "byte",
+		RefSpecs:   []gitconfig.RefSpec{"refs/heads/master:refs/heads/main"},
+		Auth:       gitTestAuth(reader),
+	})
+	if err == nil {
+		t.Error("pushed with a read only key")
+	}
+
+	_, err = st.Stat("/notes.git")
+	if err == nil {
+		t.Error("read only push created a repository")
+	}
+
+	err = repo.PushContext(t.Context(), &gogit.PushOptions{
+		RemoteName: "byte",
+		RefSpecs:   []gitconfig.RefSpec{"refs/heads/master:refs/heads/main"},
+		Auth:       gitTestAuth(member),
+	})
+	if err != nil {
+		t.Fatalf("failed to push: %v", err)
+	}
+
+	clone, err := gogit.CloneContext(t.Context(), memstorage.NewStorage(), memfs.New(),
+		&gogit.CloneOptions{URL: strings.TrimSuffix(url, ".git"), Auth: gitTestAuth(reader)})
+	if err != nil {
+		t.Fatalf("failed to clone: %v", err)
+	}
+
+	tree, err := clone.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := tree.Filesystem.Open("todo.txt")
+	if err != nil {
+		t.Fatalf("cloned worktree: %v", err)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil || string(data) != "water the plants\n" {
+		t.Errorf("cloned todo.txt = %q, %v", data, err)
+	}
+
+	//nolint: errcheck
+	file.Close()
+
+	err = repo.PushContext(t.Context(), &gogit.PushOptions{
+		RemoteName: "byte",
+		RefSpecs:   []gitconfig.RefSpec{"refs/heads/master:refs/heads/main"},
+		Auth:       gitTestAuth(member),
+	})
+	if !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		t.Errorf("pushed again: %v, want %v", err, gogit.NoErrAlreadyUpToDate)
+	}
+}
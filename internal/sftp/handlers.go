@@ -1,8 +1,10 @@
 package sftp
 
 import (
+	"errors"
 	"io"
 	"log/slog"
+	"math"
 	"os"
 
 	"github.com/cmp0st/byte/internal/storage"
@@ -18,31 +20,27 @@ const (
 type Handlers struct {
 	Storage storage.Interface
 	Logger  *slog.Logger
+
+	handles handleSet
 }
 
 func (s *Handlers) Fileread(r *sftp.Request) (io.ReaderAt, error) {
-	logger := s.Logger.With(
-		slog.String("path", r.Filepath),
-		slog.String("method", r.Method),
-		slog.String("target", r.Target),
-		slog.Any("attrs", r.Attrs),
-		slog.Any("flags", r.Flags),
-	)
-	logger.Debug("sftp file read", "method", "Fileread")
-
-	file, err := s.Storage.Open(r.Filepath)
-	if err != nil {
-		logger.Error("failed to open file for reading", slog.Any("err", err))
-
-		return nil, sftpErrFromPathError(err)
-	}
+	return s.open(r, "reading")
+}
 
-	logger.Info("file opened for reading")
+func (s *Handlers) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	return s.open(r, "writing")
+}
 
-	return file, nil
+// OpenFile implements sftp.OpenFileWriter so that files opened for both
+// reading and writing share a single handle.
+func (s *Handlers) OpenFile(r *sftp.Request) (sftp.WriterAtReaderAt, error) {
+	return s.open(r, "reading and writing")
 }
 
-func (s *Handlers) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+// open opens the file of r with the flags the client asked for and tracks the
+// handle until it is closed.
+func (s *Handlers) open(r *sftp.Request, purpose string) (afero.File, error) {
 	logger := s.Logger.With(
 		slog.String("path", r.Filepath),
 		slog.String("method", r.Method),
@@ -50,53 +48,18 @@ func (s *Handlers) Filewrite(r *sftp.Request) (io.WriterAt, error) {
 		slog.Any("attrs", r.Attrs),
 		slog.Any("flags", r.Flags),
 	)
-	logger.Debug("sftp file write")
-
-	var flags int
-
-	pflags := r.Pflags()
-	if pflags.Write {
-		if pflags.Read {
-			flags = os.O_RDWR
-		} else {
-			flags = os.O_WRONLY
-		}
-	} else {
-		flags = os.O_RDONLY
-	}
-
-	if pflags.Creat {
-		flags |= os.O_CREATE
-	}
-
-	if pflags.Append {
-		flags |= os.O_APPEND
-	}
-
-	if pflags.Read {
-		flags |= os.O_RDONLY
-	}
-
-	if pflags.Trunc {
-		flags |= os.O_TRUNC
-	}
-
-	if pflags.Excl {
-		flags |= os.O_EXCL
-	}
+	logger.Debug("sftp file open")
 
-	// First try to open the file normally
-	file, err := s.Storage.OpenFile(r.Filepath, flags, DefaultFilePerms)
+	file, err := s.Storage.OpenFile(r.Filepath, openFlags(r.Pflags()), DefaultFilePerms)
 	if err != nil {
-		logger.Error(
-			"failed to open file for writing",
-			"error", err,
-		)
+		logger.Error("failed to open file for "+purpose, slog.Any("err", err))
 
 		return nil, sftpErrFromPathError(err)
 	}
 
-	return file, nil
+	logger.Info("file opened for " + purpose)
+
+	return newFileHandle(&s.handles, file, r.Filepath), nil
 }
 
 func (s *Handlers) Filecmd(r *sftp.Request) error {
@@ -154,10 +117,42 @@ func (s *Handlers) Filecmd(r *sftp.Request) error {
 		}
 
 		return sftpErrFromPathError(err)
-	case "Setstat":
-		logger.Debug("Setstat operation (no-op)")
+	case "Symlink":
+		linker, ok := s.Storage.(afero.Linker)
+		if !ok {
+			logger.Warn("symlink not supported by storage backend")
 
-		return nil
+			return sftp.ErrSSHFxOpUnsupported
+		}
+
+		// NB: for symlinks the request Filepath is the link target and the
+		// Target is the new link path.
+		err = linker.SymlinkIfPossible(r.Filepath, r.Target)
+		if err != nil {
+			logger.Error("failed to create symlink", slog.Any("err", err))
+		} else {
+			logger.Info("symlink created")
+		}
+
+		return sftpErrFromPathError(err)
+	case "Link":
+		linker, ok := s.Storage.(storage.HardLinker)
+		if !ok {
+			logger.Warn("hard link not supported by storage backend")
+
+			return sftp.ErrSSHFxOpUnsupported
+		}
+
+		err = linker.LinkIfPossible(r.Filepath, r.Target)
+		if err != nil {
+			logger.Error("failed to create hard link", slog.Any("err", err))
+		} else {
+			logger.Info("hard link created")
+		}
+
+		return sftpErrFromPathError(err)
+	case "Setstat":
+		return s.setstat(r, logger)
 	default:
 		logger.Warn("Unsupported SFTP operation")
 
@@ -177,19 +172,16 @@ func (s *Handlers) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
 
 	switch r.Method {
 	case "List":
-		entries, err := afero.ReadDir(s.Storage, r.Filepath)
+		lister, err := newDirLister(s.Storage, r.Filepath, &s.handles, logger)
 		if err != nil {
 			logger.Error("failed to list directory", slog.Any("err", err))
 
 			return nil, sftpErrFromPathError(err)
 		}
 
-		logger.Info(
-			"directory listed",
-			slog.Int("entries", len(entries)),
-		)
+		logger.Info("directory opened for listing")
 
-		return listerat(entries), nil
+		return lister, nil
 
 	case "Stat":
 		info, err := s.Storage.Stat(r.Filepath)
@@ -207,10 +199,6 @@ func (s *Handlers) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
 		)
 
 		return listerat([]os.FileInfo{info}), nil
-	case "Readlink":
-		logger.Error("readlink operation not supported")
-
-		return nil, sftp.ErrSSHFxOpUnsupported
 	default:
 		logger.Warn("Unsupported file list operation")
 
@@ -218,6 +206,190 @@ func (s *Handlers) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
 	}
 }
 
+// setstat applies the attributes of a setstat or fsetstat request. Only the
+// size is supported, for truncating files. Ownership, permissions and times
+// are managed by the server, so the client's values are ignored.
+func (s *Handlers) setstat(r *sftp.Request, logger *slog.Logger) error {
+	if !r.AttrFlags().Size {
+		logger.Debug("setstat without size ignored")
+
+		return nil
+	}
+
+	size := r.Attributes().Size
+	if size > math.MaxInt64 {
+		return sftp.ErrSSHFxFailure
+	}
+
+	file, err := s.Storage.OpenFile(r.Filepath, os.O_WRONLY, DefaultFilePerms)
+	if err != nil {
+		logger.Error("failed to open file for truncating", slog.Any("err", err))
+
+		return sftpErrFromPathError(err)
+	}
+	//nolint: errcheck
+	defer file.Close()
+
+	err = file.Truncate(int64(size))
+	if err != nil {
+		logger.Error("failed to truncate file", slog.Any("err", err))
+
+		return sftpErrFromPathError(err)
+	}
+
+	logger.Info("file truncated", slog.Uint64("size", size))
+
+	return nil
+}
+
+// Close closes the handles the client left open when its session ended.
+func (s *Handlers) Close() error {
+	var errs []error
+
+	for handle, path := range s.handles.snapshot() {
+		s.Logger.Warn("closing sftp handle left open", slog.String("path", path))
+
+		errs = append(errs, handle.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// PosixRename implements sftp.PosixRenameFileCmder for the
+// posix-rename@openssh.com extension, atomically replacing any existing
+// target.
+func (s *Handlers) PosixRename(r *sftp.Request) error {
+	logger := s.Logger.With(
+		slog.String("path", r.Filepath),
+		slog.String("method", r.Method),
+		slog.String("target", r.Target),
+	)
+	logger.Debug("sftp posix rename")
+
+	err := s.Storage.Rename(r.Filepath, r.Target)
+	if err != nil {
+		logger.Error("failed to rename file", slog.Any("err", err))
+	} else {
+		logger.Info("file renamed")
+	}
+
+	return sftpErrFromPathError(err)
+}
+
+// StatVFS implements sftp.StatVFSFileCmder for the statvfs@openssh.com
+// extension.
+func (s *Handlers) StatVFS(r *sftp.Request) (*sftp.StatVFS, error) {
+	logger := s.Logger.With(
+		slog.String("path", r.Filepath),
+		slog.String("method", r.Method),
+	)
+	logger.Debug("sftp statvfs")
+
+	usage, err := storage.UsageOf(s.Storage, r.Filepath)
+	if err != nil {
+		logger.Error("failed to stat filesystem", slog.Any("err", err))
+
+		return nil, sftpErrFromPathError(err)
+	}
+
+	return &sftp.StatVFS{
+		Bsize:   usage.BlockSize,
+		Frsize:  usage.BlockSize,
+		Blocks:  usage.Blocks,
+		Bfree:   usage.BlocksFree,
+		Bavail:  usage.BlocksAvail,
+		Files:   usage.Files,
+		Ffree:   usage.FilesFree,
+		Favail:  usage.FilesFree,
+		Namemax: usage.NameMax,
+	}, nil
+}
+
+// Fsync flushes the named file to stable storage for the fsync@openssh.com
+// extension. It syncs the handles the session has open on the file rather
+// than reopening it, which write-only grants would refuse.
+func (s *Handlers) Fsync(path string) error {
+	logger := s.Logger.With(
+		slog.String("path", path),
+		slog.String("method", "Fsync"),
+	)
+	logger.Debug("sftp fsync")
+
+	files := s.handles.files(path)
+	if len(files) == 0 {
+		logger.Error("failed to sync file", slog.Any("err", os.ErrClosed))
+
+		return sftp.ErrSSHFxFailure
+	}
+
+	for _, file := range files {
+		err := file.Sync()
+		if err != nil {
+			logger.Error("failed to sync file", slog.Any("err", err))
+
+			return sftpErrFromPathError(err)
+		}
+	}
+
+	return nil
+}
+
+// Lstat implements sftp.LstatFileLister so that symbolic links are reported
+// as links rather than followed.
+func (s *Handlers) Lstat(r *sftp.Request) (sftp.ListerAt, error) {
+	logger := s.Logger.With(
+		slog.String("path", r.Filepath),
+		slog.String("method", r.Method),
+	)
+	logger.Debug("sftp file lstat")
+
+	lstater, ok := s.Storage.(afero.Lstater)
+	if !ok {
+		info, err := s.Storage.Stat(r.Filepath)
+		if err != nil {
+			logger.Error("failed to stat file", slog.Any("err", err))
+
+			return nil, sftpErrFromPathError(err)
+		}
+
+		return listerat([]os.FileInfo{info}), nil
+	}
+
+	info, _, err := lstater.LstatIfPossible(r.Filepath)
+	if err != nil {
+		logger.Error("failed to lstat file", slog.Any("err", err))
+
+		return nil, sftpErrFromPathError(err)
+	}
+
+	return listerat([]os.FileInfo{info}), nil
+}
+
+// Readlink implements sftp.ReadlinkFileLister.
+func (s *Handlers) Readlink(path string) (string, error) {
+	logger := s.Logger.With(
+		slog.String("path", path),
+		slog.String("method", "Readlink"),
+	)
+	logger.Debug("sftp readlink")
+
+	reader, ok := s.Storage.(afero.LinkReader)
+	if !ok {
+		logger.Warn("readlink not supported by storage backend")
+
+		return "", sftp.ErrSSHFxOpUnsupported
+	}
+
+	target, err := reader.ReadlinkIfPossible(path)
+	if err != nil {
+		logger.Error("failed to read link", slog.Any("err", err))
+
+		return "", sftpErrFromPathError(err)
+	}
+
+	return target, nil
+}
+
 type listerat []os.FileInfo
 
 func (f listerat) ListAt(ls []os.FileInfo, offset int64) (int, error) {
@@ -238,11 +410,21 @@ func sftpErrFromPathError(err error) error {
 		return nil
 	}
 
-	if os.IsNotExist(err) {
+	if errors.Is(err, afero.ErrNoSymlink) ||
+		errors.Is(err, afero.ErrNoReadlink) ||
+		errors.Is(err, storage.ErrNoHardLink) {
+		return sftp.ErrSSHFxOpUnsupported
+	}
+
+	if errors.Is(err, storage.ErrLinkOutsideRoot) {
+		return sftp.ErrSSHFxPermissionDenied
+	}
+
+	if os.IsNotExist(err) || errors.Is(err, os.ErrNotExist) {
 		return sftp.ErrSSHFxNoSuchFile
 	}
 
-	if os.IsPermission(err) {
+	if os.IsPermission(err) || errors.Is(err, os.ErrPermission) {
 		return sftp.ErrSSHFxPermissionDenied
 	}
 
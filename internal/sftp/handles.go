@@ -0,0 +1,283 @@
+package sftp
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"github.com/spf13/afero"
+	"github.com/spf13/afero/mem"
+)
+
+// handleSet is the files and directories a session has open. Handles leave
+// the set when they are closed, so whatever is left once the session ends was
+// abandoned by the client.
+type handleSet struct {
+	mu   sync.Mutex
+	open map[io.Closer]string
+}
+
+func (s *handleSet) add(c io.Closer, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.open == nil {
+		s.open = make(map[io.Closer]string)
+	}
+
+	s.open[c] = path
+}
+
+// remove reports whether c was still open.
+func (s *handleSet) remove(c io.Closer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, found := s.open[c]
+	delete(s.open, c)
+
+	return found
+}
+
+// snapshot returns the open handles, which can then be closed without
+// holding the lock.
+func (s *handleSet) snapshot() map[io.Closer]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return maps.Clone(s.open)
+}
+
+// files returns the files open on path.
+func (s *handleSet) files(path string) []*fileHandle {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var files []*fileHandle
+
+	for c, p := range s.open {
+		file, ok := c.(*fileHandle)
+		if ok && p == path {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+// openFlags translates the flags of an sftp open request to os.OpenFile
+// flags. Appending is left out since *os.File refuses WriteAt on files opened
+// with os.O_APPEND, and clients send the offsets of appended data anyway.
+func openFlags(pflags sftp.FileOpenFlags) int {
+	var flags int
+
+	switch {
+	case pflags.Read && pflags.Write:
+		flags = os.O_RDWR
+	case pflags.Write, pflags.Append:
+		flags = os.O_WRONLY
+	default:
+		flags = os.O_RDONLY
+	}
+
+	if pflags.Creat {
+		flags |= os.O_CREATE
+	}
+
+	if pflags.Trunc {
+		flags |= os.O_TRUNC
+	}
+
+	if pflags.Excl {
+		flags |= os.O_EXCL
+	}
+
+	return flags
+}
+
+// fileHandle is a file opened by an sftp session.
+type fileHandle struct {
+	afero.File
+
+	handles *handleSet
+
+	// NB: pkg/sftp serves the requests on a handle concurrently. The
+	// in-memory backend implements ReadAt and WriteAt by moving the shared
+	// offset of the file, so those files need their requests serialized.
+	mu        sync.Mutex
+	serialize bool
+}
+
+func newFileHandle(handles *handleSet, file afero.File, path string) *fileHandle {
+	// NB: look through the bandwidth limits to the file of the backend.
+	backend := file
+	if throttled, ok := file.(*throttledFile); ok {
+		backend = throttled.File
+	}
+
+	_, serialize := backend.(*mem.File)
+
+	f := &fileHandle{
+		File:      file,
+		handles:   handles,
+		serialize: serialize,
+	}
+
+	handles.add(f, path)
+
+	return f
+}
+
+func (f *fileHandle) ReadAt(p []byte, off int64) (int, error) {
+	if f.serialize {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+	}
+
+	n, err := f.File.ReadAt(p, off)
+
+	// NB: the in-memory backend fails reads starting past the end of the
+	// file with io.ErrUnexpectedEOF, which clients reading ahead of the end
+	// take as an error rather than the end of the file.
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+func (f *fileHandle) WriteAt(p []byte, off int64) (int, error) {
+	if f.serialize {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+	}
+
+	return f.File.WriteAt(p, off)
+}
+
+func (f *fileHandle) Sync() error {
+	if f.serialize {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+	}
+
+	return f.File.Sync()
+}
+
+func (f *fileHandle) Close() error {
+	if !f.handles.remove(f) {
+		return os.ErrClosed
+	}
+
+	return f.File.Close()
+}
+
+// dirLister lists a directory a page at a time instead of reading it whole
+// when it is opened.
+type dirLister struct {
+	storage afero.Fs
+	path    string
+	handles *handleSet
+	logger  *slog.Logger
+
+	mu     sync.Mutex
+	dir    afero.File
+	offset int64
+}
+
+func newDirLister(
+	storage afero.Fs,
+	path string,
+	handles *handleSet,
+	logger *slog.Logger,
+) (*dirLister, error) {
+	dir, err := storage.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &dirLister{
+		storage: storage,
+		path:    path,
+		handles: handles,
+		logger:  logger,
+		dir:     dir,
+	}
+
+	handles.add(l, path)
+
+	return l, nil
+}
+
+func (l *dirLister) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(ls) == 0 {
+		return 0, nil
+	}
+
+	err := l.seek(offset)
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := l.dir.Readdir(len(ls))
+	n := copy(ls, entries)
+	l.offset += int64(n)
+
+	if errors.Is(err, io.EOF) || (err == nil && n == 0) {
+		l.logger.Debug("directory listed", slog.Int64("entries", l.offset))
+
+		return n, io.EOF
+	}
+
+	return n, err
+}
+
+// seek moves the directory to the entry at offset. Clients read directories
+// front to back, so going backwards reopens the directory.
+func (l *dirLister) seek(offset int64) error {
+	if offset < l.offset {
+		dir, err := l.storage.Open(l.path)
+		if err != nil {
+			return err
+		}
+
+		//nolint: errcheck
+		l.dir.Close()
+
+		l.dir = dir
+		l.offset = 0
+	}
+
+	for l.offset < offset {
+		skipped, err := l.dir.Readdir(int(offset - l.offset))
+		l.offset += int64(len(skipped))
+
+		if err != nil {
+			return err
+		}
+
+		if len(skipped) == 0 {
+			return io.EOF
+		}
+	}
+
+	return nil
+}
+
+func (l *dirLister) Close() error {
+	if !l.handles.remove(l) {
+		return os.ErrClosed
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.dir.Close()
+}
@@ -0,0 +1,318 @@
+package sftp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/cmp0st/byte/internal/storage"
+	"github.com/pkg/sftp"
+	"github.com/spf13/afero"
+)
+
+// testBackends returns the storage backends handles are tested against.
+func testBackends(t testing.TB) map[string]func() storage.Interface {
+	return map[string]func() storage.Interface{
+		"posix": func() storage.Interface {
+			return storage.NewPosix(t.TempDir())
+		},
+		"in-memory": storage.NewInMemory,
+	}
+}
+
+func newTestDir(t *testing.T, st storage.Interface, entries int) []string {
+	t.Helper()
+
+	err := st.MkdirAll("/dir", 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, entries)
+	for i := range names {
+		names[i] = fmt.Sprintf("f%02d", i)
+
+		err = afero.WriteFile(st, "/dir/"+names[i], nil, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return names
+}
+
+// listAt lists a page of count entries at offset and returns their names.
+func listAt(t *testing.T, l *dirLister, offset int64, count int) ([]string, error) {
+	t.Helper()
+
+	page := make([]os.FileInfo, count)
+
+	n, err := l.ListAt(page, offset)
+
+	names := make([]string, n)
+	for i, info := range page[:n] {
+		names[i] = info.Name()
+	}
+
+	return names, err
+}
+
+func TestDirListerPages(t *testing.T) {
+	for name, backend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			st := backend()
+			want := newTestDir(t, st, 10)
+
+			l, err := newDirLister(st, "/dir", &handleSet{}, slog.New(slog.DiscardHandler))
+			if err != nil {
+				t.Fatal(err)
+			}
+			//nolint: errcheck
+			defer l.Close()
+
+			var got []string
+
+			for offset := int64(0); ; {
+				names, err := listAt(t, l, offset, 3)
+				got = append(got, names...)
+				offset += int64(len(names))
+
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				if err != nil {
+					t.Fatalf("failed to list at %d: %v", offset, err)
+				}
+			}
+
+			slices.Sort(got)
+
+			if !slices.Equal(got, want) {
+				t.Fatalf("listed %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDirListerOffsets(t *testing.T) {
+	for name, backend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			st := backend()
+			newTestDir(t, st, 10)
+
+			l, err := newDirLister(st, "/dir", &handleSet{}, slog.New(slog.DiscardHandler))
+			if err != nil {
+				t.Fatal(err)
+			}
+			//nolint: errcheck
+			defer l.Close()
+
+			// NB: the order of the entries is up to the backend, so it is
+			// taken from a whole listing.
+			all, err := listAt(t, l, 0, 10)
+			if err != nil || len(all) != 10 {
+				t.Fatalf("listed %v, %v, want 10 entries", all, err)
+			}
+
+			tests := []struct {
+				name   string
+				offset int64
+				count  int
+				want   []string
+				err    error
+			}{
+				{name: "backwards to the start", offset: 0, count: 3, want: all[:3]},
+				{name: "next page", offset: 3, count: 3, want: all[3:6]},
+				{name: "skip forward", offset: 8, count: 1, want: all[8:9]},
+				{name: "backwards", offset: 2, count: 2, want: all[2:4]},
+				{name: "last page", offset: 7, count: 5, want: all[7:]},
+				{name: "at the end", offset: 10, count: 3, want: []string{}, err: io.EOF},
+				{name: "past the end", offset: 12, count: 3, want: []string{}, err: io.EOF},
+				{name: "empty page", offset: 0, count: 0, want: []string{}},
+			}
+
+			for _, test := range tests {
+				names, err := listAt(t, l, test.offset, test.count)
+				if !errors.Is(err, test.err) || !slices.Equal(names, test.want) {
+					t.Errorf(
+						"%s: listed %v, %v, want %v, %v",
+						test.name, names, err, test.want, test.err,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlesClosedWithSession(t *testing.T) {
+	for name, backend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			st := backend()
+			newTestDir(t, st, 3)
+
+			client, h, done := newTestClient(t, st)
+
+			for _, name := range []string{"/dir/f00", "/dir/f01"} {
+				file, err := client.OpenFile(name, os.O_RDWR)
+				if err != nil {
+					t.Fatalf("failed to open %s: %v", name, err)
+				}
+
+				_, err = file.Write([]byte("data"))
+				if err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+
+			_, err := newDirLister(st, "/dir", &h.handles, h.Logger)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			open := h.handles.snapshot()
+			if len(open) != 3 {
+				t.Fatalf("%d handles open, want 3", len(open))
+			}
+
+			err = client.Close()
+			if err != nil {
+				t.Fatalf("failed to close client: %v", err)
+			}
+
+			<-done
+
+			if left := h.handles.snapshot(); len(left) != 0 {
+				t.Fatalf("%d handles left open after the session", len(left))
+			}
+
+			for handle, path := range open {
+				err = handle.Close()
+				if !errors.Is(err, os.ErrClosed) {
+					t.Errorf("handle of %s was not closed: %v", path, err)
+				}
+			}
+
+			data, err := afero.ReadFile(st, "/dir/f00")
+			if err != nil || string(data) != "data" {
+				t.Fatalf("read %q, %v after the session", data, err)
+			}
+		})
+	}
+}
+
+// legacyHandlers serve files the way Handlers did before handles were
+// tracked, handing the files of storage straight to the request server.
+type legacyHandlers struct {
+	storage storage.Interface
+}
+
+func (h legacyHandlers) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	return h.storage.Open(r.Filepath)
+}
+
+func (h legacyHandlers) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	return h.storage.OpenFile(r.Filepath, openFlags(r.Pflags()), DefaultFilePerms)
+}
+
+// benchmarkClients returns clients served the old way and through Handlers.
+func benchmarkClients(b *testing.B, st storage.Interface) map[string]*sftp.Client {
+	b.Helper()
+
+	h := &Handlers{
+		Storage: st,
+		Logger:  slog.New(slog.DiscardHandler),
+	}
+
+	legacy, _ := serveTestClient(b, func(rwc io.ReadWriteCloser) *sftp.RequestServer {
+		return sftp.NewRequestServer(rwc, sftp.Handlers{
+			FileGet:  legacyHandlers{st},
+			FilePut:  legacyHandlers{st},
+			FileCmd:  h,
+			FileList: h,
+		})
+	}, func() error { return nil })
+
+	handles, _ := serveTestClient(b, func(rwc io.ReadWriteCloser) *sftp.RequestServer {
+		return sftp.NewRequestServer(rwc, sftp.Handlers{
+			FileGet:  h,
+			FilePut:  h,
+			FileCmd:  h,
+			FileList: h,
+		})
+	}, h.Close)
+
+	return map[string]*sftp.Client{"legacy": legacy, "handles": handles}
+}
+
+const benchmarkFileSize = 4 << 20
+
+func BenchmarkRead(b *testing.B) {
+	for name, backend := range testBackends(b) {
+		st := backend()
+
+		err := afero.WriteFile(st, "/f", make([]byte, benchmarkFileSize), 0o644)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for path, client := range benchmarkClients(b, st) {
+			b.Run(name+"/"+path, func(b *testing.B) {
+				b.SetBytes(benchmarkFileSize)
+
+				for b.Loop() {
+					file, err := client.Open("/f")
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					_, err = file.WriteTo(io.Discard)
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					err = file.Close()
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkWrite(b *testing.B) {
+	data := make([]byte, benchmarkFileSize)
+
+	for name, backend := range testBackends(b) {
+		st := backend()
+
+		for path, client := range benchmarkClients(b, st) {
+			b.Run(name+"/"+path, func(b *testing.B) {
+				b.SetBytes(benchmarkFileSize)
+
+				for b.Loop() {
+					file, err := client.OpenFile("/f", os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					_, err = file.ReadFrom(bytes.NewReader(data))
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					err = file.Close()
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
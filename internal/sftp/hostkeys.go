@@ -0,0 +1,267 @@
+package sftp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/key"
+	"github.com/cmp0st/byte/internal/logging"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// OpenSSH extensions that let clients learn about host keys other than the
+// one used for the handshake, see section 2.5 of PROTOCOL in OpenSSH.
+const (
+	HostKeysExtension      = "hostkeys-00@openssh.com"
+	HostKeysProveExtension = "hostkeys-prove-00@openssh.com"
+)
+
+var ErrUnknownHostKey = errors.New("unknown host key")
+
+// hostKeys are the keys a server authenticates with plus the ones it only
+// announces, typically the next version during a rotation of the host keys or
+// of the server secret.
+type hostKeys struct {
+	logger *slog.Logger
+
+	current   []gossh.Signer
+	announced []gossh.Signer
+
+	// payload is the hostkeys-00@openssh.com request, which is the same for
+	// every connection.
+	payload []byte
+}
+
+// announcedKey is the ssh.Context key set once a connection was sent the
+// host keys.
+type announcedKey struct{}
+
+// newHostKeys derives the host keys from the current version of the server
+// secret. The keys of the other versions of the secret are announced so that
+// clients keep trusting the server while the secret is rotated.
+func newHostKeys(
+	k key.ServerKeyring,
+	c config.SSHHostKeys,
+	logger *slog.Logger,
+) (*hostKeys, error) {
+	version := c.Version
+	if version == 0 {
+		version = key.DefaultSSHHostKeyVersion
+	}
+
+	h := &hostKeys{logger: logger}
+
+	versions := []int{version}
+
+	for _, v := range c.Announce {
+		if !slices.Contains(versions, v) {
+			versions = append(versions, v)
+		}
+	}
+
+	err := h.add(k.CurrentChain(), versions, version)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, secretVersion := range k.Versions() {
+		if secretVersion == k.Current {
+			continue
+		}
+
+		chain, err := k.Chain(secretVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		err = h.add(chain, []int{version}, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// add derives the host keys of versions from chain and announces them. The
+// keys of version current are also used to authenticate.
+func (h *hostKeys) add(chain key.ServerChain, versions []int, current int) error {
+	for _, v := range versions {
+		keys, err := chain.SSHHostKeys(v)
+		if err != nil {
+			return err
+		}
+
+		for _, raw := range keys {
+			signer, err := gossh.NewSignerFromKey(raw)
+			if err != nil {
+				return fmt.Errorf("failed to create ssh host key signer: %w", err)
+			}
+
+			if v == current {
+				h.current = append(h.current, signer)
+			}
+
+			h.announced = append(h.announced, signer)
+			h.payload = appendString(h.payload, signer.PublicKey().Marshal())
+		}
+	}
+
+	return nil
+}
+
+// Option registers the current host keys and the handlers of the host key
+// extensions with srv.
+func (h *hostKeys) Option(srv *ssh.Server) error {
+	for _, signer := range h.current {
+		srv.AddHostKey(signer)
+	}
+
+	if srv.ChannelHandlers == nil {
+		srv.ChannelHandlers = map[string]ssh.ChannelHandler{}
+	}
+
+	srv.ChannelHandlers["session"] = h.announce(ssh.DefaultSessionHandler)
+
+	if srv.RequestHandlers == nil {
+		srv.RequestHandlers = map[string]ssh.RequestHandler{}
+	}
+
+	srv.RequestHandlers[HostKeysProveExtension] = h.prove
+
+	return nil
+}
+
+// announce sends the host keys to the client when it opens its first
+// session, which is after authentication as the extension requires.
+func (h *hostKeys) announce(next ssh.ChannelHandler) ssh.ChannelHandler {
+	return func(
+		srv *ssh.Server,
+		conn *gossh.ServerConn,
+		newChan gossh.NewChannel,
+		ctx ssh.Context,
+	) {
+		ctx.Lock()
+		announced := ctx.Value(announcedKey{}) != nil
+		ctx.SetValue(announcedKey{}, true)
+		ctx.Unlock()
+
+		if !announced {
+			_, _, err := conn.SendRequest(HostKeysExtension, false, h.payload)
+			if err != nil {
+				h.logger.With(logging.SSHAttrs(ctx)...).Debug(
+					"failed to announce ssh host keys",
+					slog.Any("err", err),
+				)
+			}
+		}
+
+		next(srv, conn, newChan, ctx)
+	}
+}
+
+// prove signs every host key the client asks about to show that the server
+// holds the private key.
+func (h *hostKeys) prove(ctx ssh.Context, _ *ssh.Server, req *gossh.Request) (bool, []byte) {
+	logger := h.logger.With(logging.SSHAttrs(ctx)...)
+
+	sessionID, err := hex.DecodeString(ctx.SessionID())
+	if err != nil {
+		logger.Error("failed to decode ssh session id", slog.Any("err", err))
+
+		return false, nil
+	}
+
+	var proofs []byte
+
+	for rest := req.Payload; len(rest) > 0; {
+		var blob []byte
+
+		blob, rest, err = parseString(rest)
+		if err != nil {
+			logger.Warn("invalid ssh host key proof request", slog.Any("err", err))
+
+			return false, nil
+		}
+
+		proof, err := h.sign(blob, sessionID)
+		if err != nil {
+			logger.Warn("failed to prove ssh host key", slog.Any("err", err))
+
+			return false, nil
+		}
+
+		proofs = appendString(proofs, proof)
+	}
+
+	logger.Debug("proved ssh host keys")
+
+	return true, proofs
+}
+
+func (h *hostKeys) sign(blob, sessionID []byte) ([]byte, error) {
+	idx := slices.IndexFunc(h.announced, func(s gossh.Signer) bool {
+		return bytes.Equal(s.PublicKey().Marshal(), blob)
+	})
+	if idx < 0 {
+		return nil, ErrUnknownHostKey
+	}
+
+	signer := h.announced[idx]
+
+	data := gossh.Marshal(struct {
+		Request   string
+		SessionID []byte
+		HostKey   []byte
+	}{HostKeysProveExtension, sessionID, blob})
+
+	var (
+		sig *gossh.Signature
+		err error
+	)
+
+	// NB: SHA-1 RSA signatures are disabled in OpenSSH, so RSA keys are
+	// proved with the SHA-512 variant that clients prefer for them.
+	algorithmSigner, ok := signer.(gossh.AlgorithmSigner)
+	if ok && signer.PublicKey().Type() == gossh.KeyAlgoRSA {
+		sig, err = algorithmSigner.SignWithAlgorithm(rand.Reader, data, gossh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = signer.Sign(rand.Reader, data)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign host key proof: %w", err)
+	}
+
+	return gossh.Marshal(sig), nil
+}
+
+// appendString appends s in the SSH wire format for strings.
+func appendString(b, s []byte) []byte {
+	//nolint: gosec // host keys and signatures are far below 4 GiB
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+
+	return append(b, s...)
+}
+
+// parseString reads a string in the SSH wire format from the start of b.
+func parseString(b []byte) ([]byte, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, errors.New("short string length")
+	}
+
+	n := binary.BigEndian.Uint32(b)
+	if uint64(len(b)-4) < uint64(n) {
+		return nil, nil, errors.New("short string")
+	}
+
+	return b[4 : 4+n], b[4+n:], nil
+}
@@ -0,0 +1,230 @@
+package sftp
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net"
+	"slices"
+	"testing"
+
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/key"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+func newTestKeyring(t *testing.T, secrets map[int][]byte, current int) key.ServerKeyring {
+	t.Helper()
+
+	keyring, err := key.NewServerKeyring(secrets, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return *keyring
+}
+
+func equalKeys(a, b [][]byte) bool {
+	return slices.EqualFunc(a, b, bytes.Equal)
+}
+
+// publicKeys returns the marshalled public keys of signers.
+func publicKeys(signers []gossh.Signer) [][]byte {
+	var keys [][]byte
+	for _, signer := range signers {
+		keys = append(keys, signer.PublicKey().Marshal())
+	}
+
+	return keys
+}
+
+func TestNewHostKeys(t *testing.T) {
+	keyring := newTestKeyring(t, map[int][]byte{
+		1: []byte("0123456789abcdef0123456789abcdef"),
+		2: []byte("fedcba9876543210fedcba9876543210"),
+	}, 2)
+
+	h, err := newHostKeys(
+		keyring,
+		config.SSHHostKeys{Version: 2, Announce: []int{1, 2}},
+		slog.New(slog.DiscardHandler),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want [][]gossh.Signer
+
+	for _, v := range []struct{ secret, hostKeys int }{{2, 2}, {2, 1}, {1, 2}} {
+		chain, err := keyring.Chain(v.secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys, err := chain.SSHHostKeys(v.hostKeys)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var signers []gossh.Signer
+
+		for _, k := range keys {
+			signer, err := gossh.NewSignerFromKey(k)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signers = append(signers, signer)
+		}
+
+		want = append(want, signers)
+	}
+
+	// NB: only the configured version of the current secret authenticates,
+	// every other combination is announced for clients to learn ahead.
+	if got := publicKeys(h.current); !equalKeys(got, publicKeys(want[0])) {
+		t.Errorf("authenticating with %d keys, want the 3 of the current version", len(got))
+	}
+
+	announced := publicKeys(slices.Concat(want...))
+	if got := publicKeys(h.announced); !equalKeys(got, announced) {
+		t.Errorf("announcing %d keys, want %d", len(got), len(announced))
+	}
+
+	var payload [][]byte
+	for rest := h.payload; len(rest) > 0; {
+		var blob []byte
+
+		blob, rest, err = parseString(rest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		payload = append(payload, blob)
+	}
+
+	if !equalKeys(payload, announced) {
+		t.Errorf("announcement lists %d keys, want %d", len(payload), len(announced))
+	}
+}
+
+func TestHostKeyProof(t *testing.T) {
+	keyring := newTestKeyring(t, map[int][]byte{
+		1: []byte("0123456789abcdef0123456789abcdef"),
+	}, 1)
+
+	h, err := newHostKeys(keyring, config.SSHHostKeys{}, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessionID := []byte("session")
+
+	for _, signer := range h.announced {
+		blob := signer.PublicKey().Marshal()
+
+		proof, err := h.sign(blob, sessionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var sig gossh.Signature
+
+		err = gossh.Unmarshal(proof, &sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data := gossh.Marshal(struct {
+			Request   string
+			SessionID []byte
+			HostKey   []byte
+		}{HostKeysProveExtension, sessionID, blob})
+
+		err = signer.PublicKey().Verify(data, &sig)
+		if err != nil {
+			t.Errorf("proof of %s key: %v", signer.PublicKey().Type(), err)
+		}
+
+		if signer.PublicKey().Type() == gossh.KeyAlgoRSA && sig.Format != gossh.KeyAlgoRSASHA512 {
+			t.Errorf("proof of rsa key: got %s, want %s", sig.Format, gossh.KeyAlgoRSASHA512)
+		}
+	}
+
+	_, err = h.sign(newTestSigner(t).PublicKey().Marshal(), sessionID)
+	if !errors.Is(err, ErrUnknownHostKey) {
+		t.Errorf("proof of unknown key: got %v, want %v", err, ErrUnknownHostKey)
+	}
+}
+
+func TestParseString(t *testing.T) {
+	b := appendString(appendString(nil, []byte("first")), nil)
+
+	first, rest, err := parseString(b)
+	if err != nil || string(first) != "first" {
+		t.Fatalf("parseString = %q, %v, want first", first, err)
+	}
+
+	second, rest, err := parseString(rest)
+	if err != nil || len(second) != 0 || len(rest) != 0 {
+		t.Fatalf("parseString = %q, %q, %v, want empty", second, rest, err)
+	}
+
+	for _, b := range [][]byte{{0, 0, 0}, {0, 0, 0, 2, 'a'}} {
+		_, _, err = parseString(b)
+		if err == nil {
+			t.Errorf("parseString(%q) succeeded", b)
+		}
+	}
+}
+
+func TestHostKeyVersion(t *testing.T) {
+	keyring := newTestKeyring(t, map[int][]byte{
+		key.DefaultKeyVersion: []byte("0123456789abcdef0123456789abcdef"),
+	}, key.DefaultKeyVersion)
+
+	want, err := keyring.CurrentChain().SSHHostKeyEd25519(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantKey, err := gossh.NewPublicKey(want.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := newTestDB(t)
+	_, signer := newTestDevice(t, db, auth.RoleMember)
+	addr := newTestServer(
+		t,
+		config.SFTP{HostKeys: config.SSHHostKeys{Version: 2}},
+		storage.NewInMemory(),
+		db,
+	)
+
+	var got gossh.PublicKey
+
+	client, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:              "test",
+		Auth:              []gossh.AuthMethod{gossh.PublicKeys(signer)},
+		HostKeyAlgorithms: []string{gossh.KeyAlgoED25519},
+		HostKeyCallback: func(_ string, _ net.Addr, key gossh.PublicKey) error {
+			got = key
+
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//nolint: errcheck
+	client.Close()
+
+	if got == nil || !bytes.Equal(got.Marshal(), wantKey.Marshal()) {
+		t.Errorf("server authenticated with %v, want version 2 of the ed25519 host key", got)
+	}
+}
@@ -0,0 +1,406 @@
+package sftp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/logging"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// limiter enforces config.SSHLimits across all connections of a server, and
+// refuses the peers and devices banned by attempts.
+type limiter struct {
+	limits   config.SSHLimits
+	attempts *auth.AttemptLimiter
+	logger   *slog.Logger
+
+	mu    sync.Mutex
+	conns int
+	keys  map[string]*keyUsage
+}
+
+// keyUsage is what the connections of a single key share.
+type keyUsage struct {
+	conns int
+	read  *Throttle
+	write *Throttle
+}
+
+type (
+	// usageKey is the ssh.Context key of the keyUsage a connection counts
+	// against.
+	usageKey struct{}
+
+	// authKey is the ssh.Context key of the authAttempt of a connection.
+	authKey struct{}
+)
+
+// authAttempt is the outcome of the public key authentication of a
+// connection.
+type authAttempt struct {
+	failed        bool
+	authenticated bool
+
+	// accepted is what the key handler stored on the context for each key it
+	// accepted, by fingerprint.
+	accepted map[string]acceptedKey
+
+	verify   sync.Once
+	verified bool
+}
+
+// acceptedKey is the device and options of a key the key handler accepted.
+type acceptedKey struct {
+	device string
+	opts   auth.SSHOptions
+}
+
+func newLimiter(
+	limits config.SSHLimits,
+	attempts *auth.AttemptLimiter,
+	logger *slog.Logger,
+) *limiter {
+	return &limiter{
+		limits:   limits,
+		attempts: attempts,
+		logger:   logger,
+		keys:     make(map[string]*keyUsage),
+	}
+}
+
+// ConnCallback rejects connections from banned peers and over the global
+// limit, and watches the accepted ones for timeouts so they can be logged.
+func (l *limiter) ConnCallback(ctx ssh.Context, conn net.Conn) net.Conn {
+	logger := l.logger.With(
+		slog.Any("local_addr", conn.LocalAddr()),
+		slog.Any("remote_addr", conn.RemoteAddr()),
+	)
+
+	retryAt, err := l.attempts.Check(conn.RemoteAddr().String(), "", time.Now())
+	if err != nil {
+		logger.Warn(
+			"ssh connection rejected: too many failed authentication attempts",
+			slog.Time("retry_at", retryAt),
+			slog.Any("err", err),
+		)
+
+		return nil
+	}
+
+	// NB: a client may offer several keys before one is accepted, so a
+	// connection only counts as a failed attempt once it closes without
+	// being authenticated.
+	attempt := &authAttempt{accepted: make(map[string]acceptedKey)}
+	ctx.SetValue(authKey{}, attempt)
+
+	l.mu.Lock()
+
+	if l.limits.MaxConnections > 0 && l.conns >= l.limits.MaxConnections {
+		l.mu.Unlock()
+
+		logger.Warn(
+			"ssh connection rejected: connection limit reached",
+			slog.Int("max_connections", l.limits.MaxConnections),
+		)
+
+		return nil
+	}
+
+	l.conns++
+	l.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		l.mu.Lock()
+		l.conns--
+		failed := attempt.failed && !attempt.authenticated
+		l.mu.Unlock()
+
+		if !failed {
+			return
+		}
+
+		for _, ban := range l.attempts.Fail(conn.RemoteAddr().String(), "", time.Now()) {
+			logger.Warn(
+				"ssh authentication failed too many times, banned",
+				slog.Time("until", ban.Until),
+				slog.Int("count", ban.Count),
+			)
+		}
+	}()
+
+	return &limitedConn{
+		Conn:    conn,
+		ctx:     ctx,
+		limiter: l,
+		opened:  time.Now(),
+	}
+}
+
+// PublicKeyHandler wraps next so that connections failing to authenticate
+// count against the failed attempts of their peer, and remembers what next
+// stored on the context for each accepted key.
+//
+// NB: it also runs when a client merely asks whether a key would be accepted,
+// without proving it holds the key. Nothing that needs that proof may happen
+// here, which is what ChannelHandler is for.
+func (l *limiter) PublicKeyHandler(next ssh.PublicKeyHandler) ssh.PublicKeyHandler {
+	return func(ctx ssh.Context, key ssh.PublicKey) bool {
+		attempt, _ := ctx.Value(authKey{}).(*authAttempt)
+
+		if !next(ctx, key) {
+			l.recordFailure(attempt)
+
+			return false
+		}
+
+		// NB: devices are also banned after failing to authenticate to the
+		// API, which the keys registered to them must not get around.
+		device := auth.DeviceFromContext(ctx)
+		if device != "" {
+			retryAt, err := l.attempts.Check("", device, time.Now())
+			if err != nil {
+				l.logger.With(logging.SSHAttrs(ctx)...).Warn(
+					"ssh authentication denied: too many failed authentication attempts",
+					slog.String("device_id", device),
+					slog.Time("retry_at", retryAt),
+					slog.Any("err", err),
+				)
+
+				l.recordFailure(attempt)
+
+				return false
+			}
+		}
+
+		if attempt != nil {
+			l.mu.Lock()
+			attempt.accepted[gossh.FingerprintSHA256(key)] = acceptedKey{
+				device: device,
+				opts:   auth.SSHOptionsFromContext(ctx),
+			}
+			l.mu.Unlock()
+		}
+
+		return true
+	}
+}
+
+// ChannelHandler wraps next so that the first session of a connection, which
+// can only be opened once authentication completed, counts the connection
+// against the limit of the key it authenticated with. Connections over the
+// limit are closed.
+func (l *limiter) ChannelHandler(next ssh.ChannelHandler) ssh.ChannelHandler {
+	return func(
+		srv *ssh.Server,
+		conn *gossh.ServerConn,
+		newChan gossh.NewChannel,
+		ctx ssh.Context,
+	) {
+		attempt, _ := ctx.Value(authKey{}).(*authAttempt)
+		if attempt == nil {
+			//nolint: errcheck
+			newChan.Reject(gossh.Prohibited, "not authenticated")
+
+			return
+		}
+
+		attempt.verify.Do(func() {
+			attempt.verified = l.authenticated(ctx, attempt)
+		})
+
+		if !attempt.verified {
+			//nolint: errcheck
+			newChan.Reject(gossh.ResourceShortage, "connection limit reached")
+			//nolint: errcheck
+			conn.Close()
+
+			return
+		}
+
+		next(srv, conn, newChan, ctx)
+	}
+}
+
+// authenticated records the connection of ctx as authenticated with the key
+// the client signed with and reports whether it may be used.
+func (l *limiter) authenticated(ctx ssh.Context, attempt *authAttempt) bool {
+	key, _ := ctx.Value(ssh.ContextKeyPublicKey).(ssh.PublicKey)
+	if key == nil {
+		return false
+	}
+
+	fingerprint := gossh.FingerprintSHA256(key)
+
+	l.mu.Lock()
+	accepted, found := attempt.accepted[fingerprint]
+	l.mu.Unlock()
+
+	if !found {
+		return false
+	}
+
+	// NB: the context holds whatever the key handler stored for the last key
+	// it accepted, which need not be the one the client signed with.
+	auth.SSHContextWithDevice(ctx, accepted.device)
+	auth.SSHContextWithOptions(ctx, &accepted.opts)
+
+	if !l.acquire(ctx, fingerprint) {
+		return false
+	}
+
+	l.mu.Lock()
+	attempt.authenticated = true
+	l.mu.Unlock()
+
+	l.attempts.Succeed(ctx.RemoteAddr().String(), accepted.device, time.Now())
+
+	return true
+}
+
+// recordFailure marks the connection of attempt as having failed to
+// authenticate.
+func (l *limiter) recordFailure(attempt *authAttempt) {
+	if attempt == nil {
+		return
+	}
+
+	l.mu.Lock()
+	attempt.failed = true
+	l.mu.Unlock()
+}
+
+// acquire counts the connection of ctx against fingerprint until it closes.
+func (l *limiter) acquire(ctx ssh.Context, fingerprint string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	usage := l.keys[fingerprint]
+	if usage == nil {
+		usage = &keyUsage{
+			read:  NewThrottle(l.limits.ReadBytesPerSecond),
+			write: NewThrottle(l.limits.WriteBytesPerSecond),
+		}
+		l.keys[fingerprint] = usage
+	}
+
+	limit := l.limits.MaxConnectionsPerKey
+	if limit > 0 && usage.conns >= limit {
+		l.logger.With(logging.SSHAttrs(ctx)...).Warn(
+			"ssh connection rejected: per key connection limit reached",
+			slog.String("fingerprint", fingerprint),
+			slog.Int("max_connections_per_key", limit),
+		)
+
+		return false
+	}
+
+	usage.conns++
+
+	ctx.SetValue(usageKey{}, usage)
+
+	go func() {
+		<-ctx.Done()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.release(fingerprint)
+	}()
+
+	return true
+}
+
+// release must be called with l.mu held.
+func (l *limiter) release(fingerprint string) {
+	usage := l.keys[fingerprint]
+	if usage == nil {
+		return
+	}
+
+	usage.conns--
+	if usage.conns <= 0 {
+		delete(l.keys, fingerprint)
+	}
+}
+
+// throttlesFromContext returns the bandwidth throttles shared by the
+// connections of the key that authenticated ctx.
+func throttlesFromContext(ctx context.Context) (*Throttle, *Throttle) {
+	usage, ok := ctx.Value(usageKey{}).(*keyUsage)
+	if !ok {
+		return nil, nil
+	}
+
+	return usage.read, usage.write
+}
+
+// limitedConn logs connections closed by the idle or session timeout. The
+// deadlines themselves are set by ssh.Server.
+type limitedConn struct {
+	net.Conn
+
+	//nolint: containedctx
+	ctx     ssh.Context
+	limiter *limiter
+	opened  time.Time
+	logged  atomic.Bool
+}
+
+func (c *limitedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.check(err)
+
+	return n, err
+}
+
+func (c *limitedConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.check(err)
+
+	return n, err
+}
+
+func (c *limitedConn) check(err error) {
+	if !errors.Is(err, os.ErrDeadlineExceeded) || c.logged.Swap(true) {
+		return
+	}
+
+	attrs := logging.SSHAttrs(c.ctx)
+	if attrs == nil {
+		attrs = []any{
+			slog.Any("local_addr", c.LocalAddr()),
+			slog.Any("remote_addr", c.RemoteAddr()),
+		}
+	}
+
+	logger := c.limiter.logger.With(attrs...)
+
+	limits := c.limiter.limits
+	elapsed := time.Since(c.opened)
+
+	if limits.MaxSessionDuration > 0 && elapsed >= limits.MaxSessionDuration {
+		logger.Warn(
+			"ssh connection closed: maximum session duration reached",
+			slog.Duration("max_session_duration", limits.MaxSessionDuration),
+		)
+
+		return
+	}
+
+	logger.Warn(
+		"ssh connection closed: idle timeout reached",
+		slog.Duration("idle_timeout", limits.IdleTimeout),
+	)
+}
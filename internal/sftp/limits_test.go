@@ -0,0 +1,141 @@
+package sftp
+
+import (
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+func TestMaxConnections(t *testing.T) {
+	db := newTestDB(t)
+	_, first := newTestDevice(t, db, auth.RoleMember)
+	_, second := newTestDevice(t, db, auth.RoleMember)
+
+	addr := newTestServer(
+		t,
+		config.SFTP{Limits: config.SSHLimits{MaxConnections: 1}},
+		storage.NewInMemory(),
+		db,
+	)
+
+	client, err := dialTestServer(t, addr, first)
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	_, err = dialTestServer(t, addr, second)
+	if err == nil {
+		t.Fatal("connected over the connection limit")
+	}
+
+	//nolint: errcheck
+	client.Close()
+
+	waitForLogin(t, addr, second)
+}
+
+func TestMaxConnectionsPerKey(t *testing.T) {
+	db := newTestDB(t)
+	_, member := newTestDevice(t, db, auth.RoleMember)
+	_, other := newTestDevice(t, db, auth.RoleMember)
+
+	addr := newTestServer(
+		t,
+		config.SFTP{Limits: config.SSHLimits{MaxConnectionsPerKey: 1}},
+		storage.NewInMemory(),
+		db,
+	)
+
+	client, err := dialTestServer(t, addr, member)
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	_, err = runTestCommand(client, "ls")
+	if err != nil {
+		t.Fatalf("failed to run a command: %v", err)
+	}
+
+	// NB: the limit is enforced when the first session is opened, once the
+	// client proved it holds the key.
+	extra, err := dialTestServer(t, addr, member)
+	if err == nil {
+		_, err = runTestCommand(extra, "ls")
+	}
+
+	if err == nil {
+		t.Fatal("opened a session over the limit of the key")
+	}
+
+	otherClient, err := dialTestServer(t, addr, other)
+	if err != nil {
+		t.Fatalf("failed to log in with another key: %v", err)
+	}
+
+	_, err = runTestCommand(otherClient, "ls")
+	if err != nil {
+		t.Errorf("limit of a key applied to another: %v", err)
+	}
+
+	//nolint: errcheck
+	client.Close()
+
+	waitForLogin(t, addr, member)
+}
+
+func TestIdleTimeout(t *testing.T) {
+	db := newTestDB(t)
+	_, member := newTestDevice(t, db, auth.RoleMember)
+
+	addr := newTestServer(
+		t,
+		config.SFTP{Limits: config.SSHLimits{IdleTimeout: 200 * time.Millisecond}},
+		storage.NewInMemory(),
+		db,
+	)
+
+	client, err := dialTestServer(t, addr, member)
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	_, err = runTestCommand(client, "ls")
+	if err == nil {
+		t.Error("ran a command on a connection past its idle timeout")
+	}
+}
+
+// waitForLogin fails t unless signer can open a session on the server at
+// addr within a second, giving the server time to notice closed connections.
+func waitForLogin(t *testing.T, addr string, signer gossh.Signer) {
+	t.Helper()
+
+	var err error
+
+	for range 20 {
+		var client *gossh.Client
+
+		client, err = dialTestServer(t, addr, signer)
+		if err == nil {
+			_, err = runTestCommand(client, "ls")
+
+			//nolint: errcheck
+			client.Close()
+		}
+
+		if err == nil {
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Errorf("failed to log in once connections closed: %v", err)
+}
@@ -0,0 +1,262 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/scp"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/storage"
+	"github.com/spf13/afero"
+)
+
+const SCPCommand = "scp"
+
+var ErrSCPNotRegularFile = errors.New("not a regular file")
+
+// scpMiddleware serves scp source (-f) and sink (-t) requests from the exec
+// channel. Other commands are passed on to next.
+func scpMiddleware(s storage.Interface) wish.Middleware {
+	h := &scpHandler{storage: s}
+	serve := scp.Middleware(h, h)
+
+	return func(next ssh.Handler) ssh.Handler {
+		scpNext := serve(next)
+
+		return func(sess ssh.Session) {
+			info := scp.GetInfo(sess.Command())
+			if !info.Ok {
+				next(sess)
+
+				return
+			}
+
+			logger := sessionLogger(sess).With(
+				slog.String("op", string(info.Op)),
+				slog.String("path", info.Path),
+				slog.Bool("recursive", info.Recursive),
+				slog.Bool("preserve", scpPreserve(sess.Command())),
+			)
+
+			if !auth.SSHOptionsFromContext(sess.Context()).AllowsCommand(sess.Command()) {
+				logger.Warn("scp denied by force-command")
+				wish.Fatalln(sess, "scp: command not allowed")
+
+				return
+			}
+
+			logger.Info("scp session started")
+
+			scpNext(sess)
+
+			logger.Info("scp session ended")
+		}
+	}
+}
+
+// scpPreserve reports whether scp was invoked with -p, which preserves
+// modification times. Modes are managed by the server like over sftp, so the
+// client's are ignored.
+func scpPreserve(cmd []string) bool {
+	return slices.Contains(cmd, "-p")
+}
+
+// scpHandler implements scp.Handler on top of the storage of a session. Every
+// method applies the restrictions of the authenticated key so scp sees
+// exactly what sftp would.
+type scpHandler struct {
+	storage storage.Interface
+}
+
+var _ scp.Handler = &scpHandler{}
+
+func (h *scpHandler) fs(sess ssh.Session) storage.Interface {
+	return sessionStorage(sess.Context(), h.storage)
+}
+
+func (h *scpHandler) Glob(sess ssh.Session, pattern string) ([]string, error) {
+	matches, err := afero.Glob(h.fs(sess), scpPath(pattern))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	return matches, nil
+}
+
+func (h *scpHandler) WalkDir(sess ssh.Session, root string, fn fs.WalkDirFunc) error {
+	root = scpPath(root)
+
+	return afero.Walk(h.fs(sess), root, func(name string, info fs.FileInfo, err error) error {
+		// NB: the storage root has no name to send so only its contents are
+		// copied.
+		if name == "/" && err == nil {
+			return nil
+		}
+
+		var d fs.DirEntry
+		if info != nil {
+			d = fs.FileInfoToDirEntry(info)
+		}
+
+		return fn(name, d, err)
+	})
+}
+
+func (h *scpHandler) NewDirEntry(sess ssh.Session, name string) (*scp.DirEntry, error) {
+	name = scpPath(name)
+
+	info, err := h.fs(sess).Stat(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %q: %w", name, err)
+	}
+
+	entry := &scp.DirEntry{
+		Children: []scp.Entry{},
+		Name:     path.Base(name),
+		Filepath: name,
+		Mode:     info.Mode(),
+	}
+
+	if scpPreserve(sess.Command()) {
+		entry.Mtime = info.ModTime().Unix()
+		entry.Atime = entry.Mtime
+	}
+
+	return entry, nil
+}
+
+func (h *scpHandler) NewFileEntry(
+	sess ssh.Session,
+	name string,
+) (*scp.FileEntry, func() error, error) {
+	name = scpPath(name)
+	fsys := h.fs(sess)
+
+	info, err := fsys.Stat(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat %q: %w", name, err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil, nil, fmt.Errorf("%q: %w", name, ErrSCPNotRegularFile)
+	}
+
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %q: %w", name, err)
+	}
+
+	entry := &scp.FileEntry{
+		Name:     path.Base(name),
+		Filepath: name,
+		Mode:     info.Mode(),
+		Size:     info.Size(),
+		Reader:   file,
+	}
+
+	if scpPreserve(sess.Command()) {
+		entry.Mtime = info.ModTime().Unix()
+		entry.Atime = entry.Mtime
+	}
+
+	sessionLogger(sess).Info(
+		"scp file sent",
+		slog.String("file", name),
+		slog.Int64("size", info.Size()),
+	)
+
+	return entry, file.Close, nil
+}
+
+func (h *scpHandler) Mkdir(sess ssh.Session, entry *scp.DirEntry) error {
+	name := scpPath(entry.Filepath)
+	fsys := h.fs(sess)
+
+	err := fsys.Mkdir(name, DefaultDirectoryPerms)
+	if err != nil {
+		// NB: scp -r into an existing directory merges the contents like
+		// OpenSSH does.
+		info, statErr := fsys.Stat(name)
+		if statErr != nil || !info.IsDir() {
+			return fmt.Errorf("failed to create directory %q: %w", name, err)
+		}
+	}
+
+	return h.preserve(sess, fsys, name, entry.Mtime, entry.Atime)
+}
+
+func (h *scpHandler) Write(sess ssh.Session, entry *scp.FileEntry) (int64, error) {
+	name := scpPath(entry.Filepath)
+	fsys := h.fs(sess)
+
+	// NB: wish always treats the sink target as a directory. OpenSSH writes to
+	// the target itself when it is not a directory, e.g. scp a.txt host:b.txt.
+	dir := path.Dir(name)
+
+	info, err := fsys.Stat(dir)
+	if dir != "/" && (os.IsNotExist(err) || (err == nil && !info.IsDir())) {
+		name = dir
+	}
+
+	file, err := fsys.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, DefaultFilePerms)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %q for writing: %w", name, err)
+	}
+
+	// NB: the file is closed once and for all before its times are set,
+	// since closing a written file may touch its modification time.
+	written, err := io.Copy(file, entry.Reader)
+	if err != nil {
+		//nolint: errcheck
+		file.Close()
+
+		return written, fmt.Errorf("failed to write %q: %w", name, err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return written, fmt.Errorf("failed to close %q: %w", name, err)
+	}
+
+	sessionLogger(sess).Info(
+		"scp file received",
+		slog.String("file", name),
+		slog.Int64("size", written),
+	)
+
+	return written, h.preserve(sess, fsys, name, entry.Mtime, entry.Atime)
+}
+
+// preserve applies the times sent by the client when scp was invoked with -p.
+func (h *scpHandler) preserve(
+	sess ssh.Session,
+	fsys storage.Interface,
+	name string,
+	mtime, atime int64,
+) error {
+	if !scpPreserve(sess.Command()) || mtime == 0 || atime == 0 {
+		return nil
+	}
+
+	err := fsys.Chtimes(name, time.Unix(atime, 0), time.Unix(mtime, 0))
+	if err != nil {
+		return fmt.Errorf("failed to chtimes %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// scpPath maps a path from the scp command line, which is relative to the
+// login directory, onto the storage namespace.
+func scpPath(name string) string {
+	return path.Clean("/" + name)
+}
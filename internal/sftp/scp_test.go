@@ -0,0 +1,55 @@
+package sftp
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+func TestSCPPreserve(t *testing.T) {
+	st := storage.NewInMemory()
+	db := newTestDB(t)
+	_, signer := newTestDevice(t, db, auth.RoleMember)
+
+	client, err := dialTestServer(t, newTestServer(t, config.SFTP{}, st, db), signer)
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	sess, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	//nolint: errcheck
+	defer sess.Close()
+
+	// NB: this is what scp -p sends for a file with mode 0777, which must
+	// not make it executable.
+	mtime := time.Unix(1_700_000_000, 0)
+	sess.Stdin = bytes.NewBufferString("T1700000000 0 1700000000 0\nC0777 4 f\ndata\x00")
+
+	var out bytes.Buffer
+	sess.Stdout = &out
+
+	err = sess.Run("scp -p -t /")
+	if err != nil {
+		t.Fatalf("scp failed: %v, %q", err, out.String())
+	}
+
+	info, err := st.Stat("/f")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != DefaultFilePerms {
+		t.Errorf("got mode %v, want %v", info.Mode().Perm(), DefaultFilePerms)
+	}
+
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("got modification time %v, want %v", info.ModTime(), mtime)
+	}
+}
@@ -9,36 +9,63 @@ import (
 	"github.com/charmbracelet/wish"
 	"github.com/cmp0st/byte/internal/auth"
 	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/database"
 	"github.com/cmp0st/byte/internal/key"
 	"github.com/cmp0st/byte/internal/logging"
 	"github.com/cmp0st/byte/internal/storage"
 	"github.com/pkg/sftp"
+	gossh "golang.org/x/crypto/ssh"
 )
 
 func NewServer(
 	ctx context.Context,
 	c config.SFTP,
 	s storage.Interface,
-	k key.ServerChain,
+	k key.ServerKeyring,
+	db *database.DB,
+	attempts *auth.AttemptLimiter,
 ) (*ssh.Server, error) {
 	logger := logging.FromContext(ctx)
 
-	raw, err := k.SSHHostKey()
+	hostKeys, err := newHostKeys(k, c.HostKeys, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	hostKeyPEM, err := key.ToPEM(raw)
+	userCAs, err := userCertificateAuthorities(k, c.TrustedUserCAKeys)
 	if err != nil {
 		return nil, err
 	}
 
+	// NB: fsync@openssh.com is advertised by extensionConn since pkg/sftp
+	// does not know about it.
+	err = sftp.SetSFTPExtensions(
+		HardlinkExtension,
+		PosixRenameExtension,
+		StatVFSExtension,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure sftp extensions: %w", err)
+	}
+
+	limits := newLimiter(c.Limits, attempts, logger)
+
 	middleware := logging.SSHMiddleware(logger)
 	//nolint: contextcheck
 	sftpHandler := func(sess ssh.Session) {
-		logger := logging.FromContext(sess.Context())
+		logger := sessionLogger(sess)
+
+		if !auth.SSHOptionsFromContext(sess.Context()).AllowsSFTP() {
+			logger.Warn("sftp subsystem denied by force-command")
+
+			//nolint: errcheck
+			sess.Exit(1)
+
+			return
+		}
+
 		h := &Handlers{
-			Storage: s,
+			Storage: sessionStorage(sess.Context(), s),
 			Logger:  logger,
 		}
 
@@ -49,7 +76,7 @@ func NewServer(
 			FileList: h,
 		}
 
-		server := sftp.NewRequestServer(sess, handlers)
+		server := sftp.NewRequestServer(newExtensionConn(sess, h), handlers)
 
 		err := server.Serve()
 		if err != nil {
@@ -57,17 +84,83 @@ func NewServer(
 		} else {
 			logger.Info("sftp session ended")
 		}
+
+		err = h.Close()
+		if err != nil {
+			logger.Error("failed to close sftp handles", slog.Any("err", err))
+		}
 	}
 
 	return wish.NewServer(
 		wish.WithAddress(fmt.Sprintf("%s:%d", c.Host, c.Port)),
-		wish.WithHostKeyPEM(hostKeyPEM),
+		hostKeys.Option,
 		// NB: this middleware is only invoked on the default handler and it
-		// is bypassed on the subsystem and request handlers. It is set here to
-		// cover future functionality related to SSH
-		wish.WithMiddleware(logging.SSHMiddleware(logger)),
+		// is bypassed on the subsystem and request handlers. Exec commands
+		// such as scp and git, and interactive sessions run on the default
+		// handler. Middleware listed last runs first, so the built-in shell
+		// commands see whatever exec requests the others pass on, and every
+		// other one sees the forced command of the key instead of the one
+		// the client asked for.
+		wish.WithMiddleware(
+			shellMiddleware(s),
+			browserMiddleware(s),
+			gitMiddleware(s),
+			scpMiddleware(s),
+			forceCommandMiddleware(),
+			logging.SSHMiddleware(logger),
+		),
 		//nolint: contextcheck
-		wish.WithPublicKeyAuth(auth.SSHPublicKey(c.AuthorizedKeys)),
+		wish.WithPublicKeyAuth(
+			limits.PublicKeyHandler(auth.SSHPublicKey(c.AuthorizedKeys, userCAs, db)),
+		),
+		func(srv *ssh.Server) error {
+			srv.PtyCallback = auth.SSHPty
+			srv.ConnCallback = limits.ConnCallback
+			srv.ChannelHandlers = map[string]ssh.ChannelHandler{
+				"session": limits.ChannelHandler(ssh.DefaultSessionHandler),
+			}
+
+			return nil
+		},
+		wish.WithIdleTimeout(c.Limits.IdleTimeout),
+		wish.WithMaxTimeout(c.Limits.MaxSessionDuration),
 		wish.WithSubsystem("sftp", ssh.SubsystemHandler(middleware(sftpHandler))),
 	)
 }
+
+// userCertificateAuthorities returns the CA keys whose user certificates are
+// accepted: the CAs derived from every version of the server secret plus any
+// configured ones.
+func userCertificateAuthorities(k key.ServerKeyring, trusted []string) ([]gossh.PublicKey, error) {
+	var authorities []gossh.PublicKey
+
+	for _, version := range k.Versions() {
+		chain, err := k.Chain(version)
+		if err != nil {
+			return nil, err
+		}
+
+		caKey, err := chain.SSHUserCAKey()
+		if err != nil {
+			return nil, err
+		}
+
+		caPub, err := gossh.NewPublicKey(caKey.Public())
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert ssh user ca public key: %w", err)
+		}
+
+		authorities = append(authorities, caPub)
+	}
+
+	for i, authorizedKey := range trusted {
+		pub, _, _, _, err := gossh.ParseAuthorizedKey([]byte(authorizedKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted user ca key at index %d: %w", i, err)
+		}
+
+		authorities = append(authorities, pub)
+	}
+
+	return authorities, nil
+}
@@ -0,0 +1,392 @@
+package sftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pkg/sftp"
+	"github.com/spf13/afero"
+	gossh "golang.org/x/crypto/ssh"
+	_ "modernc.org/sqlite"
+
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/config"
+	"github.com/cmp0st/byte/internal/database"
+	"github.com/cmp0st/byte/internal/key"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite", t.TempDir()+"/byte.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		//nolint: errcheck
+		conn.Close()
+	})
+
+	db := &database.DB{DB: conn}
+
+	err = db.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// newTestServer serves st over ssh, configured by c, to the keys of the
+// devices in db and returns its address.
+func newTestServer(t *testing.T, c config.SFTP, st storage.Interface, db *database.DB) string {
+	t.Helper()
+
+	keyring, err := key.NewServerKeyring(
+		map[int][]byte{key.DefaultKeyVersion: []byte("0123456789abcdef0123456789abcdef")},
+		key.DefaultKeyVersion,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := NewServer(
+		t.Context(),
+		c,
+		st,
+		*keyring,
+		db,
+		auth.NewAttemptLimiter(auth.AttemptPolicy{}, auth.DefaultAttemptLimiterSize),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//nolint: errcheck
+	go srv.Serve(l)
+
+	t.Cleanup(func() {
+		//nolint: errcheck
+		srv.Close()
+	})
+
+	return l.Addr().String()
+}
+
+// newTestSigner returns a signer of a new ed25519 key.
+func newTestSigner(t *testing.T) gossh.Signer {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := gossh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer
+}
+
+// newTestDevice adds a device with role and an ssh key to db, and returns the
+// device along with the signer of its key.
+func newTestDevice(t *testing.T, db *database.DB, role auth.Role) (string, gossh.Signer) {
+	t.Helper()
+
+	signer := newTestSigner(t)
+	device := database.Device{
+		ID:         uuid.NewString(),
+		Role:       string(role),
+		KeyVersion: key.DefaultKeyVersion,
+	}
+
+	err := db.AddDevice(t.Context(), device)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.AddSSHKey(t.Context(), database.SSHKey{
+		Fingerprint: gossh.FingerprintSHA256(signer.PublicKey()),
+		DeviceID:    device.ID,
+		PublicKey:   strings.TrimSpace(string(gossh.MarshalAuthorizedKey(signer.PublicKey()))),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return device.ID, signer
+}
+
+func dialTestServer(t *testing.T, addr string, signer gossh.Signer) (*gossh.Client, error) {
+	t.Helper()
+
+	client, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User: "test",
+		Auth: []gossh.AuthMethod{gossh.PublicKeys(signer)},
+		//nolint: gosec
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.Cleanup(func() {
+		//nolint: errcheck
+		client.Close()
+	})
+
+	return client, nil
+}
+
+// runTestCommand runs cmd in a session of client and returns its output.
+func runTestCommand(client *gossh.Client, cmd string) (string, error) {
+	sess, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	//nolint: errcheck
+	defer sess.Close()
+
+	out, err := sess.CombinedOutput(cmd)
+
+	return string(out), err
+}
+
+func TestSessionRoles(t *testing.T) {
+	tests := []struct {
+		role  auth.Role
+		login bool
+		write bool
+	}{
+		{role: auth.RoleAdmin, login: true, write: true},
+		{role: auth.RoleMember, login: true, write: true},
+		{role: auth.RoleReadOnly, login: true},
+		{role: auth.RoleUploadOnly},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.role), func(t *testing.T) {
+			st := storage.NewInMemory()
+
+			err := afero.WriteFile(st, "/f", []byte("data"), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			db := newTestDB(t)
+			_, signer := newTestDevice(t, db, test.role)
+
+			client, err := dialTestServer(t, newTestServer(t, config.SFTP{}, st, db), signer)
+			if !test.login {
+				if err == nil {
+					t.Fatal("logged in with a role that cannot be enforced")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to log in: %v", err)
+			}
+
+			files, err := sftp.NewClient(client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			//nolint: errcheck
+			defer files.Close()
+
+			file, err := files.Open("/f")
+			if err != nil {
+				t.Fatalf("failed to open a file: %v", err)
+			}
+
+			//nolint: errcheck
+			file.Close()
+
+			file, err = files.Create("/new")
+			if err == nil {
+				//nolint: errcheck
+				file.Close()
+			}
+
+			if (err == nil) != test.write {
+				t.Errorf("created a file: %v, want %v", err, test.write)
+			}
+
+			_, err = runTestCommand(client, "rm /f")
+			if (err == nil) != test.write {
+				t.Errorf("removed a file over exec: %v, want %v", err, test.write)
+			}
+
+			_, err = st.Stat("/f")
+			if errors.Is(err, os.ErrNotExist) != test.write {
+				t.Errorf("file removed: %v, want %v", err, test.write)
+			}
+		})
+	}
+}
+
+func TestSessionRevokedGrants(t *testing.T) {
+	st := storage.NewInMemory()
+
+	err := afero.WriteFile(st, "/f", []byte("data"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := newTestDB(t)
+	device, signer := newTestDevice(t, db, auth.RoleMember)
+
+	err = db.SetPathGrant(t.Context(), database.PathGrant{
+		DeviceID: device,
+		Grant:    storage.Grant{Prefix: "/f", Read: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.RemovePathGrant(t.Context(), device, "/f")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := dialTestServer(t, newTestServer(t, config.SFTP{}, st, db), signer)
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	files, err := sftp.NewClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//nolint: errcheck
+	defer files.Close()
+
+	// NB: revoking the last grant leaves the session with nothing rather than
+	// with the whole storage.
+	_, err = files.Stat("/f")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Stat(/f) after revoking every grant = %v, want %v", err, os.ErrNotExist)
+	}
+
+	out, err := runTestCommand(client, "ls /")
+	if err != nil || strings.TrimSpace(out) != "" {
+		t.Errorf("ls / after revoking every grant = %q, %v, want nothing", out, err)
+	}
+}
+
+func TestAuthorizedKeyOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		login   bool
+		write   bool
+		files   []string
+	}{
+		{name: "none", login: true, write: true, files: []string{"f", "photos"}},
+		{
+			name:    "root",
+			options: `byte-root="/photos"`,
+			login:   true,
+			write:   true,
+			files:   []string{"p"},
+		},
+		{name: "read-only", options: "byte-readonly", login: true, files: []string{"f", "photos"}},
+		{
+			name:    "from",
+			options: `from="127.0.0.1"`,
+			login:   true,
+			write:   true,
+			files:   []string{"f", "photos"},
+		},
+		{name: "other address", options: `from="198.51.100.0/24"`},
+		{name: "negated address", options: `from="127.0.0.*,!127.0.0.1"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			st := storage.NewInMemory()
+
+			err := afero.WriteFile(st, "/f", []byte("data"), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = afero.WriteFile(st, "/photos/p", []byte("data"), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signer := newTestSigner(t)
+
+			key := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(signer.PublicKey())))
+			if test.options != "" {
+				key = test.options + " " + key
+			}
+
+			addr := newTestServer(t, config.SFTP{AuthorizedKeys: []string{key}}, st, newTestDB(t))
+
+			client, err := dialTestServer(t, addr, signer)
+			if !test.login {
+				if err == nil {
+					t.Fatal("logged in from an address the key does not allow")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to log in: %v", err)
+			}
+
+			files, err := sftp.NewClient(client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			//nolint: errcheck
+			defer files.Close()
+
+			infos, err := files.ReadDir("/")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, info := range infos {
+				names = append(names, info.Name())
+			}
+
+			if !slices.Equal(names, test.files) {
+				t.Errorf("listed %v, want %v", names, test.files)
+			}
+
+			file, err := files.Create("/new")
+			if err == nil {
+				//nolint: errcheck
+				file.Close()
+			}
+
+			if (err == nil) != test.write {
+				t.Errorf("created a file: %v, want %v", err, test.write)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package sftp
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/logging"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+// sessionStorage applies the restrictions of the authenticated key to s so
+// that every handler of a session sees the same confined view of storage.
+func sessionStorage(ctx context.Context, s storage.Interface) storage.Interface {
+	opts := auth.SSHOptionsFromContext(ctx)
+
+	if opts.Root != "" && opts.Root != "/" {
+		s = storage.NewBasePath(s, opts.Root)
+	}
+
+	if opts.ReadOnly {
+		s = storage.NewReadOnly(s)
+	}
+
+	if opts.Restricted {
+		s = storage.NewGranted(s, opts.Grants)
+	}
+
+	read, write := throttlesFromContext(ctx)
+
+	return newThrottledStorage(ctx, s, read, write, logging.FromContext(ctx))
+}
+
+// sessionLogger returns the session logger annotated with the device and
+// restrictions of the authenticated key.
+func sessionLogger(sess ssh.Session) *slog.Logger {
+	logger := logging.FromContext(sess.Context())
+
+	device := auth.DeviceFromContext(sess.Context())
+	if device != "" {
+		logger = logger.With(slog.String("device_id", device))
+	}
+
+	opts := auth.SSHOptionsFromContext(sess.Context())
+	if opts.Root != "" || opts.ReadOnly {
+		logger = logger.With(
+			slog.String("root", opts.Root),
+			slog.Bool("read_only", opts.ReadOnly),
+		)
+	}
+
+	if opts.Restricted {
+		logger = logger.With(slog.Int("path_grants", len(opts.Grants)))
+	}
+
+	return logger
+}
@@ -0,0 +1,81 @@
+package sftp
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/cmp0st/byte/internal/auth"
+	"github.com/cmp0st/byte/internal/shell"
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+// shellMiddleware runs the built-in file commands of package shell for exec
+// requests. It handles every command the other middleware passed on, so
+// unknown commands are rejected here instead of reaching the default handler.
+func shellMiddleware(s storage.Interface) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(sess ssh.Session) {
+			cmd := sess.Command()
+			if len(cmd) == 0 {
+				next(sess)
+
+				return
+			}
+
+			logger := sessionLogger(sess).With(
+				slog.String("command", cmd[0]),
+				slog.Any("args", cmd[1:]),
+			)
+
+			if !shell.IsCommand(cmd[0]) {
+				logger.Warn("unknown command denied")
+				wish.Errorf(
+					sess,
+					"byte: unknown command %q, available commands: %s\n",
+					cmd[0],
+					strings.Join(shell.Commands(), ", "),
+				)
+
+				//nolint: errcheck
+				sess.Exit(shell.ExitUnknownCommand)
+
+				return
+			}
+
+			if !auth.SSHOptionsFromContext(sess.Context()).AllowsCommand(cmd) {
+				logger.Warn("command denied by force-command")
+				wish.Fatalln(sess, "byte: command not allowed")
+
+				return
+			}
+
+			logger.Info("command started")
+
+			err := shell.Run(sess.Context(), &shell.Env{
+				Storage: sessionStorage(sess.Context(), s),
+				Logger:  logger,
+				Stdout:  sess,
+				Stderr:  sess.Stderr(),
+			}, cmd)
+			if err != nil {
+				logger.Warn("command failed", slog.Any("err", err))
+
+				// NB: problems with individual paths were already written
+				// to stderr by the command itself.
+				if !errors.Is(err, shell.ErrFailed) {
+					wish.Errorf(sess, "%s: %v\n", cmd[0], err)
+				}
+
+				//nolint: errcheck
+				sess.Exit(shell.ExitStatus(err))
+
+				return
+			}
+
+			logger.Info("command ended")
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package sftp
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cmp0st/byte/internal/storage"
+	"github.com/spf13/afero"
+)
+
+// Throttle is a token bucket limiting transfers to a number of bytes per
+// second, with bursts of up to one second worth of bytes. A nil Throttle does
+// not limit anything.
+type Throttle struct {
+	rate float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewThrottle returns a Throttle for bytesPerSecond, or nil if bytesPerSecond
+// is not positive.
+func NewThrottle(bytesPerSecond int64) *Throttle {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	return &Throttle{
+		rate:   float64(bytesPerSecond),
+		tokens: float64(bytesPerSecond),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until n more bytes fit within the rate and reports whether it
+// had to wait at all. Transfers larger than the burst go into debt that later
+// calls pay off.
+func (t *Throttle) Wait(ctx context.Context, n int) (bool, error) {
+	if t == nil || n <= 0 {
+		return false, nil
+	}
+
+	t.mu.Lock()
+
+	now := time.Now()
+	t.tokens = min(t.rate, t.tokens+now.Sub(t.last).Seconds()*t.rate)
+	t.last = now
+	t.tokens -= float64(n)
+
+	delay := time.Duration(-t.tokens / t.rate * float64(time.Second))
+
+	t.mu.Unlock()
+
+	if delay <= 0 {
+		return false, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return true, ctx.Err()
+	case <-timer.C:
+		return true, nil
+	}
+}
+
+var (
+	_ afero.Symlinker       = &throttledStorage{}
+	_ storage.HardLinker    = &throttledStorage{}
+	_ storage.UsageReporter = &throttledStorage{}
+)
+
+// throttledStorage limits the bandwidth of the files opened through it, so
+// that every subsystem of a session shares the limits of its key.
+type throttledStorage struct {
+	storage.Interface
+
+	//nolint: containedctx
+	ctx    context.Context
+	read   *Throttle
+	write  *Throttle
+	logger *slog.Logger
+}
+
+func newThrottledStorage(
+	ctx context.Context,
+	s storage.Interface,
+	read, write *Throttle,
+	logger *slog.Logger,
+) storage.Interface {
+	if read == nil && write == nil {
+		return s
+	}
+
+	return &throttledStorage{
+		Interface: s,
+		ctx:       ctx,
+		read:      read,
+		write:     write,
+		logger:    logger,
+	}
+}
+
+func (s *throttledStorage) Create(name string) (afero.File, error) {
+	file, err := s.Interface.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.throttle(file, name), nil
+}
+
+func (s *throttledStorage) Open(name string) (afero.File, error) {
+	file, err := s.Interface.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.throttle(file, name), nil
+}
+
+func (s *throttledStorage) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	file, err := s.Interface.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.throttle(file, name), nil
+}
+
+func (s *throttledStorage) throttle(file afero.File, name string) afero.File {
+	return &throttledFile{
+		File:   file,
+		ctx:    s.ctx,
+		read:   s.read,
+		write:  s.write,
+		logger: s.logger.With(slog.String("path", name)),
+	}
+}
+
+func (s *throttledStorage) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	lstater, ok := s.Interface.(afero.Lstater)
+	if !ok {
+		fi, err := s.Interface.Stat(name)
+
+		return fi, false, err
+	}
+
+	return lstater.LstatIfPossible(name)
+}
+
+func (s *throttledStorage) SymlinkIfPossible(oldname, newname string) error {
+	linker, ok := s.Interface.(afero.Linker)
+	if !ok {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: afero.ErrNoSymlink}
+	}
+
+	return linker.SymlinkIfPossible(oldname, newname)
+}
+
+func (s *throttledStorage) ReadlinkIfPossible(name string) (string, error) {
+	reader, ok := s.Interface.(afero.LinkReader)
+	if !ok {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: afero.ErrNoReadlink}
+	}
+
+	return reader.ReadlinkIfPossible(name)
+}
+
+func (s *throttledStorage) LinkIfPossible(oldname, newname string) error {
+	linker, ok := s.Interface.(storage.HardLinker)
+	if !ok {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: storage.ErrNoHardLink}
+	}
+
+	return linker.LinkIfPossible(oldname, newname)
+}
+
+func (s *throttledStorage) Usage(name string) (*storage.Usage, error) {
+	return storage.UsageOf(s.Interface, name)
+}
+
+// throttledFile limits the reads and writes made on a file.
+type throttledFile struct {
+	afero.File
+
+	//nolint: containedctx
+	ctx    context.Context
+	read   *Throttle
+	write  *Throttle
+	logger *slog.Logger
+	logged atomic.Bool
+}
+
+func (f *throttledFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+
+	waitErr := f.wait(f.read, n, "read")
+	if err == nil {
+		err = waitErr
+	}
+
+	return n, err
+}
+
+func (f *throttledFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off)
+
+	waitErr := f.wait(f.read, n, "read")
+	if err == nil {
+		err = waitErr
+	}
+
+	return n, err
+}
+
+func (f *throttledFile) Write(p []byte) (int, error) {
+	err := f.wait(f.write, len(p), "write")
+	if err != nil {
+		return 0, err
+	}
+
+	return f.File.Write(p)
+}
+
+func (f *throttledFile) WriteAt(p []byte, off int64) (int, error) {
+	err := f.wait(f.write, len(p), "write")
+	if err != nil {
+		return 0, err
+	}
+
+	return f.File.WriteAt(p, off)
+}
+
+func (f *throttledFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// wait throttles a transfer of n bytes and logs the first time a file is
+// slowed down.
+func (f *throttledFile) wait(t *Throttle, n int, direction string) error {
+	waited, err := t.Wait(f.ctx, n)
+	if waited && !f.logged.Swap(true) {
+		f.logger.Info(
+			"ssh transfer throttled: bandwidth limit reached",
+			slog.String("direction", direction),
+			slog.Float64("bytes_per_second", t.rate),
+		)
+	}
+
+	return err
+}
@@ -0,0 +1,102 @@
+package sftp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+func TestThrottle(t *testing.T) {
+	var unlimited *Throttle
+
+	waited, err := unlimited.Wait(t.Context(), 1<<30)
+	if waited || err != nil {
+		t.Errorf("nil throttle waited %v, %v", waited, err)
+	}
+
+	if NewThrottle(0) != nil {
+		t.Error("NewThrottle(0) limits transfers")
+	}
+
+	throttle := NewThrottle(10_000)
+
+	// NB: a fresh throttle allows a burst of one second worth of bytes.
+	waited, err = throttle.Wait(t.Context(), 10_000)
+	if waited || err != nil {
+		t.Errorf("burst waited %v, %v", waited, err)
+	}
+
+	start := time.Now()
+
+	waited, err = throttle.Wait(t.Context(), 2_000)
+	if !waited || err != nil {
+		t.Errorf("transfer over the burst waited %v, %v", waited, err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("transfer over the burst waited %v, want about 200ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	waited, err = throttle.Wait(ctx, 1_000_000)
+	if !waited || !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled transfer waited %v, %v, want %v", waited, err, context.Canceled)
+	}
+}
+
+func TestThrottledStorage(t *testing.T) {
+	st := storage.NewInMemory()
+
+	if newThrottledStorage(t.Context(), st, nil, nil, slog.Default()) != st {
+		t.Error("storage without throttles was wrapped")
+	}
+
+	throttled := newThrottledStorage(
+		t.Context(), st, nil, NewThrottle(10_000), slog.New(slog.DiscardHandler),
+	)
+
+	file, err := throttled.Create("/f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//nolint: errcheck
+	defer file.Close()
+
+	start := time.Now()
+
+	for range 3 {
+		_, err = file.Write(make([]byte, 5_000))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("wrote 15000 bytes at 10000 per second in %v, want about 500ms", elapsed)
+	}
+
+	// NB: reads are not limited by the write throttle.
+	reader, err := throttled.Open("/f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//nolint: errcheck
+	defer reader.Close()
+
+	start = time.Now()
+
+	_, err = reader.Read(make([]byte, 15_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("read throttled by the write limit for %v", elapsed)
+	}
+}
@@ -0,0 +1,346 @@
+package shell
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+var ErrIsDirectory = errors.New("is a directory")
+
+const timeFmt = "2006-01-02 15:04"
+
+func ls(_ context.Context, env *Env, flags flagSet, args []string) error {
+	if len(args) == 0 {
+		args = []string{"/"}
+	}
+
+	f := &failures{env: env, command: "ls"}
+
+	for i, arg := range args {
+		name, err := cleanPath(arg)
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		info, err := env.Storage.Stat(name)
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		if !info.IsDir() {
+			err = writeEntry(env.Stdout, info, arg, flags['l'])
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		entries, err := afero.ReadDir(env.Storage, name)
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		env.Logger.Info("directory listed", slog.String("path", name))
+
+		if len(args) > 1 {
+			header := arg + ":\n"
+			if i > 0 {
+				header = "\n" + header
+			}
+
+			_, err = io.WriteString(env.Stdout, header)
+			if err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+
+		for _, entry := range entries {
+			err = writeEntry(env.Stdout, entry, entry.Name(), flags['l'])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return f.err()
+}
+
+// writeEntry writes a line of ls output. Directories are marked with a
+// trailing slash so scripts can tell them apart without -l.
+func writeEntry(w io.Writer, info os.FileInfo, name string, long bool) error {
+	if info.IsDir() {
+		name += "/"
+	}
+
+	line := name + "\n"
+	if long {
+		line = fmt.Sprintf(
+			"%s %12d %s %s\n",
+			info.Mode(),
+			info.Size(),
+			info.ModTime().UTC().Format(timeFmt),
+			name,
+		)
+	}
+
+	_, err := io.WriteString(w, line)
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+func stat(_ context.Context, env *Env, _ flagSet, args []string) error {
+	f := &failures{env: env, command: "stat"}
+
+	for _, arg := range args {
+		name, err := cleanPath(arg)
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		info, err := env.Storage.Stat(name)
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		env.Logger.Info("file stat", slog.String("path", name))
+
+		_, err = fmt.Fprintf(
+			env.Stdout,
+			"  File: %s\n  Size: %d\n  Type: %s\n  Mode: %s\nModify: %s\n",
+			name,
+			info.Size(),
+			fileType(info.Mode()),
+			info.Mode().Perm(),
+			info.ModTime().UTC().Format(time.RFC3339),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	return f.err()
+}
+
+func fileType(mode fs.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "directory"
+	case mode.IsRegular():
+		return "regular file"
+	case mode&fs.ModeSymlink != 0:
+		return "symbolic link"
+	}
+
+	return "other"
+}
+
+// du reports the apparent size in bytes of every operand, summed over
+// everything below it for directories.
+func du(ctx context.Context, env *Env, flags flagSet, args []string) error {
+	if len(args) == 0 {
+		args = []string{"/"}
+	}
+
+	f := &failures{env: env, command: "du"}
+
+	for _, arg := range args {
+		name, err := cleanPath(arg)
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		var total int64
+
+		err = afero.Walk(env.Storage, name, func(_ string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if info.Mode().IsRegular() {
+				total += info.Size()
+			}
+
+			return nil
+		})
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		env.Logger.Info("disk usage computed", slog.String("path", name))
+
+		size := fmt.Sprint(total)
+		if flags['h'] {
+			size = humanSize(total)
+		}
+
+		_, err = fmt.Fprintf(env.Stdout, "%s\t%s\n", size, arg)
+		if err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	return f.err()
+}
+
+// sha256sum writes checksums in the format sha256sum -c reads back.
+func sha256sum(ctx context.Context, env *Env, _ flagSet, args []string) error {
+	f := &failures{env: env, command: "sha256sum"}
+
+	for _, arg := range args {
+		var sum []byte
+
+		err := readFile(ctx, env, arg, func(r io.Reader) error {
+			h := sha256.New()
+
+			_, err := io.Copy(h, r)
+			if err != nil {
+				return err
+			}
+
+			sum = h.Sum(nil)
+
+			return nil
+		})
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		_, err = fmt.Fprintf(env.Stdout, "%s  %s\n", hex.EncodeToString(sum), arg)
+		if err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	return f.err()
+}
+
+func cat(ctx context.Context, env *Env, _ flagSet, args []string) error {
+	f := &failures{env: env, command: "cat"}
+	out := &outputWriter{w: env.Stdout}
+
+	for _, arg := range args {
+		err := readFile(ctx, env, arg, func(r io.Reader) error {
+			_, err := io.Copy(out, r)
+
+			return err
+		})
+		if out.err != nil {
+			return fmt.Errorf("failed to write output: %w", out.err)
+		}
+
+		if err != nil {
+			f.add(arg, err)
+		}
+	}
+
+	return f.err()
+}
+
+// outputWriter remembers write errors so they can be told apart from read
+// errors after a copy.
+type outputWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (o *outputWriter) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	if err != nil {
+		o.err = err
+	}
+
+	return n, err
+}
+
+// readFile opens the regular file named by arg and passes its content to
+// read, stopping early if ctx is cancelled.
+func readFile(ctx context.Context, env *Env, arg string, read func(io.Reader) error) error {
+	name, err := cleanPath(arg)
+	if err != nil {
+		return err
+	}
+
+	file, err := env.Storage.Open(name)
+	if err != nil {
+		return err
+	}
+	//nolint: errcheck
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return ErrIsDirectory
+	}
+
+	env.Logger.Info("file opened for reading", slog.String("path", name))
+
+	return read(&contextReader{ctx: ctx, r: file})
+}
+
+// contextReader stops a copy once the session is gone.
+type contextReader struct {
+	ctx context.Context //nolint: containedctx
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if r.ctx.Err() != nil {
+		return 0, r.ctx.Err()
+	}
+
+	return r.r.Read(p)
+}
+
+func humanSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%c", float64(size)/float64(div), "KMGTPE"[exp])
+}
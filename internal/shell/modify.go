@@ -0,0 +1,170 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+var (
+	ErrRoot         = errors.New("refusing to modify the root directory")
+	ErrNotDirectory = errors.New("not a directory")
+	ErrIntoItself   = errors.New("cannot move a directory into itself")
+	ErrSameFile     = errors.New("source and target are the same file")
+)
+
+func mkdir(_ context.Context, env *Env, flags flagSet, args []string) error {
+	f := &failures{env: env, command: "mkdir"}
+
+	for _, arg := range args {
+		name, err := cleanPath(arg)
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		if flags['p'] {
+			err = env.Storage.MkdirAll(name, storage.DefaultDirectoryPerms)
+		} else {
+			err = env.Storage.Mkdir(name, storage.DefaultDirectoryPerms)
+		}
+
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		env.Logger.Info("directory created", slog.String("path", name))
+	}
+
+	return f.err()
+}
+
+// rm removes files, and directories with everything in them when -r is
+// given. -f ignores operands that do not exist.
+func rm(_ context.Context, env *Env, flags flagSet, args []string) error {
+	f := &failures{env: env, command: "rm"}
+
+	for _, arg := range args {
+		name, err := cleanPath(arg)
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		if name == "/" {
+			f.add(arg, ErrRoot)
+
+			continue
+		}
+
+		info, err := env.Storage.Stat(name)
+		if errors.Is(err, os.ErrNotExist) && flags['f'] {
+			continue
+		}
+
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		switch {
+		case info.IsDir() && !flags['r']:
+			err = ErrIsDirectory
+		case info.IsDir():
+			err = env.Storage.RemoveAll(name)
+		default:
+			err = env.Storage.Remove(name)
+		}
+
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		env.Logger.Info("file removed", slog.String("path", name))
+	}
+
+	return f.err()
+}
+
+// mv renames a single source to target, or moves every source into target
+// when target is an existing directory.
+func mv(_ context.Context, env *Env, _ flagSet, args []string) error {
+	f := &failures{env: env, command: "mv"}
+
+	sources, last := args[:len(args)-1], args[len(args)-1]
+
+	target, err := cleanPath(last)
+	if err != nil {
+		f.add(last, err)
+
+		return f.err()
+	}
+
+	info, err := env.Storage.Stat(target)
+	into := err == nil && info.IsDir()
+
+	if len(sources) > 1 && !into {
+		f.add(last, ErrNotDirectory)
+
+		return f.err()
+	}
+
+	for _, arg := range sources {
+		source, err := cleanPath(arg)
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		if source == "/" {
+			f.add(arg, ErrRoot)
+
+			continue
+		}
+
+		destination := target
+		if into {
+			destination = path.Join(target, path.Base(source))
+		}
+
+		if destination == source {
+			f.add(arg, ErrSameFile)
+
+			continue
+		}
+
+		if strings.HasPrefix(destination, source+"/") {
+			f.add(arg, ErrIntoItself)
+
+			continue
+		}
+
+		err = env.Storage.Rename(source, destination)
+		if err != nil {
+			f.add(arg, err)
+
+			continue
+		}
+
+		env.Logger.Info(
+			"file renamed",
+			slog.String("path", source),
+			slog.String("target", destination),
+		)
+	}
+
+	return f.err()
+}
@@ -0,0 +1,234 @@
+// Package shell runs a fixed set of file commands, such as ls and sha256sum,
+// against a storage backend for SSH exec requests. Every command is
+// implemented on top of storage.Interface; nothing is ever handed to a system
+// shell, so quoting, globbing and redirection have no special meaning.
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+// Exit statuses reported for failed commands, following the conventions of
+// POSIX shells and utilities.
+const (
+	ExitFailure        = 1
+	ExitUsage          = 2
+	ExitUnknownCommand = 127
+)
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrUsage          = errors.New("invalid usage")
+	ErrFailed         = errors.New("command failed")
+	ErrInvalidPath    = errors.New("invalid path")
+)
+
+// Env is what a command runs against. Storage is expected to already carry
+// the restrictions of the session, such as its root and read-only mode.
+type Env struct {
+	Storage storage.Interface
+	Logger  *slog.Logger
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+type command struct {
+	usage string
+
+	// flags lists the single letter flags the command accepts.
+	flags string
+
+	// minArgs is the least number of operands the command needs.
+	minArgs int
+
+	run func(ctx context.Context, env *Env, flags flagSet, args []string) error
+}
+
+var commands = map[string]command{
+	"ls": {
+		usage: "ls [-l] [path ...]",
+		flags: "l",
+		run:   ls,
+	},
+	"stat": {
+		usage:   "stat path ...",
+		minArgs: 1,
+		run:     stat,
+	},
+	"du": {
+		usage: "du [-h] [path ...]",
+		flags: "h",
+		run:   du,
+	},
+	"sha256sum": {
+		usage:   "sha256sum path ...",
+		minArgs: 1,
+		run:     sha256sum,
+	},
+	"cat": {
+		usage:   "cat path ...",
+		minArgs: 1,
+		run:     cat,
+	},
+	"mkdir": {
+		usage:   "mkdir [-p] path ...",
+		flags:   "p",
+		minArgs: 1,
+		run:     mkdir,
+	},
+	"rm": {
+		usage:   "rm [-rf] path ...",
+		flags:   "rf",
+		minArgs: 1,
+		run:     rm,
+	},
+	"mv": {
+		usage:   "mv source ... target",
+		minArgs: 2,
+		run:     mv,
+	},
+}
+
+// Commands returns the names of the built-in commands in sorted order.
+func Commands() []string {
+	return slices.Sorted(maps.Keys(commands))
+}
+
+// IsCommand reports whether name is a built-in command.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+
+	return ok
+}
+
+// Run runs the built-in command named by args[0] with the remaining
+// arguments. Problems with individual paths are written to Stderr as they
+// happen and reported together as ErrFailed once the command is done.
+func Run(ctx context.Context, env *Env, args []string) error {
+	if len(args) == 0 {
+		return ErrUnknownCommand
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, args[0])
+	}
+
+	flags, operands, err := parseFlags(args[1:], cmd.flags)
+	if err != nil {
+		return fmt.Errorf("%w: %w (usage: %s)", ErrUsage, err, cmd.usage)
+	}
+
+	if len(operands) < cmd.minArgs {
+		return fmt.Errorf("%w: missing operand (usage: %s)", ErrUsage, cmd.usage)
+	}
+
+	return cmd.run(ctx, env, flags, operands)
+}
+
+// ExitStatus returns the exit status a session should report for an error
+// returned by Run.
+func ExitStatus(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrUnknownCommand):
+		return ExitUnknownCommand
+	case errors.Is(err, ErrUsage):
+		return ExitUsage
+	}
+
+	return ExitFailure
+}
+
+type flagSet map[rune]bool
+
+// parseFlags splits args into flags and operands the way POSIX utilities do:
+// flags come first, may be combined as in -rf and end at the first operand or
+// at "--".
+func parseFlags(args []string, allowed string) (flagSet, []string, error) {
+	flags := flagSet{}
+
+	for i, arg := range args {
+		if arg == "--" {
+			return flags, args[i+1:], nil
+		}
+
+		if len(arg) < 2 || arg[0] != '-' {
+			return flags, args[i:], nil
+		}
+
+		for _, flag := range arg[1:] {
+			if !strings.ContainsRune(allowed, flag) {
+				return nil, nil, fmt.Errorf("unknown flag -%c", flag)
+			}
+
+			flags[flag] = true
+		}
+	}
+
+	return flags, nil, nil
+}
+
+// cleanPath validates a path operand and returns it as an absolute storage
+// path. Relative paths are relative to the root of the session, which is
+// also where ".." stops, the same way SFTP request paths are resolved.
+func cleanPath(name string) (string, error) {
+	if name == "" || strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPath, name)
+	}
+
+	return path.Clean("/" + name), nil
+}
+
+// failures collects the problems a command runs into with individual paths
+// so it can carry on with the others, as coreutils do.
+type failures struct {
+	env     *Env
+	command string
+	failed  bool
+}
+
+func (f *failures) add(name string, err error) {
+	f.failed = true
+
+	f.env.Logger.Error(
+		"command failed on path",
+		slog.String("path", name),
+		slog.Any("err", err),
+	)
+
+	// NB: storage errors name the path the backend saw, which may include
+	// the root of the session, so only the cause is shown to the client.
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+
+	var linkErr *os.LinkError
+	if errors.As(err, &linkErr) {
+		err = linkErr.Err
+	}
+
+	//nolint: errcheck
+	fmt.Fprintf(f.env.Stderr, "%s: %s: %v\n", f.command, name, err)
+}
+
+func (f *failures) err() error {
+	if f.failed {
+		return ErrFailed
+	}
+
+	return nil
+}
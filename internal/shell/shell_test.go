@@ -0,0 +1,253 @@
+package shell
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+func newTestStorage(t *testing.T) storage.Interface {
+	t.Helper()
+
+	st := storage.NewInMemory()
+
+	err := st.MkdirAll("/photos/2024", 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string]string{
+		"/a.txt":            "hello",
+		"/photos/notes.txt": "notes",
+		"/photos/2024/p":    "0123456789",
+	} {
+		err = afero.WriteFile(st, name, []byte(data), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return st
+}
+
+// runTest runs args against st and returns what the command wrote to stdout
+// and stderr.
+func runTest(t *testing.T, st storage.Interface, args ...string) (string, string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+
+	err := Run(t.Context(), &Env{
+		Storage: st,
+		Logger:  slog.New(slog.DiscardHandler),
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	}, args)
+
+	return stdout.String(), stderr.String(), err
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args   []string
+		stdout string
+		stderr string
+		err    error
+		status int
+	}{
+		{args: []string{"ls"}, stdout: "a.txt\nphotos/\n"},
+		{args: []string{"ls", "photos/2024/../"}, stdout: "2024/\nnotes.txt\n"},
+		{args: []string{"ls", "a.txt", "/photos/2024"}, stdout: "a.txt\n\n/photos/2024:\np\n"},
+		{args: []string{"ls", "--", "-l"}, stderr: "ls: -l: file does not exist\n", err: ErrFailed},
+		{args: []string{"ls", "-a"}, err: ErrUsage, status: ExitUsage},
+		{args: []string{"cat"}, err: ErrUsage, status: ExitUsage},
+		{args: []string{"sh", "-c", "ls"}, err: ErrUnknownCommand, status: ExitUnknownCommand},
+		{args: []string{"cat", "a.txt", "/photos/notes.txt"}, stdout: "hellonotes"},
+		{
+			args:   []string{"cat", "missing", "photos", "a.txt"},
+			stdout: "hello",
+			stderr: "cat: missing: file does not exist\ncat: photos: is a directory\n",
+			err:    ErrFailed,
+		},
+		{
+			args:   []string{"sha256sum", "a.txt"},
+			stdout: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  a.txt\n",
+		},
+		{args: []string{"du", "photos", "a.txt"}, stdout: "15\tphotos\n5\ta.txt\n"},
+		{args: []string{"du", "-h", "/"}, stdout: "20B\t/\n"},
+		{
+			args:   []string{"stat", "a\x00"},
+			stderr: "stat: a\x00: invalid path: \"a\\x00\"\n",
+			err:    ErrFailed,
+		},
+	}
+
+	for _, test := range tests {
+		stdout, stderr, err := runTest(t, newTestStorage(t), test.args...)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: got %v, want %v", test.args, err, test.err)
+		}
+
+		status := test.status
+		if test.err != nil && status == 0 {
+			status = ExitFailure
+		}
+
+		if got := ExitStatus(err); got != status {
+			t.Errorf("%q: exit status %d, want %d", test.args, got, status)
+		}
+
+		if stdout != test.stdout {
+			t.Errorf("%q: stdout %q, want %q", test.args, stdout, test.stdout)
+		}
+
+		if stderr != test.stderr {
+			t.Errorf("%q: stderr %q, want %q", test.args, stderr, test.stderr)
+		}
+	}
+}
+
+func TestModify(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  error
+
+		// exist and gone are paths expected after the command.
+		exist []string
+		gone  []string
+	}{
+		{args: []string{"mkdir", "albums"}, exist: []string{"/albums"}},
+		{args: []string{"mkdir", "-p", "a/b"}, exist: []string{"/a/b"}},
+		{args: []string{"rm", "a.txt"}, gone: []string{"/a.txt"}},
+		{args: []string{"rm", "photos"}, err: ErrFailed, exist: []string{"/photos"}},
+		{args: []string{"rm", "-r", "photos"}, gone: []string{"/photos"}},
+		{args: []string{"rm", "-r", "/.."}, err: ErrFailed, exist: []string{"/photos"}},
+		{args: []string{"rm", "missing"}, err: ErrFailed},
+		{args: []string{"rm", "-f", "missing", "a.txt"}, gone: []string{"/a.txt"}},
+		{
+			args:  []string{"mv", "a.txt", "b.txt"},
+			exist: []string{"/b.txt"},
+			gone:  []string{"/a.txt"},
+		},
+		{
+			args:  []string{"mv", "a.txt", "photos/2024/p", "photos/2024/.."},
+			exist: []string{"/photos/a.txt", "/photos/p"},
+			gone:  []string{"/a.txt", "/photos/2024/p"},
+		},
+		{args: []string{"mv", "a.txt", "photos/notes.txt", "b"}, err: ErrFailed},
+		{
+			args:  []string{"mv", "photos", "photos/2024"},
+			err:   ErrFailed,
+			exist: []string{"/photos/2024/p"},
+		},
+		{args: []string{"mv", "photos/notes.txt", "photos"}, err: ErrFailed},
+		{args: []string{"mv", "/", "b"}, err: ErrFailed},
+	}
+
+	for _, test := range tests {
+		st := newTestStorage(t)
+
+		_, _, err := runTest(t, st, test.args...)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: got %v, want %v", test.args, err, test.err)
+		}
+
+		for _, name := range test.exist {
+			_, err = st.Stat(name)
+			if err != nil {
+				t.Errorf("%q: %s: %v", test.args, name, err)
+			}
+		}
+
+		for _, name := range test.gone {
+			_, err = st.Stat(name)
+			if !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("%q: %s: got %v, want %v", test.args, name, err, os.ErrNotExist)
+			}
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	st := newTestStorage(t)
+
+	for _, args := range [][]string{
+		{"mkdir", "albums"},
+		{"rm", "a.txt"},
+		{"mv", "a.txt", "b.txt"},
+	} {
+		_, stderr, err := runTest(t, storage.NewReadOnly(st), args...)
+		if !errors.Is(err, ErrFailed) || stderr == "" {
+			t.Errorf("%q on read only storage: %v, %q", args, err, stderr)
+		}
+	}
+
+	_, err := st.Stat("/a.txt")
+	if err != nil {
+		t.Errorf("file of read only storage: %v", err)
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		args     []string
+		flags    string
+		operands []string
+		fails    bool
+	}{
+		{args: []string{"-rf", "a"}, flags: "fr", operands: []string{"a"}},
+		{args: []string{"-r", "-f", "a", "-r"}, flags: "fr", operands: []string{"a", "-r"}},
+		{args: []string{"--", "-r"}, operands: []string{"-r"}},
+		{args: []string{"-", "a"}, operands: []string{"-", "a"}},
+		{args: []string{"-rx"}, fails: true},
+	}
+
+	for _, test := range tests {
+		flags, operands, err := parseFlags(test.args, "rf")
+		if (err != nil) != test.fails {
+			t.Errorf("parseFlags(%q) = %v, want failure %v", test.args, err, test.fails)
+
+			continue
+		}
+
+		var set []rune
+		for flag := range flags {
+			set = append(set, flag)
+		}
+
+		slices.Sort(set)
+
+		if string(set) != test.flags || !slices.Equal(operands, test.operands) {
+			t.Errorf(
+				"parseFlags(%q) = %q, %q, want %q, %q",
+				test.args, string(set), operands, test.flags, test.operands,
+			)
+		}
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{size: 0, want: "0B"},
+		{size: 1023, want: "1023B"},
+		{size: 1024, want: "1.0K"},
+		{size: 1536, want: "1.5K"},
+		{size: 5 << 30, want: "5.0G"},
+	}
+
+	for _, test := range tests {
+		if got := humanSize(test.size); got != test.want {
+			t.Errorf("humanSize(%d) = %q, want %q", test.size, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Symlinker = &BasePath{}
+	_ HardLinker      = &BasePath{}
+	_ UsageReporter   = &BasePath{}
+)
+
+// BasePath confines all operations to a directory of another storage backend.
+//
+// Unlike afero.BasePathFs, every name is cleaned as an absolute path before
+// being joined with the root so ".." can never climb out of it, and link
+// targets are resolved relative to the confined namespace.
+type BasePath struct {
+	source Interface
+	root   string
+}
+
+func NewBasePath(source Interface, root string) Interface {
+	return &BasePath{
+		source: source,
+		root:   cleanPath(root),
+	}
+}
+
+func (b *BasePath) realPath(name string) string {
+	return path.Join(b.root, cleanPath(name))
+}
+
+// stripRoot maps a path of the source backend back into the confined
+// namespace, reporting false if it lies outside of the root.
+func (b *BasePath) stripRoot(name string) (string, bool) {
+	if b.root == "/" {
+		return name, true
+	}
+
+	if name == b.root {
+		return "/", true
+	}
+
+	rel, found := strings.CutPrefix(name, b.root+"/")
+	if !found {
+		return "", false
+	}
+
+	return "/" + rel, true
+}
+
+func (b *BasePath) Create(name string) (afero.File, error) {
+	f, err := b.source.Create(b.realPath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &basePathFile{File: f, name: cleanPath(name)}, nil
+}
+
+func (b *BasePath) Mkdir(name string, perm os.FileMode) error {
+	return b.source.Mkdir(b.realPath(name), perm)
+}
+
+func (b *BasePath) MkdirAll(name string, perm os.FileMode) error {
+	return b.source.MkdirAll(b.realPath(name), perm)
+}
+
+func (b *BasePath) Open(name string) (afero.File, error) {
+	f, err := b.source.Open(b.realPath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &basePathFile{File: f, name: cleanPath(name)}, nil
+}
+
+func (b *BasePath) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	f, err := b.source.OpenFile(b.realPath(name), flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &basePathFile{File: f, name: cleanPath(name)}, nil
+}
+
+func (b *BasePath) Remove(name string) error {
+	return b.source.Remove(b.realPath(name))
+}
+
+func (b *BasePath) RemoveAll(name string) error {
+	return b.source.RemoveAll(b.realPath(name))
+}
+
+func (b *BasePath) Rename(oldname, newname string) error {
+	err := checkMovedLinks(b, oldname, newname, nil)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+
+	return b.source.Rename(b.realPath(oldname), b.realPath(newname))
+}
+
+func (b *BasePath) Stat(name string) (os.FileInfo, error) {
+	return b.source.Stat(b.realPath(name))
+}
+
+func (b *BasePath) Name() string {
+	return "BasePath"
+}
+
+func (b *BasePath) Chmod(name string, mode os.FileMode) error {
+	return b.source.Chmod(b.realPath(name), mode)
+}
+
+func (b *BasePath) Chown(name string, uid, gid int) error {
+	return b.source.Chown(b.realPath(name), uid, gid)
+}
+
+func (b *BasePath) Chtimes(name string, atime, mtime time.Time) error {
+	return b.source.Chtimes(b.realPath(name), atime, mtime)
+}
+
+func (b *BasePath) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	lstater, ok := b.source.(afero.Lstater)
+	if !ok {
+		fi, err := b.Stat(name)
+
+		return fi, false, err
+	}
+
+	return lstater.LstatIfPossible(b.realPath(name))
+}
+
+func (b *BasePath) SymlinkIfPossible(oldname, newname string) error {
+	linker, ok := b.source.(afero.Linker)
+	if !ok {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: afero.ErrNoSymlink}
+	}
+
+	target, err := ResolveLinkTarget(oldname, newname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	err = checkLinkParents(b, newname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	return linker.SymlinkIfPossible(b.realPath(target), b.realPath(newname))
+}
+
+func (b *BasePath) ReadlinkIfPossible(name string) (string, error) {
+	reader, ok := b.source.(afero.LinkReader)
+	if !ok {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: afero.ErrNoReadlink}
+	}
+
+	dest, err := reader.ReadlinkIfPossible(b.realPath(name))
+	if err != nil {
+		return "", err
+	}
+
+	if path.IsAbs(dest) {
+		stripped, ok := b.stripRoot(path.Clean(dest))
+		if !ok {
+			return "", &os.PathError{Op: "readlink", Path: name, Err: ErrLinkOutsideRoot}
+		}
+
+		return stripped, nil
+	}
+
+	_, err = ResolveLinkTarget(dest, name)
+	if err != nil {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: err}
+	}
+
+	return dest, nil
+}
+
+func (b *BasePath) LinkIfPossible(oldname, newname string) error {
+	linker, ok := b.source.(HardLinker)
+	if !ok {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: ErrNoHardLink}
+	}
+
+	err := checkMovedLinks(b, oldname, newname, nil)
+	if err != nil {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: err}
+	}
+
+	return linker.LinkIfPossible(b.realPath(oldname), b.realPath(newname))
+}
+
+func (b *BasePath) Usage(name string) (*Usage, error) {
+	return UsageOf(b.source, b.realPath(name))
+}
+
+// basePathFile reports names relative to the confined namespace so the root
+// is never leaked through afero.File.Name.
+type basePathFile struct {
+	afero.File
+
+	name string
+}
+
+func (f *basePathFile) Name() string {
+	return f.name
+}
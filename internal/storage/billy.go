@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"os"
+	"path"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/helper/chroot"
+	"github.com/spf13/afero"
+)
+
+// DefaultDirectoryPerms is used for the parent directories billy expects to
+// be created implicitly.
+const DefaultDirectoryPerms = 0o700
+
+var _ billy.Filesystem = &Billy{}
+
+// Billy adapts a storage backend to billy.Filesystem so libraries built on
+// go-billy, such as go-git, can use any backend.
+type Billy struct {
+	source Interface
+}
+
+func NewBilly(source Interface) *Billy {
+	return &Billy{source: source}
+}
+
+func (b *Billy) Create(filename string) (billy.File, error) {
+	f, err := b.source.Create(cleanPath(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	return &billyFile{File: f}, nil
+}
+
+func (b *Billy) Open(filename string) (billy.File, error) {
+	f, err := b.source.Open(cleanPath(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	return &billyFile{File: f}, nil
+}
+
+func (b *Billy) OpenFile(filename string, flag int, perm os.FileMode) (billy.File, error) {
+	name := cleanPath(filename)
+
+	if flag&os.O_CREATE != 0 {
+		// NB: billy creates missing parent directories, afero does not.
+		err := b.source.MkdirAll(path.Dir(name), DefaultDirectoryPerms)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := b.source.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &billyFile{File: f}, nil
+}
+
+func (b *Billy) Stat(filename string) (os.FileInfo, error) {
+	return b.source.Stat(cleanPath(filename))
+}
+
+func (b *Billy) Rename(oldpath, newpath string) error {
+	newpath = cleanPath(newpath)
+
+	err := b.source.MkdirAll(path.Dir(newpath), DefaultDirectoryPerms)
+	if err != nil {
+		return err
+	}
+
+	return b.source.Rename(cleanPath(oldpath), newpath)
+}
+
+func (b *Billy) Remove(filename string) error {
+	return b.source.Remove(cleanPath(filename))
+}
+
+func (b *Billy) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+func (b *Billy) TempFile(dir, prefix string) (billy.File, error) {
+	dir = cleanPath(dir)
+
+	err := b.source.MkdirAll(dir, DefaultDirectoryPerms)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := afero.TempFile(b.source, dir, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &billyFile{File: f}, nil
+}
+
+func (b *Billy) ReadDir(dirname string) ([]os.FileInfo, error) {
+	return afero.ReadDir(b.source, cleanPath(dirname))
+}
+
+func (b *Billy) MkdirAll(filename string, perm os.FileMode) error {
+	return b.source.MkdirAll(cleanPath(filename), perm)
+}
+
+func (b *Billy) Lstat(filename string) (os.FileInfo, error) {
+	lstater, ok := b.source.(afero.Lstater)
+	if !ok {
+		return b.Stat(filename)
+	}
+
+	fi, _, err := lstater.LstatIfPossible(cleanPath(filename))
+
+	return fi, err
+}
+
+func (b *Billy) Symlink(target, link string) error {
+	linker, ok := b.source.(afero.Linker)
+	if !ok {
+		return &os.LinkError{Op: "symlink", Old: target, New: link, Err: afero.ErrNoSymlink}
+	}
+
+	return linker.SymlinkIfPossible(target, cleanPath(link))
+}
+
+func (b *Billy) Readlink(link string) (string, error) {
+	reader, ok := b.source.(afero.LinkReader)
+	if !ok {
+		return "", &os.PathError{Op: "readlink", Path: link, Err: afero.ErrNoReadlink}
+	}
+
+	return reader.ReadlinkIfPossible(cleanPath(link))
+}
+
+func (b *Billy) Chroot(dir string) (billy.Filesystem, error) {
+	return chroot.New(b, cleanPath(dir)), nil
+}
+
+func (b *Billy) Root() string {
+	return "/"
+}
+
+// billyFile adds the locking methods billy expects. Locks are advisory in
+// billy and storage backends have no equivalent, so they are no-ops.
+type billyFile struct {
+	afero.File
+}
+
+func (f *billyFile) Lock() error {
+	return nil
+}
+
+func (f *billyFile) Unlock() error {
+	return nil
+}
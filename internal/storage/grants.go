@@ -0,0 +1,441 @@
+package storage
+
+import (
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Symlinker = &Granted{}
+	_ HardLinker      = &Granted{}
+	_ UsageReporter   = &Granted{}
+)
+
+// Grant gives access to a path and everything below it.
+type Grant struct {
+	Prefix string
+	Read   bool
+	Write  bool
+	Delete bool
+}
+
+// covers reports whether name is the prefix of the grant or below it.
+func (g Grant) covers(name string) bool {
+	return g.Prefix == "/" || name == g.Prefix || strings.HasPrefix(name, g.Prefix+"/")
+}
+
+type permission int
+
+const (
+	permRead permission = iota
+	permWrite
+	permDelete
+)
+
+func (g Grant) allows(perm permission) bool {
+	switch perm {
+	case permRead:
+		return g.Read
+	case permWrite:
+		return g.Write
+	case permDelete:
+		return g.Delete
+	}
+
+	return false
+}
+
+// Granted limits the underlying storage to the paths covered by grants.
+//
+// Paths outside of every grant do not exist, except for the root and the
+// directories leading to a grant so that it can be navigated to. Those
+// directories only list the entries leading to a grant. Operations on covered paths that the
+// grants do not allow fail with a permission error.
+//
+// NB: grants apply to names, so links already in storage are followed
+// wherever they point. Links created or moved through Granted may only point
+// to paths granted at least the access of the link itself.
+type Granted struct {
+	source Interface
+	grants []Grant
+}
+
+func NewGranted(source Interface, grants []Grant) Interface {
+	cleaned := make([]Grant, len(grants))
+	for i, grant := range grants {
+		grant.Prefix = cleanPath(grant.Prefix)
+		cleaned[i] = grant
+	}
+
+	return &Granted{
+		source: source,
+		grants: cleaned,
+	}
+}
+
+func (g *Granted) allowed(name string, perm permission) bool {
+	return slices.ContainsFunc(g.grants, func(grant Grant) bool {
+		return grant.covers(name) && grant.allows(perm)
+	})
+}
+
+// leadsToGrant reports whether name is a directory above a grant.
+// NB: the root is one even without grants, so that it can be listed empty.
+func (g *Granted) leadsToGrant(name string) bool {
+	return name == "/" || slices.ContainsFunc(g.grants, func(grant Grant) bool {
+		return strings.HasPrefix(grant.Prefix, name+"/")
+	})
+}
+
+func (g *Granted) visible(name string) bool {
+	return g.leadsToGrant(name) || slices.ContainsFunc(g.grants, func(grant Grant) bool {
+		return grant.covers(name)
+	})
+}
+
+// check returns an error unless name may be used with perm. Paths that are not
+// visible at all do not exist. name must be clean, see cleanPath.
+func (g *Granted) check(op, name string, perm permission) error {
+	if g.allowed(name, perm) {
+		return nil
+	}
+
+	if !g.visible(name) {
+		return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+
+	return &os.PathError{Op: op, Path: name, Err: os.ErrPermission}
+}
+
+// checkLink returns an error unless link may point to target, which must
+// allow whatever link allows since it is accessed through it.
+func (g *Granted) checkLink(op, target, link string) error {
+	for _, perm := range []permission{permRead, permWrite} {
+		if g.allowed(link, perm) && !g.allowed(target, perm) {
+			return &os.LinkError{Op: op, Old: target, New: link, Err: os.ErrPermission}
+		}
+	}
+
+	return nil
+}
+
+func (g *Granted) Create(name string) (afero.File, error) {
+	name = cleanPath(name)
+
+	err := g.check("create", name, permWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.source.Create(name)
+}
+
+func (g *Granted) Mkdir(name string, perm os.FileMode) error {
+	name = cleanPath(name)
+
+	err := g.check("mkdir", name, permWrite)
+	if err != nil {
+		return err
+	}
+
+	return g.source.Mkdir(name, perm)
+}
+
+func (g *Granted) MkdirAll(name string, perm os.FileMode) error {
+	name = cleanPath(name)
+
+	err := g.check("mkdir", name, permWrite)
+	if err != nil {
+		return err
+	}
+
+	return g.source.MkdirAll(name, perm)
+}
+
+func (g *Granted) Open(name string) (afero.File, error) {
+	return g.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (g *Granted) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	name = cleanPath(name)
+
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		err := g.check("open", name, permWrite)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if flag&os.O_WRONLY != 0 {
+		return g.source.OpenFile(name, flag, perm)
+	}
+
+	// NB: directories leading to a grant can be opened to list the way to
+	// it, but nothing else can be read from them.
+	leads := !g.allowed(name, permRead) && g.leadsToGrant(name)
+	if !leads {
+		err := g.check("open", name, permRead)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := g.source.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	if leads {
+		info, err := f.Stat()
+		if err != nil || !info.IsDir() {
+			//nolint: errcheck
+			f.Close()
+
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrPermission}
+		}
+	}
+
+	return &grantedFile{File: f, granted: g, path: name}, nil
+}
+
+func (g *Granted) Remove(name string) error {
+	name = cleanPath(name)
+
+	err := g.check("remove", name, permDelete)
+	if err != nil {
+		return err
+	}
+
+	return g.source.Remove(name)
+}
+
+func (g *Granted) RemoveAll(name string) error {
+	name = cleanPath(name)
+
+	err := g.check("remove", name, permDelete)
+	if err != nil {
+		return err
+	}
+
+	return g.source.RemoveAll(name)
+}
+
+func (g *Granted) Rename(oldname, newname string) error {
+	oldname, newname = cleanPath(oldname), cleanPath(newname)
+
+	err := g.check("rename", oldname, permDelete)
+	if err != nil {
+		return err
+	}
+
+	err = g.check("rename", newname, permWrite)
+	if err != nil {
+		return err
+	}
+
+	err = checkMovedLinks(g.source, oldname, newname, func(target, link string) error {
+		return g.checkLink("rename", target, link)
+	})
+	if err != nil {
+		return err
+	}
+
+	return g.source.Rename(oldname, newname)
+}
+
+func (g *Granted) Stat(name string) (os.FileInfo, error) {
+	name = cleanPath(name)
+
+	if !g.visible(name) {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+
+	return g.source.Stat(name)
+}
+
+func (g *Granted) Name() string {
+	return "Granted"
+}
+
+func (g *Granted) Chmod(name string, mode os.FileMode) error {
+	name = cleanPath(name)
+
+	err := g.check("chmod", name, permWrite)
+	if err != nil {
+		return err
+	}
+
+	return g.source.Chmod(name, mode)
+}
+
+func (g *Granted) Chown(name string, uid, gid int) error {
+	name = cleanPath(name)
+
+	err := g.check("chown", name, permWrite)
+	if err != nil {
+		return err
+	}
+
+	return g.source.Chown(name, uid, gid)
+}
+
+func (g *Granted) Chtimes(name string, atime, mtime time.Time) error {
+	name = cleanPath(name)
+
+	err := g.check("chtimes", name, permWrite)
+	if err != nil {
+		return err
+	}
+
+	return g.source.Chtimes(name, atime, mtime)
+}
+
+func (g *Granted) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	name = cleanPath(name)
+
+	if !g.visible(name) {
+		return nil, false, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+	}
+
+	lstater, ok := g.source.(afero.Lstater)
+	if !ok {
+		fi, err := g.source.Stat(name)
+
+		return fi, false, err
+	}
+
+	return lstater.LstatIfPossible(name)
+}
+
+func (g *Granted) SymlinkIfPossible(oldname, newname string) error {
+	linker, ok := g.source.(afero.Linker)
+	if !ok {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: afero.ErrNoSymlink}
+	}
+
+	newname = cleanPath(newname)
+
+	err := g.check("symlink", newname, permWrite)
+	if err != nil {
+		return err
+	}
+
+	target, err := ResolveLinkTarget(oldname, newname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	err = g.checkLink("symlink", target, newname)
+	if err != nil {
+		return err
+	}
+
+	// NB: the target is checked against the grants by name, which is only
+	// where the link points if no directory above it is a link.
+	err = checkLinkParents(g.source, newname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	return linker.SymlinkIfPossible(oldname, newname)
+}
+
+func (g *Granted) ReadlinkIfPossible(name string) (string, error) {
+	name = cleanPath(name)
+
+	reader, ok := g.source.(afero.LinkReader)
+	if !ok {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: afero.ErrNoReadlink}
+	}
+
+	err := g.check("readlink", name, permRead)
+	if err != nil {
+		return "", err
+	}
+
+	return reader.ReadlinkIfPossible(name)
+}
+
+func (g *Granted) LinkIfPossible(oldname, newname string) error {
+	linker, ok := g.source.(HardLinker)
+	if !ok {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: ErrNoHardLink}
+	}
+
+	oldname, newname = cleanPath(oldname), cleanPath(newname)
+
+	err := g.check("link", newname, permWrite)
+	if err != nil {
+		return err
+	}
+
+	err = g.checkLink("link", oldname, newname)
+	if err != nil {
+		return err
+	}
+
+	err = checkMovedLinks(g.source, oldname, newname, func(target, link string) error {
+		return g.checkLink("link", target, link)
+	})
+	if err != nil {
+		return err
+	}
+
+	return linker.LinkIfPossible(oldname, newname)
+}
+
+func (g *Granted) Usage(name string) (*Usage, error) {
+	name = cleanPath(name)
+
+	if !g.visible(name) {
+		return nil, &os.PathError{Op: "statvfs", Path: name, Err: os.ErrNotExist}
+	}
+
+	return UsageOf(g.source, name)
+}
+
+// grantedFile hides the directory entries that are not visible through the
+// grants.
+type grantedFile struct {
+	afero.File
+
+	granted *Granted
+	path    string
+}
+
+func (f *grantedFile) Readdir(count int) ([]os.FileInfo, error) {
+	for {
+		infos, err := f.File.Readdir(count)
+		n := len(infos)
+
+		infos = slices.DeleteFunc(infos, func(info os.FileInfo) bool {
+			return !f.granted.visible(path.Join(f.path, info.Name()))
+		})
+
+		// NB: a page of only hidden entries would look like the end of the
+		// directory, so keep reading until something is visible.
+		if len(infos) > 0 || err != nil || count <= 0 || n == 0 {
+			return infos, err
+		}
+	}
+}
+
+func (f *grantedFile) Readdirnames(count int) ([]string, error) {
+	for {
+		names, err := f.File.Readdirnames(count)
+		n := len(names)
+
+		names = slices.DeleteFunc(names, func(name string) bool {
+			return !f.granted.visible(path.Join(f.path, name))
+		})
+
+		if len(names) > 0 || err != nil || count <= 0 || n == 0 {
+			return names, err
+		}
+	}
+}
@@ -0,0 +1,427 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// newGrantedTest limits source to a public directory that can be read, a home
+// that can be used freely and a drop box that can only be written to, next to
+// paths outside of every grant.
+func newGrantedTest(t *testing.T, source Interface) Interface {
+	t.Helper()
+
+	for _, dir := range []string{"/pub", "/publish", "/home/alice/d", "/home/bob", "/drop"} {
+		err := source.MkdirAll(dir, 0o700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{
+		"/a", "/pub/f", "/publish/f", "/home/alice/f", "/home/bob/f", "/drop/f", "/secret",
+	} {
+		err := afero.WriteFile(source, name, []byte(name), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewGranted(source, []Grant{
+		{Prefix: "pub", Read: true},
+		{Prefix: "/home/alice/", Read: true, Write: true, Delete: true},
+		{Prefix: "/drop", Write: true},
+	})
+}
+
+func TestGrantedVisibility(t *testing.T) {
+	granted := newGrantedTest(t, NewPosix(t.TempDir()))
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "/"},
+		{name: "/pub"},
+		{name: "/pub/f"},
+		{name: "/pub/../pub/f"},
+		{name: "/publish", err: os.ErrNotExist},
+		{name: "/publish/f", err: os.ErrNotExist},
+		{name: "/home"},
+		{name: "/home/alice/f"},
+		{name: "/home/bob", err: os.ErrNotExist},
+		{name: "/home/bob/f", err: os.ErrNotExist},
+		{name: "/home/alice/../bob/f", err: os.ErrNotExist},
+		{name: "/drop/f"},
+		{name: "/secret", err: os.ErrNotExist},
+		{name: "/../secret", err: os.ErrNotExist},
+	}
+
+	for _, test := range tests {
+		_, err := granted.Stat(test.name)
+		if !errors.Is(err, test.err) {
+			t.Errorf("Stat(%q) = %v, want %v", test.name, err, test.err)
+		}
+
+		_, _, err = granted.(afero.Lstater).LstatIfPossible(test.name)
+		if !errors.Is(err, test.err) {
+			t.Errorf("LstatIfPossible(%q) = %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestGrantedPermissions(t *testing.T) {
+	granted := newGrantedTest(t, NewPosix(t.TempDir()))
+
+	open := func(name string, flag int) func() error {
+		return func() error {
+			f, err := granted.OpenFile(name, flag, 0o600)
+			if err != nil {
+				return err
+			}
+
+			return f.Close()
+		}
+	}
+
+	read := func(name string) func() error {
+		return open(name, os.O_RDONLY)
+	}
+
+	tests := []struct {
+		name string
+		op   func() error
+		err  error
+	}{
+		{name: "read a public file", op: read("/pub/f")},
+		{name: "read a home file", op: read("/home/alice/f")},
+		{name: "read a dropped file", op: read("/drop/f"), err: os.ErrPermission},
+		{name: "read a hidden file", op: read("/secret"), err: os.ErrNotExist},
+		{name: "read a directory above a grant", op: read("/home")},
+		{
+			name: "write a public file",
+			op:   open("/pub/f", os.O_WRONLY),
+			err:  os.ErrPermission,
+		},
+		{
+			name: "append to a public file",
+			op:   open("/pub/f", os.O_RDONLY|os.O_APPEND),
+			err:  os.ErrPermission,
+		},
+		{name: "write a home file", op: open("/home/alice/f", os.O_RDWR)},
+		{name: "drop a file", op: open("/drop/new", os.O_WRONLY|os.O_CREATE)},
+		{
+			name: "read back a dropped file",
+			op:   open("/drop/new", os.O_RDWR),
+			err:  os.ErrPermission,
+		},
+		{
+			name: "create a hidden file",
+			op:   open("/home/bob/new", os.O_WRONLY|os.O_CREATE),
+			err:  os.ErrNotExist,
+		},
+		{
+			name: "create above a grant",
+			op:   open("/home/new", os.O_WRONLY|os.O_CREATE),
+			err:  os.ErrNotExist,
+		},
+		{
+			name: "make a directory above a grant",
+			op:   func() error { return granted.Mkdir("/home", 0o700) },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "make a home directory",
+			op:   func() error { return granted.Mkdir("/home/alice/new", 0o700) },
+		},
+		{
+			name: "make a public directory",
+			op:   func() error { return granted.MkdirAll("/pub/new/dir", 0o700) },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "chmod a public file",
+			op:   func() error { return granted.Chmod("/pub/f", 0o777) },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "remove a public file",
+			op:   func() error { return granted.Remove("/pub/f") },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "remove a dropped file",
+			op:   func() error { return granted.Remove("/drop/f") },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "remove a hidden directory",
+			op:   func() error { return granted.RemoveAll("/home/bob") },
+			err:  os.ErrNotExist,
+		},
+		{
+			name: "remove a home file",
+			op:   func() error { return granted.Remove("/home/alice/f") },
+		},
+		{
+			name: "remove a directory above a grant",
+			op:   func() error { return granted.RemoveAll("/home") },
+			err:  os.ErrPermission,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.op()
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestGrantedListing(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want []string
+		err  error
+	}{
+		{dir: "/", want: []string{"drop", "home", "pub"}},
+		{dir: "/home", want: []string{"alice"}},
+		{dir: "/home/alice", want: []string{"d", "f"}},
+		{dir: "/pub", want: []string{"f"}},
+		{dir: "/drop", err: os.ErrPermission},
+		{dir: "/home/bob", err: os.ErrNotExist},
+	}
+
+	// NB: the in-memory backend lists entries in order, so the hidden /a
+	// makes up the whole first page of the root.
+	backends := map[string]Interface{
+		"posix":     NewPosix(t.TempDir()),
+		"in-memory": NewInMemory(),
+	}
+
+	for name, source := range backends {
+		granted := newGrantedTest(t, source)
+
+		for _, test := range tests {
+			// NB: listing a page at a time must skip pages of only hidden
+			// entries rather than stop at them.
+			for _, count := range []int{-1, 1} {
+				names, err := listGranted(granted, test.dir, count)
+				if !errors.Is(err, test.err) || !slices.Equal(names, test.want) {
+					t.Errorf(
+						"%s: listing %s by %d = %v, %v, want %v, %v",
+						name, test.dir, count, names, err, test.want, test.err,
+					)
+				}
+			}
+		}
+	}
+}
+
+// listGranted lists dir by pages of count entries, both by name and by info,
+// and returns the sorted names if both listings agree.
+func listGranted(fs Interface, dir string, count int) ([]string, error) {
+	var names, infos []string
+
+	for _, byInfo := range []bool{false, true} {
+		f, err := fs.Open(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for {
+			var page []string
+
+			if byInfo {
+				var list []os.FileInfo
+
+				list, err = f.Readdir(count)
+				for _, info := range list {
+					page = append(page, info.Name())
+				}
+
+				infos = append(infos, page...)
+			} else {
+				page, err = f.Readdirnames(count)
+				names = append(names, page...)
+			}
+
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			if err != nil {
+				//nolint: errcheck
+				f.Close()
+
+				return nil, err
+			}
+
+			// NB: an empty page is the end of the directory to clients.
+			if count <= 0 || len(page) == 0 {
+				break
+			}
+		}
+
+		//nolint: errcheck
+		f.Close()
+	}
+
+	slices.Sort(names)
+	slices.Sort(infos)
+
+	if !slices.Equal(names, infos) {
+		return nil, errors.New("listings by name and by info differ")
+	}
+
+	return names, nil
+}
+
+func TestGrantedLinks(t *testing.T) {
+	source := NewPosix(t.TempDir())
+	granted := newGrantedTest(t, source)
+	linker := granted.(afero.Linker)
+
+	// NB: links already in storage are followed wherever they point, moving
+	// them is what must not make them point outside of their grants.
+	err := source.(afero.Linker).SymlinkIfPossible("/home/alice/f", "/home/alice/d/home")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{"/home/alice/e", "/home/alice/d2", "/drop/x"} {
+		err = source.MkdirAll(dir, 0o700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = source.(afero.Linker).SymlinkIfPossible("/pub/f", "/home/alice/e/pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		op   func() error
+		err  error
+	}{
+		{
+			name: "link to a home file",
+			op:   func() error { return linker.SymlinkIfPossible("f", "/home/alice/l") },
+		},
+		{
+			name: "link to a read only file",
+			op:   func() error { return linker.SymlinkIfPossible("/pub/f", "/home/alice/p") },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "link to a hidden file",
+			op:   func() error { return linker.SymlinkIfPossible("/secret", "/home/alice/s") },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "link up out of a grant",
+			op:   func() error { return linker.SymlinkIfPossible("../bob/f", "/home/alice/b") },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "drop a link to a home file",
+			op:   func() error { return linker.SymlinkIfPossible("/home/alice/f", "/drop/l") },
+		},
+		{
+			name: "drop a link to a read only file",
+			op:   func() error { return linker.SymlinkIfPossible("/pub/f", "/drop/p") },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "link in a read only directory",
+			op:   func() error { return linker.SymlinkIfPossible("f", "/pub/l") },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "hard link to a read only file",
+			op: func() error {
+				return granted.(HardLinker).LinkIfPossible("/pub/f", "/home/alice/h")
+			},
+			err: os.ErrPermission,
+		},
+		{
+			name: "rename out of a read only directory",
+			op:   func() error { return granted.Rename("/pub/f", "/home/alice/g") },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "rename into a read only directory",
+			op:   func() error { return granted.Rename("/home/alice/f", "/pub/g") },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "rename into a hidden directory",
+			op:   func() error { return granted.Rename("/home/alice/f", "/home/bob/g") },
+			err:  os.ErrNotExist,
+		},
+		{
+			name: "rename a link next to a home file",
+			op:   func() error { return granted.Rename("/home/alice/l", "/home/alice/d/l") },
+		},
+		{
+			name: "rename a link to a home file into the drop box",
+			op:   func() error { return granted.Rename("/home/alice/d", "/drop/d") },
+		},
+		{
+			name: "rename a link to a read only file into the drop box",
+			op:   func() error { return granted.Rename("/home/alice/e", "/drop/x/e") },
+			err:  os.ErrPermission,
+		},
+		{
+			name: "rename a link to a read only file to where it is hidden",
+			op:   func() error { return granted.Rename("/home/alice/e", "/home/alice/d2/e") },
+			err:  os.ErrPermission,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.op()
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+		}
+	}
+
+	_, err = granted.Stat("/home/alice/e/pub")
+	if err != nil {
+		t.Fatalf("link moved despite the rename failing: %v", err)
+	}
+}
+
+func TestGrantedNothing(t *testing.T) {
+	source := NewInMemory()
+
+	err := afero.WriteFile(source, "/a", []byte("a"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NB: without grants there is nothing to see, but the root still lists.
+	granted := NewGranted(source, nil)
+
+	names, err := listGranted(granted, "/", -1)
+	if err != nil || len(names) != 0 {
+		t.Errorf("listing / = %v, %v, want nothing", names, err)
+	}
+
+	_, err = granted.Stat("/a")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Stat(/a) = %v, want %v", err, os.ErrNotExist)
+	}
+
+	err = afero.WriteFile(granted, "/b", []byte("b"), 0o600)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("writing /b = %v, want %v", err, os.ErrNotExist)
+	}
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	// ErrLinkOutsideRoot is returned when a link target resolves outside of
+	// the storage root.
+	ErrLinkOutsideRoot = errors.New("link target outside storage root")
+
+	// ErrLinkInLinkedDir is returned when a link would be created in a
+	// directory reached through another link.
+	ErrLinkInLinkedDir = errors.New("link parent directory is a link")
+
+	// ErrNoHardLink is wrapped in an os.LinkError when a backend does not
+	// support hard links.
+	ErrNoHardLink = errors.New("hard link not supported")
+)
+
+// HardLinker is an optional interface implemented by storage backends that
+// support hard links. It mirrors afero.Linker for symbolic links.
+type HardLinker interface {
+	LinkIfPossible(oldname, newname string) error
+}
+
+// ResolveLinkTarget resolves a link target relative to the directory that
+// contains link and returns the cleaned absolute path within the storage root.
+// Targets that would climb above the root return ErrLinkOutsideRoot.
+func ResolveLinkTarget(target, link string) (string, error) {
+	if target == "" {
+		return "", ErrLinkOutsideRoot
+	}
+
+	var dir string
+	if !path.IsAbs(target) {
+		dir = strings.TrimPrefix(path.Dir(cleanPath(link)), "/")
+	}
+
+	// NB: path.Clean drops ".." elements above "/" on absolute paths, so
+	// the join and escape check are done on the relative form instead.
+	rel := path.Join(dir, strings.TrimPrefix(target, "/"))
+	if escapesRoot(rel) {
+		return "", ErrLinkOutsideRoot
+	}
+
+	if rel == "" || rel == "." {
+		return "/", nil
+	}
+
+	return "/" + rel, nil
+}
+
+// checkLinkParents returns ErrLinkInLinkedDir if a directory above name is a
+// symbolic link.
+// NB: link targets are resolved from the directory their name says they are
+// in, which is only where they end up if no directory above them is a link.
+// Chaining links through linked directories would otherwise climb out of the
+// root, e.g. x/y/d -> ../.. then x/y/d/e -> ../../../secret.
+func checkLinkParents(source afero.Fs, name string) error {
+	lstater, ok := source.(afero.Lstater)
+	if !ok {
+		return nil
+	}
+
+	for dir := path.Dir(cleanPath(name)); dir != "/"; dir = path.Dir(dir) {
+		info, _, err := lstater.LstatIfPossible(dir)
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return ErrLinkInLinkedDir
+		}
+	}
+
+	return nil
+}
+
+// checkMovedLinks returns an error if moving oldname to newname would take a
+// link, or a directory containing links, below a linked directory or to a
+// place where its target is outside the root or rejected by check, if any.
+// check is given the target resolved from the new place of the link and that
+// place.
+// NB: links are written relative to their directory, so moving them changes
+// where they point.
+func checkMovedLinks(
+	source afero.Fs,
+	oldname, newname string,
+	check func(target, link string) error,
+) error {
+	lstater, ok := source.(afero.Lstater)
+	if !ok {
+		return nil
+	}
+
+	reader, ok := source.(afero.LinkReader)
+	if !ok {
+		return nil
+	}
+
+	oldname, newname = cleanPath(oldname), cleanPath(newname)
+
+	// NB: targets are resolved from the new place of links by name, which is
+	// not where they end up below a linked directory, see checkLinkParents.
+	parents := checkLinkParents(source, newname)
+
+	// NB: links moved further from the root only climb less far out of
+	// their directory, so they can only escape when moved closer to it.
+	if check == nil && parents == nil &&
+		strings.Count(newname, "/") >= strings.Count(oldname, "/") {
+		return nil
+	}
+
+	info, _, err := lstater.LstatIfPossible(oldname)
+	if err != nil || (info.Mode()&os.ModeSymlink == 0 && !info.IsDir()) {
+		// NB: moving what does not exist fails on its own.
+		return nil
+	}
+
+	return afero.Walk(source, oldname, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+
+		if parents != nil {
+			return parents
+		}
+
+		dest, err := reader.ReadlinkIfPossible(name)
+		if err != nil {
+			return err
+		}
+
+		moved := path.Join(newname, strings.TrimPrefix(name, oldname))
+
+		target, err := ResolveLinkTarget(dest, moved)
+		if err != nil || check == nil {
+			return err
+		}
+
+		return check(target, moved)
+	})
+}
+
+func cleanPath(name string) string {
+	return path.Clean("/" + name)
+}
+
+func escapesRoot(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, "../")
+}
@@ -0,0 +1,257 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestResolveLinkTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		link   string
+		want   string
+		err    error
+	}{
+		{target: "b", link: "/a/l", want: "/a/b"},
+		{target: "../b", link: "/a/l", want: "/b"},
+		{target: "/b/c", link: "/a/l", want: "/b/c"},
+		{target: "..", link: "/a/l", want: "/"},
+		{target: "../..", link: "/a/l", err: ErrLinkOutsideRoot},
+		{target: "/../b", link: "/a/l", err: ErrLinkOutsideRoot},
+		{target: "", link: "/a/l", err: ErrLinkOutsideRoot},
+	}
+
+	for _, test := range tests {
+		got, err := ResolveLinkTarget(test.target, test.link)
+		if !errors.Is(err, test.err) || got != test.want {
+			t.Errorf(
+				"ResolveLinkTarget(%q, %q) = %q, %v, want %q, %v",
+				test.target, test.link, got, err, test.want, test.err,
+			)
+		}
+	}
+}
+
+// newLinkTestRoot returns a storage root with the x/y directory, next to a
+// secret file that must never be reachable through it.
+func newLinkTestRoot(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+
+	err := os.MkdirAll(filepath.Join(root, "b", "x", "y"), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.MkdirAll(filepath.Join(root, "x", "y"), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{filepath.Join(dir, "secret"), filepath.Join(root, "secret")} {
+		err = os.WriteFile(name, []byte(name), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestSymlinkChainEscape(t *testing.T) {
+	// NB: the first link points to a directory above its own, which the
+	// target of the second one climbs out of.
+	tests := []struct {
+		name    string
+		storage func(root string) Interface
+		dir     string
+		target  string
+	}{
+		{
+			name:    "posix",
+			storage: NewPosix,
+			dir:     "../..",
+			target:  "../../../secret",
+		},
+		{
+			name: "base path",
+			storage: func(root string) Interface {
+				return NewBasePath(NewPosix(root), "/b")
+			},
+			dir:    "../..",
+			target: "../../../secret",
+		},
+		{
+			// NB: the target of the second link looks like /x/y/secret,
+			// but is /secret, which is not granted.
+			name: "granted",
+			storage: func(root string) Interface {
+				return NewGranted(NewPosix(root), []Grant{
+					{Prefix: "/x", Read: true, Write: true},
+				})
+			},
+			dir:    "..",
+			target: "../secret",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := newLinkTestRoot(t)
+			fs := test.storage(filepath.Join(dir, "root"))
+
+			//nolint: forcetypeassert
+			linker := fs.(afero.Linker)
+
+			err := linker.SymlinkIfPossible(test.dir, "/x/y/d")
+			if err != nil {
+				t.Fatalf("failed to link within the root: %v", err)
+			}
+
+			err = linker.SymlinkIfPossible(test.target, "/x/y/d/e")
+			if !errors.Is(err, ErrLinkInLinkedDir) {
+				t.Fatalf("linked through a linked directory: %v", err)
+			}
+
+			for _, name := range []string{"/e", "/x/e", "/x/y/d/e"} {
+				data, err := afero.ReadFile(fs, name)
+				if err == nil {
+					t.Fatalf("read through %s: %s", name, data)
+				}
+			}
+		})
+	}
+}
+
+func TestRenameLinkEscape(t *testing.T) {
+	dir := newLinkTestRoot(t)
+	fs := NewPosix(filepath.Join(dir, "root"))
+
+	//nolint: forcetypeassert
+	linker := fs.(afero.Linker)
+
+	// NB: from x/y, ../../secret is the secret of the root, but moved one
+	// level closer to the root it is the secret next to it.
+	err := linker.SymlinkIfPossible("../../secret", "/x/y/e")
+	if err != nil {
+		t.Fatalf("failed to link within the root: %v", err)
+	}
+
+	err = fs.Rename("/x/y", "/q")
+	if !errors.Is(err, ErrLinkOutsideRoot) {
+		t.Fatalf("moved the directory of a link out of the root: %v", err)
+	}
+
+	err = fs.Rename("/x/y/e", "/x/e")
+	if !errors.Is(err, ErrLinkOutsideRoot) {
+		t.Fatalf("moved a link out of the root: %v", err)
+	}
+
+	//nolint: forcetypeassert
+	err = fs.(HardLinker).LinkIfPossible("/x/y/e", "/e")
+	if !errors.Is(err, ErrLinkOutsideRoot) {
+		t.Fatalf("hard linked a link out of the root: %v", err)
+	}
+
+	err = fs.Rename("/x/y", "/x/z")
+	if err != nil {
+		t.Fatalf("failed to move a link at the same depth: %v", err)
+	}
+
+	data, err := afero.ReadFile(fs, "/x/z/e")
+	if err != nil || string(data) != filepath.Join(dir, "root", "secret") {
+		t.Fatalf("moved link reads %q, %v", data, err)
+	}
+}
+
+func TestMoveLinkIntoLinkedDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage func(root string) Interface
+	}{
+		{name: "posix", storage: NewPosix},
+		{
+			name: "base path",
+			storage: func(root string) Interface {
+				return NewBasePath(NewPosix(root), "/b")
+			},
+		},
+		{
+			name: "granted",
+			storage: func(root string) Interface {
+				return NewGranted(NewPosix(root), []Grant{
+					{Prefix: "/", Read: true, Write: true, Delete: true},
+				})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := newLinkTestRoot(t)
+			fs := test.storage(filepath.Join(dir, "root"))
+
+			for _, name := range []string{"/a/b/c", "/q/r"} {
+				err := fs.MkdirAll(name, 0o700)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			//nolint: forcetypeassert
+			linker := fs.(afero.Linker)
+
+			err := linker.SymlinkIfPossible("/", "/x/y/d")
+			if err != nil {
+				t.Fatalf("failed to link to the root: %v", err)
+			}
+
+			// NB: the link is written as ../../../secret, which climbs out
+			// of the root from below /x/y/d/q/r, the directory /q/r.
+			err = linker.SymlinkIfPossible("/secret", "/a/b/c/l")
+			if err != nil {
+				t.Fatalf("failed to link within the root: %v", err)
+			}
+
+			err = fs.Rename("/a/b/c/l", "/x/y/d/q/r/l")
+			if !errors.Is(err, ErrLinkInLinkedDir) {
+				t.Fatalf("moved a link below a linked directory: %v", err)
+			}
+
+			err = fs.Rename("/a/b", "/x/y/d/q/b")
+			if !errors.Is(err, ErrLinkInLinkedDir) {
+				t.Fatalf("moved a directory of links below a linked directory: %v", err)
+			}
+
+			//nolint: forcetypeassert
+			err = fs.(HardLinker).LinkIfPossible("/a/b/c/l", "/x/y/d/q/r/l")
+			if !errors.Is(err, ErrLinkInLinkedDir) {
+				t.Fatalf("hard linked a link below a linked directory: %v", err)
+			}
+
+			for _, name := range []string{"/q/r/l", "/q/b/c/l", "/x/y/d/q/r/l"} {
+				data, err := afero.ReadFile(fs, name)
+				if err == nil {
+					t.Fatalf("read through %s: %s", name, data)
+				}
+			}
+
+			// NB: files that are not links can still be moved there.
+			err = afero.WriteFile(fs, "/a/f", nil, 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = fs.Rename("/a/f", "/x/y/d/q/r/f")
+			if err != nil {
+				t.Fatalf("failed to move a file below a linked directory: %v", err)
+			}
+		})
+	}
+}
@@ -1,7 +1,119 @@
 package storage
 
-import "github.com/spf13/afero"
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Symlinker = &Posix{}
+	_ HardLinker      = &Posix{}
+)
+
+// Posix is a storage backend rooted at a directory on the local filesystem.
+type Posix struct {
+	*afero.BasePathFs
+
+	root string
+}
 
 func NewPosix(rootPath string) Interface {
-	return afero.NewBasePathFs(afero.NewOsFs(), rootPath)
+	//nolint: forcetypeassert
+	return &Posix{
+		BasePathFs: afero.NewBasePathFs(afero.NewOsFs(), rootPath).(*afero.BasePathFs),
+		root:       filepath.Clean(rootPath),
+	}
+}
+
+// SymlinkIfPossible creates newname as a symbolic link to oldname. The link is
+// always written relative to the directory containing newname so that host
+// paths never end up inside the storage root, and never in a directory reached
+// through another link, see checkLinkParents.
+func (p *Posix) SymlinkIfPossible(oldname, newname string) error {
+	target, err := ResolveLinkTarget(oldname, newname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	realNew, err := p.RealPath(newname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	err = checkLinkParents(p.BasePathFs, newname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	rel, err := filepath.Rel(filepath.Dir(cleanPath(newname)), target)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	return os.Symlink(rel, realNew)
+}
+
+// ReadlinkIfPossible returns the destination of the named symbolic link.
+// Destinations that point outside of the storage root are reported as an
+// error rather than returned to the caller.
+func (p *Posix) ReadlinkIfPossible(name string) (string, error) {
+	realName, err := p.RealPath(name)
+	if err != nil {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: err}
+	}
+
+	dest, err := os.Readlink(realName)
+	if err != nil {
+		return "", err
+	}
+
+	if filepath.IsAbs(dest) {
+		rel, err := filepath.Rel(p.root, dest)
+		if err != nil || escapesRoot(rel) {
+			return "", &os.PathError{Op: "readlink", Path: name, Err: ErrLinkOutsideRoot}
+		}
+
+		return "/" + filepath.ToSlash(rel), nil
+	}
+
+	_, err = ResolveLinkTarget(dest, name)
+	if err != nil {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: err}
+	}
+
+	return filepath.ToSlash(dest), nil
+}
+
+// Rename moves oldname to newname, unless it would take links where their
+// target is outside of the storage root, see checkMovedLinks.
+func (p *Posix) Rename(oldname, newname string) error {
+	err := checkMovedLinks(p, oldname, newname, nil)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+
+	return p.BasePathFs.Rename(oldname, newname)
+}
+
+// LinkIfPossible creates newname as a hard link to oldname.
+func (p *Posix) LinkIfPossible(oldname, newname string) error {
+	// NB: hard links to a symbolic link are links too.
+	err := checkMovedLinks(p, oldname, newname, nil)
+	if err != nil {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: err}
+	}
+
+	realOld, err := p.RealPath(oldname)
+	if err != nil {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: err}
+	}
+
+	realNew, err := p.RealPath(newname)
+	if err != nil {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: err}
+	}
+
+	return os.Link(realOld, realNew)
 }
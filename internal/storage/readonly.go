@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Symlinker = &ReadOnly{}
+	_ HardLinker      = &ReadOnly{}
+	_ UsageReporter   = &ReadOnly{}
+)
+
+// ReadOnly rejects every operation that would modify the underlying storage
+// with a permission error.
+type ReadOnly struct {
+	*afero.ReadOnlyFs
+
+	source Interface
+}
+
+func NewReadOnly(source Interface) Interface {
+	//nolint: forcetypeassert
+	return &ReadOnly{
+		ReadOnlyFs: afero.NewReadOnlyFs(source).(*afero.ReadOnlyFs),
+		source:     source,
+	}
+}
+
+func (r *ReadOnly) LinkIfPossible(oldname, newname string) error {
+	return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: syscall.EPERM}
+}
+
+func (r *ReadOnly) Usage(name string) (*Usage, error) {
+	return UsageOf(r.source, name)
+}
@@ -0,0 +1,55 @@
+package storage
+
+const (
+	// Synthetic capacity reported for backends that cannot measure their
+	// own usage. 1 TiB in 4 KiB blocks.
+	SyntheticBlockSize = 4096
+	SyntheticBlocks    = 1 << 28
+	SyntheticFiles     = 1 << 32
+
+	// Longest file name reported for backends that do not impose one.
+	DefaultNameMax = 255
+)
+
+// Usage describes the capacity of a storage backend in the terms used by
+// statvfs(3).
+type Usage struct {
+	BlockSize   uint64
+	Blocks      uint64
+	BlocksFree  uint64
+	BlocksAvail uint64
+	Files       uint64
+	FilesFree   uint64
+	NameMax     uint64
+}
+
+// UsageReporter is an optional interface implemented by storage backends that
+// can report real filesystem usage.
+type UsageReporter interface {
+	Usage(name string) (*Usage, error)
+}
+
+// UsageOf returns the usage of the filesystem containing name. Backends that
+// do not implement UsageReporter get synthetic, mostly empty, values so that
+// clients such as df still have something sensible to show.
+func UsageOf(fs Interface, name string) (*Usage, error) {
+	reporter, ok := fs.(UsageReporter)
+	if ok {
+		return reporter.Usage(name)
+	}
+
+	_, err := fs.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Usage{
+		BlockSize:   SyntheticBlockSize,
+		Blocks:      SyntheticBlocks,
+		BlocksFree:  SyntheticBlocks,
+		BlocksAvail: SyntheticBlocks,
+		Files:       SyntheticFiles,
+		FilesFree:   SyntheticFiles,
+		NameMax:     DefaultNameMax,
+	}, nil
+}
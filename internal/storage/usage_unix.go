@@ -0,0 +1,36 @@
+//go:build linux || darwin
+
+package storage
+
+import (
+	"os"
+	"syscall"
+)
+
+var _ UsageReporter = &Posix{}
+
+// Usage reports the real capacity of the filesystem backing the posix root.
+func (p *Posix) Usage(name string) (*Usage, error) {
+	realName, err := p.RealPath(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "statfs", Path: name, Err: err}
+	}
+
+	var st syscall.Statfs_t
+
+	err = syscall.Statfs(realName, &st)
+	if err != nil {
+		return nil, &os.PathError{Op: "statfs", Path: name, Err: err}
+	}
+
+	//nolint: gosec,unconvert
+	return &Usage{
+		BlockSize:   uint64(st.Bsize),
+		Blocks:      st.Blocks,
+		BlocksFree:  st.Bfree,
+		BlocksAvail: st.Bavail,
+		Files:       st.Files,
+		FilesFree:   st.Ffree,
+		NameMax:     DefaultNameMax,
+	}, nil
+}
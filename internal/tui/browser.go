@@ -0,0 +1,372 @@
+// Package tui implements the interactive file browser served to SSH sessions
+// that request a terminal without a command.
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cmp0st/byte/internal/storage"
+	"github.com/spf13/afero"
+)
+
+const DefaultDirectoryPerms = 0o700
+
+// NB: the first window size message arrives right after the program starts,
+// these only matter for terminals that never report a size.
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+var ErrInvalidName = errors.New("invalid name")
+
+type mode int
+
+const (
+	modeBrowse mode = iota
+	modePreview
+	modeInput
+	modeConfirm
+)
+
+// Browser is a Bubble Tea model for navigating a storage backend.
+type Browser struct {
+	storage storage.Interface
+	logger  *slog.Logger
+	styles  styles
+
+	width  int
+	height int
+
+	cwd     string
+	entries []os.FileInfo
+	cursor  int
+	offset  int
+
+	mode mode
+	op   *operation
+
+	// input is the text typed for the name of a rename or mkdir operation.
+	input string
+
+	preview       *preview
+	previewOffset int
+
+	status string
+	err    error
+}
+
+var _ tea.Model = &Browser{}
+
+func NewBrowser(s storage.Interface, r *lipgloss.Renderer, logger *slog.Logger) *Browser {
+	b := &Browser{
+		storage: s,
+		logger:  logger,
+		styles:  newStyles(r),
+		width:   defaultWidth,
+		height:  defaultHeight,
+		cwd:     "/",
+	}
+
+	b.load()
+
+	return b
+}
+
+func (b *Browser) Init() tea.Cmd {
+	return nil
+}
+
+func (b *Browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// NB: some clients report a zero sized window when they are not
+		// attached to a real terminal.
+		if msg.Width > 0 && msg.Height > 0 {
+			b.width = msg.Width
+			b.height = msg.Height
+			b.clampOffset()
+		}
+
+		return b, nil
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return b, tea.Quit
+		}
+
+		switch b.mode {
+		case modeBrowse:
+			return b.updateBrowse(msg)
+		case modePreview:
+			return b.updatePreview(msg)
+		case modeInput:
+			return b.updateInput(msg)
+		case modeConfirm:
+			return b.updateConfirm(msg)
+		}
+	}
+
+	return b, nil
+}
+
+func (b *Browser) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b.status = ""
+	b.err = nil
+
+	switch msg.String() {
+	case "q":
+		return b, tea.Quit
+	case "up", "k":
+		b.move(-1)
+	case "down", "j":
+		b.move(1)
+	case "pgup":
+		b.move(-b.listHeight())
+	case "pgdown":
+		b.move(b.listHeight())
+	case "home", "g":
+		b.move(-len(b.entries))
+	case "end", "G":
+		b.move(len(b.entries))
+	case "enter", "right", "l":
+		b.open()
+	case "backspace", "left", "h":
+		b.up()
+	case "d", "delete":
+		entry := b.selected()
+		if entry != nil {
+			b.op = &operation{kind: opDelete, entry: entry}
+			b.mode = modeConfirm
+		}
+	case "r":
+		entry := b.selected()
+		if entry != nil {
+			b.op = &operation{kind: opRename, entry: entry}
+			b.input = entry.Name()
+			b.mode = modeInput
+		}
+	case "n":
+		b.op = &operation{kind: opMkdir}
+		b.input = ""
+		b.mode = modeInput
+	}
+
+	return b, nil
+}
+
+func (b *Browser) updatePreview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rows := b.previewHeight()
+	last := max(len(b.preview.lines)-rows, 0)
+
+	switch msg.String() {
+	case "q", "esc", "backspace", "left", "h":
+		b.preview = nil
+		b.mode = modeBrowse
+	case "up", "k":
+		b.previewOffset = max(b.previewOffset-1, 0)
+	case "down", "j":
+		b.previewOffset = min(b.previewOffset+1, last)
+	case "pgup":
+		b.previewOffset = max(b.previewOffset-rows, 0)
+	case "pgdown", " ":
+		b.previewOffset = min(b.previewOffset+rows, last)
+	case "home", "g":
+		b.previewOffset = 0
+	case "end", "G":
+		b.previewOffset = last
+	}
+
+	return b, nil
+}
+
+func (b *Browser) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		b.cancel()
+	case tea.KeyEnter:
+		err := validateName(b.input)
+		if err != nil {
+			b.err = err
+
+			return b, nil
+		}
+
+		b.err = nil
+		b.op.name = b.input
+		b.mode = modeConfirm
+	case tea.KeyBackspace:
+		runes := []rune(b.input)
+		if len(runes) > 0 {
+			b.input = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		b.input += string(msg.Runes)
+	}
+
+	return b, nil
+}
+
+func (b *Browser) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() != "y" && msg.String() != "Y" {
+		b.cancel()
+
+		return b, nil
+	}
+
+	op := b.op
+	b.op = nil
+	b.mode = modeBrowse
+
+	logger := b.logger.With(
+		slog.String("op", op.kind.String()),
+		slog.String("path", op.path(b.cwd)),
+	)
+	if op.name != "" {
+		logger = logger.With(slog.String("name", op.name))
+	}
+
+	err := op.run(b.storage, b.cwd)
+	if err != nil {
+		logger.Error("browser operation failed", slog.Any("err", err))
+
+		b.err = err
+	} else {
+		logger.Info("browser operation completed")
+
+		b.status = op.done()
+	}
+
+	b.load()
+
+	if op.kind != opDelete {
+		b.selectName(op.name)
+	}
+
+	return b, nil
+}
+
+func (b *Browser) cancel() {
+	b.op = nil
+	b.input = ""
+	b.mode = modeBrowse
+	b.status = "cancelled"
+}
+
+func (b *Browser) selected() os.FileInfo {
+	if len(b.entries) == 0 {
+		return nil
+	}
+
+	return b.entries[b.cursor]
+}
+
+func (b *Browser) move(delta int) {
+	if len(b.entries) == 0 {
+		return
+	}
+
+	b.cursor = min(max(b.cursor+delta, 0), len(b.entries)-1)
+	b.clampOffset()
+}
+
+// clampOffset scrolls the listing so the cursor stays visible.
+func (b *Browser) clampOffset() {
+	rows := b.listHeight()
+
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+
+	if b.cursor >= b.offset+rows {
+		b.offset = b.cursor - rows + 1
+	}
+
+	b.offset = max(min(b.offset, len(b.entries)-rows), 0)
+}
+
+func (b *Browser) open() {
+	entry := b.selected()
+	if entry == nil {
+		return
+	}
+
+	name := path.Join(b.cwd, entry.Name())
+
+	if entry.IsDir() {
+		b.cwd = name
+		b.cursor = 0
+		b.offset = 0
+		b.load()
+
+		return
+	}
+
+	p, err := loadPreview(b.storage, name)
+	if err != nil {
+		b.err = err
+
+		return
+	}
+
+	b.preview = p
+	b.previewOffset = 0
+	b.mode = modePreview
+}
+
+func (b *Browser) up() {
+	if b.cwd == "/" {
+		return
+	}
+
+	child := path.Base(b.cwd)
+	b.cwd = path.Dir(b.cwd)
+	b.load()
+	b.selectName(child)
+}
+
+func (b *Browser) selectName(name string) {
+	for i, entry := range b.entries {
+		if entry.Name() == name {
+			b.cursor = i
+			b.clampOffset()
+
+			return
+		}
+	}
+}
+
+// load reads the current directory, listing directories before files.
+func (b *Browser) load() {
+	entries, err := afero.ReadDir(b.storage, b.cwd)
+	if err != nil {
+		b.logger.Error("failed to list directory", slog.String("path", b.cwd), slog.Any("err", err))
+
+		b.err = fmt.Errorf("failed to list %s: %w", b.cwd, err)
+		b.entries = nil
+	} else {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].IsDir() && !entries[j].IsDir()
+		})
+
+		b.entries = entries
+	}
+
+	b.cursor = min(b.cursor, max(len(b.entries)-1, 0))
+	b.clampOffset()
+}
+
+func validateName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\x00") {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+
+	return nil
+}
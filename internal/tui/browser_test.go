@@ -0,0 +1,285 @@
+package tui
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/afero"
+
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+// newTestBrowser returns a browser of st sized like a small terminal.
+func newTestBrowser(st storage.Interface) *Browser {
+	b := NewBrowser(st, lipgloss.NewRenderer(io.Discard), slog.New(slog.DiscardHandler))
+	b.Update(tea.WindowSizeMsg{Width: 60, Height: 12})
+
+	return b
+}
+
+// press sends keys to b, one message per key name or typed rune.
+func press(b *Browser, keys ...string) {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "backspace":
+			msg = tea.KeyMsg{Type: tea.KeyBackspace}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+
+		b.Update(msg)
+	}
+}
+
+// typeText sends each rune of text to b.
+func typeText(b *Browser, text string) {
+	for _, r := range text {
+		press(b, string(r))
+	}
+}
+
+func entryNames(b *Browser) []string {
+	var names []string
+	for _, entry := range b.entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+func newTestStorage(t *testing.T) storage.Interface {
+	t.Helper()
+
+	st := storage.NewInMemory()
+
+	err := st.MkdirAll("/photos/2024", 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string]string{
+		"/a.txt":            "hello",
+		"/photos/notes.txt": "notes",
+	} {
+		err = afero.WriteFile(st, name, []byte(data), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return st
+}
+
+func TestBrowserNavigate(t *testing.T) {
+	b := newTestBrowser(newTestStorage(t))
+
+	if got, want := entryNames(b), []string{"photos", "a.txt"}; !slices.Equal(got, want) {
+		t.Fatalf("listed %v, want %v with directories first", got, want)
+	}
+
+	press(b, "enter")
+
+	if b.cwd != "/photos" {
+		t.Fatalf("opened %q, want /photos", b.cwd)
+	}
+
+	if got, want := entryNames(b), []string{"2024", "notes.txt"}; !slices.Equal(got, want) {
+		t.Errorf("listed %v, want %v", got, want)
+	}
+
+	press(b, "j", "enter")
+
+	if b.mode != modePreview || b.preview == nil || b.preview.name != "/photos/notes.txt" {
+		t.Fatalf("previewing %v in mode %v, want /photos/notes.txt", b.preview, b.mode)
+	}
+
+	press(b, "esc", "h")
+
+	if b.cwd != "/" || b.mode != modeBrowse {
+		t.Fatalf("went back to %q in mode %v, want / in browse mode", b.cwd, b.mode)
+	}
+
+	if entry := b.selected(); entry == nil || entry.Name() != "photos" {
+		t.Errorf("selected %v after going back, want photos", entry)
+	}
+
+	// NB: the cursor stops at either end of the listing.
+	press(b, "j", "j", "j")
+
+	if entry := b.selected(); entry.Name() != "a.txt" {
+		t.Errorf("selected %s past the end, want a.txt", entry.Name())
+	}
+
+	press(b, "h")
+
+	if b.cwd != "/" {
+		t.Errorf("went up from the root to %q", b.cwd)
+	}
+}
+
+func TestBrowserOperations(t *testing.T) {
+	st := newTestStorage(t)
+	b := newTestBrowser(st)
+
+	press(b, "n")
+	typeText(b, "albums")
+	press(b, "enter", "y")
+
+	info, err := st.Stat("/albums")
+	if err != nil || !info.IsDir() {
+		t.Fatalf("created /albums: %v, want a directory", err)
+	}
+
+	if entry := b.selected(); entry == nil || entry.Name() != "albums" {
+		t.Errorf("selected %v after mkdir, want albums", entry)
+	}
+
+	press(b, "r", "backspace", "backspace")
+	typeText(b, "m")
+	press(b, "enter", "y")
+
+	_, err = st.Stat("/album")
+	if err != nil {
+		t.Errorf("renamed /albums to /album: %v", err)
+	}
+
+	b.selectName("photos")
+	press(b, "d", "n")
+
+	_, err = st.Stat("/photos/notes.txt")
+	if err != nil || b.status != "cancelled" {
+		t.Errorf("cancelled delete: %v, status %q", err, b.status)
+	}
+
+	press(b, "d", "y")
+
+	_, err = st.Stat("/photos")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("deleted /photos and its contents: %v, want %v", err, os.ErrNotExist)
+	}
+
+	if got, want := entryNames(b), []string{"album", "a.txt"}; !slices.Equal(got, want) {
+		t.Errorf("listed %v after delete, want %v", got, want)
+	}
+}
+
+func TestBrowserInvalidName(t *testing.T) {
+	st := newTestStorage(t)
+	b := newTestBrowser(st)
+
+	press(b, "n")
+	typeText(b, "../etc")
+	press(b, "enter")
+
+	if !errors.Is(b.err, ErrInvalidName) || b.mode != modeInput {
+		t.Fatalf("entered ../etc: %v in mode %v, want %v", b.err, b.mode, ErrInvalidName)
+	}
+
+	press(b, "esc")
+
+	if b.mode != modeBrowse || b.op != nil {
+		t.Errorf("escaped to mode %v with operation %v, want browse mode", b.mode, b.op)
+	}
+}
+
+func TestBrowserReadOnly(t *testing.T) {
+	st := newTestStorage(t)
+	b := newTestBrowser(storage.NewReadOnly(st))
+
+	press(b, "j", "d", "y")
+
+	if b.err == nil {
+		t.Error("deleted a file of read only storage")
+	}
+
+	_, err := st.Stat("/a.txt")
+	if err != nil {
+		t.Errorf("file of read only storage: %v", err)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "photos", valid: true},
+		{name: "my photos", valid: true},
+		{name: ".hidden", valid: true},
+		{name: ""},
+		{name: "."},
+		{name: ".."},
+		{name: "a/b"},
+		{name: "a\x00b"},
+	}
+
+	for _, test := range tests {
+		err := validateName(test.name)
+		if (err == nil) != test.valid {
+			t.Errorf("validateName(%q) = %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestLoadPreview(t *testing.T) {
+	st := storage.NewInMemory()
+
+	files := map[string][]byte{
+		"/text":   []byte("hello\r\n\tworld \x1b[31mred\n"),
+		"/binary": {0xff, 0xd8, 0xff, 0xe0, 0, 1, 2},
+		"/large":  []byte(strings.Repeat("a", PreviewLimit+1)),
+	}
+
+	for name, data := range files {
+		err := afero.WriteFile(st, name, data, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := loadPreview(st, "/text")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"hello", "    world ?[31mred", ""}
+	if p.binary || p.truncated || !slices.Equal(p.lines, want) {
+		t.Errorf("preview of text = %+v, want lines %q", p, want)
+	}
+
+	p, err = loadPreview(st, "/binary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.binary || len(p.lines) != 0 {
+		t.Errorf("preview of binary = %+v, want binary without lines", p)
+	}
+
+	p, err = loadPreview(st, "/large")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.truncated || p.size != PreviewLimit+1 || len(p.lines[0]) != PreviewLimit {
+		t.Errorf("preview of large file truncated %v, size %d", p.truncated, p.size)
+	}
+
+	_, err = loadPreview(st, "/missing")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("preview of missing file = %v, want %v", err, os.ErrNotExist)
+	}
+}
@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+type opKind int
+
+const (
+	opDelete opKind = iota
+	opRename
+	opMkdir
+)
+
+func (k opKind) String() string {
+	switch k {
+	case opDelete:
+		return "delete"
+	case opRename:
+		return "rename"
+	case opMkdir:
+		return "mkdir"
+	}
+
+	return "unknown"
+}
+
+// operation is a modification of storage awaiting confirmation.
+type operation struct {
+	kind opKind
+
+	// entry is the entry being deleted or renamed.
+	entry os.FileInfo
+
+	// name is the new name for rename and mkdir.
+	name string
+}
+
+func (o *operation) path(cwd string) string {
+	if o.entry == nil {
+		return path.Join(cwd, o.name)
+	}
+
+	return path.Join(cwd, o.entry.Name())
+}
+
+func (o *operation) prompt() string {
+	switch o.kind {
+	case opDelete:
+		if o.entry.IsDir() {
+			return fmt.Sprintf("Delete %s/ and everything in it?", sanitize(o.entry.Name()))
+		}
+
+		return fmt.Sprintf("Delete %s?", sanitize(o.entry.Name()))
+	case opRename:
+		return fmt.Sprintf("Rename %s to %s?", sanitize(o.entry.Name()), sanitize(o.name))
+	case opMkdir:
+		return fmt.Sprintf("Create directory %s?", sanitize(o.name))
+	}
+
+	return ""
+}
+
+func (o *operation) inputPrompt() string {
+	if o.kind == opRename {
+		return "Rename to:"
+	}
+
+	return "New directory:"
+}
+
+func (o *operation) done() string {
+	switch o.kind {
+	case opDelete:
+		return "deleted " + sanitize(o.entry.Name())
+	case opRename:
+		return "renamed to " + sanitize(o.name)
+	case opMkdir:
+		return "created " + sanitize(o.name)
+	}
+
+	return ""
+}
+
+func (o *operation) run(s storage.Interface, cwd string) error {
+	switch o.kind {
+	case opDelete:
+		if o.entry.IsDir() {
+			return s.RemoveAll(o.path(cwd))
+		}
+
+		return s.Remove(o.path(cwd))
+	case opRename:
+		return s.Rename(o.path(cwd), path.Join(cwd, o.name))
+	case opMkdir:
+		return s.Mkdir(o.path(cwd), DefaultDirectoryPerms)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/cmp0st/byte/internal/storage"
+)
+
+// PreviewLimit caps how much of a file is read for preview.
+const PreviewLimit = 64 * 1024
+
+const tabWidth = 4
+
+// preview is the content of a text file prepared for display.
+type preview struct {
+	name  string
+	size  int64
+	lines []string
+
+	// binary is set when the file does not look like text, in which case
+	// lines is empty.
+	binary    bool
+	truncated bool
+}
+
+func loadPreview(s storage.Interface, name string) (*preview, error) {
+	file, err := s.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	//nolint: errcheck
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, PreviewLimit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	p := &preview{
+		name:      name,
+		size:      info.Size(),
+		truncated: info.Size() > PreviewLimit,
+	}
+
+	if !strings.HasPrefix(http.DetectContentType(data), "text/") {
+		p.binary = true
+
+		return p, nil
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\t", strings.Repeat(" ", tabWidth))
+
+	p.lines = strings.Split(sanitize(text), "\n")
+
+	return p, nil
+}
+
+// sanitize replaces control characters so file names and contents cannot
+// inject escape sequences into the client terminal.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' {
+			return r
+		}
+
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return '?'
+		}
+
+		return r
+	}, s)
+}
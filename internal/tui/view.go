@@ -0,0 +1,253 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Colors follow the design tokens of the web UI, see
+// docs/design/01-configurable-color-system.md.
+var (
+	colorTextPrimary   = lipgloss.AdaptiveColor{Light: "#111827", Dark: "#f9fafb"}
+	colorTextSecondary = lipgloss.AdaptiveColor{Light: "#374151", Dark: "#d1d5db"}
+	colorTextMuted     = lipgloss.AdaptiveColor{Light: "#6b7280", Dark: "#9ca3af"}
+	colorTextDisabled  = lipgloss.AdaptiveColor{Light: "#9ca3af", Dark: "#6b7280"}
+	colorBgTertiary    = lipgloss.AdaptiveColor{Light: "#f3f4f6", Dark: "#374151"}
+
+	colorInteractivePrimary   = lipgloss.Color("#10b981")
+	colorInteractiveSecondary = lipgloss.Color("#3b82f6")
+	colorInteractiveAccent    = lipgloss.Color("#f59e0b")
+	colorInteractiveDanger    = lipgloss.Color("#ef4444")
+)
+
+// NB: breadcrumb, status and help lines plus the blank lines around the
+// listing.
+const chromeHeight = 5
+
+const (
+	sizeWidth = 9
+	timeWidth = 16
+	timeFmt   = "2006-01-02 15:04"
+)
+
+type styles struct {
+	prompt    lipgloss.Style
+	command   lipgloss.Style
+	link      lipgloss.Style
+	current   lipgloss.Style
+	separator lipgloss.Style
+
+	dir      lipgloss.Style
+	file     lipgloss.Style
+	selected lipgloss.Style
+	meta     lipgloss.Style
+
+	status  lipgloss.Style
+	err     lipgloss.Style
+	confirm lipgloss.Style
+	help    lipgloss.Style
+}
+
+func newStyles(r *lipgloss.Renderer) styles {
+	return styles{
+		prompt:    r.NewStyle().Foreground(colorTextMuted),
+		command:   r.NewStyle().Foreground(colorTextSecondary),
+		link:      r.NewStyle().Foreground(colorInteractivePrimary),
+		current:   r.NewStyle().Foreground(colorTextPrimary).Bold(true),
+		separator: r.NewStyle().Foreground(colorTextMuted),
+
+		dir:      r.NewStyle().Foreground(colorInteractiveSecondary).Bold(true),
+		file:     r.NewStyle().Foreground(colorTextSecondary),
+		selected: r.NewStyle().Background(colorBgTertiary),
+		meta:     r.NewStyle().Foreground(colorTextMuted),
+
+		status:  r.NewStyle().Foreground(colorInteractivePrimary),
+		err:     r.NewStyle().Foreground(colorInteractiveDanger),
+		confirm: r.NewStyle().Foreground(colorInteractiveAccent).Bold(true),
+		help:    r.NewStyle().Foreground(colorTextDisabled),
+	}
+}
+
+func (b *Browser) listHeight() int {
+	return max(b.height-chromeHeight, 1)
+}
+
+func (b *Browser) previewHeight() int {
+	return max(b.height-chromeHeight, 1)
+}
+
+func (b *Browser) View() string {
+	var sb strings.Builder
+
+	if b.mode == modePreview {
+		sb.WriteString(b.breadcrumbs("cat", b.preview.name))
+		sb.WriteString("\n\n")
+		sb.WriteString(b.previewView())
+	} else {
+		sb.WriteString(b.breadcrumbs("cd", b.cwd))
+		sb.WriteString("\n\n")
+		sb.WriteString(b.listView())
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(b.statusView())
+	sb.WriteString("\n")
+	sb.WriteString(b.helpView())
+
+	return sb.String()
+}
+
+// breadcrumbs renders name the same way as the web UI: a shell prompt
+// followed by each path segment, starting from root.
+func (b *Browser) breadcrumbs(command, name string) string {
+	segments := []string{"root"}
+
+	for _, segment := range strings.Split(name, "/") {
+		if segment != "" {
+			segments = append(segments, sanitize(segment))
+		}
+	}
+
+	parts := make([]string, len(segments))
+	for i, segment := range segments {
+		if i == len(segments)-1 {
+			parts[i] = b.styles.current.Render(segment)
+		} else {
+			parts[i] = b.styles.link.Render(segment)
+		}
+	}
+
+	line := b.styles.prompt.Render("$") + " " +
+		b.styles.command.Render(command) + " " +
+		strings.Join(parts, b.styles.separator.Render(" / "))
+
+	return ansi.Truncate(line, b.width, "…")
+}
+
+func (b *Browser) listView() string {
+	rows := b.listHeight()
+	lines := make([]string, 0, rows)
+
+	if len(b.entries) == 0 {
+		lines = append(lines, b.styles.meta.Render("  (empty)"))
+	}
+
+	end := min(b.offset+rows, len(b.entries))
+	for i := b.offset; i < end; i++ {
+		lines = append(lines, b.entryView(b.entries[i], i == b.cursor))
+	}
+
+	for len(lines) < rows {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (b *Browser) entryView(entry os.FileInfo, selected bool) string {
+	nameWidth := max(b.width-2-sizeWidth-timeWidth-2, 1)
+
+	name := sanitize(entry.Name())
+	size := humanSize(entry.Size())
+	style := b.styles.file
+
+	if entry.IsDir() {
+		name += "/"
+		size = "-"
+		style = b.styles.dir
+	}
+
+	marker := "  "
+	if selected {
+		marker = b.styles.link.Render("›") + " "
+	}
+
+	name = ansi.Truncate(name, nameWidth, "…")
+	name += strings.Repeat(" ", nameWidth-ansi.StringWidth(name))
+
+	meta := fmt.Sprintf(" %*s %s", sizeWidth, size, entry.ModTime().Format(timeFmt))
+
+	if selected {
+		return marker + b.styles.selected.Inherit(style).Render(name) +
+			b.styles.selected.Inherit(b.styles.meta).Render(meta)
+	}
+
+	return marker + style.Render(name) + b.styles.meta.Render(meta)
+}
+
+func (b *Browser) previewView() string {
+	rows := b.previewHeight()
+	lines := make([]string, 0, rows)
+
+	switch {
+	case b.preview.binary:
+		lines = append(lines, b.styles.meta.Render(
+			fmt.Sprintf("binary file, %s, no preview", humanSize(b.preview.size)),
+		))
+	default:
+		end := min(b.previewOffset+rows, len(b.preview.lines))
+		for _, line := range b.preview.lines[b.previewOffset:end] {
+			lines = append(lines, ansi.Truncate(line, b.width, "…"))
+		}
+	}
+
+	for len(lines) < rows {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (b *Browser) statusView() string {
+	switch {
+	case b.mode == modeInput:
+		return b.styles.confirm.Render(b.op.inputPrompt()) + " " + sanitize(b.input) + "█"
+	case b.mode == modeConfirm:
+		return b.styles.confirm.Render(b.op.prompt() + " [y/N]")
+	case b.err != nil:
+		return b.styles.err.Render(ansi.Truncate(sanitize(b.err.Error()), b.width, "…"))
+	case b.mode == modePreview && b.preview.truncated:
+		return b.styles.meta.Render(fmt.Sprintf("showing the first %s", humanSize(PreviewLimit)))
+	case b.status != "":
+		return b.styles.status.Render(b.status)
+	}
+
+	return ""
+}
+
+func (b *Browser) helpView() string {
+	var help string
+
+	switch b.mode {
+	case modeBrowse:
+		help = "↑/↓ move • enter open • ← back • n mkdir • r rename • d delete • q quit"
+	case modePreview:
+		help = "↑/↓ scroll • pgup/pgdown page • esc back"
+	case modeInput:
+		help = "enter continue • esc cancel"
+	case modeConfirm:
+		help = "y confirm • any other key cancels"
+	}
+
+	return b.styles.help.Render(ansi.Truncate(help, b.width, "…"))
+}
+
+func humanSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -37,6 +37,7 @@ final class AppState: ObservableObject, AppStateProtocol {
   func loadConfiguration() {
     guard
       let serverURL = keychainService.loadString(for: AppConstants.Keychain.Keys.serverURL),
+      let serverID = keychainService.loadString(for: AppConstants.Keychain.Keys.serverID),
       let deviceID = keychainService.loadString(for: AppConstants.Keychain.Keys.deviceID),
       let secret = keychainService.loadString(for: AppConstants.Keychain.Keys.secret)
     else {
@@ -45,10 +46,20 @@ final class AppState: ObservableObject, AppStateProtocol {
       return
     }
 
+    // Devices configured before key versions use the first one
+    let keyVersion = keychainService.loadString(for: AppConstants.Keychain.Keys.keyVersion)
+      .flatMap { Int($0) } ?? 1
+    // and keys that were never rotated have no generation
+    let keyGeneration = keychainService.loadString(for: AppConstants.Keychain.Keys.keyGeneration)
+      .flatMap { Int($0) } ?? 0
+
     let config = ByteClientConfiguration(
       serverURL: serverURL,
+      serverID: serverID,
       deviceID: deviceID,
-      secret: secret
+      secret: secret,
+      keyVersion: keyVersion,
+      keyGeneration: keyGeneration
     )
 
     do {
@@ -65,7 +76,14 @@ final class AppState: ObservableObject, AppStateProtocol {
     }
   }
 
-  func saveConfiguration(serverURL: String, deviceID: String, secret: String) async {
+  func saveConfiguration(
+    serverURL: String,
+    serverID: String,
+    deviceID: String,
+    secret: String,
+    keyVersion: Int,
+    keyGeneration: Int
+  ) async {
     isLoading = true
     error = nil
 
@@ -73,8 +91,11 @@ final class AppState: ObservableObject, AppStateProtocol {
       // Validate configuration first
       let config = ByteClientConfiguration(
         serverURL: serverURL,
+        serverID: serverID,
         deviceID: deviceID,
-        secret: secret
+        secret: secret,
+        keyVersion: keyVersion,
+        keyGeneration: keyGeneration
       )
 
       try config.validate()
@@ -85,8 +106,14 @@ final class AppState: ObservableObject, AppStateProtocol {
       // Save to keychain
       guard
         keychainService.save(serverURL, for: AppConstants.Keychain.Keys.serverURL),
+        keychainService.save(serverID, for: AppConstants.Keychain.Keys.serverID),
         keychainService.save(deviceID, for: AppConstants.Keychain.Keys.deviceID),
-        keychainService.save(secret, for: AppConstants.Keychain.Keys.secret)
+        keychainService.save(secret, for: AppConstants.Keychain.Keys.secret),
+        keychainService.save(String(keyVersion), for: AppConstants.Keychain.Keys.keyVersion),
+        keychainService.save(
+          String(keyGeneration),
+          for: AppConstants.Keychain.Keys.keyGeneration
+        )
       else {
         throw AppError.keychainSaveFailed
       }
@@ -109,8 +136,11 @@ final class AppState: ObservableObject, AppStateProtocol {
 
   func clearConfiguration() {
     keychainService.delete(for: AppConstants.Keychain.Keys.serverURL)
+    keychainService.delete(for: AppConstants.Keychain.Keys.serverID)
     keychainService.delete(for: AppConstants.Keychain.Keys.deviceID)
     keychainService.delete(for: AppConstants.Keychain.Keys.secret)
+    keychainService.delete(for: AppConstants.Keychain.Keys.keyVersion)
+    keychainService.delete(for: AppConstants.Keychain.Keys.keyGeneration)
 
     client = nil
     configuration = nil
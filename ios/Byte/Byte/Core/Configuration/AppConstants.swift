@@ -15,8 +15,11 @@ enum AppConstants {
 
     enum Keys {
       static let serverURL = "serverURL"
+      static let serverID = "serverID"
       static let deviceID = "deviceID"
       static let secret = "secret"
+      static let keyVersion = "keyVersion"
+      static let keyGeneration = "keyGeneration"
     }
   }
 
@@ -23,9 +23,19 @@ protocol AppStateProtocol: ObservableObject {
   /// Save new configuration
   /// - Parameters:
   ///   - serverURL: The server URL
+  ///   - serverID: The server identity tokens are minted for
   ///   - deviceID: The device ID
   ///   - secret: The secret key
-  func saveConfiguration(serverURL: String, deviceID: String, secret: String) async
+  ///   - keyVersion: The version of the server secret the secret key is derived from
+  ///   - keyGeneration: The number of times the secret key was rotated
+  func saveConfiguration(
+    serverURL: String,
+    serverID: String,
+    deviceID: String,
+    secret: String,
+    keyVersion: Int,
+    keyGeneration: Int
+  ) async
 
   /// Clear all stored configuration
   func clearConfiguration()
@@ -5,6 +5,7 @@
 //  Created by Nathan Smith on 10/4/25.
 //
 
+import ByteClient
 import SwiftUI
 
 /// Initial setup view for configuring the app
@@ -13,8 +14,11 @@ struct SetupView: View {
 
   @EnvironmentObject var appState: AppState
   @State private var serverURL = ""
+  @State private var serverID = ""
   @State private var deviceID = ""
   @State private var secret = ""
+  @State private var keyVersion = "1"
+  @State private var keyGeneration = "0"
   @State private var showingSecretField = false
   @State private var showingScanner = false
   @State private var scannedCode: String?
@@ -70,6 +74,17 @@ struct SetupView: View {
             .foregroundColor(.secondary)
         }
         .accessibilityLabel("Server URL input")
+
+      TextField("Server ID", text: $serverID)
+        .textContentType(.none)
+        .autocapitalization(.none)
+        .disableAutocorrection(true)
+        .accessibilityLabel("Server ID input")
+
+      Text("Server ID is printed when the device is created")
+        .font(.caption)
+        .foregroundColor(.secondary)
+        .accessibilityLabel("Note: Server ID is printed when the device is created")
     }
   }
 
@@ -117,6 +132,24 @@ struct SetupView: View {
         .font(.caption)
         .foregroundColor(.secondary)
         .accessibilityLabel("Note: Enter your base64-encoded secret key")
+
+      TextField("Key Version", text: $keyVersion)
+        .keyboardType(.numberPad)
+        .accessibilityLabel("Key version input")
+
+      Text("Key version is printed when the device is created")
+        .font(.caption)
+        .foregroundColor(.secondary)
+        .accessibilityLabel("Note: Key version is printed when the device is created")
+
+      TextField("Key Generation", text: $keyGeneration)
+        .keyboardType(.numberPad)
+        .accessibilityLabel("Key generation input")
+
+      Text("Key generation is printed when the device key is rotated")
+        .font(.caption)
+        .foregroundColor(.secondary)
+        .accessibilityLabel("Note: Key generation is printed when the device key is rotated")
     }
   }
 
@@ -137,8 +170,11 @@ struct SetupView: View {
         Task {
           await appState.saveConfiguration(
             serverURL: serverURL,
+            serverID: serverID,
             deviceID: deviceID,
-            secret: secret
+            secret: secret,
+            keyVersion: Int(keyVersion) ?? 0,
+            keyGeneration: Int(keyGeneration) ?? -1
           )
         }
       } label: {
@@ -162,7 +198,8 @@ struct SetupView: View {
   // MARK: - Helper Properties
 
   private var isConnectDisabled: Bool {
-    serverURL.isEmpty || deviceID.isEmpty || secret.isEmpty || appState.isLoading
+    serverURL.isEmpty || serverID.isEmpty || deviceID.isEmpty || secret.isEmpty
+      || appState.isLoading
   }
 
   // MARK: - Methods
@@ -176,18 +213,59 @@ struct SetupView: View {
       return
     }
 
+    // Devices created with a pairing code claim their credentials with it
+    if let serverUrl = json["serverUrl"], let pairingCode = json["pairingCode"] {
+      claimDevice(serverURL: serverUrl, pairingCode: pairingCode)
+      return
+    }
+
     if let serverUrl = json["serverUrl"],
+      let serverId = json["serverId"],
       let deviceId = json["deviceId"],
       let secret = json["secret"]
     {
       self.serverURL = serverUrl
+      self.serverID = serverId
       self.deviceID = deviceId
       self.secret = secret
+      // Devices of the first version of the server secret have no key version
+      self.keyVersion = json["keyVersion"] ?? "1"
+      // and keys that were never rotated have no key generation
+      self.keyGeneration = json["keyGeneration"] ?? "0"
       localError = nil
     } else {
       localError = AppError.missingQRCodeFields.localizedDescription
     }
   }
+
+  private func claimDevice(serverURL: String, pairingCode: String) {
+    Task {
+      do {
+        let config = try await Pairing.claim(serverURL: serverURL, pairingCode: pairingCode)
+
+        self.serverURL = config.serverURL
+        self.serverID = config.serverID
+        self.deviceID = config.deviceID
+        self.secret = config.secret
+        self.keyVersion = String(config.keyVersion)
+        self.keyGeneration = String(config.keyGeneration)
+        localError = nil
+
+        // The pairing code cannot be claimed again, so the credentials are
+        // saved right away
+        await appState.saveConfiguration(
+          serverURL: config.serverURL,
+          serverID: config.serverID,
+          deviceID: config.deviceID,
+          secret: config.secret,
+          keyVersion: config.keyVersion,
+          keyGeneration: config.keyGeneration
+        )
+      } catch {
+        localError = error.localizedDescription
+      }
+    }
+  }
 }
 
 // MARK: - Helper Extensions
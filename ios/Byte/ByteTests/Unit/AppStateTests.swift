@@ -45,6 +45,7 @@ final class AppStateTests: XCTestCase {
     // Given
     mockKeychainService.mockData = [
       AppConstants.Keychain.Keys.serverURL: "https://example.com",
+      AppConstants.Keychain.Keys.serverID: "byte:00112233445566778899aabbccddeeff",
       AppConstants.Keychain.Keys.deviceID: UUID().uuidString,
       AppConstants.Keychain.Keys.secret: "validBase64Secret==",
     ]
@@ -76,14 +77,18 @@ final class AppStateTests: XCTestCase {
     // Given
     mockKeychainService.shouldSucceed = true
     let serverURL = "https://example.com"
+    let serverID = "byte:00112233445566778899aabbccddeeff"
     let deviceID = UUID().uuidString
     let secret = "validBase64Secret=="
 
     // When
     await sut.saveConfiguration(
       serverURL: serverURL,
+      serverID: serverID,
       deviceID: deviceID,
-      secret: secret
+      secret: secret,
+      keyVersion: 2,
+      keyGeneration: 3
     )
 
     // Then
@@ -98,6 +103,10 @@ final class AppStateTests: XCTestCase {
       mockKeychainService.mockData[AppConstants.Keychain.Keys.serverURL],
       serverURL
     )
+    XCTAssertEqual(
+      mockKeychainService.mockData[AppConstants.Keychain.Keys.serverID],
+      serverID
+    )
     XCTAssertEqual(
       mockKeychainService.mockData[AppConstants.Keychain.Keys.deviceID],
       deviceID
@@ -106,6 +115,14 @@ final class AppStateTests: XCTestCase {
       mockKeychainService.mockData[AppConstants.Keychain.Keys.secret],
       secret
     )
+    XCTAssertEqual(
+      mockKeychainService.mockData[AppConstants.Keychain.Keys.keyVersion],
+      "2"
+    )
+    XCTAssertEqual(
+      mockKeychainService.mockData[AppConstants.Keychain.Keys.keyGeneration],
+      "3"
+    )
   }
 
   func testSaveConfiguration_WithKeychainFailure_SetsError() async {
@@ -115,8 +132,11 @@ final class AppStateTests: XCTestCase {
     // When
     await sut.saveConfiguration(
       serverURL: "https://example.com",
+      serverID: "byte:00112233445566778899aabbccddeeff",
       deviceID: UUID().uuidString,
-      secret: "secret"
+      secret: "secret",
+      keyVersion: 1,
+      keyGeneration: 0
     )
 
     // Then
@@ -129,6 +149,7 @@ final class AppStateTests: XCTestCase {
     // Given - Set up configured state
     mockKeychainService.mockData = [
       AppConstants.Keychain.Keys.serverURL: "https://example.com",
+      AppConstants.Keychain.Keys.serverID: "byte:00112233445566778899aabbccddeeff",
       AppConstants.Keychain.Keys.deviceID: UUID().uuidString,
       AppConstants.Keychain.Keys.secret: "secret",
     ]
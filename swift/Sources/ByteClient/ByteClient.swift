@@ -32,7 +32,13 @@ public class ByteClient {
 
     // Create client chain for key derivation
     do {
-      self.clientChain = try ClientChain(root: rawKey, clientID: configuration.deviceID)
+      self.clientChain = try ClientChain(
+        root: rawKey,
+        clientID: configuration.deviceID,
+        serverID: configuration.serverID,
+        keyVersion: configuration.keyVersion,
+        keyGeneration: configuration.keyGeneration
+      )
     } catch let keyError as KeyError {
       throw ByteClientError.keyDerivationError(keyError.localizedDescription)
     }
@@ -5,30 +5,48 @@ public struct ByteClientConfiguration {
   /// The server URL to connect to
   public let serverURL: String
 
+  /// The identity of the server, printed when the device was created
+  public let serverID: String
+
   /// The device ID (UUID v4)
   public let deviceID: String
 
   /// The base64-encoded secret key for authentication
   public let secret: String
 
+  /// The version of the server secret the secret key is derived from
+  public let keyVersion: Int
+
+  /// The number of times the secret key was rotated
+  public let keyGeneration: Int
+
   /// Optional timeout for requests (default: 30 seconds)
   public let timeout: TimeInterval
 
   /// Create a new client configuration
   /// - Parameters:
   ///   - serverURL: The server URL to connect to
+  ///   - serverID: The identity of the server
   ///   - deviceID: The device ID (must be a valid UUID v4)
   ///   - secret: The base64-encoded secret key
+  ///   - keyVersion: The version of the server secret (default: 1)
+  ///   - keyGeneration: The generation of the secret key (default: 0)
   ///   - timeout: Request timeout in seconds (default: 30)
   public init(
     serverURL: String,
+    serverID: String,
     deviceID: String,
     secret: String,
+    keyVersion: Int = 1,
+    keyGeneration: Int = 0,
     timeout: TimeInterval = 30.0
   ) {
     self.serverURL = serverURL
+    self.serverID = serverID
     self.deviceID = deviceID
     self.secret = secret
+    self.keyVersion = keyVersion
+    self.keyGeneration = keyGeneration
     self.timeout = timeout
   }
 }
@@ -38,6 +56,9 @@ public enum ByteClientConfigurationError: Error, LocalizedError {
   case invalidDeviceID(String)
   case invalidSecret(String)
   case invalidServerURL(String)
+  case invalidServerID(String)
+  case invalidKeyVersion(Int)
+  case invalidKeyGeneration(Int)
 
   public var errorDescription: String? {
     switch self {
@@ -47,6 +68,12 @@ public enum ByteClientConfigurationError: Error, LocalizedError {
       return "Invalid secret: \(secret). Must be valid base64."
     case .invalidServerURL(let url):
       return "Invalid server URL: \(url)"
+    case .invalidServerID(let id):
+      return "Invalid server ID: \(id). Must be the server ID printed when the device was created."
+    case .invalidKeyVersion(let version):
+      return "Invalid key version: \(version). Must be 1 or more."
+    case .invalidKeyGeneration(let generation):
+      return "Invalid key generation: \(generation). Must be 0 or more."
     }
   }
 }
@@ -79,5 +106,20 @@ public extension ByteClientConfiguration {
     guard !serverURL.isEmpty, URL(string: serverURL) != nil else {
       throw ByteClientConfigurationError.invalidServerURL(serverURL)
     }
+
+    // Validate server ID is set, tokens can't be minted without it
+    guard !serverID.isEmpty else {
+      throw ByteClientConfigurationError.invalidServerID(serverID)
+    }
+
+    // Validate key version, versions start at 1
+    guard keyVersion >= 1 else {
+      throw ByteClientConfigurationError.invalidKeyVersion(keyVersion)
+    }
+
+    // Validate key generation, generations start at 0
+    guard keyGeneration >= 0 else {
+      throw ByteClientConfigurationError.invalidKeyGeneration(keyGeneration)
+    }
   }
 }
@@ -28,6 +28,7 @@ public enum ByteClientError: Error, LocalizedError {
 public enum KeyError: Error, LocalizedError {
   case invalidRootKey
   case invalidClientID
+  case missingServerID
   case keyDerivationFailed(String)
   case encryptionFailed(String)
   case decryptionFailed(String)
@@ -38,6 +39,8 @@ public enum KeyError: Error, LocalizedError {
       return "Invalid client root key - must be 32 bytes"
     case .invalidClientID:
       return "Invalid client ID - must be a valid UUID v4"
+    case .missingServerID:
+      return "Missing server ID - required to mint tokens"
     case .keyDerivationFailed(let message):
       return "Key derivation failed: \(message)"
     case .encryptionFailed(let message):
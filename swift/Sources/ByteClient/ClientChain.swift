@@ -10,18 +10,34 @@ private let clientKeyEncryptionKeyDomainSeparator = "client.key-encryption-key.v
 private let clientKeyEncryptionKeySize = 32
 private let clientIDUUIDVersion = 4
 private let defaultTokenExpiration: TimeInterval = 30.0
+private let defaultKeyVersion = 1
 
 /// Swift equivalent of the Go ClientChain structure
 public struct ClientChain: Sendable {
   private let seed: Data
   public let clientID: String
+  /// Identity of the server tokens are minted for, only needed to mint tokens
+  public let serverID: String
+  /// Version of the server secret the root key is derived from
+  public let keyVersion: Int
+  /// Number of times the root key was rotated
+  public let keyGeneration: Int
 
   /// Initialize a new ClientChain
   /// - Parameters:
   ///   - root: The root key bytes (must be 32 bytes)
   ///   - clientID: The client ID (must be a valid UUID v4)
+  ///   - serverID: The identity of the server, as printed when the device was created
+  ///   - keyVersion: The version of the server secret, as printed when the device was created
+  ///   - keyGeneration: The generation of the root key, as printed when it was rotated
   /// - Throws: `KeyError` if the parameters are invalid
-  public init(root: Data, clientID: String) throws {
+  public init(
+    root: Data,
+    clientID: String,
+    serverID: String = "",
+    keyVersion: Int = 1,
+    keyGeneration: Int = 0
+  ) throws {
     guard root.count == clientRootKeySize else {
       throw KeyError.invalidRootKey
     }
@@ -40,6 +56,9 @@ public struct ClientChain: Sendable {
 
     self.seed = root
     self.clientID = clientID
+    self.serverID = serverID
+    self.keyVersion = keyVersion
+    self.keyGeneration = keyGeneration
   }
 
   /// Derive the PASETO token key using HKDF
@@ -61,6 +80,10 @@ public struct ClientChain: Sendable {
   }
 
   public func token() throws -> String {
+    guard !serverID.isEmpty else {
+      throw KeyError.missingServerID
+    }
+
     // Create PASETO token similar to Go implementation
     let now = Date()
     let expiration = now.addingTimeInterval(defaultTokenExpiration)
@@ -69,12 +92,30 @@ public struct ClientChain: Sendable {
     token.expiration = expiration
     token.issuedAt = now
     token.notBefore = now
+    // Random token ID so the server can reject replayed tokens
+    token.jti = UUID().uuidString.lowercased()
+    // Bind the token to the server it is meant for and the device minting it
+    token.audience = serverID
+    token.issuer = clientID
 
     let claims = token.claimsJSON
 
     let key = try self.tokenKey()
+    // Name the key version and generation in the footer so the server can
+    // pick the key, the server assumes the first generation of the first
+    // version when it is missing
+    var footer = Data()
+    if keyVersion > defaultKeyVersion || keyGeneration > 0 {
+      var kid = String(keyVersion)
+      if keyGeneration > 0 {
+        kid += ".\(keyGeneration)"
+      }
+
+      footer = try JSONSerialization.data(withJSONObject: ["kid": kid])
+    }
+
     let encrypted = Version4.Local.encrypt(
-      Package(claims),
+      Package(claims, footer: footer),
       with: key,
       implicit: self.clientID.data(using: .utf8)!,
     )
@@ -0,0 +1,76 @@
+import Foundation
+
+/// Claims the credentials of a new device with the pairing code printed when
+/// it was created, like `byte device claim`
+public enum Pairing {
+  /// Procedure of the pairing service, which requires no authentication
+  static let claimDevicePath = "/devices.v1.PairingService/ClaimDevice"
+
+  /// Claim the credentials of a new device. Pairing codes can only be claimed once.
+  /// - Parameters:
+  ///   - serverURL: The server URL printed along with the pairing code
+  ///   - pairingCode: The pairing code
+  ///   - session: The URL session to send the request with
+  /// - Returns: The configuration of the device
+  /// - Throws: `ByteClientError` if the code cannot be claimed
+  public static func claim(
+    serverURL: String,
+    pairingCode: String,
+    session: URLSession = .shared
+  ) async throws -> ByteClientConfiguration {
+    guard let url = URL(string: serverURL + claimDevicePath) else {
+      throw ByteClientError.configurationError(.invalidServerURL(serverURL))
+    }
+
+    // The Connect protocol with the JSON codec spares generating code for a
+    // single unauthenticated call
+    var request = URLRequest(url: url)
+    request.httpMethod = "POST"
+    request.setValue("application/json", forHTTPHeaderField: "Content-Type")
+    request.httpBody = try JSONSerialization.data(withJSONObject: ["pairingCode": pairingCode])
+
+    let data: Data
+    let response: URLResponse
+    do {
+      (data, response) = try await session.data(for: request)
+    } catch {
+      throw ByteClientError.networkError(error.localizedDescription)
+    }
+
+    guard let http = response as? HTTPURLResponse, http.statusCode == 200 else {
+      let json = try? JSONSerialization.jsonObject(with: data) as? [String: Any]
+      let message = json?["message"] as? String ?? "failed to claim device"
+      throw ByteClientError.authenticationError(message)
+    }
+
+    return try configuration(serverURL: serverURL, claimed: data)
+  }
+
+  /// Decode the response of a claimed pairing code
+  static func configuration(
+    serverURL: String,
+    claimed data: Data
+  ) throws -> ByteClientConfiguration {
+    guard
+      let json = try? JSONSerialization.jsonObject(with: data) as? [String: Any],
+      let deviceID = json["deviceId"] as? String,
+      let deviceKey = json["deviceKey"] as? String,
+      let serverID = json["serverId"] as? String
+    else {
+      throw ByteClientError.invalidResponse("missing device credentials")
+    }
+
+    // Zero values are omitted from JSON, the first key version is 1
+    let keyVersion = json["keyVersion"] as? Int ?? 1
+    let keyGeneration = json["keyGeneration"] as? Int ?? 0
+
+    return ByteClientConfiguration(
+      serverURL: serverURL,
+      serverID: serverID,
+      deviceID: deviceID,
+      secret: deviceKey,
+      keyVersion: keyVersion,
+      keyGeneration: keyGeneration
+    )
+  }
+}
@@ -31,6 +31,9 @@ struct GenerateTokenCommand: ParsableCommand {
   @Argument(help: "Client ID (UUID v4)")
   var clientID: String
 
+  @Argument(help: "Server ID the token is minted for")
+  var serverID: String
+
   func run() throws {
     // Parse the base64 root key
     guard let rootKeyData = Data(base64Encoded: rootKeyBase64) else {
@@ -46,7 +49,7 @@ struct GenerateTokenCommand: ParsableCommand {
     // Create the client chain
     let clientChain: ClientChain
     do {
-      clientChain = try ClientChain(root: rootKeyData, clientID: clientID)
+      clientChain = try ClientChain(root: rootKeyData, clientID: clientID, serverID: serverID)
     } catch {
       throw ValidationError("Failed to create client chain: \(error.localizedDescription)")
     }
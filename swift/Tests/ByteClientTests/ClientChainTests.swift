@@ -79,4 +79,76 @@ final class ClientChainTests: XCTestCase {
     XCTAssertEqual(decrypted1, plaintext)
     XCTAssertEqual(decrypted2, plaintext)
   }
+
+  func testTokenRequiresServerID() throws {
+    let rootKey = Data(repeating: 0x42, count: 32)
+    let clientID = "550e8400-e29b-41d4-a716-446655440000"
+
+    let clientChain = try ClientChain(root: rootKey, clientID: clientID)
+
+    XCTAssertThrowsError(try clientChain.token()) { error in
+      guard case KeyError.missingServerID = error else {
+        XCTFail("Expected missingServerID error")
+        return
+      }
+    }
+  }
+
+  func testTokenForServer() throws {
+    let rootKey = Data(repeating: 0x42, count: 32)
+    let clientID = "550e8400-e29b-41d4-a716-446655440000"
+
+    let clientChain = try ClientChain(
+      root: rootKey,
+      clientID: clientID,
+      serverID: "byte:00112233445566778899aabbccddeeff"
+    )
+
+    XCTAssertTrue(try clientChain.token().hasPrefix("v4.local."))
+  }
+
+  func testTokenNamesKeyVersion() throws {
+    let rootKey = Data(repeating: 0x42, count: 32)
+    let clientID = "550e8400-e29b-41d4-a716-446655440000"
+    let serverID = "byte:00112233445566778899aabbccddeeff"
+
+    let firstVersion = try ClientChain(root: rootKey, clientID: clientID, serverID: serverID)
+    XCTAssertEqual(try firstVersion.token().split(separator: ".").count, 3)
+
+    let laterVersion = try ClientChain(
+      root: rootKey,
+      clientID: clientID,
+      serverID: serverID,
+      keyVersion: 2
+    )
+
+    let parts = try laterVersion.token().split(separator: ".")
+    XCTAssertEqual(parts.count, 4)
+
+    var footer = parts[3].replacingOccurrences(of: "-", with: "+")
+      .replacingOccurrences(of: "_", with: "/")
+    footer += String(repeating: "=", count: (4 - footer.count % 4) % 4)
+
+    let json = try JSONSerialization.jsonObject(with: XCTUnwrap(Data(base64Encoded: footer)))
+    XCTAssertEqual(json as? [String: String], ["kid": "2"])
+  }
+
+  func testTokenNamesKeyGeneration() throws {
+    let clientChain = try ClientChain(
+      root: Data(repeating: 0x42, count: 32),
+      clientID: "550e8400-e29b-41d4-a716-446655440000",
+      serverID: "byte:00112233445566778899aabbccddeeff",
+      keyGeneration: 3
+    )
+
+    let parts = try clientChain.token().split(separator: ".")
+    XCTAssertEqual(parts.count, 4)
+
+    var footer = parts[3].replacingOccurrences(of: "-", with: "+")
+      .replacingOccurrences(of: "_", with: "/")
+    footer += String(repeating: "=", count: (4 - footer.count % 4) % 4)
+
+    let json = try JSONSerialization.jsonObject(with: XCTUnwrap(Data(base64Encoded: footer)))
+    XCTAssertEqual(json as? [String: String], ["kid": "1.3"])
+  }
 }
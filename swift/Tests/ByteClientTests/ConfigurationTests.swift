@@ -6,6 +6,7 @@ final class ConfigurationTests: XCTestCase {
   func testValidConfiguration() throws {
     let config = ByteClientConfiguration(
       serverURL: "https://example.com",
+      serverID: "byte:00112233445566778899aabbccddeeff",
       deviceID: "550e8400-e29b-41d4-a716-446655440000",  // Valid UUID v4
       secret: "dGVzdA==",  // "test" in base64
       timeout: 30.0
@@ -17,6 +18,7 @@ final class ConfigurationTests: XCTestCase {
   func testInvalidDeviceID() {
     let config = ByteClientConfiguration(
       serverURL: "https://example.com",
+      serverID: "byte:00112233445566778899aabbccddeeff",
       deviceID: "not-a-uuid",
       secret: "dGVzdA==",
       timeout: 30.0
@@ -33,6 +35,7 @@ final class ConfigurationTests: XCTestCase {
   func testInvalidSecret() {
     let config = ByteClientConfiguration(
       serverURL: "https://example.com",
+      serverID: "byte:00112233445566778899aabbccddeeff",
       deviceID: "550e8400-e29b-41d4-a716-446655440000",
       secret: "not-base64!@#",
       timeout: 30.0
@@ -49,6 +52,7 @@ final class ConfigurationTests: XCTestCase {
   func testInvalidServerURL() {
     let config = ByteClientConfiguration(
       serverURL: "",
+      serverID: "byte:00112233445566778899aabbccddeeff",
       deviceID: "550e8400-e29b-41d4-a716-446655440000",
       secret: "dGVzdA==",
       timeout: 30.0
@@ -61,4 +65,57 @@ final class ConfigurationTests: XCTestCase {
       }
     }
   }
+
+  func testMissingServerID() {
+    let config = ByteClientConfiguration(
+      serverURL: "https://example.com",
+      serverID: "",
+      deviceID: "550e8400-e29b-41d4-a716-446655440000",
+      secret: "dGVzdA==",
+      timeout: 30.0
+    )
+
+    XCTAssertThrowsError(try config.validate()) { error in
+      guard case ByteClientConfigurationError.invalidServerID = error else {
+        XCTFail("Expected invalidServerID error")
+        return
+      }
+    }
+  }
+
+  func testInvalidKeyVersion() {
+    let config = ByteClientConfiguration(
+      serverURL: "https://example.com",
+      serverID: "byte:00112233445566778899aabbccddeeff",
+      deviceID: "550e8400-e29b-41d4-a716-446655440000",
+      secret: "dGVzdA==",
+      keyVersion: 0,
+      timeout: 30.0
+    )
+
+    XCTAssertThrowsError(try config.validate()) { error in
+      guard case ByteClientConfigurationError.invalidKeyVersion = error else {
+        XCTFail("Expected invalidKeyVersion error")
+        return
+      }
+    }
+  }
+
+  func testInvalidKeyGeneration() {
+    let config = ByteClientConfiguration(
+      serverURL: "https://example.com",
+      serverID: "byte:00112233445566778899aabbccddeeff",
+      deviceID: "550e8400-e29b-41d4-a716-446655440000",
+      secret: "dGVzdA==",
+      keyGeneration: -1,
+      timeout: 30.0
+    )
+
+    XCTAssertThrowsError(try config.validate()) { error in
+      guard case ByteClientConfigurationError.invalidKeyGeneration = error else {
+        XCTFail("Expected invalidKeyGeneration error")
+        return
+      }
+    }
+  }
 }
@@ -10,11 +10,13 @@ import XCTest
 /// 2. Set the environment variables:
 ///    - BYTE_CLIENT_ID (UUID v4)
 ///    - BYTE_CLIENT_SECRET (base64 encoded)
+///    - BYTE_SERVER_ID (printed when the device was created)
 /// 3. Run: swift test --filter IntegrationTests
 ///
 /// Example:
 /// export BYTE_CLIENT_ID="550e8400-e29b-41d4-a716-446655440000"
 /// export BYTE_CLIENT_SECRET="dGVzdGtleWZvcmJ5dGVzZXJ2ZXJjbGllbnR0ZXN0"
+/// export BYTE_SERVER_ID="byte:00112233445566778899aabbccddeeff"
 /// swift test --filter IntegrationTests
 final class IntegrationTests: XCTestCase {
   private var client: ByteClient?
@@ -22,15 +24,20 @@ final class IntegrationTests: XCTestCase {
   override func setUp() async throws {
     // Only run if environment variables are set
     guard let clientId = ProcessInfo.processInfo.environment["BYTE_CLIENT_ID"],
-      let secret = ProcessInfo.processInfo.environment["BYTE_CLIENT_SECRET"]
+      let secret = ProcessInfo.processInfo.environment["BYTE_CLIENT_SECRET"],
+      let serverID = ProcessInfo.processInfo.environment["BYTE_SERVER_ID"]
     else {
       throw XCTSkip(
-        "Integration tests require BYTE_CLIENT_ID and BYTE_CLIENT_SECRET environment variables"
+        """
+        Integration tests require BYTE_CLIENT_ID, BYTE_CLIENT_SECRET and BYTE_SERVER_ID \
+        environment variables
+        """
       )
     }
 
     let config = ByteClientConfiguration(
       serverURL: "http://localhost:8080",
+      serverID: serverID,
       deviceID: clientId,
       secret: secret,
       timeout: 30.0
@@ -185,6 +192,7 @@ final class IntegrationTests: XCTestCase {
   func testClientConfiguration() async throws {
     let config = ByteClientConfiguration(
       serverURL: "http://localhost:8080",
+      serverID: "byte:00112233445566778899aabbccddeeff",
       deviceID: "550e8400-e29b-41d4-a716-446655440000",
       secret: "dGVzdA==",
       timeout: 30.0
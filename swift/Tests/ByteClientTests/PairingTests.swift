@@ -0,0 +1,40 @@
+import XCTest
+
+@testable import ByteClient
+
+final class PairingTests: XCTestCase {
+  func testConfigurationFromClaimedDevice() throws {
+    let data = Data(
+      """
+      {
+        "deviceId": "550e8400-e29b-41d4-a716-446655440000",
+        "deviceKey": "QkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkI=",
+        "keyVersion": 2,
+        "serverId": "byte:00112233445566778899aabbccddeeff"
+      }
+      """.utf8
+    )
+
+    let config = try Pairing.configuration(serverURL: "https://example.com", claimed: data)
+
+    XCTAssertEqual(config.serverURL, "https://example.com")
+    XCTAssertEqual(config.serverID, "byte:00112233445566778899aabbccddeeff")
+    XCTAssertEqual(config.deviceID, "550e8400-e29b-41d4-a716-446655440000")
+    XCTAssertEqual(config.secret, "QkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkI=")
+    XCTAssertEqual(config.keyVersion, 2)
+    XCTAssertEqual(config.keyGeneration, 0)
+  }
+
+  func testConfigurationFromInvalidResponse() {
+    let data = Data(#"{"deviceId": "550e8400-e29b-41d4-a716-446655440000"}"#.utf8)
+
+    XCTAssertThrowsError(
+      try Pairing.configuration(serverURL: "https://example.com", claimed: data)
+    ) { error in
+      guard case ByteClientError.invalidResponse = error else {
+        XCTFail("Expected invalidResponse error")
+        return
+      }
+    }
+  }
+}
@@ -1,15 +1,15 @@
 #!/bin/bash
 
 # Script to run integration tests against localhost:8080
-# Usage: ./run-integration-test.sh CLIENT_ID CLIENT_SECRET
+# Usage: ./run-integration-test.sh CLIENT_ID CLIENT_SECRET SERVER_ID
 
 set -e
 
-if [ $# -ne 2 ]; then
-    echo "Usage: $0 CLIENT_ID CLIENT_SECRET"
+if [ $# -ne 3 ]; then
+    echo "Usage: $0 CLIENT_ID CLIENT_SECRET SERVER_ID"
     echo ""
     echo "Example:"
-    echo "  $0 550e8400-e29b-41d4-a716-446655440000 dGVzdGtleWZvcmJ5dGVzZXJ2ZXJjbGllbnR0ZXN0"
+    echo "  $0 550e8400-e29b-41d4-a716-446655440000 dGVzdGtleWZvcmJ5dGVzZXJ2ZXJjbGllbnR0ZXN0 byte:00112233445566778899aabbccddeeff"
     echo ""
     echo "Make sure your server is running on localhost:8080"
     exit 1
@@ -17,6 +17,7 @@ fi
 
 CLIENT_ID="$1"
 CLIENT_SECRET="$2"
+SERVER_ID="$3"
 
 echo "🚀 Running ByteClient integration tests..."
 echo "📡 Server: http://localhost:8080"
@@ -37,6 +38,7 @@ echo ""
 # Export environment variables and run tests
 export BYTE_CLIENT_ID="$CLIENT_ID"
 export BYTE_CLIENT_SECRET="$CLIENT_SECRET"
+export BYTE_SERVER_ID="$SERVER_ID"
 
 # Run the integration tests
 swift test --filter IntegrationTests --verbose